@@ -0,0 +1,121 @@
+package main
+
+import (
+	"archive/zip" // archive/zip: reading zip archives (zipアーカイブの読み取り)
+	"context"     // context: cancellation (キャンセル)
+	"errors"      // errors: insecure entry detection (安全でないエントリの判定)
+	"fmt"         // fmt: error wrapping (エラーのラップ)
+	"io"          // io: bounded reads (上限付きの読み取り)
+	"io/fs"       // io/fs: member name validation and not-found errors (メンバー名の検証と存在しないエラー)
+	"net/url"     // net/url: unescaping member names (メンバー名のアンエスケープ)
+	"strings"     // strings: splitting at the separator (区切りでの分割)
+)
+
+// archiveSeparator divides an archive's URI from the member path, as in JAR URLs
+// archiveSeparator: JARのURLと同様に、アーカイブのURIとメンバーのパスを分ける区切り
+const archiveSeparator = "!/"
+
+// defaultArchiveMaxBytes caps the uncompressed member size ArchiveProvider reads (10MB)
+// defaultArchiveMaxBytes: ArchiveProviderが読み取る展開後のメンバーサイズの上限 (10MB)
+const defaultArchiveMaxBytes = 10 << 20
+
+// ArchiveProvider is the built-in provider for members of zip archives under Root
+// ArchiveProvider: Root配下のzipアーカイブのメンバーを提供する組み込みプロバイダー
+// file:///docs.zip!/readme.md reads readme.md from docs.zip. The archive resolves
+// like a FileProvider path; the member must be a clean relative name, so entries
+// such as ../x are never served, and a member larger than MaxBytes once
+// decompressed is refused whatever size its header declares.
+// file:///docs.zip!/readme.mdはdocs.zipからreadme.mdを読み取る。アーカイブはFileProviderと同様に解決される。
+// メンバーは正規化された相対名でなければならず、../xのようなエントリは提供されない。
+// 展開後にMaxBytesを超えるメンバーは、ヘッダーが申告するサイズに関わらず拒否する
+type ArchiveProvider struct {
+	Root     string // root: sandbox directory (サンドボックスのディレクトリ)
+	MaxBytes int64  // maxBytes: uncompressed member limit, 10MB when zero (展開後のメンバー上限、0なら10MB)
+}
+
+func (p ArchiveProvider) CanHandle(uri string) bool {
+	archive, _, ok := splitArchiveURI(uri)
+	if !ok || !hasScheme(uri, "file") {
+		return false
+	}
+	archive = strings.ToLower(archive)
+	return strings.HasSuffix(archive, ".zip") || strings.HasSuffix(archive, ".jar")
+}
+
+func (p ArchiveProvider) Read(ctx context.Context, uri string) (Content, error) {
+	archiveURI, member, _ := splitArchiveURI(uri)
+	member, err := url.PathUnescape(member)
+	if err != nil {
+		return Content{}, fmt.Errorf("%w: %v", errInvalidURI, err)
+	}
+
+	// Security: zip-slip (パスを逸脱するメンバー名) を拒否
+	if !fs.ValidPath(member) || member == "." || strings.Contains(member, `\`) {
+		return Content{}, fmt.Errorf("%w: invalid archive member %q", errInvalidURI, member)
+	}
+
+	f, err := FileProvider{Root: p.Root}.open(ctx, archiveURI)
+	if err != nil {
+		return Content{}, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return Content{}, fmt.Errorf("stat %s: %w", archiveURI, err)
+	}
+	zr, err := zip.NewReader(f, info.Size())
+	if err != nil && !errors.Is(err, zip.ErrInsecurePath) { // such entries never match below: そのようなエントリは以下で一致しない
+		return Content{}, fmt.Errorf("open archive %s: %w", archiveURI, err)
+	}
+
+	// Exact name match only: 名前の完全一致のみ
+	var entry *zip.File
+	for _, zf := range zr.File {
+		if zf.Name == member {
+			entry = zf
+			break
+		}
+	}
+	if entry == nil || entry.FileInfo().IsDir() {
+		return Content{}, notFound(fmt.Errorf("read %s: %w", uri, fs.ErrNotExist))
+	}
+
+	// Security: 展開爆弾を拒否
+	limit := p.MaxBytes
+	if limit <= 0 {
+		limit = defaultArchiveMaxBytes
+	}
+	if entry.UncompressedSize64 > uint64(limit) {
+		return Content{}, fmt.Errorf("read %s: member exceeds %d bytes", uri, limit)
+	}
+	rc, err := entry.Open()
+	if err != nil {
+		return Content{}, fmt.Errorf("read %s: %w", uri, err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, limit+1)) // the header may lie: ヘッダーは偽りうる
+	if err != nil {
+		return Content{}, fmt.Errorf("read %s: %w", uri, err)
+	}
+	if int64(len(data)) > limit {
+		return Content{}, fmt.Errorf("read %s: member exceeds %d bytes", uri, limit)
+	}
+	return fileContent(member, data), nil
+}
+
+// splitArchiveURI splits uri at the first archive separator, escaped or not
+// splitArchiveURI: uriを最初のアーカイブ区切り (エスケープの有無を問わない) で分割する関数
+// Normalized URIs carry the separator as %21/.
+// 正規化されたURIでは区切りは%21/になる
+func splitArchiveURI(uri string) (archive, member string, ok bool) {
+	i := strings.Index(uri, archiveSeparator)
+	j := strings.Index(strings.ToLower(uri), "%21/")
+	switch {
+	case i < 0 && j < 0:
+		return uri, "", false
+	case j < 0 || (i >= 0 && i < j):
+		return uri[:i], uri[i+len(archiveSeparator):], true
+	default:
+		return uri[:j], uri[j+len("%21/"):], true
+	}
+}
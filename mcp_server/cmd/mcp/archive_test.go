@@ -0,0 +1,66 @@
+package main
+
+import (
+	"archive/zip"   // archive/zip: building test archives (テスト用アーカイブの作成)
+	"context"       // context: provider calls (プロバイダーの呼び出し)
+	"errors"        // errors: error matching (エラーの照合)
+	"os"            // os: writing the archive (アーカイブの書き込み)
+	"path/filepath" // path/filepath: archive path (アーカイブのパス)
+	"strings"       // strings: large members (大きなメンバー)
+	"testing"       // testing: test framework (テストフレームワーク)
+)
+
+// writeZip writes a zip archive holding members to name under dir
+// writeZip: membersを持つzipアーカイブをdir配下のnameへ書き込む関数
+func writeZip(t *testing.T, dir, name string, members map[string]string) {
+	t.Helper()
+	f, err := os.Create(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	for member, data := range members {
+		w, err := zw.Create(member)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(data))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestArchiveProvider checks that zip members are read through resources/read, that
+// missing members are not found, and that escaping names and oversized members are
+// refused
+// TestArchiveProvider: zipのメンバーがresources/readで読み取られ、存在しないメンバーは見つからず、
+// 逸脱する名前と大きすぎるメンバーが拒否されることを確認するテスト
+func TestArchiveProvider(t *testing.T) {
+	dir := t.TempDir()
+	writeZip(t, dir, "docs.zip", map[string]string{
+		"readme.md":   "# Docs",
+		"sub/big.txt": strings.Repeat("x", 100),
+		"../evil.txt": "evil",
+	})
+	c := NewClient(NewMCPServer(WithRootDir(dir)))
+
+	read, err := c.ReadResource("file:///docs.zip!/readme.md")
+	if err != nil || len(read.Contents) != 1 || read.Contents[0].Text != "# Docs" {
+		t.Fatalf("readme: got %+v, %v", read, err)
+	}
+	_, err = c.ReadResource("file:///docs.zip!/missing.md")
+	wantRPCCode(t, err, CodeResourceNotFound)
+
+	p := ArchiveProvider{Root: dir, MaxBytes: 50}
+	if !p.CanHandle("file:///docs.zip!/readme.md") || p.CanHandle("file:///docs.zip") || p.CanHandle("file:///docs.tar!/a") {
+		t.Fatal("CanHandle matched the wrong URIs")
+	}
+	if _, err := p.Read(context.Background(), "file:///docs.zip!/../evil.txt"); !errors.Is(err, errInvalidURI) {
+		t.Fatalf("escaping member: got %v, want errInvalidURI", err)
+	}
+	if _, err := p.Read(context.Background(), "file:///docs.zip!/sub/big.txt"); err == nil || !strings.Contains(err.Error(), "exceeds 50 bytes") {
+		t.Fatalf("oversized member: got %v", err)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"       // context: identifies the connection (接続の識別)
+	"encoding/json" // encoding/json: JSON encoding (JSONエンコード)
+	"fmt"           // fmt: formatted I/O (フォーマット済みI/O)
+	"io"            // io: writer interfaces (ライターインターフェース)
+	"os"            // os: file access (ファイルアクセス)
+	"sync"          // sync: write serialization (書き込みの直列化)
+	"time"          // time: timestamps and durations (時刻と所要時間)
+)
+
+// AuditRecord describes one tool invocation for the audit trail
+// AuditRecord: 監査証跡に記録する1回のツール呼び出し
+// audit trail: 監査証跡
+type AuditRecord struct {
+	Time          time.Time   `json:"time"`                // time: call start (呼び出し開始時刻)
+	Tool          string      `json:"tool"`                // tool: tool name (ツール名)
+	ArgumentsHash string      `json:"argumentsHash"`       // argumentsHash: SHA-256 of the arguments (引数のSHA-256)
+	Arguments     interface{} `json:"arguments,omitempty"` // arguments: verbatim arguments, debug mode only (デバッグ時のみの生の引数)
+	ClientID      string      `json:"clientId,omitempty"`  // clientId: client name from initialize (initializeで得たクライアント名)
+	Success       bool        `json:"success"`             // success: 成功したか
+	Error         string      `json:"error,omitempty"`     // error: failure reason (失敗理由)
+	DurationMs    float64     `json:"durationMs"`          // durationMs: elapsed milliseconds (経過ミリ秒)
+}
+
+// AuditLogger receives a record after every tool invocation
+// AuditLogger: ツール呼び出しのたびに記録を受け取るインターフェース
+// Errors are logged by the server and never fail the call.
+// エラーはサーバーがログに記録し、呼び出し自体は失敗させない
+type AuditLogger interface {
+	LogToolCall(record AuditRecord) error
+}
+
+// JSONLAuditLogger appends audit records as JSON lines
+// JSONLAuditLogger: 監査記録をJSON Lines形式で追記するロガー
+// append: 追記する
+type JSONLAuditLogger struct {
+	mu sync.Mutex // mu: serializes writes (書き込みを直列化)
+	w  io.Writer  // w: destination (出力先)
+}
+
+// NewJSONLAuditLogger writes audit records to w
+// NewJSONLAuditLogger: 監査記録をwへ書き込むロガーを作成する関数
+func NewJSONLAuditLogger(w io.Writer) *JSONLAuditLogger {
+	return &JSONLAuditLogger{w: w}
+}
+
+// OpenAuditLog opens path for appending and returns a logger writing to it
+// OpenAuditLog: pathを追記モードで開き、そこへ書き込むロガーを返す関数
+// The file is created with owner-only permissions when missing.
+// ファイルが存在しない場合は所有者のみの権限で作成する
+func OpenAuditLog(path string) (*JSONLAuditLogger, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open audit log: %w", err)
+	}
+	return NewJSONLAuditLogger(f), nil
+}
+
+// LogToolCall writes record as a single JSON line
+// LogToolCall: 記録を1行のJSONとして書き込む関数
+func (l *JSONLAuditLogger) LogToolCall(record AuditRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("marshal audit record: %w", err)
+	}
+	data = append(data, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, err = l.w.Write(data)
+	return err
+}
+
+// Close closes the underlying writer when it is closable
+// Close: 基になるライターが閉じられる場合は閉じる関数
+func (l *JSONLAuditLogger) Close() error {
+	if c, ok := l.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// audit records a finished tool call with the configured AuditLogger
+// audit: 完了したツール呼び出しを設定済みのAuditLoggerへ記録する関数
+// A result carrying an "error" key counts as a failure. The tool's Redact fields, and
+// for meta/invoke the target's, are masked before the arguments are hashed or captured.
+// "error"キーを含む結果は失敗として扱う。ツールのRedactフィールド (meta/invokeでは対象のものも) は
+// 引数のハッシュ化や記録の前に伏せ字にする
+func (s *MCPServer) audit(ctx context.Context, tool Tool, arguments interface{}, start time.Time, result map[string]interface{}, callErr error) {
+	if s.auditLogger == nil {
+		return
+	}
+	arguments = s.redactArguments(ctx, tool, arguments)
+
+	record := AuditRecord{
+		Time:          start.UTC(),
+		Tool:          tool.Name,
+		ArgumentsHash: hashArguments(arguments),
+		ClientID:      s.clientID(ctx),
+		Success:       true,
+		DurationMs:    float64(time.Since(start)) / float64(time.Millisecond),
+	}
+	// Full capture only in debug mode: デバッグモードでのみ引数を完全に記録
+	if s.debug {
+		record.Arguments = arguments
+	}
+	switch {
+	case callErr != nil:
+		record.Success = false
+		record.Error = callErr.Error()
+	case result["error"] != nil:
+		record.Success = false
+		record.Error = fmt.Sprint(result["error"])
+	}
+
+	if err := s.auditLogger.LogToolCall(record); err != nil {
+		s.logger.ErrorContext(ctx, "audit log write failed", "tool", tool.Name, "error", err)
+	}
+}
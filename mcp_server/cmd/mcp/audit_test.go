@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"         // bytes: captured audit log (取得した監査ログ)
+	"context"       // context: handler signature (ハンドラーのシグネチャ)
+	"encoding/json" // encoding/json: decoding audit records (監査記録のデコード)
+	"errors"        // errors: failing handler (失敗するハンドラー)
+	"strings"       // strings: splitting JSON lines (JSON Linesの分割)
+	"testing"       // testing: test framework (テストフレームワーク)
+)
+
+// TestAuditLog checks that every tool call produces one JSON-lines record with the
+// tool, argument hash, client id, outcome and duration, and that arguments are only
+// captured verbatim in debug mode
+// TestAuditLog: 各ツール呼び出しがツール、引数のハッシュ、クライアントID、結果、所要時間を持つ
+// JSON Linesの記録を1件生成し、引数はデバッグモードでのみそのまま記録されることを確認するテスト
+func TestAuditLog(t *testing.T) {
+	for _, debug := range []bool{false, true} {
+		var buf bytes.Buffer
+		s := NewMCPServer(WithDebug(debug), WithAuditLogger(NewJSONLAuditLogger(&buf)))
+		s.RegisterTool(EchoTool())
+		s.RegisterTool(Tool{
+			Name: "fail",
+			Handler: func(ctx context.Context, arguments interface{}) (map[string]interface{}, error) {
+				return nil, errors.New("nope")
+			},
+		})
+		c := NewClient(s)
+		if err := c.call("initialize", map[string]interface{}{"clientInfo": map[string]interface{}{"name": "cli"}}, nil); err != nil {
+			t.Fatal(err)
+		}
+		args := map[string]interface{}{"message": "hi"}
+		c.CallTool("echo", args)
+		c.CallTool("fail", nil)
+
+		var records []AuditRecord
+		for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+			var record AuditRecord
+			if err := json.Unmarshal([]byte(line), &record); err != nil {
+				t.Fatalf("line %q: %v", line, err)
+			}
+			records = append(records, record)
+		}
+		if len(records) != 2 {
+			t.Fatalf("debug %v: got %d records, want 2:\n%s", debug, len(records), buf.String())
+		}
+
+		echo, fail := records[0], records[1]
+		if echo.Tool != "echo" || echo.ClientID != "cli" || !echo.Success || echo.Error != "" ||
+			echo.ArgumentsHash != hashArguments(args) || echo.Time.IsZero() || echo.DurationMs < 0 {
+			t.Fatalf("debug %v: echo record %+v", debug, echo)
+		}
+		if captured := echo.Arguments != nil; captured != debug {
+			t.Fatalf("debug %v: arguments captured is %v", debug, captured)
+		}
+		if fail.Tool != "fail" || fail.Success || fail.Error != "nope" {
+			t.Fatalf("debug %v: fail record %+v", debug, fail)
+		}
+	}
+}
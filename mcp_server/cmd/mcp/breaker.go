@@ -0,0 +1,127 @@
+package main
+
+import (
+	"sync" // sync: guards breaker state (ブレーカー状態の保護)
+	"time" // time: cooldown (クールダウン)
+)
+
+// Default breaker settings for downstream servers
+// 下流サーバー用ブレーカーのデフォルト設定
+const (
+	defaultBreakerThreshold = 5                // threshold: consecutive failures before opening (開くまでの連続失敗回数)
+	defaultBreakerCooldown  = 30 * time.Second // cooldown: time open before a trial call (試行呼び出しまで開いている時間)
+)
+
+// breakerState is the state of a circuit breaker
+// breakerState: サーキットブレーカーの状態
+type breakerState int
+
+const (
+	breakerClosed   breakerState = iota // closed: calls pass (呼び出しを通す)
+	breakerOpen                         // open: calls fail fast (呼び出しを即座に失敗させる)
+	breakerHalfOpen                     // half-open: one trial call is in flight (試行呼び出しが1件実行中)
+)
+
+// circuitBreaker stops calls to a failing downstream for a cooldown
+// circuitBreaker: 失敗している下流への呼び出しをクールダウンの間止める構造体
+// It opens after threshold consecutive failures. Once cooldown has passed it lets a
+// single trial call through: success closes it, failure opens it again.
+// 連続threshold回の失敗で開く。cooldown経過後は試行呼び出しを1件だけ通し、
+// 成功すれば閉じ、失敗すれば再び開く
+// circuit breaker: サーキットブレーカー、consecutive: 連続した
+type circuitBreaker struct {
+	threshold int              // threshold: failures before opening (開くまでの失敗回数)
+	cooldown  time.Duration    // cooldown: time open before a trial call (試行呼び出しまで開いている時間)
+	now       func() time.Time // now: clock, replaceable in tests (時計、テストで差し替え可能)
+
+	mu       sync.Mutex   // mu: guards the fields below (以下のフィールドを保護)
+	state    breakerState // state: current state (現在の状態)
+	failures int          // failures: consecutive failures (連続失敗回数)
+	openedAt time.Time    // openedAt: when the breaker last opened (最後に開いた時刻)
+}
+
+// newCircuitBreaker creates a closed breaker, or nil when threshold disables it
+// newCircuitBreaker: 閉じたブレーカーを作成する関数 (thresholdが無効ならnil)
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	if threshold <= 0 {
+		return nil
+	}
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown, now: time.Now}
+}
+
+// allow reports whether a call may proceed
+// allow: 呼び出しを進めてよいかを判定する関数
+// A nil breaker allows every call.
+// nilのブレーカーは全ての呼び出しを許可する
+func (b *circuitBreaker) allow() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case breakerOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = breakerHalfOpen // trial call: 試行呼び出し
+		return true
+	case breakerHalfOpen:
+		return false // trial already running: 試行呼び出しが実行中
+	default:
+		return true
+	}
+}
+
+// record updates the breaker with the outcome of an allowed call
+// record: 許可された呼び出しの結果でブレーカーを更新する関数
+func (b *circuitBreaker) record(ok bool) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if ok {
+		b.state = breakerClosed
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= b.threshold {
+		b.state = breakerOpen
+		b.openedAt = b.now()
+	}
+}
+
+// abandon returns the breaker to open after a trial call that proved nothing
+// abandon: 結果の判断できなかった試行呼び出しの後にブレーカーを開いた状態へ戻す関数
+// The cooldown has already passed, so the next call becomes the trial.
+// クールダウンは経過済みのため、次の呼び出しが試行呼び出しになる
+func (b *circuitBreaker) abandon() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == breakerHalfOpen {
+		b.state = breakerOpen
+	}
+}
+
+// healthy reports whether the breaker would let a call through now
+// healthy: 現時点でブレーカーが呼び出しを通すかを判定する関数
+func (b *circuitBreaker) healthy() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case breakerOpen:
+		return b.now().Sub(b.openedAt) >= b.cooldown
+	case breakerHalfOpen:
+		return false
+	default:
+		return true
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context" // context: RunIO lifetime (RunIOの存続期間)
+	"errors"  // errors: error matching (エラーの照合)
+	"io"      // io: pipes to the downstream (下流へのパイプ)
+	"testing" // testing: test framework (テストフレームワーク)
+	"time"    // time: fake clock (偽の時計)
+)
+
+// streamDownstream connects an initialized client to s over pipes
+// streamDownstream: パイプ経由でsに接続した初期化済みのクライアントを返す関数
+// kill ends the connection from the server side, so later calls fail in transport.
+// killはサーバー側から接続を終了させるため、以降の呼び出しはトランスポートで失敗する
+func streamDownstream(t *testing.T, s *MCPServer) (c *Client, kill func()) {
+	t.Helper()
+	clientIn, serverOut := io.Pipe()
+	serverIn, clientOut := io.Pipe()
+	go s.RunIO(context.Background(), serverIn, serverOut)
+	c = NewStreamClient(clientIn, clientOut)
+	if _, err := c.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	return c, func() {
+		serverOut.Close()
+		serverIn.Close()
+	}
+}
+
+// TestCircuitBreaker checks that the breaker opens after threshold failures, lets a
+// single trial through after the cooldown, and closes again on its success
+// TestCircuitBreaker: ブレーカーがthreshold回の失敗で開き、クールダウン後に試行呼び出しを
+// 1件だけ通し、その成功で再び閉じることを確認するテスト
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := newCircuitBreaker(2, time.Minute)
+	b.now = func() time.Time { return now }
+
+	b.record(false)
+	if !b.allow() {
+		t.Fatal("opened before the threshold")
+	}
+	b.record(false)
+	if b.allow() || b.healthy() {
+		t.Fatal("still closed after the threshold")
+	}
+
+	now = now.Add(time.Minute)
+	if !b.allow() || b.allow() {
+		t.Fatal("cooldown did not let exactly one trial through")
+	}
+	b.record(false) // failed trial reopens: 失敗した試行で再び開く
+	if b.allow() {
+		t.Fatal("failed trial did not reopen the breaker")
+	}
+
+	now = now.Add(time.Minute)
+	b.allow()
+	b.record(true)
+	if !b.allow() || !b.allow() {
+		t.Fatal("successful trial did not close the breaker")
+	}
+	if newCircuitBreaker(0, time.Minute) != nil || !(*circuitBreaker)(nil).allow() {
+		t.Fatal("a disabled breaker must allow every call")
+	}
+}
+
+// TestDownstreamBreaker checks that a downstream failing in transport is cut off with
+// -32000 after the threshold, while JSON-RPC errors do not count as failures
+// TestDownstreamBreaker: トランスポートで失敗する下流がしきい値の後に-32000で遮断され、
+// JSON-RPCエラーは失敗として数えられないことを確認するテスト
+func TestDownstreamBreaker(t *testing.T) {
+	s := NewMCPServer(WithDownstreamBreaker(2, time.Hour))
+	client, kill := streamDownstream(t, newEchoServer())
+	if err := s.AddDownstream("down", client); err != nil {
+		t.Fatal(err)
+	}
+	c := NewClient(s)
+
+	var rpcErr *JSONRPCError
+	for i := 0; i < 3; i++ {
+		if _, err := c.CallTool("down.echo", map[string]interface{}{}); !errors.As(err, &rpcErr) || rpcErr.Code != -32602 {
+			t.Fatalf("invalid arguments: got %v, want the downstream's -32602", err)
+		}
+	}
+
+	kill()
+	for i := 0; i < 2; i++ {
+		if _, err := c.CallTool("down.echo", map[string]interface{}{"message": "m"}); errors.As(err, &rpcErr) && rpcErr.Message == "downstream unavailable" {
+			t.Fatalf("call %d: breaker opened before the threshold", i)
+		}
+	}
+	_, err := c.CallTool("down.echo", map[string]interface{}{"message": "m"})
+	if !errors.As(err, &rpcErr) || rpcErr.Code != -32000 || rpcErr.Message != "downstream unavailable" {
+		t.Fatalf("after the threshold: got %v, want downstream unavailable", err)
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"container/list" // container/list: doubly linked list (双方向連結リスト)
+	"sync"           // sync: mutual exclusion (排他制御)
+	"time"           // time: expiry timestamps (有効期限のタイムスタンプ)
+)
+
+// Cache stores results of read-only resources and idempotent tools
+// Cache: 読み取り専用リソースと冪等なツールの結果を保存するインターフェース
+// Implementations must be safe for concurrent use.
+// 実装は並行利用に対して安全でなければならない
+type Cache interface {
+	Get(key string) (interface{}, bool) // get: 取得する
+	Set(key string, value interface{})  // set: 設定する
+	Delete(key string)                  // delete: 削除する
+}
+
+// lruEntry is one cached value
+// lruEntry: キャッシュされた値1件
+type lruEntry struct {
+	key     string      // key: cache key (キャッシュキー)
+	value   interface{} // value: cached value (キャッシュされた値)
+	expires time.Time   // expires: expiry time, zero for none (有効期限、ゼロなら無期限)
+}
+
+// LRUCache is an in-memory least-recently-used cache with optional TTL
+// LRUCache: 任意のTTLを持つメモリ内LRU (最も長く使われていないものから破棄) キャッシュ
+type LRUCache struct {
+	mu         sync.Mutex               // mu: guards the fields below (以下のフィールドを保護)
+	maxEntries int                      // maxEntries: capacity, 0 for unbounded (容量、0なら無制限)
+	ttl        time.Duration            // ttl: entry lifetime, 0 for none (エントリの寿命、0なら無期限)
+	order      *list.List               // order: most recent at front (先頭が最新)
+	entries    map[string]*list.Element // entries: index by key (キーによる索引)
+	now        func() time.Time         // now: clock, replaceable in tests (時計、テストで差し替え可能)
+}
+
+// NewLRUCache creates an LRU cache holding at most maxEntries values for ttl each
+// NewLRUCache: 最大maxEntries件の値をそれぞれttlの間保持するLRUキャッシュを作成する関数
+func NewLRUCache(maxEntries int, ttl time.Duration) *LRUCache {
+	return &LRUCache{
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+		now:        time.Now,
+	}
+}
+
+// Get returns the value for key if present and not expired
+// Get: キーに対する値が存在し期限切れでなければ返す関数
+func (c *LRUCache) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*lruEntry)
+	if !entry.expires.IsZero() && !c.now().Before(entry.expires) {
+		// Expired: 期限切れ
+		c.removeElement(elem)
+		return nil, false
+	}
+	c.order.MoveToFront(elem) // touch: 最近使用したものとして更新
+	return entry.value, true
+}
+
+// Set stores value under key, evicting the least recently used entry when full
+// Set: キーに値を保存し、満杯なら最も長く使われていないエントリを破棄する関数
+// evicting: 追い出す、破棄する
+func (c *LRUCache) Set(key string, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.set(key, value)
+}
+
+// set stores value under key; the caller holds c.mu
+// set: キーに値を保存する関数 (呼び出し元がc.muを保持)
+func (c *LRUCache) set(key string, value interface{}) {
+	var expires time.Time
+	if c.ttl > 0 {
+		expires = c.now().Add(c.ttl)
+	}
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = &lruEntry{key: key, value: value, expires: expires}
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&lruEntry{key: key, value: value, expires: expires})
+	if c.maxEntries > 0 && c.order.Len() > c.maxEntries {
+		c.removeElement(c.order.Back()) // oldest: 最も古いもの
+	}
+}
+
+// Delete removes key from the cache
+// Delete: キャッシュからキーを削除する関数
+func (c *LRUCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		c.removeElement(elem)
+	}
+}
+
+// Clear removes every entry from the cache
+// Clear: キャッシュから全てのエントリを削除する関数
+func (c *LRUCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.order.Init()
+	c.entries = make(map[string]*list.Element)
+}
+
+// removeElement unlinks elem; the caller holds c.mu
+// removeElement: elemを取り除く関数 (呼び出し元がc.muを保持)
+func (c *LRUCache) removeElement(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.entries, elem.Value.(*lruEntry).key)
+}
+
+// resourceCacheKey and toolCacheKey build cache keys
+// resourceCacheKey / toolCacheKey: キャッシュキーを生成する関数
+// scope is the owning session id for a session tool, empty for a global one.
+// scopeはセッションのツールなら所有するセッションID、グローバルなツールなら空
+func resourceCacheKey(uri string) string {
+	return "resource:" + uri
+}
+
+func toolCacheKey(scope, name string, arguments interface{}) string {
+	return "tool:" + scope + ":" + name + ":" + hashArguments(arguments)
+}
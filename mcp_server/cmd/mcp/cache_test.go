@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context" // context: handler signature and session context (ハンドラーのシグネチャとセッションコンテキスト)
+	"net/url" // net/url: scheme handler signature (スキームハンドラーのシグネチャ)
+	"testing" // testing: test framework (テストフレームワーク)
+	"time"    // time: cache TTL (キャッシュのTTL)
+)
+
+// textTool returns an idempotent tool that always answers text
+// textTool: 常にtextを返す冪等なツールを返す関数
+func textTool(name, text string) Tool {
+	return Tool{
+		Name:        name,
+		Annotations: &ToolAnnotations{IdempotentHint: Bool(true)},
+		Handler: func(ctx context.Context, arguments interface{}) (map[string]interface{}, error) {
+			return map[string]interface{}{"content": []interface{}{map[string]interface{}{"type": "text", "text": text}}}, nil
+		},
+	}
+}
+
+// TestLRUCache checks least-recently-used eviction and TTL expiry
+// TestLRUCache: LRUによる破棄とTTLによる期限切れを確認するテスト
+func TestLRUCache(t *testing.T) {
+	c := NewLRUCache(2, time.Minute)
+	now := time.Unix(0, 0)
+	c.now = func() time.Time { return now }
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Get("a") // a is now the most recent: aが最新になる
+	c.Set("c", 3)
+	if _, ok := c.Get("b"); ok {
+		t.Fatal("b survived eviction")
+	}
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Fatalf("a: got %v, %v", v, ok)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, ok := c.Get("c"); ok {
+		t.Fatal("c survived its TTL")
+	}
+}
+
+// TestResourceCache checks that cacheable resources are served from the cache
+// until their TTL passes or NotifyResourceUpdated invalidates them
+// TestResourceCache: キャッシュ可能なリソースが、TTLの経過かNotifyResourceUpdatedによる
+// 無効化までキャッシュから返されることを確認するテスト
+func TestResourceCache(t *testing.T) {
+	cache := NewLRUCache(16, time.Minute)
+	now := time.Unix(0, 0)
+	cache.now = func() time.Time { return now }
+	s := NewMCPServer(WithCache(cache))
+	reads := 0
+	s.RegisterSchemeHandler("mem", func(ctx context.Context, u *url.URL) (Content, error) {
+		reads++
+		return Content{Text: "x"}, nil
+	})
+	s.RegisterResource(Resource{URI: "mem://a", Cacheable: true})
+	c := NewClient(s)
+	read := func(want int) {
+		t.Helper()
+		if _, err := c.ReadResource("mem://a"); err != nil {
+			t.Fatal(err)
+		}
+		if reads != want {
+			t.Fatalf("got %d reads, want %d", reads, want)
+		}
+	}
+
+	read(1)
+	read(1) // cached: キャッシュ済み
+	now = now.Add(2 * time.Minute)
+	read(2) // expired: 期限切れ
+	s.NotifyResourceUpdated("mem://a")
+	read(3) // invalidated: 無効化済み
+}
+
+// TestToolCache checks that idempotent tool results are cached per arguments
+// and that tools without the hint are always run
+// TestToolCache: 冪等なツールの結果が引数毎にキャッシュされ、
+// ヒントの無いツールは常に実行されることを確認するテスト
+func TestToolCache(t *testing.T) {
+	s := NewMCPServer(WithCache(NewLRUCache(16, time.Minute)))
+	runs := map[string]int{}
+	for _, tool := range []Tool{textTool("idempotent", "x"), {Name: "plain"}} {
+		tool.Handler = func(ctx context.Context, arguments interface{}) (map[string]interface{}, error) {
+			runs[tool.Name]++
+			return map[string]interface{}{"content": []interface{}{}}, nil
+		}
+		s.RegisterTool(tool)
+	}
+	c := NewClient(s)
+	for _, name := range []string{"idempotent", "plain"} {
+		for _, x := range []int{1, 1, 2} {
+			if _, err := c.CallTool(name, map[string]interface{}{"x": x}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if runs["idempotent"] != 2 || runs["plain"] != 3 {
+		t.Fatalf("got runs %v, want idempotent 2 and plain 3", runs)
+	}
+}
+
+// TestToolCacheSessionScope checks that a session tool never shares cached results
+// with the global tool it shadows or with other sessions
+// TestToolCacheSessionScope: セッションのツールが覆い隠すグローバルなツールや
+// 他のセッションとキャッシュ結果を共有しないことを確認するテスト
+func TestToolCacheSessionScope(t *testing.T) {
+	s := NewMCPServer(WithCache(NewLRUCache(16, time.Minute)), WithSingleFlight())
+	s.RegisterTool(textTool("t", "global"))
+	a, _ := s.sessions.create(nil)
+	b, _ := s.sessions.create(nil)
+	if err := a.RegisterTool(textTool("t", "session a")); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.RegisterTool(textTool("t", "session b")); err != nil {
+		t.Fatal(err)
+	}
+
+	call := func(ctx context.Context) string {
+		resp := s.HandleRequest(ctx, &JSONRPCRequest{
+			JSONRPC: "2.0",
+			ID:      IntID(1),
+			Method:  "tools/call",
+			Params:  map[string]interface{}{"name": "t", "arguments": map[string]interface{}{}},
+		})
+		if resp.Error != nil {
+			t.Fatal(resp.Error)
+		}
+		result := resp.Result.(map[string]interface{})
+		return result["content"].([]interface{})[0].(map[string]interface{})["text"].(string)
+	}
+
+	// Each scope is called twice so the second call comes from the cache.
+	// 各スコープを2回呼び、2回目はキャッシュから返るようにする
+	for i := 0; i < 2; i++ {
+		for ctx, want := range map[context.Context]string{
+			context.Background():                    "global",
+			SessionContext(context.Background(), a): "session a",
+			SessionContext(context.Background(), b): "session b",
+		} {
+			if got := call(ctx); got != want {
+				t.Fatalf("call %d: got %q, want %q", i, got, want)
+			}
+		}
+	}
+}
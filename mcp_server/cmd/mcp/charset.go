@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"           // bytes: replacement character search (置換文字の検索)
+	"encoding/base64" // encoding/base64: blob decoding (blobのデコード)
+	"fmt"             // fmt: formatted errors (フォーマット済みエラー)
+	"unicode/utf8"    // unicode/utf8: UTF-8 validation (UTF-8検証)
+
+	"golang.org/x/text/encoding/charmap"   // charmap: single-byte charsets (1バイト文字コード)
+	"golang.org/x/text/encoding/htmlindex" // htmlindex: charset names (文字コード名)
+)
+
+// CharsetAuto detects a file's charset instead of naming it
+// CharsetAuto: 文字コードを指定する代わりにファイルの文字コードを検出する値
+const CharsetAuto = "auto"
+
+// detectCandidates are the multi-byte charsets tried, in order, for non-UTF-8 files
+// detectCandidates: UTF-8ではないファイルに対して順に試すマルチバイト文字コード
+// Single-byte windows-1252 (Latin-1) is the final fallback, since any bytes decode as it.
+// 1バイトのwindows-1252 (Latin-1) はどのバイト列もデコードできるため最後の候補とする
+var detectCandidates = []string{"shift_jis", "euc-jp"}
+
+// decodeCharset converts data in the given charset, or CharsetAuto, to UTF-8 text
+// decodeCharset: 指定した文字コード (またはCharsetAuto) のdataをUTF-8テキストに変換する関数
+// It returns the text and the canonical name of the charset used.
+// テキストと使用した文字コードの正規名を返す
+func decodeCharset(data []byte, charset string) (string, string, error) {
+	if charset == CharsetAuto {
+		return detectCharset(data)
+	}
+	enc, err := htmlindex.Get(charset)
+	if err != nil {
+		return "", "", fmt.Errorf("unknown charset %q: %w", charset, err)
+	}
+	name, _ := htmlindex.Name(enc)
+	text, err := enc.NewDecoder().Bytes(data)
+	if err != nil {
+		return "", "", fmt.Errorf("decode %s: %w", name, err)
+	}
+	return string(text), name, nil
+}
+
+// detectCharset guesses the charset of data and decodes it
+// detectCharset: dataの文字コードを推測してデコードする関数
+// A candidate is accepted only if it decodes without replacement characters.
+// 置換文字を出さずにデコードできた候補だけを採用する
+func detectCharset(data []byte) (string, string, error) {
+	if utf8.Valid(data) {
+		return string(data), "utf-8", nil
+	}
+	for _, name := range detectCandidates {
+		enc, err := htmlindex.Get(name)
+		if err != nil {
+			continue
+		}
+		text, err := enc.NewDecoder().Bytes(data)
+		if err == nil && !bytes.ContainsRune(text, utf8.RuneError) {
+			return string(text), name, nil
+		}
+	}
+	text, err := charmap.Windows1252.NewDecoder().Bytes(data)
+	if err != nil {
+		return "", "", fmt.Errorf("decode windows-1252: %w", err)
+	}
+	return string(text), "windows-1252", nil
+}
+
+// convertCharset rewrites a file content as UTF-8 text and reports the charset in _meta
+// convertCharset: ファイルの内容をUTF-8テキストに書き換え、文字コードを_metaで報告する関数
+// With CharsetAuto only textual MIME types are converted; a named charset is always applied.
+// CharsetAutoではテキスト系のMIMEタイプだけを変換し、名前を指定した文字コードは常に適用する
+func convertCharset(content Content, charset string) (Content, error) {
+	if charset == CharsetAuto && !isTextMimeType(content.MimeType) {
+		return content, nil
+	}
+
+	raw := []byte(content.Text)
+	if content.Blob != "" {
+		data, err := base64.StdEncoding.DecodeString(content.Blob)
+		if err != nil {
+			return content, fmt.Errorf("decode blob: %w", err)
+		}
+		raw = data
+	}
+	text, name, err := decodeCharset(raw, charset)
+	if err != nil {
+		return content, err
+	}
+
+	content.Text, content.Blob = text, ""
+	meta := make(map[string]interface{}, len(content.Meta)+1)
+	for k, v := range content.Meta {
+		meta[k] = v
+	}
+	meta["charset"] = name // charset: 元の文字コード
+	content.Meta = meta
+	return content, nil
+}
+
+// charsetFor returns the charset conversion that applies to uri, or "" for none
+// charsetFor: uriに適用する文字コード変換を返す関数 (無ければ"")
+// A registered resource's Charset overrides the server-wide setting.
+// 登録済みリソースのCharsetはサーバー全体の設定より優先される
+func (s *MCPServer) charsetFor(uri string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if resource, ok := s.resources[uri]; ok && resource.Charset != "" {
+		return resource.Charset
+	}
+	return s.charset
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"os"            // os: writing encoded files (エンコード済みファイルの書き込み)
+	"path/filepath" // path/filepath: file paths (ファイルパス)
+	"testing"       // testing: test framework (テストフレームワーク)
+)
+
+// TestCharsetConversion checks that file resources are decoded to UTF-8 text from
+// Shift_JIS and Latin-1, globally with CharsetAuto or per resource, and only on opt-in
+// TestCharsetConversion: ファイルリソースがShift_JISやLatin-1からUTF-8テキストへ、
+// CharsetAutoで全体的に、またはリソース毎に、オプトイン時だけ変換されることを確認するテスト
+func TestCharsetConversion(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"sjis.txt":   {0x93, 0xfa, 0x96, 0x7b, 0x8c, 0xea}, // 日本語 in Shift_JIS: Shift_JISの日本語
+		"latin1.txt": {'c', 'a', 'f', 0xe9},                // café in Latin-1: Latin-1のcafé
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	auto := NewClient(NewMCPServer(WithRootDir(dir), WithCharset(CharsetAuto)))
+	rr, err := auto.ReadResource("file:///sjis.txt")
+	if err != nil || rr.Contents[0].Text != "日本語" || rr.Contents[0].Meta["charset"] != "shift_jis" {
+		t.Fatalf("auto Shift_JIS: got %+v, %v", rr, err)
+	}
+	if rr, err := auto.ReadResource("file:///latin1.txt"); err != nil || rr.Contents[0].Text != "café" {
+		t.Fatalf("auto Latin-1: got %+v, %v", rr, err)
+	}
+
+	// A resource's own Charset, without the server-wide option: サーバー全体のオプション無しでリソース自身のCharset
+	s := NewMCPServer(WithRootDir(dir))
+	s.RegisterResource(Resource{URI: "file:///sjis.txt", Name: "sjis", Charset: "Shift_JIS"})
+	c := NewClient(s)
+	if rr, err := c.ReadResource("file:///sjis.txt"); err != nil || rr.Contents[0].Text != "日本語" {
+		t.Fatalf("per-resource Shift_JIS: got %+v, %v", rr, err)
+	}
+	if rr, err := c.ReadResource("file:///latin1.txt"); err != nil || rr.Contents[0].Blob == "" || rr.Contents[0].Text != "" {
+		t.Fatalf("unconverted Latin-1: got %+v, %v; want a blob", rr, err)
+	}
+}
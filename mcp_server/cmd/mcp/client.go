@@ -0,0 +1,251 @@
+package main
+
+import (
+	"context"       // context: cancellation and deadlines (キャンセルと期限)
+	"encoding/json" // encoding/json: JSON encoding and decoding (JSONエンコード・デコード)
+	"fmt"           // fmt: formatted I/O (フォーマット済みI/O)
+	"sync/atomic"   // sync/atomic: atomic counters (アトミックカウンター)
+)
+
+// Client is a client for an MCPServer, in-process or over a stream
+// Client: プロセス内またはストリーム経由でMCPServerと通信するクライアント
+// NewClient calls HandleRequest directly, so no bytes are serialized over a transport;
+// NewStreamClient and StartClient talk to another server, for proxying or aggregation.
+// NewClientはHandleRequestを直接呼び出すため、トランスポート上でのバイト列のシリアライズは行わない。
+// NewStreamClientとStartClientは、プロキシや集約のために別のサーバーと通信する
+type Client struct {
+	server *MCPServer   // server: in-process target, nil for streams (プロセス内の対象、ストリームではnil)
+	conn   *clientConn  // conn: stream connection, nil in-process (ストリーム接続、プロセス内ではnil)
+	nextID atomic.Int64 // nextID: next request identifier (次のリクエスト識別子)
+}
+
+// ServerInfo describes the server in an initialize result
+// ServerInfo: initialize結果に含まれるサーバー情報
+type ServerInfo struct {
+	Name    string `json:"name"`    // name: server name (サーバー名)
+	Version string `json:"version"` // version: server version (サーバーバージョン)
+}
+
+// InitializeResult is the result of the initialize method
+// InitializeResult: initializeメソッドの結果
+// Structs rather than maps fix the field order, so the encoded result is byte-stable.
+// マップではなく構造体を使うことでフィールド順が固定され、エンコード結果がバイト単位で安定する
+type InitializeResult struct {
+	ProtocolVersion string             `json:"protocolVersion"`        // protocolVersion: プロトコルバージョン
+	Capabilities    ServerCapabilities `json:"capabilities"`           // capabilities: サーバー機能
+	ServerInfo      ServerInfo         `json:"serverInfo"`             // serverInfo: サーバー情報
+	Instructions    string             `json:"instructions,omitempty"` // instructions: 利用方法の説明
+}
+
+// ServerCapabilities lists the optional features the server supports
+// ServerCapabilities: サーバーが対応する任意機能の一覧
+type ServerCapabilities struct {
+	Tools     *ListChangedCapability `json:"tools,omitempty"`     // tools: ツール
+	Resources *ResourcesCapability   `json:"resources,omitempty"` // resources: リソース
+	Prompts   *ListChangedCapability `json:"prompts,omitempty"`   // prompts: プロンプト
+}
+
+// ListChangedCapability reports whether list change notifications are sent
+// ListChangedCapability: 一覧変更通知を送信するかを表す機能
+type ListChangedCapability struct {
+	ListChanged bool `json:"listChanged,omitempty"` // listChanged: リスト変更通知
+}
+
+// ResourcesCapability describes the resource features
+// ResourcesCapability: リソース関連の機能
+type ResourcesCapability struct {
+	Subscribe   bool `json:"subscribe,omitempty"`   // subscribe: 購読
+	ListChanged bool `json:"listChanged,omitempty"` // listChanged: リスト変更通知
+}
+
+// CallToolResult is the decoded result of the tools/call method
+// CallToolResult: tools/callメソッドのデコード済み結果
+type CallToolResult struct {
+	Content []map[string]interface{} `json:"content,omitempty"` // content: result content (結果コンテンツ)
+	Error   string                   `json:"error,omitempty"`   // error: tool error message (ツールエラーメッセージ)
+
+	// StructuredContent is the machine-readable result as raw JSON, when the tool returned one
+	// StructuredContent: ツールが返した場合の、機械可読な結果 (生のJSON)
+	StructuredContent json.RawMessage `json:"structuredContent,omitempty"`
+}
+
+// ReadResourceResult is the decoded result of the resources/read method
+// ReadResourceResult: resources/readメソッドのデコード済み結果
+type ReadResourceResult struct {
+	Contents []Content `json:"contents"` // contents: resource contents (リソース内容)
+}
+
+// NewClient creates a client bound to server
+// NewClient: サーバーに結び付いたクライアントを作成する関数
+func NewClient(server *MCPServer) *Client {
+	return &Client{server: server}
+}
+
+// Error implements the error interface for JSON-RPC errors
+// Error: JSON-RPCエラーをerrorインターフェースとして扱うためのメソッド
+func (e *JSONRPCError) Error() string {
+	return fmt.Sprintf("json-rpc error %d: %s", e.Code, e.Message)
+}
+
+// RPCCode implements CodedError, so a downstream server's error code passes through a proxy
+// RPCCode: CodedErrorを実装し、下流サーバーのエラーコードがプロキシを通過できるようにする
+func (e *JSONRPCError) RPCCode() int {
+	return e.Code
+}
+
+// call sends one request and decodes its result into result
+// call: リクエストを1件送信し、結果をresultへデコードする関数
+// decodes: デコードする、復号する
+func (c *Client) call(method string, params interface{}, result interface{}) error {
+	return c.callContext(context.Background(), method, params, result)
+}
+
+// callContext is call bound to ctx
+// callContext: ctxに結び付いたcall
+func (c *Client) callContext(ctx context.Context, method string, params interface{}, result interface{}) error {
+	// Round-trip params through JSON so handlers see the same shapes as on the wire
+	// パラメータをJSON経由で往復させ、ハンドラーが通信時と同じ形を受け取るようにする
+	var wireParams interface{}
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return fmt.Errorf("marshal params: %w", err)
+		}
+		if err := json.Unmarshal(data, &wireParams); err != nil {
+			return fmt.Errorf("unmarshal params: %w", err)
+		}
+	}
+	wireParams = withCorrelationMeta(ctx, wireParams)
+
+	req := &JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      IntID(c.nextID.Add(1)), // add: 加算する
+		Method:  method,
+		Params:  wireParams,
+	}
+	var data []byte
+	if c.conn != nil {
+		// Over the stream: ストリーム経由
+		msg, err := c.conn.roundTrip(ctx, req)
+		if err != nil {
+			return err
+		}
+		if msg.Error != nil {
+			return msg.Error
+		}
+		data = msg.Result
+	} else {
+		resp := c.server.HandleRequest(ctx, req)
+		if resp.Error != nil {
+			return resp.Error
+		}
+		var err error
+		if data, err = json.Marshal(resp.Result); err != nil {
+			return fmt.Errorf("marshal result: %w", err)
+		}
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, result); err != nil {
+		return fmt.Errorf("decode %s result: %w", method, err)
+	}
+	return nil
+}
+
+// Initialize performs the initialize handshake
+// Initialize: initializeハンドシェイクを実行する関数
+// handshake: ハンドシェイク、接続確立
+func (c *Client) Initialize() (*InitializeResult, error) {
+	var result InitializeResult
+	params := map[string]interface{}{
+		"protocolVersion": latestProtocolVersion, // protocolVersion: 要求するバージョン
+		"capabilities":    map[string]interface{}{},
+		"clientInfo":      map[string]interface{}{"name": "MCPClient", "version": defaultServerVersion}, // clientInfo: クライアント情報
+	}
+	if err := c.call("initialize", params, &result); err != nil {
+		return nil, err
+	}
+	// Complete the handshake: ハンドシェイクを完了
+	if c.conn == nil {
+		c.server.HandleNotification(context.Background(), &JSONRPCRequest{JSONRPC: "2.0", Method: "notifications/initialized"})
+	} else if err := c.conn.send(&JSONRPCNotification{JSONRPC: "2.0", Method: "notifications/initialized"}); err != nil {
+		return nil, fmt.Errorf("send initialized: %w", err)
+	}
+	return &result, nil
+}
+
+// ListTools returns the tools registered on the server
+// ListTools: サーバーに登録されたツールを返す関数
+// A paginated list is followed through nextCursor to the last page.
+// ページ分割された一覧はnextCursorをたどって最後のページまで取得する
+func (c *Client) ListTools() ([]Tool, error) {
+	var tools []Tool
+	var params interface{} // first page: 最初のページ
+	for {
+		var result ToolsListResult
+		if err := c.call("tools/list", params, &result); err != nil {
+			return nil, err
+		}
+		tools = append(tools, result.Tools...)
+		if result.NextCursor == "" {
+			return tools, nil
+		}
+		params = map[string]interface{}{"cursor": result.NextCursor} // next page: 次のページ
+	}
+}
+
+// GetTool returns the definition of the named tool
+// GetTool: 指定したツールの定義を返す関数
+func (c *Client) GetTool(name string) (*Tool, error) {
+	var result ToolsGetResult
+	if err := c.call("tools/get", map[string]interface{}{"name": name}, &result); err != nil {
+		return nil, err
+	}
+	return &result.Tool, nil
+}
+
+// CallTool invokes the named tool with args
+// CallTool: 指定したツールを引数付きで呼び出す関数
+// invokes: 呼び出す、起動する
+func (c *Client) CallTool(name string, args map[string]interface{}) (*CallToolResult, error) {
+	params := map[string]interface{}{
+		"name":      name, // name: ツール名
+		"arguments": args, // arguments: 引数
+	}
+	var result CallToolResult
+	if err := c.call("tools/call", params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ListResources returns the resources registered on the server
+// ListResources: サーバーに登録されたリソースを返す関数
+// A paginated list is followed through nextCursor to the last page.
+// ページ分割された一覧はnextCursorをたどって最後のページまで取得する
+func (c *Client) ListResources() ([]Resource, error) {
+	var resources []Resource
+	var params interface{} // first page: 最初のページ
+	for {
+		var result ResourcesListResult
+		if err := c.call("resources/list", params, &result); err != nil {
+			return nil, err
+		}
+		resources = append(resources, result.Resources...)
+		if result.NextCursor == "" {
+			return resources, nil
+		}
+		params = map[string]interface{}{"cursor": result.NextCursor} // next page: 次のページ
+	}
+}
+
+// ReadResource reads the resource at uri
+// ReadResource: uriのリソースを読み取る関数
+func (c *Client) ReadResource(uri string) (*ReadResourceResult, error) {
+	var result ReadResourceResult
+	if err := c.call("resources/read", map[string]interface{}{"uri": uri}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
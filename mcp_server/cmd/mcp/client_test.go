@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context" // context: RunIO lifetime (RunIOの存続期間)
+	"errors"  // errors: error matching (エラーの照合)
+	"fmt"     // fmt: per-call messages (呼び出し毎のメッセージ)
+	"io"      // io: pipes between client and server (クライアントとサーバー間のパイプ)
+	"sync"    // sync: concurrent calls (並行した呼び出し)
+	"testing" // testing: test framework (テストフレームワーク)
+	"time"    // time: notification wait (通知の待機)
+)
+
+// TestClientInProcess checks the typed calls of an in-process client
+// TestClientInProcess: プロセス内クライアントの型付き呼び出しを確認するテスト
+func TestClientInProcess(t *testing.T) {
+	c := NewClient(newEchoServer())
+
+	init, err := c.Initialize()
+	if err != nil || init.ServerInfo.Name != "MCPServer" || init.ProtocolVersion == "" {
+		t.Fatalf("Initialize: %+v, %v", init, err)
+	}
+
+	tools, err := c.ListTools()
+	if err != nil || len(tools) != 1 || tools[0].Name != "echo" {
+		t.Fatalf("ListTools: %+v, %v", tools, err)
+	}
+
+	result, err := c.CallTool("echo", map[string]interface{}{"message": "hi"})
+	if err != nil || len(result.Content) != 1 || result.Content[0]["text"] != "Echo: hi" {
+		t.Fatalf("CallTool: %+v, %v", result, err)
+	}
+
+	read, err := c.ReadResource("data:,x")
+	if err != nil || len(read.Contents) != 1 || read.Contents[0].Text != "x" {
+		t.Fatalf("ReadResource: %+v, %v", read, err)
+	}
+}
+
+// TestClientErrors checks that JSON-RPC errors come back as *JSONRPCError
+// TestClientErrors: JSON-RPCエラーが*JSONRPCErrorとして返ることを確認するテスト
+func TestClientErrors(t *testing.T) {
+	c := NewClient(newEchoServer())
+
+	_, err := c.CallTool("nope", nil)
+	var rpcErr *JSONRPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Code != -32602 {
+		t.Fatalf("unknown tool: got %v, want -32602", err)
+	}
+
+	_, err = c.ReadResource("ftp://host/x")
+	if !errors.As(err, &rpcErr) {
+		t.Fatalf("unsupported scheme: got %v, want a JSON-RPC error", err)
+	}
+}
+
+// TestStreamClient checks a client talking to a server over pipes: concurrent calls
+// are matched to their responses, notifications reach the handler, and calls after
+// the connection ends fail with ErrClientClosed
+// TestStreamClient: パイプ経由でサーバーと話すクライアントで、並行した呼び出しがレスポンスと
+// 対応付けられ、通知がハンドラーに届き、接続終了後の呼び出しがErrClientClosedで失敗することを確認するテスト
+func TestStreamClient(t *testing.T) {
+	s := newEchoServer()
+	clientIn, serverOut := io.Pipe()
+	serverIn, clientOut := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- s.RunIO(context.Background(), serverIn, serverOut)
+		serverOut.Close()
+	}()
+
+	c := NewStreamClient(clientIn, clientOut)
+	notes := make(chan string, 1)
+	c.OnNotification(func(n JSONRPCNotification) { notes <- n.Method })
+	if _, err := c.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			want := fmt.Sprintf("Echo: %d", i)
+			result, err := c.CallTool("echo", map[string]interface{}{"message": fmt.Sprint(i)})
+			if err != nil || result.Content[0]["text"] != want {
+				t.Errorf("call %d: %+v, %v", i, result, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	s.NotifyToolsListChanged()
+	select {
+	case method := <-notes:
+		if method != "notifications/tools/list_changed" {
+			t.Fatalf("notification: got %s", method)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no notification")
+	}
+
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	<-c.conn.done // the reader has seen the end of the stream: 読み取り側がストリームの終わりを検出した
+	if _, err := c.ListTools(); !errors.Is(err, ErrClientClosed) {
+		t.Fatalf("after close: got %v, want ErrClientClosed", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"bufio"         // bufio: line-delimited reading (行区切りの読み取り)
+	"bytes"         // bytes: batch detection (バッチの判定)
+	"context"       // context: cancellation and deadlines (キャンセルと期限)
+	"encoding/json" // encoding/json: wire encoding (通信のエンコード)
+	"errors"        // errors: error values (エラー値)
+	"fmt"           // fmt: error wrapping (エラーのラップ)
+	"io"            // io: streams (ストリーム)
+	"log/slog"      // log/slog: dropped messages (破棄したメッセージ)
+	"os"            // os: subprocess stderr (サブプロセスの標準エラー)
+	"os/exec"       // os/exec: downstream subprocess (下流のサブプロセス)
+	"sync"          // sync: pending request table (保留中リクエストの表)
+)
+
+// ErrClientClosed reports a call on a client whose connection has ended
+// ErrClientClosed: 接続が終了したクライアントでの呼び出しを表すエラー
+var ErrClientClosed = errors.New("client connection closed")
+
+// maxClientLine bounds one message read from a downstream server (16MB)
+// maxClientLine: 下流サーバーから読み取る1メッセージの上限 (16MB)
+const maxClientLine = 16 << 20
+
+// wireMessage is any JSON-RPC message read from a downstream server
+// wireMessage: 下流サーバーから読み取る任意のJSON-RPCメッセージ
+type wireMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      RequestID       `json:"id"`
+	Method  string          `json:"method,omitempty"` // method: set for notifications and requests (通知とリクエストで設定)
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *JSONRPCError   `json:"error,omitempty"`
+}
+
+// clientConn correlates requests and responses over a line-delimited stream
+// clientConn: 行区切りのストリーム上でリクエストとレスポンスを対応付ける構造体
+// A single reader goroutine routes responses to waiting calls by id and
+// notifications to the notification handler.
+// 1つの読み取りgoroutineが、レスポンスをidで待機中の呼び出しへ、通知を通知ハンドラーへ振り分ける
+// correlates: 対応付ける
+type clientConn struct {
+	writeMu sync.Mutex // writeMu: serializes writes (書き込みを直列化)
+	w       io.Writer  // w: requests to the server (サーバーへのリクエスト)
+	close   func() error
+
+	mu      sync.Mutex                             // mu: guards the fields below (以下のフィールドを保護)
+	pending map[RequestID]chan *wireMessage        // pending: calls awaiting a response (応答待ちの呼び出し)
+	notify  func(notification JSONRPCNotification) // notify: notification handler, may be nil (通知ハンドラー、nil可)
+	logger  *slog.Logger                           // logger: reports dropped messages (破棄したメッセージを報告)
+	err     error                                  // err: why the connection ended (接続が終了した理由)
+	done    chan struct{}                          // done: closed when the reader stops (読み取り終了時に閉じる)
+}
+
+// newClientConn starts reading responses from r
+// newClientConn: rからレスポンスの読み取りを開始する関数
+func newClientConn(r io.Reader, w io.Writer, close func() error) *clientConn {
+	conn := &clientConn{
+		w:       w,
+		close:   close,
+		pending: make(map[RequestID]chan *wireMessage),
+		logger:  slog.Default(),
+		done:    make(chan struct{}),
+	}
+	go conn.readLoop(r)
+	return conn
+}
+
+// readLoop dispatches incoming messages until r ends
+// readLoop: rが終わるまで受信メッセージを振り分ける関数
+func (c *clientConn) readLoop(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxClientLine)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		// Servers may batch notifications: サーバーは通知をバッチで送ることがある
+		var msgs []*wireMessage
+		if line[0] == '[' {
+			if err := json.Unmarshal(line, &msgs); err != nil {
+				c.log().Warn("client: invalid batch from server", "error", err)
+				continue
+			}
+		} else {
+			msg := new(wireMessage)
+			if err := json.Unmarshal(line, msg); err != nil {
+				c.log().Warn("client: invalid message from server", "error", err)
+				continue
+			}
+			msgs = append(msgs, msg)
+		}
+		for _, msg := range msgs {
+			c.dispatch(msg)
+		}
+	}
+
+	err := scanner.Err()
+	if err == nil {
+		err = ErrClientClosed
+	}
+	c.mu.Lock()
+	if c.err == nil {
+		c.err = err // Close may have set it first: Closeが先に設定している場合がある
+	}
+	c.mu.Unlock()
+	close(c.done) // wakes every waiting call: 待機中の全呼び出しを起こす
+}
+
+// dispatch routes one incoming message
+// dispatch: 受信したメッセージを1件振り分ける関数
+func (c *clientConn) dispatch(msg *wireMessage) {
+	switch {
+	case msg.Method != "" && msg.ID.IsZero():
+		// Notification: 通知
+		c.mu.Lock()
+		notify := c.notify
+		c.mu.Unlock()
+		if notify != nil {
+			var params interface{}
+			if len(msg.Params) > 0 {
+				json.Unmarshal(msg.Params, &params)
+			}
+			notify(JSONRPCNotification{JSONRPC: msg.JSONRPC, Method: msg.Method, Params: params})
+		}
+	case msg.Method != "":
+		// Server-initiated requests are not supported: サーバー発のリクエストには未対応
+		c.send(&JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      msg.ID,
+			Error: &JSONRPCError{
+				Code:    -32601,             // Method not found (メソッドが見つからない)
+				Message: "Method not found", // found: 見つかった
+			},
+		})
+	default:
+		c.mu.Lock()
+		ch, ok := c.pending[msg.ID]
+		delete(c.pending, msg.ID)
+		c.mu.Unlock()
+		if ok {
+			ch <- msg
+		}
+	}
+}
+
+// send writes one message line
+// send: メッセージを1行書き込む関数
+func (c *clientConn) send(msg interface{}) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	_, err = c.w.Write(append(data, '\n'))
+	return err
+}
+
+// roundTrip sends req and waits for the response with the same id
+// roundTrip: reqを送信し、同じidのレスポンスを待つ関数
+func (c *clientConn) roundTrip(ctx context.Context, req *JSONRPCRequest) (*wireMessage, error) {
+	ch := make(chan *wireMessage, 1)
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return nil, c.err
+	}
+	c.pending[req.ID] = ch
+	c.mu.Unlock()
+	forget := func() {
+		c.mu.Lock()
+		delete(c.pending, req.ID)
+		c.mu.Unlock()
+	}
+
+	if err := c.send(req); err != nil {
+		forget()
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.err != nil {
+			return nil, c.err // closed while sending: 送信中に閉じられた
+		}
+		return nil, fmt.Errorf("send %s: %w", req.Method, err)
+	}
+	select {
+	case msg := <-ch:
+		return msg, nil
+	case <-ctx.Done():
+		forget()
+		return nil, ctx.Err()
+	case <-c.done:
+		forget()
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return nil, c.err
+	}
+}
+
+// shutdown marks the connection closed and then closes it
+// shutdown: 接続を閉じたものとしてマークしてから閉じる関数
+// Marking first makes later calls fail with ErrClientClosed rather than with the
+// write error of the closed stream.
+// 先にマークすることで、以降の呼び出しは閉じたストリームの書き込みエラーではなくErrClientClosedで失敗する
+func (c *clientConn) shutdown() error {
+	c.mu.Lock()
+	if c.err == nil {
+		c.err = ErrClientClosed
+	}
+	c.mu.Unlock()
+	return c.close()
+}
+
+// log returns the logger for problems on the connection
+// log: 接続上の問題を記録するロガーを返す関数
+func (c *clientConn) log() *slog.Logger {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.logger
+}
+
+// NewStreamClient creates a client for a server speaking line-delimited JSON-RPC over r and w
+// NewStreamClient: rとw上で行区切りのJSON-RPCを話すサーバー用のクライアントを作成する関数
+// Close closes w when it is an io.Closer.
+// wがio.Closerの場合、Closeはwを閉じる
+func NewStreamClient(r io.Reader, w io.Writer) *Client {
+	closeFn := func() error { return nil }
+	if closer, ok := w.(io.Closer); ok {
+		closeFn = closer.Close
+	}
+	return &Client{conn: newClientConn(r, w, closeFn)}
+}
+
+// StartClient runs a downstream MCP server as a subprocess and connects to its stdio
+// StartClient: 下流のMCPサーバーをサブプロセスとして起動し、その標準入出力に接続する関数
+// The subprocess's stderr is passed through. Close ends its input and waits for it to exit.
+// サブプロセスの標準エラーはそのまま流す。Closeは入力を閉じて終了を待つ
+// subprocess: サブプロセス
+func StartClient(ctx context.Context, name string, args ...string) (*Client, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start %s: %w", name, err)
+	}
+	return &Client{conn: newClientConn(stdout, stdin, func() error {
+		stdin.Close()
+		return cmd.Wait()
+	})}, nil
+}
+
+// OnNotification sets the handler for notifications from a connected server
+// OnNotification: 接続先サーバーからの通知のハンドラーを設定する関数
+// It runs on the reader goroutine, so it must not block on calls to the same client.
+// 読み取りgoroutine上で実行されるため、同じクライアントへの呼び出しでブロックしてはならない
+func (c *Client) OnNotification(handler func(notification JSONRPCNotification)) {
+	if c.conn == nil {
+		return // in-process clients receive no notifications: プロセス内クライアントは通知を受け取らない
+	}
+	c.conn.mu.Lock()
+	defer c.conn.mu.Unlock()
+	c.conn.notify = handler
+}
+
+// SetLogger sets the logger for malformed messages from a connected server
+// SetLogger: 接続先サーバーからの不正なメッセージを記録するロガーを設定する関数
+// The default is slog.Default(). AddDownstream sets the server's own logger.
+// デフォルトはslog.Default()。AddDownstreamはサーバー自身のロガーを設定する
+func (c *Client) SetLogger(logger *slog.Logger) {
+	if c.conn == nil {
+		return // in-process clients read no stream: プロセス内クライアントはストリームを読まない
+	}
+	c.conn.mu.Lock()
+	defer c.conn.mu.Unlock()
+	c.conn.logger = logger
+}
+
+// Close ends the connection to a server started or connected to by this client
+// Close: このクライアントが起動または接続したサーバーとの接続を終了する関数
+func (c *Client) Close() error {
+	if c.conn == nil {
+		return nil
+	}
+	return c.conn.shutdown()
+}
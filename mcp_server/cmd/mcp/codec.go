@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bufio"         // bufio: message splitting (メッセージの分割)
+	"encoding/json" // encoding/json: the JSON codec (JSONコーデック)
+	"fmt"           // fmt: error wrapping (エラーのラップ)
+)
+
+// Codec converts messages between Go values and their wire encoding
+// Codec: メッセージをGoの値と通信時のエンコーディングとの間で変換するインターフェース
+// The server works on JSON internally; a transport's codec only changes the bytes on
+// the wire, so handlers and results look the same whichever codec is used. Values
+// passed to Marshal may be json.RawMessage, and Unmarshal targets follow the
+// encoding/json rules, including json.RawMessage.
+// サーバーは内部でJSONを扱い、トランスポートのコーデックは通信時のバイト列のみを変えるため、
+// どのコーデックでもハンドラーと結果は同じに見える。Marshalに渡す値はjson.RawMessageの場合があり、
+// Unmarshalの格納先はjson.RawMessageを含めencoding/jsonの規則に従う
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)      // marshal: エンコードする
+	Unmarshal(data []byte, v interface{}) error // unmarshal: デコードする
+}
+
+// messageSplitter is implemented by codecs whose messages are self-delimiting
+// messageSplitter: メッセージ自体が区切りを持つコーデックが実装するインターフェース
+// Such codecs are framed by Split; all others are framed by newlines.
+// そのようなコーデックはSplitで区切られ、それ以外は改行で区切られる
+type messageSplitter interface {
+	Split(data []byte, atEOF bool) (advance int, token []byte, err error)
+}
+
+// JSONCodec encodes messages as JSON, the default wire encoding
+// JSONCodec: メッセージをJSON (デフォルトの通信時エンコーディング) としてエンコードするコーデック
+type JSONCodec struct{}
+
+func (JSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSONCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// splitFunc returns how messages encoded by codec are split from a stream
+// splitFunc: codecでエンコードされたメッセージをストリームから分割する方法を返す関数
+func splitFunc(codec Codec) bufio.SplitFunc {
+	if s, ok := codec.(messageSplitter); ok {
+		return s.Split
+	}
+	return bufio.ScanLines // newline framing: 改行による区切り
+}
+
+// decodeWire converts one message read from the wire into JSON
+// decodeWire: 通信から読み取った1件のメッセージをJSONへ変換する関数
+// Without a codec the message already is JSON.
+// コーデックが無い場合、メッセージは既にJSON
+func decodeWire(codec Codec, msg []byte) ([]byte, error) {
+	if codec == nil {
+		return msg, nil
+	}
+	var data json.RawMessage
+	if err := codec.Unmarshal(msg, &data); err != nil {
+		return nil, fmt.Errorf("decode message: %w", err)
+	}
+	return data, nil
+}
+
+// encodeWire converts one JSON message into the codec's framed wire form
+// encodeWire: 1件のJSONメッセージをコーデックの区切り付きの通信形式へ変換する関数
+func encodeWire(codec Codec, data []byte) ([]byte, error) {
+	if codec != nil {
+		var err error
+		if data, err = codec.Marshal(json.RawMessage(data)); err != nil {
+			return nil, fmt.Errorf("encode message: %w", err)
+		}
+		if _, ok := codec.(messageSplitter); ok {
+			return data, nil // self-delimiting: 自己区切り
+		}
+	}
+	return append(data, '\n'), nil // newline framing: 改行による区切り
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bufio"         // bufio: splitting the response stream (応答ストリームの分割)
+	"bytes"         // bytes: in-memory streams (メモリ上のストリーム)
+	"context"       // context: RunIO lifetime (RunIOの存続期間)
+	"encoding/json" // encoding/json: comparing decoded values (デコードした値の比較)
+	"reflect"       // reflect: deep comparison (深い比較)
+	"strings"       // strings: long strings (長い文字列)
+	"testing"       // testing: test framework (テストフレームワーク)
+)
+
+// TestMsgpackCodec checks that values round-trip through MsgpackCodec with integers
+// kept exact, and that Split waits for a whole value
+// TestMsgpackCodec: 値が整数を正確に保ったままMsgpackCodecを往復し、
+// Splitが値全体が揃うまで待つことを確認するテスト
+func TestMsgpackCodec(t *testing.T) {
+	codec := MsgpackCodec{}
+	in := map[string]interface{}{
+		"big":    json.Number("9007199254740993"),
+		"neg":    json.Number("-5"),
+		"float":  3.25,
+		"text":   strings.Repeat("ü", 200),
+		"list":   []interface{}{nil, true, "x"},
+		"nested": map[string]interface{}{"a": map[string]interface{}{}},
+	}
+	data, err := codec.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw json.RawMessage
+	if err := codec.Unmarshal(data, &raw); err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber() // compare integers exactly: 整数を正確に比較する
+	if err := dec.Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, map[string]interface{}{
+		"big": json.Number("9007199254740993"), "neg": json.Number("-5"), "float": json.Number("3.25"),
+		"text": in["text"], "list": in["list"], "nested": in["nested"],
+	}) {
+		t.Fatalf("round trip: %s", raw)
+	}
+	if err := codec.Unmarshal(append(data, 0xc0), &got); err == nil {
+		t.Fatal("trailing bytes accepted")
+	}
+
+	for i := 0; i < len(data); i++ {
+		if advance, token, err := codec.Split(data[:i], false); advance != 0 || token != nil || err != nil {
+			t.Fatalf("split of %d/%d bytes: %d %v", i, len(data), advance, err)
+		}
+	}
+	if advance, token, err := codec.Split(append(data, data...), false); advance != len(data) || !bytes.Equal(token, data) || err != nil {
+		t.Fatalf("split of two values: %d %v", advance, err)
+	}
+	if _, _, err := codec.Split(data[:len(data)-1], true); err == nil {
+		t.Fatal("truncated value at EOF accepted")
+	}
+}
+
+// TestRunIOCodec checks that RunIO reads and writes messages in the configured codec
+// with JSON and MessagePack alike
+// TestRunIOCodec: JSONとMessagePackのどちらでも、RunIOが設定したコーデックで
+// メッセージを読み書きすることを確認するテスト
+func TestRunIOCodec(t *testing.T) {
+	for _, codec := range []Codec{JSONCodec{}, MsgpackCodec{}} {
+		s := NewMCPServer(WithExampleTools(), WithCodec(codec))
+		var in bytes.Buffer
+		for _, msg := range []map[string]interface{}{
+			{"jsonrpc": "2.0", "id": 1, "method": "tools/call", "params": map[string]interface{}{"name": "echo", "arguments": map[string]interface{}{"message": "a\nb"}}},
+			{"jsonrpc": "2.0", "id": "s", "method": "nope"},
+		} {
+			data, err := codec.Marshal(msg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			in.Write(data)
+			if _, ok := codec.(messageSplitter); !ok {
+				in.WriteByte('\n')
+			}
+		}
+		var out bytes.Buffer
+		if err := s.RunIO(context.Background(), &in, &out); err != nil {
+			t.Fatal(err)
+		}
+		sc := bufio.NewScanner(&out)
+		sc.Split(splitFunc(codec))
+		var ids []string
+		for sc.Scan() {
+			var resp struct {
+				ID     json.RawMessage `json:"id"`
+				Result json.RawMessage `json:"result"`
+				Error  *JSONRPCError   `json:"error"`
+			}
+			if err := codec.Unmarshal(sc.Bytes(), &resp); err != nil {
+				t.Fatalf("%T: %v", codec, err)
+			}
+			ids = append(ids, string(resp.ID))
+			if string(resp.ID) == "1" && !strings.Contains(string(resp.Result), `a\nb`) {
+				t.Errorf("%T: echo result %s", codec, resp.Result)
+			}
+			if string(resp.ID) == `"s"` && (resp.Error == nil || resp.Error.Code != -32601) {
+				t.Errorf("%T: unknown method answered with %+v", codec, resp.Error)
+			}
+		}
+		if len(ids) != 2 {
+			t.Fatalf("%T: got responses %v, want 2", codec, ids)
+		}
+	}
+}
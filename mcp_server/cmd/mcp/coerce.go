@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json" // encoding/json: argument normalization (引数の正規化)
+	"fmt"           // fmt: array paths (配列のパス)
+	"sort"          // sort: deterministic property order (決定的なプロパティ順)
+	"strconv"       // strconv: string and number conversion (文字列と数値の変換)
+)
+
+// Coercion records one argument converted to its declared type
+// Coercion: 宣言された型へ変換した1つの引数の記録
+// coercion: 型の強制変換
+type Coercion struct {
+	Path string `json:"path"` // path: converted value, e.g. arguments.count (変換した値の位置)
+	From string `json:"from"` // from: JSON type received (受け取ったJSON型)
+	To   string `json:"to"`   // to: JSON type produced (変換後のJSON型)
+}
+
+// coerce converts common type mismatches in arguments to the schema's declared types
+// coerce: 引数のよくある型の不一致をスキーマで宣言された型へ変換する関数
+// Only exact conversions are made: numeric strings to numbers, numbers to strings,
+// and a scalar to a one-element array. Anything else is left for validation to reject.
+// 数値の文字列から数値、数値から文字列、スカラーから1要素の配列という厳密な変換だけを行う。
+// それ以外は検証で拒否されるようそのまま残す
+// conservative: 控えめな、保守的な
+func (a *argumentSchema) coerce(arguments interface{}) (interface{}, []Coercion) {
+	if a == nil || arguments == nil {
+		return arguments, nil
+	}
+	data, err := json.Marshal(arguments)
+	if err != nil {
+		return arguments, nil
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return arguments, nil
+	}
+
+	var changes []Coercion
+	coerced := a.coerceValue(a.root, decoded, "arguments", &changes)
+	if len(changes) == 0 {
+		return arguments, nil // untouched: 変更なし
+	}
+	return coerced, changes
+}
+
+// coerceValue coerces value against one schema node, recursing into objects and arrays
+// coerceValue: 1つのスキーマノードに対して値を変換し、オブジェクトと配列に再帰する関数
+func (a *argumentSchema) coerceValue(node map[string]interface{}, value interface{}, path string, changes *[]Coercion) interface{} {
+	for {
+		ref, ok := node["$ref"].(string)
+		if !ok {
+			break
+		}
+		target, err := a.resolve(ref)
+		if err != nil {
+			return value
+		}
+		node = target
+	}
+
+	if converted, to, ok := convertType(node, value); ok {
+		*changes = append(*changes, Coercion{Path: path, From: jsonType(value), To: to})
+		value = converted
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		properties, _ := node["properties"].(map[string]interface{})
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names) // stable report order: 報告順を安定させる
+		for _, name := range names {
+			if sub, ok := properties[name].(map[string]interface{}); ok {
+				v[name] = a.coerceValue(sub, v[name], path+"."+name, changes)
+			}
+		}
+	case []interface{}:
+		if items, ok := node["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				v[i] = a.coerceValue(items, item, fmt.Sprintf("%s[%d]", path, i), changes)
+			}
+		}
+	}
+	return value
+}
+
+// convertType converts value to the first declared type it can exactly become
+// convertType: 値を、厳密に変換できる最初の宣言された型へ変換する関数
+// Values that already match a declared type are never converted.
+// すでに宣言された型に一致する値は変換しない
+func convertType(node map[string]interface{}, value interface{}) (interface{}, string, bool) {
+	var declared []string
+	switch t := node["type"].(type) {
+	case string:
+		declared = []string{t}
+	case []interface{}:
+		for _, name := range t {
+			if name, ok := name.(string); ok {
+				declared = append(declared, name)
+			}
+		}
+	}
+	for _, name := range declared {
+		if hasType(value, name) {
+			return nil, "", false
+		}
+	}
+
+	for _, name := range declared {
+		switch v := value.(type) {
+		case string:
+			if name == "number" || name == "integer" {
+				n, err := strconv.ParseFloat(v, 64)
+				if err == nil && hasType(n, name) {
+					return n, name, true
+				}
+			}
+		case float64:
+			if name == "string" {
+				return strconv.FormatFloat(v, 'f', -1, 64), name, true
+			}
+		}
+		if name == "array" {
+			switch value.(type) {
+			case string, float64, bool:
+				return []interface{}{value}, name, true // scalar to one element: スカラーを1要素に
+			}
+		}
+	}
+	return nil, "", false
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"       // context: handler signature (ハンドラーのシグネチャ)
+	"encoding/json" // encoding/json: comparing arguments and results (引数と結果の比較)
+	"testing"       // testing: test framework (テストフレームワーク)
+)
+
+// TestArgumentCoercion checks that WithArgumentCoercion converts a numeric string to an
+// integer, a number to a string and a scalar to an array, and reports each in _meta
+// TestArgumentCoercion: WithArgumentCoercionが数値の文字列を整数へ、数値を文字列へ、
+// スカラーを配列へ変換し、それぞれを_metaで報告することを確認するテスト
+func TestArgumentCoercion(t *testing.T) {
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"n":    map[string]interface{}{"type": "integer"},
+			"s":    map[string]interface{}{"type": "string"},
+			"tags": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+		},
+	}
+	var got interface{}
+	handler := func(ctx context.Context, arguments interface{}) (map[string]interface{}, error) {
+		got = arguments
+		return ToolResult(), nil
+	}
+	call := func(s *MCPServer, arguments map[string]interface{}) *JSONRPCResponse {
+		return s.HandleRequest(context.Background(), &JSONRPCRequest{
+			JSONRPC: "2.0",
+			ID:      IntID(1),
+			Method:  "tools/call",
+			Params:  map[string]interface{}{"name": "c", "arguments": arguments},
+		})
+	}
+
+	s := NewMCPServer(WithArgumentCoercion())
+	s.RegisterTool(Tool{Name: "c", InputSchema: schema, Handler: handler})
+	resp := call(s, map[string]interface{}{"n": "42", "s": 5.0, "tags": "x"})
+	if resp.Error != nil {
+		t.Fatal(resp.Error)
+	}
+	if data, _ := json.Marshal(got); string(data) != `{"n":42,"s":"5","tags":["x"]}` {
+		t.Fatalf("arguments: got %s", data)
+	}
+	var result struct {
+		Meta struct {
+			Coercions []Coercion `json:"coercions"`
+		} `json:"_meta"`
+	}
+	data, _ := json.Marshal(resp.Result)
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatal(err)
+	}
+	want := []Coercion{
+		{Path: "arguments.n", From: "string", To: "integer"},
+		{Path: "arguments.s", From: "number", To: "string"},
+		{Path: "arguments.tags", From: "string", To: "array"},
+	}
+	if len(result.Meta.Coercions) != len(want) {
+		t.Fatalf("coercions: got %+v, want %+v", result.Meta.Coercions, want)
+	}
+	for i, c := range want {
+		if result.Meta.Coercions[i] != c {
+			t.Errorf("coercion %d: got %+v, want %+v", i, result.Meta.Coercions[i], c)
+		}
+	}
+
+	// Inexact conversions are left for validation: 厳密でない変換は検証に任せる
+	if resp := call(s, map[string]interface{}{"n": "4.5"}); resp.Error == nil {
+		t.Fatal(`"4.5" was coerced to an integer`)
+	}
+
+	// Coercion is opt-in: 変換はオプトイン
+	plain := NewMCPServer()
+	plain.RegisterTool(Tool{Name: "c", InputSchema: schema, Handler: handler})
+	if resp := call(plain, map[string]interface{}{"n": "42"}); resp.Error == nil {
+		t.Fatal("arguments were coerced without WithArgumentCoercion")
+	}
+}
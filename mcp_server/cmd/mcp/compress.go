@@ -0,0 +1,128 @@
+package main
+
+import (
+	"compress/gzip" // compress/gzip: response compression (レスポンス圧縮)
+	"net/http"      // net/http: response writer (レスポンスライター)
+	"strconv"       // strconv: quality values (品質値)
+	"strings"       // strings: Accept-Encoding parsing (Accept-Encodingの解析)
+)
+
+// defaultCompressMinBytes is the smallest HTTP response body worth compressing
+// defaultCompressMinBytes: 圧縮する価値のある最小のHTTPレスポンスボディサイズ
+const defaultCompressMinBytes = 1024
+
+// acceptsGzip reports whether the request's Accept-Encoding allows gzip
+// acceptsGzip: リクエストのAccept-Encodingがgzipを許可しているかを判定する関数
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(name), "gzip") {
+			continue
+		}
+		// q=0 means "not acceptable": q=0は「受け付けない」を意味する
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			q, err := strconv.ParseFloat(v, 64)
+			return err == nil && q > 0
+		}
+		return true
+	}
+	return false
+}
+
+// compressWriter gzips a response once its body reaches a size threshold
+// compressWriter: ボディがしきい値に達したらレスポンスをgzip圧縮するライター
+// The status and the first bytes are held back until the decision is made. A flush
+// decides for compression, since only event streams flush and their length is unknown.
+// 判断が下るまでステータスと先頭のバイトは保留される。フラッシュするのは長さ不明のイベントストリームのみのため、フラッシュは圧縮に決定する
+// threshold: しきい値
+type compressWriter struct {
+	http.ResponseWriter
+
+	min     int          // min: threshold in bytes (バイト単位のしきい値)
+	status  int          // status: held-back status (保留中のステータス)
+	buf     []byte       // buf: held-back body (保留中のボディ)
+	decided bool         // decided: header written (ヘッダー書き込み済み)
+	gz      *gzip.Writer // gz: nil when sending plain (非圧縮送信時はnil)
+}
+
+// newCompressWriter wraps w, compressing bodies of at least min bytes
+// newCompressWriter: wをラップし、minバイト以上のボディを圧縮するライターを作成する関数
+func newCompressWriter(w http.ResponseWriter, min int) *compressWriter {
+	w.Header().Add("Vary", "Accept-Encoding") // caches key on encoding: キャッシュはエンコーディングで区別する
+	return &compressWriter{ResponseWriter: w, min: min, status: http.StatusOK}
+}
+
+func (c *compressWriter) WriteHeader(status int) {
+	if !c.decided {
+		c.status = status
+	}
+}
+
+func (c *compressWriter) Write(p []byte) (int, error) {
+	if !c.decided {
+		c.buf = append(c.buf, p...)
+		if len(c.buf) >= c.min {
+			if err := c.start(true); err != nil {
+				return 0, err
+			}
+		}
+		return len(p), nil
+	}
+	if c.gz != nil {
+		return c.gz.Write(p)
+	}
+	return c.ResponseWriter.Write(p)
+}
+
+// Flush pushes compressed bytes through to the client
+// Flush: 圧縮済みのバイトをクライアントへ送り出す関数
+func (c *compressWriter) Flush() {
+	if !c.decided {
+		c.start(true)
+	}
+	if c.gz != nil {
+		c.gz.Flush()
+	}
+	http.NewResponseController(c.ResponseWriter).Flush()
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+// Unwrap: 内側のライターをhttp.ResponseControllerに公開する関数
+func (c *compressWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
+
+// start writes the header, choosing the encoding, and releases the held-back body
+// start: エンコーディングを選んでヘッダーを書き込み、保留中のボディを送り出す関数
+func (c *compressWriter) start(compress bool) error {
+	c.decided = true
+	if compress {
+		c.Header().Set("Content-Encoding", "gzip")
+		c.Header().Del("Content-Length") // length changes: 長さが変わる
+		c.gz = gzip.NewWriter(c.ResponseWriter)
+	}
+	c.ResponseWriter.WriteHeader(c.status)
+	buf := c.buf
+	c.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	if c.gz != nil {
+		_, err := c.gz.Write(buf)
+		return err
+	}
+	_, err := c.ResponseWriter.Write(buf)
+	return err
+}
+
+// close sends a body that stayed below the threshold, or ends the gzip stream
+// close: しきい値未満のままのボディを送信するか、gzipストリームを終了する関数
+func (c *compressWriter) close() error {
+	if !c.decided {
+		return c.start(false)
+	}
+	if c.gz != nil {
+		return c.gz.Close()
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"compress/gzip"     // compress/gzip: decoding compressed bodies (圧縮されたボディのデコード)
+	"io"                // io: reading decoded bodies (デコードしたボディの読み取り)
+	"net/http"          // net/http: requests (リクエスト)
+	"net/http/httptest" // net/http/httptest: in-memory HTTP round trips (メモリ内のHTTP往復)
+	"strings"           // strings: request bodies and matching (リクエスト本文と照合)
+	"testing"           // testing: test framework (テストフレームワーク)
+)
+
+// TestAcceptsGzip checks Accept-Encoding parsing, including q=0 refusals
+// TestAcceptsGzip: q=0による拒否を含むAccept-Encodingの解析を確認するテスト
+func TestAcceptsGzip(t *testing.T) {
+	for header, want := range map[string]bool{
+		"gzip":        true,
+		"br, GZIP":    true,
+		"gzip;q=0.5":  true,
+		"gzip;q=0":    false,
+		"br, deflate": false,
+		"":            false,
+	} {
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		r.Header.Set("Accept-Encoding", header)
+		if got := acceptsGzip(r); got != want {
+			t.Errorf("Accept-Encoding %q: got %v, want %v", header, got, want)
+		}
+	}
+}
+
+// TestHTTPCompression checks that large responses are gzipped for clients that accept
+// it, and that small responses and refusing clients get plain bodies
+// TestHTTPCompression: 受け付けるクライアントには大きなレスポンスがgzip圧縮され、
+// 小さなレスポンスと拒否するクライアントには非圧縮のボディが返ることを確認するテスト
+func TestHTTPCompression(t *testing.T) {
+	s := NewMCPServer()
+	payload := strings.Repeat("a", 5000)
+	post := func(body, encoding string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		r.Header.Set("Accept-Encoding", encoding)
+		s.ServeHTTP(w, r)
+		return w
+	}
+	large := `{"jsonrpc":"2.0","id":1,"method":"resources/read","params":{"uri":"data:,` + payload + `"}}`
+
+	w := post(large, "gzip, br")
+	if w.Header().Get("Content-Encoding") != "gzip" || w.Header().Get("Vary") != "Accept-Encoding" {
+		t.Fatalf("large response headers: %v", w.Header())
+	}
+	zr, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(zr)
+	if err != nil || !strings.Contains(string(body), payload) {
+		t.Fatalf("decoded body: %v, %.80s", err, body)
+	}
+
+	if w := post(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`, "gzip"); w.Header().Get("Content-Encoding") != "" {
+		t.Fatal("a small response was compressed")
+	}
+	if w := post(large, "gzip;q=0"); w.Header().Get("Content-Encoding") != "" || !strings.Contains(w.Body.String(), payload) {
+		t.Fatal("a response was compressed for a client refusing gzip")
+	}
+}
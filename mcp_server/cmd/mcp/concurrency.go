@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context" // context: queue cancellation (待機のキャンセル)
+	"errors"  // errors: error values (エラー値)
+)
+
+// BusyPolicy decides what a call does when its tool is at MaxConcurrent
+// BusyPolicy: ツールがMaxConcurrentに達しているときの呼び出しの挙動を決める型
+type BusyPolicy int
+
+const (
+	BusyQueue  BusyPolicy = iota // queue: wait for a slot until the call's deadline (呼び出しの期限まで空きを待つ、デフォルト)
+	BusyReject                   // reject: fail at once with ErrToolBusy (ErrToolBusyで即座に失敗する)
+)
+
+// ErrToolBusy reports that a tool rejected a call because all its slots were taken
+// ErrToolBusy: 全ての枠が使用中のためツールが呼び出しを拒否したことを表すエラー
+var ErrToolBusy = errors.New("tool is busy")
+
+// acquire takes one of the tool's concurrency slots and returns the function that frees it
+// acquire: ツールの同時実行枠を1つ確保し、解放する関数を返す関数
+// Tools without MaxConcurrent are unlimited.
+// MaxConcurrentを持たないツールは無制限
+func (t Tool) acquire(ctx context.Context) (release func(), err error) {
+	if t.slots == nil {
+		return func() {}, nil
+	}
+	select {
+	case t.slots <- struct{}{}:
+		return func() { <-t.slots }, nil
+	default:
+	}
+	if t.BusyPolicy == BusyReject {
+		return nil, ErrToolBusy
+	}
+	select {
+	case t.slots <- struct{}{}:
+		return func() { <-t.slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
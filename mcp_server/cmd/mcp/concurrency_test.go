@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context" // context: request contexts (リクエストコンテキスト)
+	"testing" // testing: test framework (テストフレームワーク)
+	"time"    // time: waiting for the queued call (待機中の呼び出しの確認)
+)
+
+// TestToolMaxConcurrent checks that a tool at MaxConcurrent queues further calls by
+// default and rejects them with -32003 under BusyReject
+// TestToolMaxConcurrent: MaxConcurrentに達したツールが既定では後続の呼び出しを待たせ、
+// BusyRejectでは-32003で拒否することを確認するテスト
+func TestToolMaxConcurrent(t *testing.T) {
+	for _, policy := range []BusyPolicy{BusyQueue, BusyReject} {
+		s := NewMCPServer()
+		started, release := make(chan struct{}, 2), make(chan struct{})
+		s.RegisterTool(Tool{Name: "gpu", MaxConcurrent: 1, BusyPolicy: policy, Handler: func(ctx context.Context, arguments interface{}) (map[string]interface{}, error) {
+			started <- struct{}{}
+			<-release
+			return ToolResult(), nil
+		}})
+		call := func(id int64) <-chan *JSONRPCResponse {
+			done := make(chan *JSONRPCResponse, 1)
+			go func() {
+				done <- s.HandleRequest(context.Background(), &JSONRPCRequest{JSONRPC: "2.0", ID: IntID(id), Method: "tools/call", Params: map[string]interface{}{"name": "gpu"}})
+			}()
+			return done
+		}
+
+		first := call(1)
+		<-started
+		second := call(2)
+		if policy == BusyReject {
+			resp := <-second
+			if resp.Error == nil || resp.Error.Code != -32003 {
+				t.Fatalf("reject: got %+v, want -32003", resp.Error)
+			}
+			if data, _ := resp.Error.Data.(map[string]interface{}); data["tool"] != "gpu" || data["maxConcurrent"] != 1 {
+				t.Fatalf("reject data: %v", resp.Error.Data)
+			}
+			close(release)
+		} else {
+			select {
+			case <-started:
+				t.Fatal("queued call ran while the only slot was taken")
+			case <-time.After(50 * time.Millisecond):
+			}
+			close(release)
+			if resp := <-second; resp.Error != nil {
+				t.Fatalf("queued call: %v", resp.Error)
+			}
+		}
+		if resp := <-first; resp.Error != nil {
+			t.Fatalf("first call: %v", resp.Error)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"     // context: carries the connection through a request (リクエスト中の接続の受け渡し)
+	"io"          // io: the connection's output (接続の出力)
+	"sync"        // sync: guards subscriptions (購読の保護)
+	"sync/atomic" // sync/atomic: handshake state (ハンドシェイクの状態)
+)
+
+// connSession is the state of one client connection
+// connSession: 1つのクライアント接続の状態
+// Each RunIO loop and each HTTP session has its own, so one client's initialize or
+// subscriptions never affect another; the tool and resource registries stay shared.
+// Requests outside any connection, such as in-process calls, use the server's default.
+// 各RunIOループと各HTTPセッションがそれぞれ持つため、あるクライアントのinitializeや購読が
+// 他へ影響することはない。ツールとリソースのレジストリは共有のまま。
+// プロセス内呼び出しなど接続に属さないリクエストはサーバーのデフォルトを使う
+type connSession struct {
+	writeMu sync.Mutex // writeMu: serializes writes to out, so a stalled client blocks only its own connection (outへの書き込みを直列化、停滞したクライアントは自身の接続のみを止める)
+
+	out   io.Writer    // out: output stream, nil when notifications cannot be delivered (出力ストリーム、通知を届けられない場合はnil)
+	batch *notifyBatch // batch: notifications waiting to be written, nil without batching; guarded by writeMu (書き込み待ちの通知、バッチ化しない場合はnil、writeMuで保護)
+	codec Codec        // codec: wire encoding, nil for newline-delimited JSON (通信時のエンコーディング、nilなら改行区切りJSON)
+
+	serial bool // serial: RunIO reads and handles requests one at a time (RunIOがリクエストを1件ずつ読み取り処理する)
+
+	initialized     atomic.Bool  // initialized: the client sent notifications/initialized (クライアントがnotifications/initializedを送信済み)
+	client          atomic.Value // client: client name from initialize (initializeで得たクライアント名)
+	protocolVersion atomic.Value // protocolVersion: version negotiated by initialize (initializeで合意したバージョン)
+
+	mu            sync.Mutex      // mu: guards the subscriptions (購読を保護)
+	subscriptions map[string]bool // subscriptions: subscribed resource URIs (購読中のリソースURI)
+	subPatterns   map[string]bool // subPatterns: subscribed URI patterns (購読中のURIパターン)
+}
+
+// newConnSession creates the state for a connection writing to out
+// newConnSession: outへ書き込む接続の状態を作成する関数
+func (s *MCPServer) newConnSession(out io.Writer) *connSession {
+	c := &connSession{
+		out:           out,
+		subscriptions: make(map[string]bool),
+		subPatterns:   make(map[string]bool),
+	}
+	if s.batchMax > 1 && s.batchWindow > 0 {
+		c.batch = &notifyBatch{window: s.batchWindow, max: s.batchMax}
+	}
+	return c
+}
+
+// connKey is the context key for the current connection
+// connKey: 現在の接続用のコンテキストキー
+type connKey struct{}
+
+// withConn returns a copy of ctx carrying c
+// withConn: cを持つctxのコピーを返す関数
+func withConn(ctx context.Context, c *connSession) context.Context {
+	return context.WithValue(ctx, connKey{}, c)
+}
+
+// connFor returns the connection carrying ctx, or the server's default
+// connFor: ctxを運ぶ接続を返す関数 (無ければサーバーのデフォルト)
+func (s *MCPServer) connFor(ctx context.Context) *connSession {
+	if c, ok := ctx.Value(connKey{}).(*connSession); ok {
+		return c
+	}
+	return s.conn
+}
+
+// subscribe adds or removes a subscription to a URI, or to a pattern when pattern is set
+// subscribe: URI、またはpatternが真ならパターンへの購読を追加・削除する関数
+func (c *connSession) subscribe(target string, pattern, on bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	set := c.subscriptions
+	if pattern {
+		set = c.subPatterns
+	}
+	if on {
+		set[target] = true
+	} else {
+		delete(set, target)
+	}
+}
+
+// subscribed reports whether the connection subscribed to uri or to a pattern matching it
+// subscribed: 接続がuri、またはそれに一致するパターンを購読しているかを判定する関数
+func (c *connSession) subscribed(uri string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.subscriptions[uri] {
+		return true
+	}
+	for pattern := range c.subPatterns {
+		if matchPattern(pattern, uri) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientName returns the client name announced in initialize, if any
+// clientName: initializeで通知されたクライアント名を返す関数
+func (c *connSession) clientName() string {
+	name, _ := c.client.Load().(string)
+	return name
+}
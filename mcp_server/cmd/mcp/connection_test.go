@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context" // context: RunIO lifetime (RunIOの存続期間)
+	"io"      // io: piped input (パイプ経由の入力)
+	"strings" // strings: output matching (出力の照合)
+	"testing" // testing: test framework (テストフレームワーク)
+)
+
+// TestConnectionIsolation checks that two connections to one server keep their own
+// handshake and subscriptions while sharing the tool registry
+// TestConnectionIsolation: 1つのサーバーへの2つの接続が、ツールの登録を共有しつつ
+// ハンドシェイクと購読をそれぞれ独自に保持することを確認するテスト
+func TestConnectionIsolation(t *testing.T) {
+	s := newEchoServer()
+	type conn struct {
+		in   *io.PipeWriter
+		out  *syncBuffer
+		done chan error
+	}
+	open := func() conn {
+		pr, pw := io.Pipe()
+		c := conn{in: pw, out: &syncBuffer{}, done: make(chan error, 1)}
+		go func() { c.done <- s.RunIO(context.Background(), pr, c.out) }()
+		return c
+	}
+	ready, fresh := open(), open()
+
+	io.WriteString(ready.in, `{"jsonrpc":"2.0","id":1,"method":"initialize"}`+"\n")
+	io.WriteString(ready.in, `{"jsonrpc":"2.0","method":"notifications/initialized"}`+"\n")
+	io.WriteString(ready.in, `{"jsonrpc":"2.0","id":2,"method":"resources/subscribe","params":{"uri":"data:,a"}}`+"\n")
+	eventually(t, func() bool { return strings.Contains(ready.out.String(), `"id":2`) })
+	io.WriteString(fresh.in, `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`+"\n")
+	eventually(t, func() bool { return strings.Contains(fresh.out.String(), `"id":1`) })
+
+	s.RegisterTool(Tool{Name: "extra"})
+	s.NotifyResourceUpdated("data:,a")
+	for _, c := range []conn{ready, fresh} {
+		c.in.Close()
+		if err := <-c.done; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := ready.out.String(); !strings.Contains(got, "notifications/tools/list_changed") || !strings.Contains(got, "notifications/resources/updated") {
+		t.Fatalf("initialized connection missed notifications:\n%s", got)
+	}
+	got := fresh.out.String()
+	if strings.Contains(got, "notifications/") || !strings.Contains(got, `"name":"echo"`) {
+		t.Fatalf("uninitialized connection:\n%s", got)
+	}
+}
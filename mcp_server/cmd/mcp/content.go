@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/base64" // encoding/base64: binary payload encoding (バイナリのエンコード)
+	"encoding/json"   // encoding/json: text rendering of structured results (構造化結果のテキスト表現)
+	"fmt"             // fmt: error wrapping (エラーのラップ)
+)
+
+// contentFields lists the string fields each known content type requires
+// contentFields: 既知のcontentの種類ごとに必須の文字列フィールドの一覧
+// Entries of other types only need a type, so newer content types pass through.
+// それ以外の種類の項目はtypeのみを要するため、新しいcontentの種類はそのまま通る
+var contentFields = map[string][]string{
+	"text":          {"text"},
+	"image":         {"data", "mimeType"},
+	"audio":         {"data", "mimeType"},
+	"resource_link": {"uri", "name"},
+}
+
+// TextContent builds a text entry for a tool result's content array
+// TextContent: ツール結果のcontent配列に入れるテキスト項目を作成する関数
+func TextContent(text string) map[string]interface{} {
+	return map[string]interface{}{
+		"type": "text", // text: テキスト
+		"text": text,
+	}
+}
+
+// ImageContent builds an image entry from raw bytes for a tool result's content array
+// ImageContent: 生のバイト列からツール結果のcontent配列に入れる画像項目を作成する関数
+// The bytes are base64-encoded as the protocol requires.
+// バイト列はプロトコルの要求どおりbase64でエンコードする
+func ImageContent(data []byte, mimeType string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":     "image", // image: 画像
+		"data":     base64.StdEncoding.EncodeToString(data),
+		"mimeType": mimeType,
+	}
+}
+
+// ToolResult wraps content entries into a tools/call result, preserving their order
+// ToolResult: content項目を順序を保ったままtools/callの結果にまとめる関数
+// Entries of different types may be mixed freely.
+// 異なる種類の項目を自由に混在させてよい
+func ToolResult(content ...map[string]interface{}) map[string]interface{} {
+	if content == nil {
+		content = []map[string]interface{}{} // empty, not null: nullではなく空配列
+	}
+	return map[string]interface{}{
+		"content": content, // content: 内容
+	}
+}
+
+// StructuredResult builds a tools/call result carrying v as structuredContent
+// StructuredResult: vをstructuredContentとして持つtools/callの結果を作成する関数
+// The content array holds the same value rendered as JSON text, for clients that
+// only read content. Handlers can return it directly.
+// contentのみを読むクライアントのため、content配列には同じ値をJSONテキストとして入れる。
+// ハンドラーはこれをそのまま返せる
+func StructuredResult(v interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("marshal structured content: %w", err)
+	}
+	result := ToolResult(TextContent(string(data)))
+	result["structuredContent"] = v // structured: 構造化された
+	return result, nil
+}
+
+// validateContent checks the shape of a tool result's content array
+// validateContent: ツール結果のcontent配列の形を検査する関数
+// Every entry must be an object with a string type and the string fields its type
+// requires; an embedded resource needs a resource object with a string uri. Results
+// without content, such as handler errors, are not checked.
+// 各項目はstringのtypeと、その種類が要するstringのフィールドを持つオブジェクトでなければならない。
+// 埋め込みリソースはstringのuriを持つresourceオブジェクトを要する。
+// ハンドラーのエラーなどcontentを持たない結果は検査しない
+func validateContent(result map[string]interface{}) error {
+	content, ok := result["content"]
+	if !ok {
+		return nil
+	}
+	var entries []interface{}
+	switch c := content.(type) {
+	case []map[string]interface{}:
+		for _, entry := range c {
+			entries = append(entries, entry)
+		}
+	case []interface{}:
+		entries = c
+	case nil:
+		return &schemaViolation{Path: "content", Keyword: "type", Reason: "must be an array"}
+	default:
+		// Other Go types are checked in their JSON form: その他のGoの型はJSON形式で検査する
+		data, err := json.Marshal(content)
+		if err != nil {
+			return fmt.Errorf("marshal content: %w", err)
+		}
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return &schemaViolation{Path: "content", Keyword: "type", Reason: "must be an array"}
+		}
+	}
+
+	for i, e := range entries {
+		path := fmt.Sprintf("content[%d]", i)
+		entry, ok := e.(map[string]interface{})
+		if !ok {
+			return &schemaViolation{Path: path, Keyword: "type", Reason: "must be an object"}
+		}
+		typ, ok := entry["type"].(string)
+		if !ok {
+			return &schemaViolation{Path: path + ".type", Keyword: "required", Reason: "must be a string"}
+		}
+		for _, field := range contentFields[typ] {
+			if _, ok := entry[field].(string); !ok {
+				return &schemaViolation{Path: path + "." + field, Keyword: "required", Reason: "must be a string for " + typ + " content"}
+			}
+		}
+		if typ == "resource" {
+			// Embedded resource: 埋め込みリソース
+			resource, ok := entry["resource"].(map[string]interface{})
+			if !ok {
+				return &schemaViolation{Path: path + ".resource", Keyword: "required", Reason: "must be an object for resource content"}
+			}
+			if _, ok := resource["uri"].(string); !ok {
+				return &schemaViolation{Path: path + ".resource.uri", Keyword: "required", Reason: "must be a string"}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"       // context: handler signature (ハンドラーのシグネチャ)
+	"encoding/json" // encoding/json: empty content encoding (空のcontentのエンコード)
+	"errors"        // errors: violation matching (違反の照合)
+	"testing"       // testing: test framework (テストフレームワーク)
+)
+
+// TestToolResultMixedContent checks that text and image entries keep their order
+// through tools/call and that an empty result encodes content as an empty array
+// TestToolResultMixedContent: テキストと画像の項目がtools/callを通して順序を保ち、
+// 空の結果ではcontentが空配列としてエンコードされることを確認するテスト
+func TestToolResultMixedContent(t *testing.T) {
+	s := NewMCPServer()
+	s.RegisterTool(Tool{
+		Name: "mixed",
+		Handler: func(ctx context.Context, arguments interface{}) (map[string]interface{}, error) {
+			return ToolResult(TextContent("a"), ImageContent([]byte{1, 2}, "image/png"), TextContent("b")), nil
+		},
+	})
+	result, err := NewClient(s).CallTool("mixed", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []map[string]interface{}{
+		{"type": "text", "text": "a"},
+		{"type": "image", "data": "AQI=", "mimeType": "image/png"},
+		{"type": "text", "text": "b"},
+	}
+	if len(result.Content) != len(want) {
+		t.Fatalf("got %d entries, want %d", len(result.Content), len(want))
+	}
+	for i, entry := range want {
+		for k, v := range entry {
+			if result.Content[i][k] != v {
+				t.Fatalf("content[%d].%s: got %v, want %v", i, k, result.Content[i][k], v)
+			}
+		}
+	}
+
+	data, err := json.Marshal(ToolResult())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"content":[]}` {
+		t.Fatalf("empty result: got %s", data)
+	}
+}
+
+// TestStructuredResult checks that a structured result carries the value both as
+// structuredContent and as JSON text in content, and that unencodable values fail
+// TestStructuredResult: 構造化された結果が値をstructuredContentとcontent内のJSONテキストの
+// 両方で持ち、エンコードできない値は失敗することを確認するテスト
+func TestStructuredResult(t *testing.T) {
+	s := NewMCPServer()
+	s.RegisterTool(Tool{
+		Name: "weather",
+		Handler: func(ctx context.Context, arguments interface{}) (map[string]interface{}, error) {
+			return StructuredResult(map[string]interface{}{"temp": 21.5})
+		},
+	})
+	result, err := NewClient(s).CallTool("weather", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(result.StructuredContent); got != `{"temp":21.5}` {
+		t.Fatalf("structuredContent: got %s", got)
+	}
+	if len(result.Content) != 1 || result.Content[0]["type"] != "text" || result.Content[0]["text"] != `{"temp":21.5}` {
+		t.Fatalf("content: got %v", result.Content)
+	}
+
+	if _, err := StructuredResult(make(chan int)); err == nil {
+		t.Fatal("StructuredResult accepted a channel")
+	}
+}
+
+// TestValidateContent checks which content arrays pass the shape check and the
+// path reported for those that do not
+// TestValidateContent: どのcontent配列が形の検査を通るかと、通らないものについて
+// 報告されるパスを確認するテスト
+func TestValidateContent(t *testing.T) {
+	for _, tt := range []struct {
+		result map[string]interface{}
+		path   string
+	}{
+		{ToolResult(TextContent("a"), ImageContent([]byte{1}, "image/png")), ""},
+		{map[string]interface{}{"content": []interface{}{map[string]interface{}{"type": "future"}}}, ""},
+		{map[string]interface{}{"isError": true}, ""},
+		{map[string]interface{}{"content": "text"}, "content"},
+		{map[string]interface{}{"content": nil}, "content"},
+		{map[string]interface{}{"content": []interface{}{"text"}}, "content[0]"},
+		{ToolResult(TextContent("a"), map[string]interface{}{"text": "b"}), "content[1].type"},
+		{map[string]interface{}{"content": []interface{}{map[string]interface{}{"type": "text", "text": 5}}}, "content[0].text"},
+		{ToolResult(map[string]interface{}{"type": "image", "data": "AQ=="}), "content[0].mimeType"},
+		{ToolResult(map[string]interface{}{"type": "resource"}), "content[0].resource"},
+		{ToolResult(map[string]interface{}{"type": "resource", "resource": map[string]interface{}{}}), "content[0].resource.uri"},
+	} {
+		err := validateContent(tt.result)
+		var violation *schemaViolation
+		if tt.path == "" && err != nil {
+			t.Errorf("%v: got %v, want valid", tt.result, err)
+		} else if tt.path != "" && (!errors.As(err, &violation) || violation.Path != tt.path) {
+			t.Errorf("%v: got %v, want a violation at %s", tt.result, err, tt.path)
+		}
+	}
+}
+
+// TestToolsCallMalformedContent checks that a handler returning malformed content is
+// answered with -32603 naming the offending path instead of the result
+// TestToolsCallMalformedContent: 不正なcontentを返すハンドラーに、結果の代わりに
+// 問題のパスを示す-32603で応答することを確認するテスト
+func TestToolsCallMalformedContent(t *testing.T) {
+	s := NewMCPServer()
+	s.RegisterTool(Tool{Name: "bad", Handler: func(ctx context.Context, arguments interface{}) (map[string]interface{}, error) {
+		return ToolResult(map[string]interface{}{"text": "no type"}), nil
+	}})
+	resp := s.HandleRequest(context.Background(), &JSONRPCRequest{JSONRPC: "2.0", ID: IntID(1), Method: "tools/call", Params: map[string]interface{}{"name": "bad"}})
+	if resp.Error == nil || resp.Error.Code != -32603 || resp.Result != nil {
+		t.Fatalf("got %+v, want -32603", resp)
+	}
+	if data, _ := resp.Error.Data.(map[string]interface{}); data["tool"] != "bad" || data["path"] != "content[0].type" {
+		t.Fatalf("error data: %v", resp.Error.Data)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"     // context: carries the correlation id (相関IDの受け渡し)
+	"crypto/rand" // crypto/rand: generated ids (生成するID)
+	"log/slog"    // log/slog: correlation attribute (相関IDの属性)
+)
+
+// correlationKey is the context key for the request's correlation id
+// correlationKey: リクエストの相関ID用のコンテキストキー
+type correlationKey struct{}
+
+// CorrelationID returns the correlation id of the request being handled, or ""
+// CorrelationID: 処理中のリクエストの相関IDを返す関数 (無ければ"")
+func CorrelationID(ctx context.Context) string {
+	id, _ := ctx.Value(correlationKey{}).(string)
+	return id
+}
+
+// withCorrelation returns a context carrying the correlation id for req
+// withCorrelation: reqの相関IDを持つコンテキストを返す関数
+// The id is taken from params._meta.correlationId when the client sent one, kept when
+// ctx already carries one, and generated otherwise. Log lines written with the context carry it as correlationId,
+// and Client calls made with it forward it to downstream servers.
+// クライアントがparams._meta.correlationIdを送った場合はそれを使い、ctxが既に持っていればそれを保ち、無ければ生成する。
+// このコンテキストで書かれたログ行はcorrelationIdとしてそれを持ち、
+// このコンテキストでのClient呼び出しは下流サーバーへそれを転送する
+func withCorrelation(ctx context.Context, req *JSONRPCRequest) context.Context {
+	id, _ := req.Meta()["correlationId"].(string)
+	if id == "" {
+		id = CorrelationID(ctx) // set by the transport: トランスポートが設定済み
+	}
+	if id == "" {
+		id = rand.Text() // generate: 生成する
+	}
+	return context.WithValue(ctx, correlationKey{}, id)
+}
+
+// withCorrelationMeta sets params._meta.correlationId to the id ctx carries, if any
+// withCorrelationMeta: ctxが相関IDを持つ場合、params._meta.correlationIdに設定する関数
+// It lets a proxied call be traced across servers. Object params are copied, not modified.
+// プロキシされた呼び出しをサーバー間で追跡できるようにする。オブジェクトのパラメータは変更せずコピーする
+func withCorrelationMeta(ctx context.Context, params interface{}) interface{} {
+	id := CorrelationID(ctx)
+	if id == "" {
+		return params
+	}
+	p, ok := params.(map[string]interface{})
+	if params != nil && !ok {
+		return params // positional params have no _meta: 位置指定のパラメータは_metaを持たない
+	}
+	copied := make(map[string]interface{}, len(p)+1)
+	for k, v := range p {
+		copied[k] = v
+	}
+	meta := make(map[string]interface{})
+	for k, v := range paramsMeta(p) {
+		meta[k] = v
+	}
+	meta["correlationId"] = id
+	copied["_meta"] = meta
+	return copied
+}
+
+// correlationHandler adds the context's correlation id to every record
+// correlationHandler: コンテキストの相関IDを全てのレコードに付与するハンドラー
+// Only the *Context logging methods pass the request context through.
+// リクエストのコンテキストを渡すのは*Context系のログメソッドのみ
+type correlationHandler struct {
+	slog.Handler // Handler: wrapped handler (ラップ先のハンドラー)
+}
+
+func (h correlationHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := CorrelationID(ctx); id != "" {
+		record.AddAttrs(slog.String("correlationId", id))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h correlationHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return correlationHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h correlationHandler) WithGroup(name string) slog.Handler {
+	return correlationHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"             // bytes: captured log output (取り込んだログ出力)
+	"context"           // context: RunIO lifetime (RunIOの存続期間)
+	"encoding/json"     // encoding/json: decoding log lines (ログ行のデコード)
+	"log/slog"          // log/slog: structured logger under test (テスト対象の構造化ロガー)
+	"net/http"          // net/http: request methods (リクエストメソッド)
+	"net/http/httptest" // net/http/httptest: in-memory HTTP round trips (メモリ内のHTTP往復)
+	"strings"           // strings: request bodies (リクエスト本文)
+	"testing"           // testing: test framework (テストフレームワーク)
+)
+
+// oversizedRequest asks for a tool list larger than the response limit used below,
+// carrying the correlation id "trace-1"
+// oversizedRequest: 以下で使うレスポンス上限より大きいツール一覧を求める、相関ID "trace-1" を持つリクエスト
+const oversizedRequest = `{"jsonrpc":"2.0","id":1,"method":"tools/list","params":{"_meta":{"correlationId":"trace-1"}}}`
+
+// logCorrelation returns the correlationId of the first log line with message msg
+// logCorrelation: メッセージがmsgである最初のログ行のcorrelationIdを返す関数
+func logCorrelation(t *testing.T, logs *bytes.Buffer, msg string) string {
+	t.Helper()
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("log line %q: %v", line, err)
+		}
+		if record["msg"] == msg {
+			id, _ := record["correlationId"].(string)
+			return id
+		}
+	}
+	t.Fatalf("no %q log line in:\n%s", msg, logs.String())
+	return ""
+}
+
+// TestCorrelationCoversResponseWrite checks that logs written while encoding a
+// response carry the request's correlation id on stdio and HTTP
+// TestCorrelationCoversResponseWrite: レスポンスのエンコード中に書かれたログが、
+// 標準入出力とHTTPでリクエストの相関IDを持つことを確認するテスト
+func TestCorrelationCoversResponseWrite(t *testing.T) {
+	newServer := func(logs *bytes.Buffer) *MCPServer {
+		s := NewMCPServer(
+			WithMaxResponseBytes(64, ResponseError),
+			WithLogger(slog.New(slog.NewJSONHandler(logs, nil))),
+		)
+		s.RegisterTool(EchoTool())
+		return s
+	}
+
+	t.Run("stdio", func(t *testing.T) {
+		var logs, out bytes.Buffer
+		if err := newServer(&logs).RunIO(context.Background(), strings.NewReader(oversizedRequest+"\n"), &out); err != nil {
+			t.Fatal(err)
+		}
+		if id := logCorrelation(t, &logs, "response exceeds limit"); id != "trace-1" {
+			t.Fatalf("correlationId: got %q, want trace-1", id)
+		}
+	})
+
+	t.Run("http", func(t *testing.T) {
+		var logs bytes.Buffer
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(oversizedRequest))
+		r.Header.Set("Content-Type", "application/json")
+		newServer(&logs).ServeHTTP(w, r)
+		if id := logCorrelation(t, &logs, "response exceeds limit"); id != "trace-1" {
+			t.Fatalf("correlationId: got %q, want trace-1", id)
+		}
+	})
+}
+
+// TestCorrelationID checks that a request's correlation id comes from
+// _meta.correlationId or is generated, tags the log lines written while handling
+// it, and is forwarded to downstream servers
+// TestCorrelationID: リクエストの相関IDが_meta.correlationIdから取られるか生成され、
+// 処理中に書かれたログ行に付き、下流サーバーへ転送されることを確認するテスト
+func TestCorrelationID(t *testing.T) {
+	var logs bytes.Buffer
+	s := NewMCPServer(WithLogger(slog.New(slog.NewJSONHandler(&logs, nil))))
+	s.RegisterTool(Tool{Name: "local", Handler: func(ctx context.Context, arguments interface{}) (map[string]interface{}, error) {
+		s.logger.InfoContext(ctx, "local tool ran")
+		return ToolResult(), nil
+	}})
+	var downstreamID string
+	d := NewMCPServer()
+	d.RegisterTool(Tool{Name: "t", Handler: func(ctx context.Context, arguments interface{}) (map[string]interface{}, error) {
+		downstreamID = CorrelationID(ctx)
+		return ToolResult(), nil
+	}})
+	client := NewClient(d)
+	if _, err := client.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddDownstream("down", client); err != nil {
+		t.Fatal(err)
+	}
+	call := func(tool string, params map[string]interface{}) {
+		t.Helper()
+		params["name"] = tool
+		if resp := s.HandleRequest(context.Background(), &JSONRPCRequest{JSONRPC: "2.0", ID: IntID(1), Method: "tools/call", Params: params}); resp.Error != nil {
+			t.Fatal(resp.Error)
+		}
+	}
+	withID := func() map[string]interface{} {
+		return map[string]interface{}{"_meta": map[string]interface{}{"correlationId": "trace-2"}}
+	}
+
+	call("local", withID())
+	if got := logCorrelation(t, &logs, "local tool ran"); got != "trace-2" {
+		t.Fatalf("log line: got correlationId %q, want trace-2", got)
+	}
+	call("down.t", withID())
+	if downstreamID != "trace-2" {
+		t.Fatalf("downstream: got %q, want trace-2", downstreamID)
+	}
+
+	logs.Reset()
+	call("local", map[string]interface{}{})
+	generated := logCorrelation(t, &logs, "local tool ran")
+	call("down.t", map[string]interface{}{})
+	if generated == "" || downstreamID == "" || downstreamID == generated {
+		t.Fatalf("generated ids: %q for the log, %q downstream; want distinct per request", generated, downstreamID)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"errors" // errors: error values (エラー値)
+	"fmt"    // fmt: error formatting (エラーの整形)
+)
+
+// defaultMaxDepth is the default nesting limit for incoming messages
+// defaultMaxDepth: 受信メッセージのネストの深さのデフォルト上限
+const defaultMaxDepth = 100
+
+// errTooDeep reports a message nested deeper than the configured limit
+// errTooDeep: 設定された上限より深くネストしたメッセージを表すエラー
+var errTooDeep = errors.New("request nested too deeply")
+
+// checkDepth reports errTooDeep when data nests objects and arrays deeper than max
+// checkDepth: dataのオブジェクトと配列のネストがmaxより深い場合にerrTooDeepを返す関数
+// It scans the raw bytes before unmarshaling, so an over-deep payload never reaches
+// the decoder or the schema validator. Brackets inside strings are skipped; other
+// syntax errors are left to the decoder. A non-positive max disables the check.
+// アンマーシャルの前に生のバイト列を走査するため、深すぎるペイロードはデコーダーにもスキーマ検証にも届かない。
+// 文字列内の括弧は無視し、その他の構文エラーはデコーダーに任せる。maxが0以下なら検査しない
+func checkDepth(data []byte, max int) error {
+	if max <= 0 {
+		return nil
+	}
+	depth := 0
+	inString, escaped := false, false
+	for _, b := range data {
+		switch {
+		case inString:
+			// Inside a string: 文字列の内部
+			if escaped {
+				escaped = false
+			} else if b == '\\' {
+				escaped = true
+			} else if b == '"' {
+				inString = false
+			}
+		case b == '"':
+			inString = true
+		case b == '{' || b == '[':
+			depth++
+			if depth > max {
+				return fmt.Errorf("%w: limit is %d", errTooDeep, max)
+			}
+		case b == '}' || b == ']':
+			depth--
+		}
+	}
+	return nil
+}
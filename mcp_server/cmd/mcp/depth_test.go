@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context" // context: RunIO lifetime (RunIOの存続期間)
+	"errors"  // errors: error matching (エラーの照合)
+	"strings" // strings: building nested payloads (ネストしたペイロードの組み立て)
+	"testing" // testing: test framework (テストフレームワーク)
+)
+
+// nested returns n levels of empty nested arrays
+// nested: n段の配列を入れ子にした文字列を返す関数
+func nested(n int) string {
+	return strings.Repeat("[", n) + strings.Repeat("]", n)
+}
+
+// TestCheckDepth checks the depth limit, that brackets inside strings are not
+// counted, and that a non-positive limit disables the check
+// TestCheckDepth: 深さの上限、文字列内の括弧が数えられないこと、
+// 0以下の上限で検査が無効になることを確認するテスト
+func TestCheckDepth(t *testing.T) {
+	for _, tt := range []struct {
+		data    string
+		max     int
+		tooDeep bool
+	}{
+		{nested(3), 3, false},
+		{nested(4), 3, true},
+		{`{"a":{"b":[1]}}`, 3, false},
+		{`{"a":{"b":[[1]]}}`, 3, true},
+		{`{"s":"[[[[[[\"{{{{"}`, 2, false},
+		{nested(1000), 0, false},
+	} {
+		err := checkDepth([]byte(tt.data), tt.max)
+		if errors.Is(err, errTooDeep) != tt.tooDeep {
+			t.Errorf("checkDepth(%.20q, %d): got %v", tt.data, tt.max, err)
+		}
+	}
+}
+
+// TestRunIOMaxDepth checks that over-deep requests are answered with -32600 under
+// both the default and a configured limit, and that the loop keeps serving
+// TestRunIOMaxDepth: 深すぎるリクエストがデフォルトと設定した上限のどちらでも-32600で応答され、
+// ループが処理を続けることを確認するテスト
+func TestRunIOMaxDepth(t *testing.T) {
+	request := func(params string) string {
+		return `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"echo","arguments":{"message":"m","extra":` + params + `}}}` + "\n"
+	}
+	list := `{"jsonrpc":"2.0","id":2,"method":"tools/list"}` + "\n"
+	for _, tt := range []struct {
+		s      *MCPServer
+		params string
+	}{
+		{newEchoServer(), nested(defaultMaxDepth)},
+		{NewMCPServer(WithExampleTools(), WithMaxDepth(8)), nested(6)}, // 3 levels of envelope: 外側の3段
+	} {
+		var out strings.Builder
+		if err := tt.s.RunIO(context.Background(), strings.NewReader(request(tt.params)+list), &out); err != nil {
+			t.Fatal(err)
+		}
+		msgs := decodeLines(t, out.String())
+		if len(msgs) != 2 {
+			t.Fatalf("got %d responses, want 2:\n%s", len(msgs), out.String())
+		}
+		if rpcErr, _ := msgs[0]["error"].(map[string]interface{}); rpcErr["code"] != float64(-32600) {
+			t.Errorf("over-deep request: %v", msgs[0])
+		}
+		if msgs[1]["id"] != float64(2) || msgs[1]["error"] != nil {
+			t.Errorf("request after the rejected one: %v", msgs[1])
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context" // context: cancellation and deadlines (キャンセルと期限)
+	"errors"  // errors: error values (エラー値)
+	"fmt"     // fmt: formatted I/O (フォーマット済みI/O)
+)
+
+// WithExampleTools registers the example echo tool
+// WithExampleTools: 例示用のechoツールを登録するオプション
+// Servers start with no tools; embedders opt in to the examples explicitly.
+// Like the other built-in tools it is registered again by Reset.
+// サーバーはツールなしで開始する。組み込み側は例示用ツールを明示的に選択する。
+// 他の組み込みツールと同様にResetで再登録される
+func WithExampleTools() Option {
+	return func(s *MCPServer) {
+		s.builtinTools = append(s.builtinTools, func(string) Tool {
+			return EchoTool()
+		})
+	}
+}
+
+// EchoTool returns a tool that echoes back its message argument
+// EchoTool: message引数をそのまま返すツールを返す関数
+// echo: エコー、反響
+func EchoTool() Tool {
+	return Tool{
+		Name:        "echo",
+		Description: "Echo back the provided message", // provided: 提供された
+		InputSchema: map[string]interface{}{
+			"type": "object", // object: オブジェクト、物体
+			"properties": map[string]interface{}{
+				"message": map[string]interface{}{
+					"type":        "string",
+					"description": "Message to echo back", // echo: エコー、反響
+				},
+			},
+			"required": []string{"message"}, // required: 必須の
+		},
+		Annotations: &ToolAnnotations{ReadOnlyHint: Bool(true)},
+		Handler:     echo,
+	}
+}
+
+// echo implements EchoTool
+// echo: EchoToolの実装
+func echo(ctx context.Context, arguments interface{}) (map[string]interface{}, error) {
+	args, ok := arguments.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("Invalid arguments") // arguments: 引数
+	}
+	message, ok := args["message"].(string)
+	if !ok {
+		return nil, errors.New("Message is required")
+	}
+	return ToolResult(TextContent(fmt.Sprintf("Echo: %s", message))), nil // sprintf: 文字列フォーマット
+}
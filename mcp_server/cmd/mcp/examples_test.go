@@ -0,0 +1,25 @@
+package main
+
+import (
+	"testing" // testing: test framework (テストフレームワーク)
+)
+
+// TestWithExampleTools checks that a server starts without tools and that
+// WithExampleTools registers the echo tool
+// TestWithExampleTools: サーバーがツール無しで起動し、WithExampleToolsがechoツールを
+// 登録することを確認するテスト
+func TestWithExampleTools(t *testing.T) {
+	tools, err := NewClient(NewMCPServer()).ListTools()
+	if err != nil || len(tools) != 0 {
+		t.Fatalf("bare server: got %v, %v; want no tools", tools, err)
+	}
+
+	c := NewClient(NewMCPServer(WithExampleTools()))
+	if tools, err = c.ListTools(); err != nil || len(tools) != 1 || tools[0].Name != "echo" {
+		t.Fatalf("with example tools: got %v, %v; want echo", tools, err)
+	}
+	result, err := c.CallTool("echo", map[string]interface{}{"message": "hi"})
+	if err != nil || result.Content[0]["text"] != "Echo: hi" {
+		t.Fatalf("echo: got %v, %v", result, err)
+	}
+}
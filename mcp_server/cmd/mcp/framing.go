@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bufio"  // bufio: byte-wise reading (1バイトずつの読み取り)
+	"errors" // errors: end of stream detection (ストリーム終端の判定)
+	"fmt"    // fmt: error wrapping (エラーのラップ)
+	"io"     // io: the input stream (入力ストリーム)
+)
+
+// Framing decides how RunIO finds message boundaries in its input
+// Framing: RunIOが入力中のメッセージの境界を見つける方法を決める型
+type Framing int
+
+const (
+	FramingLines      Framing = iota // lines: one message per line (1行に1メッセージ、デフォルト)
+	FramingJSONStream                // JSON stream: successive JSON values, newlines anywhere (連続したJSON値、改行はどこでもよい)
+)
+
+// streamsJSON reports whether RunIO reads its input as a stream of JSON values
+// streamsJSON: RunIOが入力をJSON値のストリームとして読み取るかを判定する関数
+// A codec that splits its own messages keeps its framing.
+// 自らメッセージを区切るコーデックはその区切り方を保つ
+func (s *MCPServer) streamsJSON() bool {
+	_, splits := s.codec.(messageSplitter)
+	return s.framing == FramingJSONStream && !splits
+}
+
+// errMessageTooLarge reports a streamed message larger than the size limit
+// errMessageTooLarge: サイズの上限より大きいストリーム中のメッセージを表すエラー
+var errMessageTooLarge = errors.New("message too large")
+
+// readJSONStream sends each JSON value read from in to out until in ends or stop is closed
+// readJSONStream: inが終わるかstopが閉じられるまで、inから読み取った各JSON値をoutへ送る関数
+// A value may span lines, as in pretty-printed requests. Values are framed by
+// tracking brackets and strings byte by byte, so nesting beyond maxDepth or a value
+// beyond maxBytes ends the read with an error before more of it is buffered; the
+// stream cannot resynchronize after either. A non-positive limit disables its check.
+// Whether a framed value is valid JSON is left to the caller.
+// 整形されたリクエストのように値は複数行にわたってよい。値は括弧と文字列を1バイトずつ追跡して
+// 区切るため、maxDepthを超えるネストやmaxBytesを超える値は、それ以上バッファする前にエラーで
+// 読み取りを終える。どちらの後もストリームは再同期できない。0以下の上限はその検査を無効にする。
+// 区切った値が正しいJSONかどうかは呼び出し元に任せる
+func readJSONStream(in io.Reader, out chan<- string, stop <-chan struct{}, maxDepth int, maxBytes int64) error {
+	r := bufio.NewReader(in)
+	for {
+		msg, err := nextJSONValue(r, maxDepth, maxBytes)
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("read JSON stream: %w", err)
+		}
+		select {
+		case out <- string(msg):
+		case <-stop:
+			return nil
+		}
+	}
+}
+
+// nextJSONValue reads the next JSON value from r, skipping leading whitespace
+// nextJSONValue: 先頭の空白を読み飛ばし、rから次のJSON値を読み取る関数
+// It returns io.EOF when r ends between values.
+// rが値と値の間で終わった場合はio.EOFを返す
+func nextJSONValue(r *bufio.Reader, maxDepth int, maxBytes int64) ([]byte, error) {
+	var buf []byte
+	depth := 0
+	inString, escaped := false, false
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			if errors.Is(err, io.EOF) && len(buf) > 0 {
+				if depth == 0 && !inString {
+					return buf, nil // a trailing scalar: 末尾のスカラー値
+				}
+				return nil, io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		if len(buf) == 0 && isJSONSpace(b) {
+			continue // between values: 値と値の間
+		}
+		if depth == 0 && !inString && len(buf) > 0 && (isJSONSpace(b) || b == '{' || b == '[' || b == '"') {
+			r.UnreadByte() // ends a top-level scalar: トップレベルのスカラー値の終わり
+			return buf, nil
+		}
+		if maxBytes > 0 && int64(len(buf)) >= maxBytes {
+			return nil, fmt.Errorf("%w: limit is %d bytes", errMessageTooLarge, maxBytes)
+		}
+		buf = append(buf, b)
+
+		switch {
+		case inString:
+			// Inside a string: 文字列の内部
+			if escaped {
+				escaped = false
+			} else if b == '\\' {
+				escaped = true
+			} else if b == '"' {
+				inString = false
+				if depth == 0 {
+					return buf, nil // a top-level string: トップレベルの文字列
+				}
+			}
+		case b == '"':
+			inString = true
+		case b == '{' || b == '[':
+			depth++
+			if maxDepth > 0 && depth > maxDepth {
+				return nil, fmt.Errorf("%w: limit is %d", errTooDeep, maxDepth)
+			}
+		case b == '}' || b == ']':
+			depth--
+			if depth <= 0 {
+				return buf, nil // the value is complete: 値が完結した
+			}
+		}
+	}
+}
+
+// isJSONSpace reports whether b is JSON insignificant whitespace
+// isJSONSpace: bがJSONの意味を持たない空白かを判定する関数
+func isJSONSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r'
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context" // context: RunIO lifetime (RunIOの存続期間)
+	"errors"  // errors: error matching (エラーの照合)
+	"io"      // io: composed readers (合成したリーダー)
+	"reflect" // reflect: comparing framed values (区切った値の比較)
+	"strings" // strings: stream contents (ストリームの内容)
+	"testing" // testing: test framework (テストフレームワーク)
+)
+
+// repeatReader endlessly yields the same byte
+// repeatReader: 同じバイトを際限なく返すリーダー
+type repeatReader byte
+
+func (r repeatReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = byte(r)
+	}
+	return len(p), nil
+}
+
+// frames runs readJSONStream over in and returns the values it sent and its error
+// frames: inに対してreadJSONStreamを実行し、送られた値とエラーを返す関数
+func frames(in string, maxDepth int, maxBytes int64) ([]string, error) {
+	out := make(chan string)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(out)
+		errc <- readJSONStream(strings.NewReader(in), out, nil, maxDepth, maxBytes)
+	}()
+	var values []string
+	for v := range out {
+		values = append(values, v)
+	}
+	return values, <-errc
+}
+
+// TestReadJSONStreamFraming checks that values are split at their boundaries
+// wherever the newlines fall, with brackets inside strings ignored
+// TestReadJSONStreamFraming: 改行の位置に関わらず値が境界で分割され、
+// 文字列内の括弧が無視されることを確認するテスト
+func TestReadJSONStreamFraming(t *testing.T) {
+	in := "{\n  \"a\": \"}{\\\"\",\n  \"b\": [1, {\"c\": []}]\n}[1,2]\n  3 \"x\"{}"
+	got, err := frames(in, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"{\n  \"a\": \"}{\\\"\",\n  \"b\": [1, {\"c\": []}]\n}", "[1,2]", "3", `"x"`, "{}"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+// TestReadJSONStreamLimits checks that the depth and size limits end the read while
+// the value is still arriving, so an endless value is never buffered whole
+// TestReadJSONStreamLimits: 値の到着中に深さとサイズの上限が読み取りを終え、
+// 終わりの無い値が丸ごとバッファされないことを確認するテスト
+func TestReadJSONStreamLimits(t *testing.T) {
+	if err := readJSONStream(repeatReader('['), make(chan string), nil, 100, 0); !errors.Is(err, errTooDeep) {
+		t.Fatalf("deep: got %v, want errTooDeep", err)
+	}
+	long := io.MultiReader(strings.NewReader(`{"a":"`), repeatReader('x'))
+	if err := readJSONStream(long, make(chan string), nil, 100, 1<<10); !errors.Is(err, errMessageTooLarge) {
+		t.Fatalf("large: got %v, want errMessageTooLarge", err)
+	}
+}
+
+// TestRunIOJSONStreamTooDeep checks that RunIO ends a JSON stream connection on an
+// over-deep message instead of decoding it
+// TestRunIOJSONStreamTooDeep: RunIOが深すぎるメッセージをデコードせずに
+// JSONストリームの接続を終了することを確認するテスト
+func TestRunIOJSONStreamTooDeep(t *testing.T) {
+	s := NewMCPServer(WithFraming(FramingJSONStream))
+	in := io.MultiReader(strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"ping"}`+"\n"), repeatReader('['))
+	var out strings.Builder
+	if err := s.RunIO(context.Background(), in, &out); !errors.Is(err, errTooDeep) {
+		t.Fatalf("got %v, want errTooDeep", err)
+	}
+	if !strings.Contains(out.String(), `"id":1`) {
+		t.Fatalf("request before the deep message not answered: %s", out.String())
+	}
+}
+
+// TestRunIOJSONStream checks that pretty-printed requests spanning several lines are
+// each answered once under FramingJSONStream
+// TestRunIOJSONStream: 複数行にわたる整形されたリクエストがFramingJSONStreamで
+// それぞれ1回ずつ応答されることを確認するテスト
+func TestRunIOJSONStream(t *testing.T) {
+	in := "{\n  \"jsonrpc\": \"2.0\",\n  \"id\": 1,\n  \"method\": \"tools/list\"\n}\n" +
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call",` + "\n" + `"params":{"name":"echo","arguments":{"message":"hi"}}}`
+	var out strings.Builder
+	if err := NewMCPServer(WithExampleTools(), WithFraming(FramingJSONStream)).RunIO(context.Background(), strings.NewReader(in), &out); err != nil {
+		t.Fatal(err)
+	}
+	msgs := decodeLines(t, out.String())
+	if len(msgs) != 2 || msgs[0]["id"] != float64(1) || msgs[1]["id"] != float64(2) || msgs[0]["error"] != nil || msgs[1]["error"] != nil {
+		t.Fatalf("JSON stream responses:\n%s", out.String())
+	}
+}
@@ -0,0 +1,415 @@
+package main
+
+import (
+	"bufio"         // bufio: line scanning (行の走査)
+	"bytes"         // bytes: binary detection (バイナリ判定)
+	"context"       // context: cancellation and deadlines (キャンセルと期限)
+	"encoding/json" // encoding/json: structured listings (構造化された一覧)
+	"errors"        // errors: error values (エラー値)
+	"fmt"           // fmt: formatted I/O (フォーマット済みI/O)
+	"io/fs"         // io/fs: directory walking (ディレクトリの走査)
+	"os"            // os: file access (ファイルアクセス)
+	"path/filepath" // path/filepath: sandboxed paths (サンドボックス内のパス)
+	"regexp"        // regexp: search patterns (検索パターン)
+	"strings"       // strings: extension matching (拡張子の照合)
+	"time"          // time: modification times (更新日時)
+	"unicode/utf8"  // unicode/utf8: binary detection (バイナリ判定)
+)
+
+// errOutsideSandbox reports a path that resolves outside the sandbox root
+// errOutsideSandbox: サンドボックスのルート外に解決されるパスを表すエラー
+var errOutsideSandbox = errors.New("path is outside the sandbox")
+
+// defaultWriteMaxBytes caps the content fs/write accepts (1MB)
+// defaultWriteMaxBytes: fs/writeが受け付ける内容の上限 (1MB)
+const defaultWriteMaxBytes = 1 << 20
+
+// defaultListMaxEntries caps the entries fs/list returns
+// defaultListMaxEntries: fs/listが返すエントリ数の上限
+const defaultListMaxEntries = 1000
+
+// defaultSearchMaxResults and defaultSearchMaxFileBytes bound fs/search
+// defaultSearchMaxResults / defaultSearchMaxFileBytes: fs/searchの上限
+const (
+	defaultSearchMaxResults   = 100
+	defaultSearchMaxFileBytes = 1 << 20
+)
+
+// defaultWriteExtensions are the file types fs/write may create when none are configured
+// defaultWriteExtensions: 設定が無い場合にfs/writeが作成できるファイルの種類
+var defaultWriteExtensions = []string{".txt", ".md", ".json", ".csv"}
+
+// sandboxDir resolves the directory holding name inside root, following symlinks
+// sandboxDir: root内でnameを含むディレクトリをシンボリックリンクを辿って解決する関数
+// It fails when name is not a local path or its directory resolves outside root.
+// nameがローカルなパスでない場合や、ディレクトリがroot外に解決される場合は失敗する
+func sandboxDir(root, name string) (dir, base string, err error) {
+	name = filepath.FromSlash(name)
+	if !filepath.IsLocal(name) {
+		return "", "", errOutsideSandbox // traversal: ディレクトリトラバーサル
+	}
+	dir, err = sandboxResolve(root, filepath.Dir(name))
+	if err != nil {
+		return "", "", err
+	}
+	return dir, filepath.Base(name), nil
+}
+
+// sandboxResolve resolves the existing path name inside root, following symlinks
+// sandboxResolve: root内の既存のパスnameをシンボリックリンクを辿って解決する関数
+func sandboxResolve(root, name string) (string, error) {
+	name = filepath.FromSlash(name)
+	if !filepath.IsLocal(name) {
+		return "", errOutsideSandbox // traversal: ディレクトリトラバーサル
+	}
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", fmt.Errorf("resolve root: %w", err)
+	}
+	resolved, err := filepath.EvalSymlinks(filepath.Join(realRoot, name))
+	if err != nil {
+		return "", fmt.Errorf("resolve %s: %w", filepath.ToSlash(name), err)
+	}
+	if rel, err := filepath.Rel(realRoot, resolved); err != nil || !filepath.IsLocal(rel) {
+		return "", errOutsideSandbox // escaped through a symlink: シンボリックリンク経由で脱出
+	}
+	return resolved, nil
+}
+
+// FileWriteTool returns the fs/write tool, which writes text files under root
+// FileWriteTool: root配下にテキストファイルを書き込むfs/writeツールを返す関数
+// Only the listed extensions may be written, content is capped at maxBytes, and each
+// write goes to a temporary file that is renamed into place, so readers never see a
+// partial file. Zero maxBytes and empty extensions select the defaults.
+// 書き込めるのは指定した拡張子のみで、内容はmaxBytesまでに制限される。各書き込みは一時ファイルに行われてから
+// 所定の場所へ名前変更されるため、読み手が書きかけのファイルを見ることはない。0や空の場合はデフォルト値を使う
+func FileWriteTool(root string, maxBytes int64, extensions []string) Tool {
+	if maxBytes <= 0 {
+		maxBytes = defaultWriteMaxBytes
+	}
+	if len(extensions) == 0 {
+		extensions = defaultWriteExtensions
+	}
+
+	return Tool{
+		Name:        "fs/write",
+		Description: "Write a text file under the server's root directory", // write: 書き込む
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "File path relative to the root directory", // relative: 相対的な
+				},
+				"content": map[string]interface{}{
+					"type":        "string",
+					"description": "Text to write", // text: テキスト
+				},
+			},
+			"required": []string{"path", "content"},
+		},
+		Annotations: &ToolAnnotations{DestructiveHint: Bool(true), IdempotentHint: Bool(true)},
+		Handler: func(ctx context.Context, arguments interface{}) (map[string]interface{}, error) {
+			args, _ := arguments.(map[string]interface{})
+			name, _ := args["path"].(string)
+			content, _ := args["content"].(string)
+
+			// Security: 拡張子とサイズの制限
+			ext := strings.ToLower(filepath.Ext(name))
+			allowed := false
+			for _, e := range extensions {
+				allowed = allowed || strings.EqualFold(e, ext)
+			}
+			if !allowed {
+				return nil, fmt.Errorf("extension %q is not allowed", ext)
+			}
+			if int64(len(content)) > maxBytes {
+				return nil, fmt.Errorf("content exceeds %d bytes", maxBytes)
+			}
+
+			dir, base, err := sandboxDir(root, name)
+			if err != nil {
+				return nil, err
+			}
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			if err := writeFileAtomic(filepath.Join(dir, base), []byte(content)); err != nil {
+				return nil, err
+			}
+			return ToolResult(TextContent(fmt.Sprintf("Wrote %d bytes to %s", len(content), filepath.ToSlash(name)))), nil
+		},
+	}
+}
+
+// writeFileAtomic writes data to a temporary file beside target and renames it over target
+// writeFileAtomic: target横の一時ファイルにdataを書き込み、targetへ名前変更する関数
+// atomic: 不可分な
+func writeFileAtomic(target string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(target), "."+filepath.Base(target)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // no-op after rename: 名前変更後は何もしない
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("sync: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return fmt.Errorf("chmod: %w", err)
+	}
+	return os.Rename(tmp.Name(), target)
+}
+
+// FileEntry is one entry of an fs/list listing
+// FileEntry: fs/listの一覧の1エントリ
+type FileEntry struct {
+	Path    string    `json:"path"`    // path: slash-separated, relative to the listed directory (一覧対象ディレクトリからのスラッシュ区切りの相対パス)
+	Name    string    `json:"name"`    // name: base name (ベース名)
+	Size    int64     `json:"size"`    // size: bytes (バイト数)
+	ModTime time.Time `json:"modTime"` // modTime: last modification (最終更新日時)
+	IsDir   bool      `json:"isDir"`   // isDir: directory flag (ディレクトリかどうか)
+}
+
+// FileListTool returns the fs/list tool, which lists a directory under root
+// FileListTool: root配下のディレクトリを一覧表示するfs/listツールを返す関数
+// depth limits recursion (1 lists only direct children) and glob filters entries by
+// base name. At most maxEntries entries are returned, 1000 when zero; symlinked
+// directories are listed but not entered.
+// depthは再帰の深さを制限し (1は直下のみ)、globはベース名でエントリを絞り込む。返すエントリは最大maxEntries件
+// (0なら1000件) で、シンボリックリンクのディレクトリは表示するが中には入らない
+func FileListTool(root string, maxEntries int) Tool {
+	if maxEntries <= 0 {
+		maxEntries = defaultListMaxEntries
+	}
+
+	return Tool{
+		Name:        "fs/list",
+		Description: "List a directory under the server's root directory", // list: 一覧表示する
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "Directory relative to the root directory, default \".\"",
+				},
+				"depth": map[string]interface{}{
+					"type":        "integer",
+					"minimum":     1,
+					"description": "Levels to descend, default 1", // descend: 下る
+				},
+				"glob": map[string]interface{}{
+					"type":        "string",
+					"description": "Only include entries whose name matches this pattern, such as *.go",
+				},
+			},
+		},
+		Annotations: &ToolAnnotations{ReadOnlyHint: Bool(true)},
+		Handler: func(ctx context.Context, arguments interface{}) (map[string]interface{}, error) {
+			args, _ := arguments.(map[string]interface{})
+			name, _ := args["path"].(string)
+			if name == "" {
+				name = "."
+			}
+			depth := 1
+			if d, ok := args["depth"].(float64); ok {
+				depth = int(d)
+			}
+			glob, _ := args["glob"].(string)
+			if _, err := filepath.Match(glob, ""); err != nil {
+				return nil, fmt.Errorf("invalid glob: %w", err)
+			}
+
+			dir, err := sandboxResolve(root, name)
+			if err != nil {
+				return nil, err
+			}
+
+			entries := []FileEntry{}
+			truncated := false
+			err = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				if p == dir {
+					return nil
+				}
+				rel, _ := filepath.Rel(dir, p)
+
+				// Do not descend past depth: depthより深くは下らない
+				var next error
+				if d.IsDir() && strings.Count(rel, string(filepath.Separator))+1 >= depth {
+					next = fs.SkipDir
+				}
+				if glob != "" {
+					if ok, _ := filepath.Match(glob, d.Name()); !ok {
+						return next
+					}
+				}
+				if len(entries) == maxEntries {
+					truncated = true
+					return fs.SkipAll
+				}
+				info, err := d.Info()
+				if err != nil {
+					return err
+				}
+				entries = append(entries, FileEntry{
+					Path:    filepath.ToSlash(rel),
+					Name:    d.Name(),
+					Size:    info.Size(),
+					ModTime: info.ModTime().UTC(),
+					IsDir:   d.IsDir(),
+				})
+				return next
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			listing, err := json.Marshal(map[string]interface{}{"entries": entries, "truncated": truncated})
+			if err != nil {
+				return nil, err
+			}
+			return ToolResult(TextContent(string(listing))), nil
+		},
+	}
+}
+
+// SearchMatch is one matching line found by fs/search
+// SearchMatch: fs/searchが見つけた一致行
+type SearchMatch struct {
+	Path string `json:"path"` // path: slash-separated, relative to the searched directory (検索対象ディレクトリからのスラッシュ区切りの相対パス)
+	Line int    `json:"line"` // line: 1-based line number (1始まりの行番号)
+	Text string `json:"text"` // text: the matching line (一致した行)
+}
+
+// FileSearchTool returns the fs/search tool, which searches text files under root
+// FileSearchTool: root配下のテキストファイルを検索するfs/searchツールを返す関数
+// At most maxResults matches are returned (100 when zero). Files larger than maxFileBytes
+// (1MB when zero), binary files and symlinks are skipped.
+// 返す一致は最大maxResults件 (0なら100件)。maxFileBytes (0なら1MB) を超えるファイル、バイナリファイル、シンボリックリンクはスキップする
+func FileSearchTool(root string, maxResults int, maxFileBytes int64) Tool {
+	if maxResults <= 0 {
+		maxResults = defaultSearchMaxResults
+	}
+	if maxFileBytes <= 0 {
+		maxFileBytes = defaultSearchMaxFileBytes
+	}
+
+	return Tool{
+		Name:        "fs/search",
+		Description: "Search files under the server's root directory for a pattern", // search: 検索する
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"pattern": map[string]interface{}{
+					"type":        "string",
+					"description": "Regular expression, or plain text when literal is true", // regular expression: 正規表現
+				},
+				"literal": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Match pattern as plain text", // literal: 文字どおりの
+				},
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "Directory relative to the root directory, default \".\"",
+				},
+			},
+			"required": []string{"pattern"},
+		},
+		Annotations: &ToolAnnotations{ReadOnlyHint: Bool(true)},
+		Handler: func(ctx context.Context, arguments interface{}) (map[string]interface{}, error) {
+			args, _ := arguments.(map[string]interface{})
+			pattern, _ := args["pattern"].(string)
+			if literal, _ := args["literal"].(bool); literal {
+				pattern = regexp.QuoteMeta(pattern)
+			}
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern: %w", err)
+			}
+			name, _ := args["path"].(string)
+			if name == "" {
+				name = "."
+			}
+			dir, err := sandboxResolve(root, name)
+			if err != nil {
+				return nil, err
+			}
+
+			matches := []SearchMatch{}
+			truncated := false
+			err = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				if !d.Type().IsRegular() {
+					return nil // directories and symlinks: ディレクトリとシンボリックリンク
+				}
+				if info, err := d.Info(); err != nil || info.Size() > maxFileBytes {
+					return nil // too large: 大きすぎる
+				}
+				data, err := os.ReadFile(p)
+				if err != nil || isBinary(data) {
+					return nil
+				}
+
+				rel, _ := filepath.Rel(dir, p)
+				scanner := bufio.NewScanner(bytes.NewReader(data))
+				scanner.Buffer(nil, int(maxFileBytes)+1) // one line may fill the file: 1行がファイル全体の場合もある
+				for line := 1; scanner.Scan(); line++ {
+					if !re.Match(scanner.Bytes()) {
+						continue
+					}
+					if len(matches) == maxResults {
+						truncated = true
+						return fs.SkipAll
+					}
+					matches = append(matches, SearchMatch{Path: filepath.ToSlash(rel), Line: line, Text: scanner.Text()})
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			found, err := json.Marshal(map[string]interface{}{"matches": matches, "truncated": truncated})
+			if err != nil {
+				return nil, err
+			}
+			return ToolResult(TextContent(string(found))), nil
+		},
+	}
+}
+
+// isBinary reports whether data looks like a binary file: a NUL byte or invalid UTF-8 near the start
+// isBinary: dataがバイナリファイルらしいか (先頭付近にNULバイトや不正なUTF-8があるか) を判定する関数
+func isBinary(data []byte) bool {
+	head, cut := data, len(data) > 8000
+	if cut {
+		head = data[:8000]
+	}
+	if bytes.IndexByte(head, 0) >= 0 {
+		return true
+	}
+	// Allow a rune split by the cut: 切り取りで分断された文字は許容する
+	for i := 0; cut && i < utf8.UTFMax-1 && !utf8.Valid(head); i++ {
+		head = head[:len(head)-1]
+	}
+	return !utf8.Valid(head)
+}
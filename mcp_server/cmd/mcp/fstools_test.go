@@ -0,0 +1,245 @@
+package main
+
+import (
+	"encoding/json" // encoding/json: decoding listings (一覧のデコード)
+	"os"            // os: file contents (ファイルの内容)
+	"path/filepath" // path/filepath: paths under the root (ルート配下のパス)
+	"strings"       // strings: splitting cases and joining paths (ケースの分割とパスの連結)
+	"testing"       // testing: test framework (テストフレームワーク)
+	"time"          // time: cache TTL (キャッシュのTTL)
+)
+
+// TestFileWriteToolNotCached checks that a repeated identical fs/write still writes
+// even with a result cache and single-flight enabled
+// TestFileWriteToolNotCached: 結果キャッシュとシングルフライトが有効でも、
+// 同一のfs/writeの繰り返しが毎回書き込むことを確認するテスト
+func TestFileWriteToolNotCached(t *testing.T) {
+	dir := t.TempDir()
+	c := NewClient(NewMCPServer(WithRootDir(dir), WithFileWriteTool(10), WithCache(NewLRUCache(16, time.Minute)), WithSingleFlight()))
+	path := filepath.Join(dir, "a.txt")
+	args := map[string]interface{}{"path": "a.txt", "content": "hello"}
+
+	if _, err := c.CallTool("fs/write", args); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("changed"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.CallTool("fs/write", args); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := os.ReadFile(path); err != nil || string(b) != "hello" {
+		t.Fatalf("after second write: %q, %v", b, err)
+	}
+}
+
+// TestFileWriteTool checks that fs/write creates a file under the root and refuses
+// traversal, absolute paths, symlinks out of the sandbox, disallowed extensions and
+// oversized content without leaving anything behind
+// TestFileWriteTool: fs/writeがルート配下にファイルを作成し、トラバーサル・絶対パス・
+// サンドボックス外へのシンボリックリンク・許可されない拡張子・大きすぎる内容を何も残さずに拒否することを確認するテスト
+func TestFileWriteTool(t *testing.T) {
+	dir := t.TempDir()
+	outside := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(dir, "link")); err != nil {
+		t.Fatal(err)
+	}
+	c := NewClient(NewMCPServer(WithRootDir(dir), WithFileWriteTool(10)))
+	write := func(path, content string) *CallToolResult {
+		t.Helper()
+		result, err := c.CallTool("fs/write", map[string]interface{}{"path": path, "content": content})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	if result := write("sub/a.txt", "hello"); result.Error != "" {
+		t.Fatalf("write sub/a.txt: %s", result.Error)
+	}
+	if b, err := os.ReadFile(filepath.Join(dir, "sub", "a.txt")); err != nil || string(b) != "hello" {
+		t.Fatalf("sub/a.txt: %q, %v", b, err)
+	}
+
+	for _, bad := range []string{"../x.txt", "/etc/x.txt", "link/x.txt", "a.sh", "sub/b.txt:content over ten bytes"} {
+		path, content, _ := strings.Cut(bad, ":")
+		if content == "" {
+			content = "x"
+		}
+		if result := write(path, content); result.Error == "" {
+			t.Errorf("write %s: succeeded", path)
+		}
+	}
+	if entries, _ := os.ReadDir(outside); len(entries) != 0 {
+		t.Fatalf("files written outside the root: %v", entries)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(dir, "sub")); len(entries) != 1 {
+		t.Fatalf("sub holds %v; want only a.txt, with no temporary files left", entries)
+	}
+
+	// A configured allowlist replaces the defaults: 設定した許可リストはデフォルトを置き換える
+	md := NewClient(NewMCPServer(WithRootDir(dir), WithFileWriteTool(0, ".md")))
+	for path, ok := range map[string]bool{"notes.md": true, "notes.txt": false} {
+		result, err := md.CallTool("fs/write", map[string]interface{}{"path": path, "content": "x"})
+		if err != nil || (result.Error == "") != ok {
+			t.Errorf("write %s with only .md allowed: got %+v, %v", path, result, err)
+		}
+	}
+}
+
+// TestFileListTool checks fs/list's depth limit, glob filter and entry cap, and
+// that it refuses paths leaving the root, including through a symlink
+// TestFileListTool: fs/listの深さ制限・glob絞り込み・件数上限と、シンボリックリンク経由を含め
+// ルートの外へ出るパスを拒否することを確認するテスト
+func TestFileListTool(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "a", "b", "c"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{"x.go": "package x", "y.txt": "y", "a/z.go": "z", "a/b/w.go": "w"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(t.TempDir(), filepath.Join(dir, "out")); err != nil {
+		t.Fatal(err)
+	}
+	c := NewClient(NewMCPServer(WithRootDir(dir), WithFileListTool(3)))
+	list := func(args map[string]interface{}) (entries []FileEntry, truncated bool, toolErr string) {
+		t.Helper()
+		result, err := c.CallTool("fs/list", args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Error != "" {
+			return nil, false, result.Error
+		}
+		var listing struct {
+			Entries   []FileEntry `json:"entries"`
+			Truncated bool        `json:"truncated"`
+		}
+		if err := json.Unmarshal([]byte(result.Content[0]["text"].(string)), &listing); err != nil {
+			t.Fatal(err)
+		}
+		return listing.Entries, listing.Truncated, ""
+	}
+	paths := func(entries []FileEntry) string {
+		var list []string
+		for _, entry := range entries {
+			list = append(list, entry.Path)
+		}
+		return strings.Join(list, ",")
+	}
+
+	// Four direct children, capped at three: 直下の4件を3件に制限
+	entries, truncated, _ := list(map[string]interface{}{})
+	if got := paths(entries); got != "a,out,x.go" || !truncated {
+		t.Fatalf("root: got %q, truncated %v", got, truncated)
+	}
+	if !entries[0].IsDir || entries[2].IsDir || entries[2].Size != int64(len("package x")) {
+		t.Fatalf("root entries: %+v", entries)
+	}
+
+	for _, tt := range []struct {
+		args map[string]interface{}
+		want string
+	}{
+		{map[string]interface{}{"glob": "*.go", "depth": 5}, "a/b/w.go,a/z.go,x.go"},
+		{map[string]interface{}{"path": "a", "depth": 1}, "b,z.go"},
+		{map[string]interface{}{"path": "a", "depth": 2, "glob": "*.go"}, "b/w.go,z.go"},
+	} {
+		entries, truncated, toolErr := list(tt.args)
+		if got := paths(entries); got != tt.want || truncated || toolErr != "" {
+			t.Errorf("%v: got %q, truncated %v, error %q; want %q", tt.args, got, truncated, toolErr, tt.want)
+		}
+	}
+
+	for _, path := range []string{"..", "out"} {
+		if _, _, toolErr := list(map[string]interface{}{"path": path}); toolErr == "" {
+			t.Errorf("list %s: succeeded outside the root", path)
+		}
+	}
+}
+
+// TestFileSearchTool checks that fs/search reports matching lines across files, skips
+// binary and oversized files, honours literal and path, and caps the results
+// TestFileSearchTool: fs/searchが複数ファイルにまたがる一致行を報告し、バイナリと大きすぎる
+// ファイルをスキップし、literalとpathに従い、結果数を制限することを確認するテスト
+func TestFileSearchTool(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "a"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"x.go":    "package x\nfunc TODO() {}\n",
+		"a/y.txt": "nothing\n// TODO: fix\nTODO again\n",
+		"bin.dat": "TODO\x00\x01binary",
+		"big.txt": strings.Repeat("TODO\n", 100),
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	search := func(maxResults int, args map[string]interface{}) (matches []SearchMatch, truncated bool) {
+		t.Helper()
+		c := NewClient(NewMCPServer(WithRootDir(dir), WithFileSearchTool(maxResults, 100)))
+		result, err := c.CallTool("fs/search", args)
+		if err != nil || result.Error != "" {
+			t.Fatalf("search %v: %v %s", args, err, result.Error)
+		}
+		var found struct {
+			Matches   []SearchMatch `json:"matches"`
+			Truncated bool          `json:"truncated"`
+		}
+		if err := json.Unmarshal([]byte(result.Content[0]["text"].(string)), &found); err != nil {
+			t.Fatal(err)
+		}
+		return found.Matches, found.Truncated
+	}
+
+	matches, truncated := search(10, map[string]interface{}{"pattern": "TODO"})
+	want := []SearchMatch{
+		{Path: "a/y.txt", Line: 2, Text: "// TODO: fix"},
+		{Path: "a/y.txt", Line: 3, Text: "TODO again"},
+		{Path: "x.go", Line: 2, Text: "func TODO() {}"},
+	}
+	if len(matches) != len(want) || truncated {
+		t.Fatalf("TODO: got %+v, truncated %v; want %+v", matches, truncated, want)
+	}
+	for i := range want {
+		if matches[i] != want[i] {
+			t.Errorf("match %d: got %+v, want %+v", i, matches[i], want[i])
+		}
+	}
+
+	if matches, _ := search(10, map[string]interface{}{"pattern": "TODO()", "literal": true}); len(matches) != 1 || matches[0].Path != "x.go" {
+		t.Errorf("literal TODO(): got %+v", matches)
+	}
+	if matches, _ := search(10, map[string]interface{}{"pattern": "^TODO", "path": "a"}); len(matches) != 1 || matches[0].Path != "y.txt" {
+		t.Errorf("^TODO under a: got %+v", matches)
+	}
+	if matches, truncated := search(2, map[string]interface{}{"pattern": "TODO"}); len(matches) != 2 || !truncated {
+		t.Errorf("capped at 2: got %+v, truncated %v", matches, truncated)
+	}
+}
+
+// TestIsBinary checks NUL bytes, invalid UTF-8, and a rune split at the sniffing limit
+// TestIsBinary: NULバイト、不正なUTF-8、判定範囲の境界で分断された文字を確認するテスト
+func TestIsBinary(t *testing.T) {
+	for _, tt := range []struct {
+		data string
+		want bool
+	}{
+		{"héllo", false},
+		{"a\x00b", true},
+		{"\xffa", true},
+		{strings.Repeat("a", 7999) + "é", false},
+	} {
+		if got := isBinary([]byte(tt.data)); got != tt.want {
+			t.Errorf("isBinary(%.20q): got %v, want %v", tt.data, got, tt.want)
+		}
+	}
+}
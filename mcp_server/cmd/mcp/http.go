@@ -0,0 +1,271 @@
+package main
+
+import (
+	"context"  // context: request contexts (リクエストコンテキスト)
+	"errors"   // errors: error inspection (エラー検査)
+	"io"       // io: basic I/O interfaces (基本的なI/Oインターフェース)
+	"net/http" // net/http: HTTP client and server (HTTPクライアントとサーバー)
+	"strings"  // strings: header matching (ヘッダーの照合)
+	"sync"     // sync: waits for the keep-alive goroutine (キープアライブgoroutineの待機)
+)
+
+// nonceHeader carries a client-chosen, single-use value for replay protection
+// nonceHeader: リプレイ防止のためにクライアントが選ぶ使い捨ての値を運ぶヘッダー
+const nonceHeader = "Mcp-Nonce"
+
+// defaultMaxBodyBytes is the default HTTP request body limit (4MB)
+// defaultMaxBodyBytes: HTTPリクエストボディのデフォルト上限 (4MB)
+const defaultMaxBodyBytes = 4 << 20
+
+// ServeHTTP handles a single JSON-RPC request POSTed over HTTP
+// ServeHTTP: HTTPでPOSTされた単一のJSON-RPCリクエストを処理する関数
+// With replay protection enabled, a missing Mcp-Nonce header gets 400 and a reused one 409.
+// リプレイ防止が有効な場合、Mcp-Nonceヘッダーが無ければ400、再利用されていれば409を返す
+// A successful initialize returns a session id in the Mcp-Session-Id header;
+// requests presenting an unknown or expired id get 404.
+// 成功したinitializeはMcp-Session-IdヘッダーでセッションIDを返し、
+// 不明または期限切れのIDを提示したリクエストには404を返す
+// This lets MCPServer be used directly as an http.Handler.
+// これによりMCPServerをhttp.Handlerとして直接使用できる
+func (s *MCPServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost) // allow: 許可されたメソッド
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Security: ボディサイズを制限してメモリ枯渇を防ぐ
+	// exhaust: 枯渇させる
+	r.Body = http.MaxBytesReader(w, r.Body, s.maxBodyBytes)
+	ctx := r.Context()
+
+	// Compress large responses when the client allows: クライアントが許可する場合は大きなレスポンスを圧縮
+	if s.compressMinBytes >= 0 && acceptsGzip(r) {
+		cw := newCompressWriter(w, s.compressMinBytes)
+		defer func() {
+			if err := cw.close(); err != nil {
+				s.logger.WarnContext(ctx, "HTTP response compression failed", "error", err) // compression: 圧縮
+			}
+		}()
+		w = cw
+	}
+	body, err := io.ReadAll(r.Body)
+	s.metrics.observeRequest(len(body))
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			s.writeHTTPResponse(ctx, w, http.StatusRequestEntityTooLarge, &JSONRPCResponse{
+				JSONRPC: "2.0",
+				Error: &JSONRPCError{
+					Code:    -32600,                   // Invalid Request (無効なリクエスト)
+					Message: "Request body too large", // large: 大きい
+				},
+			})
+			return
+		}
+		http.Error(w, "failed to read request body", http.StatusBadRequest)
+		return
+	}
+
+	req, err := decodeRequest(body, s.maxDepth)
+	if errors.Is(err, errInvalidRequestID) || errors.Is(err, errNotObject) || errors.Is(err, errTooDeep) {
+		s.writeHTTPResponse(ctx, w, http.StatusBadRequest, &JSONRPCResponse{
+			JSONRPC: "2.0",
+			Error: &JSONRPCError{
+				Code:    -32600,      // Invalid Request (無効なリクエスト)
+				Message: err.Error(), // message: エラーメッセージ
+			},
+		})
+		return
+	} else if err != nil {
+		s.writeHTTPResponse(ctx, w, http.StatusBadRequest, &JSONRPCResponse{
+			JSONRPC: "2.0",
+			Error: &JSONRPCError{
+				Code:    -32700,        // Parse error (解析エラー)
+				Message: "Parse error", // parse: 解析する
+			},
+		})
+		return
+	}
+
+	// Security: 同じnonceの再利用 (リプレイ) を拒否
+	// replay: 再送、再生
+	if s.nonces != nil {
+		nonce := r.Header.Get(nonceHeader)
+		if nonce == "" {
+			s.writeHTTPResponse(ctx, w, http.StatusBadRequest, &JSONRPCResponse{
+				JSONRPC: "2.0",
+				ID:      req.ID,
+				Error: &JSONRPCError{
+					Code:    -32600,                     // Invalid Request (無効なリクエスト)
+					Message: "Missing Mcp-Nonce header", // missing: 欠けている
+				},
+			})
+			return
+		}
+		switch s.nonces.use(nonce) {
+		case nonceReplayed:
+			s.writeHTTPResponse(ctx, w, http.StatusConflict, &JSONRPCResponse{
+				JSONRPC: "2.0",
+				ID:      req.ID,
+				Error: &JSONRPCError{
+					Code:    -32600,             // Invalid Request (無効なリクエスト)
+					Message: "Replayed request", // replayed: 再送された
+				},
+			})
+			return
+		case nonceFull:
+			// Refuse rather than forget a live nonce: 有効なnonceを忘れる代わりに拒否
+			s.writeHTTPResponse(ctx, w, http.StatusServiceUnavailable, &JSONRPCResponse{
+				JSONRPC: "2.0",
+				ID:      req.ID,
+				Error: &JSONRPCError{
+					Code:    -32000,        // Server error (サーバーエラー)
+					Message: "Server busy", // too many live nonces: 有効なnonceが多すぎる
+				},
+			})
+			return
+		}
+	}
+
+	// Sessions: セッション
+	// initialize starts a session; later requests may resume one by id.
+	// initializeでセッションを開始し、以降のリクエストはIDで再開できる
+	if req.Method == "initialize" {
+		conn := s.newConnSession(nil) // no stream for notifications: 通知用のストリームは無い
+		resp := s.HandleRequest(withConn(ctx, conn), &req)
+		if resp.Error == nil {
+			sess, err := s.sessions.create(conn)
+			if err != nil {
+				s.writeHTTPResponse(ctx, w, http.StatusServiceUnavailable, &JSONRPCResponse{
+					JSONRPC: "2.0",
+					ID:      req.ID,
+					Error: &JSONRPCError{
+						Code:    -32000,              // Server error (サーバーエラー)
+						Message: "Too many sessions", // session table full: セッション表が満杯
+					},
+				})
+				return
+			}
+			w.Header().Set(sessionHeader, sess.ID)
+		}
+		s.writeHTTPResponse(ctx, w, http.StatusOK, resp)
+		return
+	}
+	if id := r.Header.Get(sessionHeader); id != "" {
+		sess, ok := s.sessions.lookup(id)
+		if !ok {
+			s.writeHTTPResponse(ctx, w, http.StatusNotFound, &JSONRPCResponse{
+				JSONRPC: "2.0",
+				ID:      req.ID,
+				Error: &JSONRPCError{
+					Code:    -32600,              // Invalid Request (無効なリクエスト)
+					Message: "Session not found", // expired or unknown: 期限切れまたは不明
+				},
+			})
+			return
+		}
+		ctx = SessionContext(ctx, sess)
+		ctx = withIDScope(ctx, sess) // ids are unique per session: idはセッション毎に一意
+		ctx = withConn(ctx, sess.conn)
+	}
+
+	// Notifications are accepted without a body: 通知は本文なしで受理する
+	if req.ID.IsZero() {
+		s.HandleNotification(ctx, &req)
+		w.WriteHeader(http.StatusAccepted) // accepted: 受理された
+		return
+	}
+	ctx = withCorrelation(ctx, &req) // also covers writing the response: レスポンスの書き込みも対象
+
+	// Stream events when the client accepts them: クライアントが受け付ける場合はイベントをストリーミング
+	if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		s.serveEventStream(ctx, w, &req)
+		return
+	}
+	s.writeHTTPResponse(ctx, w, http.StatusOK, s.HandleRequest(ctx, &req))
+}
+
+// serveEventStream answers req as server-sent events
+// serveEventStream: reqにServer-Sent Eventsで応答する関数
+// Notifications raised while handling req, such as streamed tool content, precede
+// the final response event.
+// ストリーミングされたツール内容など処理中に発生した通知は、最終レスポンスのイベントより先に送られる
+func (s *MCPServer) serveEventStream(ctx context.Context, w http.ResponseWriter, req *JSONRPCRequest) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	rc := http.NewResponseController(w)
+	stream := &eventStream{
+		id:      req.ID,
+		w:       w,
+		marshal: s.marshalEvent(ctx),
+		flush: func() {
+			rc.Flush() // flush: 送り出す
+		},
+	}
+
+	// Keep-alive pings until the response is complete: レスポンス完了までキープアライブのping
+	if s.keepAlive > 0 {
+		done := make(chan struct{})
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stream.keepAlive(ctx, s.keepAlive, done)
+		}()
+		defer wg.Wait() // no writes after return: 戻った後は書き込まない
+		defer close(done)
+	}
+
+	resp := s.HandleRequest(context.WithValue(ctx, streamKey{}, stream), req)
+	n, err := stream.finish(resp)
+	s.metrics.observeResponse(n)
+	if err != nil {
+		s.logger.WarnContext(ctx, "HTTP event stream write failed", "id", req.ID.String(), "error", err) // stream: ストリーム
+	}
+}
+
+// writeHTTPResponse writes resp as a JSON body with the given status
+// writeHTTPResponse: 指定したステータスでrespをJSONボディとして書き込む関数
+func (s *MCPServer) writeHTTPResponse(ctx context.Context, w http.ResponseWriter, status int, resp *JSONRPCResponse) {
+	data, err := s.encodeResponse(ctx, resp, true)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "response encoding failed", "id", resp.ID.String(), "error", err) // encoding: エンコード
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+		return
+	}
+	s.metrics.observeResponse(len(data))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if _, err := w.Write(append(data, '\n')); err != nil {
+		s.logger.WarnContext(ctx, "HTTP response write failed", "error", err) // write: 書き込み
+	}
+}
+
+// HandleHealthz reports that the process is up
+// HandleHealthz: プロセスが起動していることを報告するハンドラー
+// It always returns 200 and can be mounted at /healthz.
+// 常に200を返し、/healthzにマウントできる
+func (s *MCPServer) HandleHealthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, "ok\n")
+}
+
+// HandleReadyz reports whether the server is ready to serve traffic
+// HandleReadyz: サーバーがトラフィックを処理できる状態かを報告するハンドラー
+// It returns 503 until some client has sent notifications/initialized, then 200; mount it at /readyz.
+// いずれかのクライアントがnotifications/initializedを送信するまでは503、送信後は200を返す。/readyzにマウントする
+func (s *MCPServer) HandleReadyz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if !s.ready.Load() {
+		w.WriteHeader(http.StatusServiceUnavailable) // unavailable: 利用不可
+		io.WriteString(w, "not ready\n")
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, "ready\n")
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"     // encoding/json: decoding response bodies (レスポンスボディのデコード)
+	"net/http"          // net/http: requests and status codes (リクエストとステータスコード)
+	"net/http/httptest" // net/http/httptest: in-memory HTTP round trips (メモリ内のHTTP往復)
+	"strings"           // strings: request bodies (リクエスト本文)
+	"testing"           // testing: test framework (テストフレームワーク)
+)
+
+// postJSON POSTs body to h and returns the recorded response
+// postJSON: bodyをhへPOSTし、記録したレスポンスを返す関数
+func postJSON(h http.Handler, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	h.ServeHTTP(w, r)
+	return w
+}
+
+// TestHTTPBodyLimit checks that an oversized body gets 413 with a JSON-RPC error
+// TestHTTPBodyLimit: 大きすぎるボディが413とJSON-RPCエラーを受け取ることを確認するテスト
+func TestHTTPBodyLimit(t *testing.T) {
+	s := NewMCPServer(WithMaxBodyBytes(64))
+
+	w := postJSON(s, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"pad":"`+strings.Repeat("x", 200)+`"}}`)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status: got %d, want 413", w.Code)
+	}
+	var resp JSONRPCResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Error == nil || resp.Error.Code != -32600 {
+		t.Fatalf("body: %s (%v)", w.Body.String(), err)
+	}
+
+	if w := postJSON(s, `{"jsonrpc":"2.0","id":1,"method":"initialize"}`); w.Code != http.StatusOK {
+		t.Fatalf("small body: got %d, want 200", w.Code)
+	}
+}
+
+// TestHealthAndReadiness checks /healthz always answers 200 and /readyz answers 503
+// until a client completes the handshake
+// TestHealthAndReadiness: /healthzが常に200を返し、/readyzがクライアントの
+// ハンドシェイク完了まで503を返すことを確認するテスト
+func TestHealthAndReadiness(t *testing.T) {
+	s := newEchoServer()
+	get := func(h http.HandlerFunc) int {
+		w := httptest.NewRecorder()
+		h(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		return w.Code
+	}
+
+	if code := get(s.HandleHealthz); code != http.StatusOK {
+		t.Fatalf("healthz: got %d, want 200", code)
+	}
+	if code := get(s.HandleReadyz); code != http.StatusServiceUnavailable {
+		t.Fatalf("readyz before initialize: got %d, want 503", code)
+	}
+	if _, err := NewClient(s).Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	if code := get(s.HandleReadyz); code != http.StatusOK {
+		t.Fatalf("readyz after initialize: got %d, want 200", code)
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context" // context: request-scoped values (リクエストスコープの値)
+)
+
+// idScopeKey is the context key for the scope request ids are unique within
+// idScopeKey: リクエストidが一意であるべき範囲を表すコンテキストキー
+type idScopeKey struct{}
+
+// withIDScope returns a context whose requests must use ids unique within scope
+// withIDScope: scope内で一意なidを使うべきリクエスト用のコンテキストを返す関数
+// scope must be a comparable value, typically a pointer identifying the connection.
+// scopeは比較可能な値で、通常は接続を識別するポインタ
+func withIDScope(ctx context.Context, scope interface{}) context.Context {
+	return context.WithValue(ctx, idScopeKey{}, scope)
+}
+
+// inFlightKey identifies an in-flight request within its scope
+// inFlightKey: 範囲内で処理中のリクエストを識別するキー
+type inFlightKey struct {
+	scope interface{} // scope: connection or session (接続またはセッション)
+	id    RequestID   // id: request id (リクエストid)
+}
+
+// claimID marks id as in flight and returns the function that releases it
+// claimID: idを処理中として登録し、解放する関数を返す関数
+// It reports false when the id is already in flight in the same scope, since the
+// responses could not be told apart. Requests without a scope or id are not tracked.
+// 同じ範囲で同じidが処理中の場合はレスポンスを区別できないためfalseを返す。
+// 範囲またはidを持たないリクエストは追跡しない
+func (s *MCPServer) claimID(ctx context.Context, id RequestID) (release func(), ok bool) {
+	scope := ctx.Value(idScopeKey{})
+	if scope == nil || id.IsZero() {
+		return func() {}, true
+	}
+	key := inFlightKey{scope: scope, id: id}
+
+	s.activeMu.Lock()
+	defer s.activeMu.Unlock()
+	if _, dup := s.activeIDs[key]; dup {
+		return nil, false
+	}
+	s.activeIDs[key] = struct{}{}
+	return func() {
+		s.activeMu.Lock()
+		defer s.activeMu.Unlock()
+		delete(s.activeIDs, key)
+	}, true
+}
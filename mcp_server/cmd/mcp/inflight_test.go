@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context" // context: id scopes (idの範囲)
+	"testing" // testing: test framework (テストフレームワーク)
+)
+
+// TestDuplicateInFlightID checks that an id reused while its request is still in
+// flight on the same connection is rejected with -32600, while other connections,
+// unscoped callers and later reuse are unaffected
+// TestDuplicateInFlightID: 同じ接続で処理中のidを再利用すると-32600で拒否され、
+// 他の接続・範囲を持たない呼び出し・処理後の再利用には影響しないことを確認するテスト
+func TestDuplicateInFlightID(t *testing.T) {
+	s := NewMCPServer()
+	started, release := make(chan struct{}), make(chan struct{})
+	s.RegisterTool(Tool{Name: "slow", Handler: func(ctx context.Context, arguments interface{}) (map[string]interface{}, error) {
+		select {
+		case started <- struct{}{}:
+			<-release // only the first call blocks: 最初の呼び出しだけが止まる
+		default:
+		}
+		return ToolResult(), nil
+	}})
+	conn := withIDScope(context.Background(), s.newConnSession(nil))
+	call := func(ctx context.Context) *JSONRPCResponse {
+		return s.HandleRequest(ctx, &JSONRPCRequest{JSONRPC: "2.0", ID: IntID(7), Method: "tools/call", Params: map[string]interface{}{"name": "slow"}})
+	}
+
+	first := make(chan *JSONRPCResponse)
+	go func() { first <- call(conn) }()
+	<-started
+
+	resp := call(conn)
+	if resp.Error == nil || resp.Error.Code != -32600 || resp.Error.Message != "duplicate in-flight request id" {
+		t.Fatalf("reused id: got %+v", resp.Error)
+	}
+	other := withIDScope(context.Background(), s.newConnSession(nil))
+	if resp := call(other); resp.Error != nil {
+		t.Fatalf("same id on another connection: %v", resp.Error)
+	}
+	if resp := call(context.Background()); resp.Error != nil {
+		t.Fatalf("same id without a scope: %v", resp.Error)
+	}
+
+	close(release)
+	if resp := <-first; resp.Error != nil {
+		t.Fatalf("first request: %v", resp.Error)
+	}
+	if resp := call(conn); resp.Error != nil {
+		t.Fatalf("id reused after completion: %v", resp.Error)
+	}
+}
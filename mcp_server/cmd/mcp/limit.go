@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"       // context: the request a response answers (レスポンスが答えるリクエスト)
+	"encoding/json" // encoding/json: generic result decoding (汎用的な結果のデコード)
+	"unicode/utf8"  // unicode/utf8: truncation on rune boundaries (文字境界での切り詰め)
+)
+
+// ResponseLimitPolicy decides what happens to a response larger than the limit
+// ResponseLimitPolicy: 上限より大きいレスポンスの扱いを決める型
+type ResponseLimitPolicy int
+
+const (
+	ResponseTruncate ResponseLimitPolicy = iota // truncate: 内容を切り詰め、_meta.truncatedを付ける (デフォルト)
+	ResponseError                               // error: -32000エラーに置き換える
+)
+
+// maxTruncatePasses bounds the shortening passes over an oversized result
+// maxTruncatePasses: 大きすぎる結果を短縮する回数の上限
+const maxTruncatePasses = 32
+
+// encodeResponse marshals resp, enforcing the response size limit
+// encodeResponse: レスポンスサイズの上限を適用しながらrespをエンコードする関数
+// An oversized response is logged, then either truncated or replaced with a -32000
+// error according to the policy. A result without content or contents text to shorten
+// cannot be truncated, so it is replaced with the error too.
+// 上限を超えたレスポンスはログに記録され、ポリシーに従って切り詰めるか-32000エラーに置き換える。
+// 短縮できるcontentまたはcontentsのテキストを持たない結果は切り詰められないため、同様にエラーに置き換える
+func (s *MCPServer) encodeResponse(ctx context.Context, resp *JSONRPCResponse, indent bool) ([]byte, error) {
+	data, err := s.marshal(resp, indent)
+	if err != nil || s.maxResponseBytes <= 0 || len(data) <= s.maxResponseBytes {
+		return data, err
+	}
+	s.logger.WarnContext(ctx, "response exceeds limit", "id", resp.ID.String(), "bytes", len(data), "limit", s.maxResponseBytes) // exceeds: 超える
+
+	if s.responsePolicy == ResponseTruncate {
+		if truncated, ok := s.truncateResponse(resp, data, indent); ok {
+			return truncated, nil
+		}
+	}
+	return s.marshal(&JSONRPCResponse{
+		JSONRPC: "2.0",
+		ID:      resp.ID,
+		Error: &JSONRPCError{
+			Code:    -32000,               // Server error (サーバーエラー)
+			Message: "Response too large", // large: 大きい
+			Data:    map[string]interface{}{"size": len(data), "limit": s.maxResponseBytes},
+		},
+	}, indent)
+}
+
+// marshalEvent returns the encoder for event stream messages of the request ctx belongs to
+// marshalEvent: ctxが属するリクエストのイベントストリームのメッセージ用エンコーダーを返す関数
+// The final response's size is limited.
+// 最終レスポンスはサイズを制限する
+func (s *MCPServer) marshalEvent(ctx context.Context) func(v interface{}) ([]byte, error) {
+	return func(v interface{}) ([]byte, error) {
+		if resp, ok := v.(*JSONRPCResponse); ok {
+			return s.encodeResponse(ctx, resp, false)
+		}
+		return s.marshal(v, false)
+	}
+}
+
+// truncateResponse shortens the text of resp's content until it fits the limit
+// truncateResponse: 上限に収まるまでrespのcontentのテキストを短縮する関数
+// The last entries are cut first, structuredContent is dropped since its text copy
+// is being cut, and _meta.truncated is set.
+// 末尾の項目から切り詰め、テキストの写しを切り詰めるためstructuredContentは取り除き、_meta.truncatedを設定する
+func (s *MCPServer) truncateResponse(resp *JSONRPCResponse, data []byte, indent bool) ([]byte, bool) {
+	// Work on a generic copy of the result: 結果の汎用的なコピーを対象にする
+	var msg struct {
+		Result map[string]interface{} `json:"result"`
+	}
+	if err := json.Unmarshal(data, &msg); err != nil || msg.Result == nil {
+		return nil, false
+	}
+	result := msg.Result
+	entries, _ := result["content"].([]interface{})
+	if entries == nil {
+		entries, _ = result["contents"].([]interface{}) // resources/read: リソースの読み取り
+	}
+	delete(result, "structuredContent")
+	meta, _ := result["_meta"].(map[string]interface{})
+	if meta == nil {
+		meta = make(map[string]interface{})
+	}
+	meta["truncated"] = true
+	result["_meta"] = meta
+
+	shortened := *resp
+	shortened.Result = result
+	for pass := 0; pass < maxTruncatePasses; pass++ {
+		data, err := s.marshal(&shortened, indent)
+		if err != nil {
+			return nil, false
+		}
+		over := len(data) - s.maxResponseBytes
+		if over <= 0 {
+			return data, true
+		}
+		if !shortenEntries(entries, over) {
+			return nil, false // nothing left to cut: 切り詰める対象が無い
+		}
+	}
+	return nil, false
+}
+
+// shortenEntries removes about over encoded bytes from the last non-empty text or blob in entries
+// shortenEntries: entriesの末尾の空でないtextまたはblobから、エンコード後で約overバイトを取り除く関数
+// It reports false when every entry is already empty.
+// 全ての項目が既に空ならfalseを返す
+func shortenEntries(entries []interface{}, over int) bool {
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry, _ := entries[i].(map[string]interface{})
+		for _, key := range []string{"text", "blob"} {
+			value, _ := entry[key].(string)
+			if value == "" {
+				continue
+			}
+			// Escaping makes encoded bytes outnumber raw ones: エスケープによりエンコード後のバイト数は元より多い
+			encoded, _ := json.Marshal(value)
+			keep := len(value) - over*len(value)/len(encoded) - 1
+			if keep < 0 {
+				keep = 0
+			}
+			if key == "blob" {
+				keep -= keep % 4 // whole base64 quanta: base64の単位を保つ
+			} else {
+				for keep > 0 && !utf8.RuneStart(value[keep]) {
+					keep-- // do not split a character: 文字を分割しない
+				}
+			}
+			entry[key] = value[:keep]
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"    // bytes: captured log output (取得したログ出力)
+	"context"  // context: RunIO lifetime and handler signature (RunIOの存続期間とハンドラーのシグネチャ)
+	"io"       // io: discarded logs (破棄するログ)
+	"log/slog" // log/slog: logger capturing warnings (警告を取得するロガー)
+	"strings"  // strings: large payloads and input (大きなペイロードと入力)
+	"testing"  // testing: test framework (テストフレームワーク)
+)
+
+// TestMaxResponseBytes checks that an oversized tool result is truncated to the limit
+// with _meta.truncated and a warning, or replaced with -32000 under ResponseError,
+// and that a result with nothing to shorten gets -32000 either way
+// TestMaxResponseBytes: 大きすぎるツール結果が_meta.truncatedと警告付きで上限まで切り詰められ、
+// ResponseErrorでは-32000に置き換えられ、短縮できる部分の無い結果はどちらでも-32000になることを確認するテスト
+func TestMaxResponseBytes(t *testing.T) {
+	const limit = 500
+	run := func(policy ResponseLimitPolicy, logs io.Writer, method, params string) (string, map[string]interface{}) {
+		t.Helper()
+		s := NewMCPServer(WithMaxResponseBytes(limit, policy), WithLogger(slog.New(slog.NewTextHandler(logs, nil))))
+		s.RegisterTool(Tool{Name: "big", Description: strings.Repeat("d", 2*limit), Handler: func(ctx context.Context, arguments interface{}) (map[string]interface{}, error) {
+			return StructuredResult(map[string]interface{}{"text": strings.Repeat("é", 2*limit)})
+		}})
+		in := `{"jsonrpc":"2.0","id":1,"method":"` + method + `","params":` + params + `}` + "\n"
+		var out strings.Builder
+		if err := s.RunIO(context.Background(), strings.NewReader(in), &out); err != nil {
+			t.Fatal(err)
+		}
+		return out.String(), decodeLines(t, out.String())[0]
+	}
+	call := `{"name":"big"}`
+
+	var logs bytes.Buffer
+	line, msg := run(ResponseTruncate, &logs, "tools/call", call)
+	result, _ := msg["result"].(map[string]interface{})
+	meta, _ := result["_meta"].(map[string]interface{})
+	if len(line) > limit+1 || meta["truncated"] != true || result["structuredContent"] != nil {
+		t.Fatalf("truncated (%d bytes): %s", len(line), line)
+	}
+	if !strings.Contains(logs.String(), "response exceeds limit") {
+		t.Fatalf("no warning: %s", logs.String())
+	}
+
+	for _, tt := range []struct {
+		policy         ResponseLimitPolicy
+		method, params string
+	}{
+		{ResponseError, "tools/call", call},
+		{ResponseTruncate, "tools/list", "{}"},
+	} {
+		_, msg := run(tt.policy, io.Discard, tt.method, tt.params)
+		rpcErr, _ := msg["error"].(map[string]interface{})
+		if rpcErr["code"] != float64(-32000) || rpcErr["message"] != "Response too large" || msg["id"] != float64(1) {
+			t.Errorf("%s under policy %d: %v", tt.method, tt.policy, msg)
+		}
+	}
+}
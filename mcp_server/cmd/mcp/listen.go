@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context" // context: shutdown (シャットダウン)
+	"errors"  // errors: error inspection (エラーの検査)
+	"fmt"     // fmt: error wrapping (エラーのラップ)
+	"io"      // io: end of input (入力の終端)
+	"net"     // net: socket listeners (ソケットのリスナー)
+	"sync"    // sync: waits for connections (接続の終了待ち)
+	"time"    // time: write deadline (書き込み期限)
+)
+
+// ListenUnix serves line-delimited JSON-RPC on a Unix domain socket at path
+// ListenUnix: pathのUnixドメインソケットで行区切りのJSON-RPCを提供する関数
+// Each connection runs its own RunIO loop, so request ids are scoped to it and its
+// responses go only to it; notifications go to every connection. It returns when ctx
+// is canceled, after closing every connection and removing the socket file.
+// A stale socket file left by a crashed process must be removed by the caller.
+// 各接続は独自のRunIOループで動作するため、リクエストidは接続毎に区別され、レスポンスはその接続にのみ
+// 送られる。通知は全ての接続へ送られる。ctxがキャンセルされると全ての接続を閉じ、ソケットファイルを
+// 削除してから戻る。クラッシュしたプロセスが残した古いソケットファイルは呼び出し元が削除すること
+// domain socket: ドメインソケット、IPC: プロセス間通信
+func (s *MCPServer) ListenUnix(ctx context.Context, path string) error {
+	ln, err := net.Listen("unix", path) // unlinked on Close: Closeで削除される
+	if err != nil {
+		return err // names the address: アドレスを含む
+	}
+	return s.serveListener(ctx, ln)
+}
+
+// ListenTCP serves line-delimited JSON-RPC on TCP connections accepted at addr
+// ListenTCP: addrで受け付けたTCP接続で行区切りのJSON-RPCを提供する関数
+// Connections behave as with ListenUnix, including framing and option handling, and
+// WithMaxConnections caps how many are served at once. It returns when ctx is canceled.
+// 接続は区切りやオプションの扱いを含めListenUnixと同様に動作し、WithMaxConnectionsで同時に
+// 処理する数を制限できる。ctxがキャンセルされると戻る
+// daemon: デーモン、常駐プロセス
+func (s *MCPServer) ListenTCP(ctx context.Context, addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err // names the address: アドレスを含む
+	}
+	return s.serveListener(ctx, ln)
+}
+
+// serveListener accepts connections from ln and runs RunIO on each until ctx is canceled
+// serveListener: lnから接続を受け付け、ctxがキャンセルされるまで各接続でRunIOを実行する関数
+// Over the connection limit, a connection is told so with an error line and closed.
+// 接続数の上限を超えた接続には、その旨のエラー行を送って閉じる
+func (s *MCPServer) serveListener(ctx context.Context, ln net.Listener) error {
+	defer ln.Close()
+	stop := context.AfterFunc(ctx, func() { ln.Close() }) // unblock Accept: Acceptの待機を解除
+	defer stop()
+
+	var slots chan struct{}
+	if s.maxConns > 0 {
+		slots = make(chan struct{}, s.maxConns)
+	}
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("accept: %w", err)
+		}
+		if slots != nil {
+			select {
+			case slots <- struct{}{}:
+			default:
+				s.rejectConn(conn)
+				continue
+			}
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if slots != nil {
+				defer func() { <-slots }()
+			}
+			s.serveConn(ctx, conn)
+		}()
+	}
+}
+
+// rejectConn answers a connection over the limit with a -32000 error and closes it
+// rejectConn: 上限を超えた接続に-32000エラーを返して閉じる関数
+func (s *MCPServer) rejectConn(conn net.Conn) {
+	defer conn.Close()
+	data, err := s.marshal(&JSONRPCResponse{
+		JSONRPC: "2.0",
+		Error: &JSONRPCError{
+			Code:    -32000,                 // Server error (サーバーエラー)
+			Message: "Too many connections", // connections: 接続
+			Data:    map[string]interface{}{"maxConnections": s.maxConns},
+		},
+	}, false)
+	if err == nil {
+		conn.SetWriteDeadline(time.Now().Add(time.Second)) // don't block Accept: Acceptを妨げない
+		conn.Write(append(data, '\n'))
+	}
+}
+
+// serveConn runs the JSON-RPC loop on one connection and closes it
+// serveConn: 1つの接続でJSON-RPCループを実行し、接続を閉じる関数
+// Closing the connection on shutdown also ends RunIO's blocked read. Each write gets
+// the connection write timeout, so a client that stops reading fails its own writes
+// instead of blocking forever.
+// シャットダウン時に接続を閉じることでRunIOのブロックした読み取りも終了する。各書き込みには
+// 接続の書き込みタイムアウトが設定されるため、読み取りを止めたクライアントは永久にブロックする
+// 代わりに自身の書き込みが失敗する
+func (s *MCPServer) serveConn(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	var out io.Writer = conn
+	if s.connWriteTimeout > 0 {
+		out = &deadlineWriter{conn: conn, timeout: s.connWriteTimeout}
+	}
+	err := s.RunIO(ctx, conn, out)
+	if err != nil && ctx.Err() == nil && !errors.Is(err, io.EOF) {
+		s.logger.Warn("connection ended", "remote", conn.RemoteAddr().String(), "error", err) // ended: 終了した
+	}
+}
+
+// defaultConnWriteTimeout bounds each write to a listener connection
+// defaultConnWriteTimeout: リスナー接続への各書き込みの上限時間
+const defaultConnWriteTimeout = 10 * time.Second
+
+// deadlineWriter writes to conn with a fresh write deadline each time
+// deadlineWriter: 毎回新しい書き込み期限を設定してconnへ書き込む構造体
+type deadlineWriter struct {
+	conn    net.Conn      // conn: the client connection (クライアント接続)
+	timeout time.Duration // timeout: time allowed per write (書き込み毎に許される時間)
+}
+
+// Write sets the deadline and writes p
+// Write: 期限を設定してpを書き込む関数
+func (w *deadlineWriter) Write(p []byte) (int, error) {
+	if err := w.conn.SetWriteDeadline(time.Now().Add(w.timeout)); err != nil {
+		return 0, err
+	}
+	return w.conn.Write(p)
+}
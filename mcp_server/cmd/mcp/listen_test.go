@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bufio"         // bufio: reading response lines (レスポンス行の読み取り)
+	"context"       // context: connection lifetime (接続の存続期間)
+	"errors"        // errors: listener shutdown errors (リスナー終了時のエラー)
+	"io"            // io: writing request lines (リクエスト行の書き込み)
+	"log/slog"      // log/slog: silences the expected warning (想定される警告を抑止)
+	"net"           // net: in-memory connections and sockets (メモリ内の接続とソケット)
+	"os"            // os: socket directory and file (ソケットのディレクトリとファイル)
+	"path/filepath" // path/filepath: socket path (ソケットのパス)
+	"strings"       // strings: matching the rejection line (拒否行の照合)
+	"testing"       // testing: test framework (テストフレームワーク)
+	"time"          // time: write deadlines (書き込み期限)
+)
+
+// dialUntil dials addr until the listener accepts or a second has passed
+// dialUntil: リスナーが受け付けるか1秒経過するまでaddrへの接続を試みる関数
+func dialUntil(t *testing.T, network, addr string) net.Conn {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		conn, err := net.Dial(network, addr)
+		if err == nil {
+			return conn
+		}
+		if time.Now().After(deadline) {
+			t.Fatal(err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestServeConnStalledClient checks that a client that stops reading neither blocks
+// other connections nor keeps its own connection open past the write timeout
+// TestServeConnStalledClient: 読み取りを止めたクライアントが他の接続を止めず、
+// 書き込みタイムアウトを過ぎて自身の接続を開いたままにしないことを確認するテスト
+func TestServeConnStalledClient(t *testing.T) {
+	s := NewMCPServer(WithConnWriteTimeout(time.Second), WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	serve := func() (net.Conn, chan struct{}) {
+		server, client := net.Pipe()
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			s.serveConn(ctx, server)
+		}()
+		return client, done
+	}
+	initialize := `{"jsonrpc":"2.0","id":1,"method":"initialize"}` + "\n"
+
+	// The stalled client's response blocks while it holds only its own connection.
+	// 停滞したクライアントへのレスポンスは自身の接続のみを占有したままブロックする
+	stalled, stalledDone := serve()
+	defer stalled.Close()
+	if _, err := io.WriteString(stalled, initialize); err != nil {
+		t.Fatal(err)
+	}
+
+	healthy, _ := serve()
+	defer healthy.Close()
+	if _, err := io.WriteString(healthy, initialize); err != nil {
+		t.Fatal(err)
+	}
+	healthy.SetReadDeadline(time.Now().Add(500 * time.Millisecond))
+	if _, err := bufio.NewReader(healthy).ReadString('\n'); err != nil {
+		t.Fatalf("healthy connection blocked: %v", err)
+	}
+
+	select {
+	case <-stalledDone:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stalled connection still open after the write timeout")
+	}
+}
+
+// TestListenUnix checks that each socket connection is answered on its own even with
+// the same request ids, and that canceling returns after removing the socket file
+// TestListenUnix: 同じリクエストidでも各ソケット接続が個別に応答を受け、
+// キャンセルするとソケットファイルを削除してから戻ることを確認するテスト
+func TestListenUnix(t *testing.T) {
+	dir, err := os.MkdirTemp("", "mcp") // short: socket paths are limited (ソケットのパス長には制限がある)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "mcp.sock")
+
+	s := newEchoServer()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.ListenUnix(ctx, path) }()
+
+	for _, message := range []string{"a", "b"} {
+		conn := dialUntil(t, "unix", path)
+		c := NewStreamClient(conn, conn)
+		if _, err := c.Initialize(); err != nil {
+			t.Fatal(err)
+		}
+		result, err := c.CallTool("echo", map[string]interface{}{"message": message})
+		if err != nil || result.Content[0]["text"] != "Echo: "+message {
+			t.Fatalf("connection %s: %+v, %v", message, result, err)
+		}
+		defer c.Close()
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("ListenUnix returned %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("socket file left behind: %v", err)
+	}
+}
+
+// TestListenTCPMaxConnections checks that a TCP connection over the limit gets a
+// -32000 error line and is closed, and that a freed slot admits the next client
+// TestListenTCPMaxConnections: 上限を超えたTCP接続が-32000のエラー行を受け取って閉じられ、
+// 枠が空くと次のクライアントが受け入れられることを確認するテスト
+func TestListenTCPMaxConnections(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0") // pick a free port: 空いているポートを選ぶ
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	s := NewMCPServer(WithMaxConnections(1))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.ListenTCP(ctx, addr) }()
+	initialize := func(conn net.Conn) (string, error) {
+		io.WriteString(conn, `{"jsonrpc":"2.0","id":1,"method":"initialize"}`+"\n")
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		return bufio.NewReader(conn).ReadString('\n')
+	}
+
+	first := dialUntil(t, "tcp", addr)
+	if line, err := initialize(first); err != nil || !strings.Contains(line, `"result"`) {
+		t.Fatalf("first connection: %q, %v", line, err)
+	}
+	second := dialUntil(t, "tcp", addr)
+	defer second.Close()
+	second.SetReadDeadline(time.Now().Add(time.Second))
+	reader := bufio.NewReader(second)
+	line, err := reader.ReadString('\n')
+	if err != nil || !strings.Contains(line, `"code":-32000`) || !strings.Contains(line, "Too many connections") {
+		t.Fatalf("second connection: %q, %v", line, err)
+	}
+	if _, err := reader.ReadString('\n'); err != io.EOF {
+		t.Fatalf("rejected connection left open: %v", err)
+	}
+
+	first.Close()
+	deadline := time.Now().Add(time.Second)
+	for {
+		conn := dialUntil(t, "tcp", addr)
+		line, err := initialize(conn)
+		conn.Close()
+		if err == nil && strings.Contains(line, `"result"`) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("freed slot not reused: %q, %v", line, err)
+		}
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("ListenTCP returned %v", err)
+	}
+}
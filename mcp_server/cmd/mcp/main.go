@@ -2,11 +2,25 @@ package main
 
 import (
 	"bufio"         // bufio: buffered I/O operations (バッファリングされたI/O操作)
+	"bytes"         // bytes: byte slice helpers (バイト列ヘルパー)
+	"context"       // context: cancellation and deadlines (キャンセルと期限)
 	"encoding/json" // encoding/json: JSON encoding and decoding (JSONエンコード・デコード)
+	"errors"        // errors: error inspection (エラー検査)
+	"flag"          // flag: command-line flag parsing (コマンドラインフラグ解析)
 	"fmt"           // fmt: formatted I/O (フォーマット済みI/O)
+	"io"            // io: basic I/O interfaces (基本的なI/Oインターフェース)
 	"log"           // log: simple logging package (シンプルなログ記録パッケージ)
+	"log/slog"      // log/slog: structured logging (構造化ログ)
+	"net/http"      // net/http: HTTP client and server (HTTPクライアントとサーバー)
+	"net/url"       // net/url: URL parsing (URL解析)
 	"os"            // os: operating system interface (オペレーティングシステムインターフェース)
+	"os/signal"     // os/signal: shutdown on interrupt (割り込みでのシャットダウン)
+	"sort"          // sort: sorting (並べ替え)
 	"strings"       // strings: string manipulation functions (文字列操作関数)
+	"sync"          // sync: mutual exclusion (排他制御)
+	"sync/atomic"   // sync/atomic: atomic flags and counters (アトミックなフラグとカウンター)
+	"syscall"       // syscall: termination signal (終了シグナル)
+	"time"          // time: durations and timers (時間とタイマー)
 )
 
 // JSONRPCRequest represents a JSON-RPC 2.0 request
@@ -14,17 +28,41 @@ import (
 // represents: 表現する、示す
 type JSONRPCRequest struct {
 	JSONRPC string      `json:"jsonrpc"` // jsonrpc: JSON-RPC protocol version (プロトコルバージョン)
-	ID      interface{} `json:"id"`      // id: request identifier (リクエスト識別子)
+	ID      RequestID   `json:"id"`      // id: request identifier (リクエスト識別子)
 	Method  string      `json:"method"`  // method: RPC method name (RPCメソッド名)
 	Params  interface{} `json:"params"`  // params: method parameters (メソッドパラメータ)
 }
 
+// errNotObject reports a valid JSON message whose top level is not an object
+// errNotObject: トップレベルがオブジェクトではない有効なJSONメッセージを表すエラー
+var errNotObject = errors.New("request must be a JSON object")
+
+// decodeRequest parses one JSON-RPC request message
+// decodeRequest: JSON-RPCリクエストメッセージを1件解析する関数
+// Bare strings, numbers, booleans and null are rejected with errNotObject instead of
+// decoding into a zero request that fails later with a misleading error. Messages
+// nested deeper than maxDepth are rejected with errTooDeep.
+// 裸の文字列・数値・真偽値・nullは、ゼロ値のリクエストとして後で紛らわしいエラーになる代わりに
+// errNotObjectで拒否する。maxDepthより深くネストしたメッセージはerrTooDeepで拒否する
+func decodeRequest(data []byte, maxDepth int) (JSONRPCRequest, error) {
+	var req JSONRPCRequest
+	if err := checkDepth(data, maxDepth); err != nil {
+		return req, err
+	}
+	trimmed := bytes.TrimSpace(data)
+	if json.Valid(trimmed) && trimmed[0] != '{' && trimmed[0] != '[' {
+		return req, errNotObject
+	}
+	err := json.Unmarshal(trimmed, &req)
+	return req, err
+}
+
 // JSONRPCResponse represents a JSON-RPC 2.0 response
 // JSONRPCResponse: JSON-RPC 2.0レスポンスを表現する構造体
 // response: 応答、返答
 type JSONRPCResponse struct {
 	JSONRPC string        `json:"jsonrpc"`          // jsonrpc: JSON-RPC protocol version
-	ID      interface{}   `json:"id"`               // id: matching request identifier
+	ID      RequestID     `json:"id"`               // id: matching request identifier
 	Result  interface{}   `json:"result,omitempty"` // result: method result (メソッド結果)
 	Error   *JSONRPCError `json:"error,omitempty"`  // error: error object (エラーオブジェクト)
 }
@@ -38,15 +76,124 @@ type JSONRPCError struct {
 	Data    interface{} `json:"data,omitempty"` // data: additional error data (追加エラーデータ)
 }
 
+// ErrIdleTimeout ends RunIO when no input arrives within the idle timeout
+// ErrIdleTimeout: アイドルタイムアウト内に入力が無い場合にRunIOを終了させるエラー
+var ErrIdleTimeout = errors.New("idle timeout")
+
+// Registration errors: 登録エラー
+var (
+	ErrTooManyTools     = errors.New("too many tools registered")     // tools: ツール
+	ErrTooManyResources = errors.New("too many resources registered") // resources: リソース
+	ErrDuplicateTool    = errors.New("tool already registered")       // duplicate: 重複
+	ErrBuiltinMethod    = errors.New("method is built in")            // built in: 組み込みの
+	ErrInvalidSchema    = errors.New("invalid schema")                // schema: スキーマ
+)
+
+// DuplicatePolicy decides what happens when a tool name is registered twice
+// DuplicatePolicy: 同じツール名が二度登録されたときの挙動を決める型
+type DuplicatePolicy int
+
+const (
+	DuplicateOverwrite DuplicatePolicy = iota // overwrite: 上書きする (デフォルト)
+	DuplicateReject                           // reject: ErrDuplicateToolを返して拒否する
+	DuplicatePanic                            // panic: パニックを起こす
+)
+
+// Default server identity: デフォルトのサーバー識別情報
+const (
+	defaultServerName    = "MCPServer" // name: 名前
+	defaultServerVersion = "1.0.0"     // version: バージョン
+)
+
+// defaultToolTimeout is the default tool execution timeout
+// defaultToolTimeout: デフォルトのツール実行タイムアウト
+const defaultToolTimeout = 30 * time.Second
+
 // MCPServer represents the MCP server instance
 // MCPServer: MCPサーバーインスタンスを表現する構造体
 // server: サーバー、提供者
 // instance: インスタンス、実例
 type MCPServer struct {
-	name      string              // name: server name (サーバー名)
-	version   string              // version: server version (サーバーバージョン)
+	name    string // name: server name (サーバー名)
+	version string // version: server version (サーバーバージョン)
+
+	instructions string // instructions: usage guidance sent in initialize (initializeで送る利用方法の案内)
+
+	mu        sync.RWMutex        // mu: guards the registries below (以下のレジストリを保護)
 	tools     map[string]Tool     // tools: available tools (利用可能なツール)
 	resources map[string]Resource // resources: available resources (利用可能なリソース)
+	static    map[string]Content  // static: in-memory contents by normalized URI (正規化済みURI毎のメモリ上の内容)
+	providers []ResourceProvider  // providers: custom resource providers (カスタムリソースプロバイダー)
+	listers   []ResourceLister    // listers: on-demand resource enumerators (オンデマンドのリソース列挙)
+	prompts   map[string]Prompt   // prompts: available prompts (利用可能なプロンプト)
+	methods   map[string]Handler  // methods: custom JSON-RPC methods (カスタムJSON-RPCメソッド)
+
+	configMethods bool               // configMethods: expose config/get and config/set (config/getとconfig/setを公開)
+	configMu      sync.Mutex         // configMu: serializes config/set (config/setを直列化)
+	settingsMu    sync.RWMutex       // settingsMu: guards settings and toolTimeout (settingsとtoolTimeoutを保護)
+	settings      map[string]Setting // settings: runtime-tunable settings (実行時に調整可能な設定)
+	logLevel      slog.LevelVar      // logLevel: adjustable log level (調整可能なログレベル)
+
+	defaultProviders []ResourceProvider       // defaultProviders: built-in providers consulted last (最後に参照される組み込みプロバイダー)
+	fallbackProvider ResourceProvider         // fallbackProvider: reads URIs no provider handles, nil to reject them (どのプロバイダーも処理しないURIを読む、nilなら拒否)
+	httpsAllowHosts  []string                 // httpsAllowHosts: internal hosts the built-in https provider may reach (組み込みhttpsプロバイダーが接続できる内部ホスト)
+	rootDir          string                   // rootDir: directory file:// URIs resolve against (file:// URIの基準ディレクトリ)
+	builtinTools     []func(root string) Tool // builtinTools: opt-in built-in tools, built once rootDir is known (オプトインの組み込みツール、rootDir確定後に生成)
+
+	charset          string          // charset: server-wide file charset conversion, "" for none (サーバー全体のファイル文字コード変換、""なら無し)
+	strictSchemas    bool            // strictSchemas: RegisterTool panics on an invalid schema (不正なスキーマでRegisterToolがパニック)
+	coerceArguments  bool            // coerceArguments: convert mismatched argument types before validation (検証前に型の不一致を変換)
+	maxContentBytes  int64           // maxContentBytes: resources/read content limit, 0 for unlimited (resources/readの内容上限、0なら無制限)
+	compressMinBytes int             // compressMinBytes: smallest gzipped HTTP body, negative to disable (gzip圧縮する最小のHTTPボディ、負なら無効)
+	updates          *updateQueue    // updates: coalescing resource update queue, nil to write at once (リソース更新をまとめるキュー、nilなら即時書き込み)
+	batchWindow      time.Duration   // batchWindow: longest a batched notification waits (バッチ中の通知が待つ最長時間)
+	batchMax         int             // batchMax: notifications per batch, 1 or less to write each at once (バッチ毎の通知数、1以下なら個別に即時書き込み)
+	breakerThreshold int             // breakerThreshold: downstream failures before opening (下流のブレーカーが開くまでの失敗回数)
+	breakerCooldown  time.Duration   // breakerCooldown: downstream breaker cooldown (下流ブレーカーのクールダウン)
+	proxy            proxy           // proxy: routes to downstream servers (下流サーバーへのルート)
+	nonces           *nonceStore     // nonces: recently seen HTTP nonces, nil when disabled (最近見たHTTPのnonce、無効時はnil)
+	jsonIndent       string          // jsonIndent: HTTP response indentation, "" for compact (HTTPレスポンスのインデント、""なら詰める)
+	jsonNoEscapeHTML bool            // jsonNoEscapeHTML: leave <, > and & unescaped (<、>、&をエスケープしない)
+	sanitizeInput    bool            // sanitizeInput: strip a BOM and control characters from stdio lines (stdioの行からBOMと制御文字を除去)
+	maxBodyBytes     int64           // maxBodyBytes: HTTP request body limit (HTTPリクエストボディの上限)
+	maxDepth         int             // maxDepth: request nesting limit, 0 for unlimited (リクエストのネスト上限、0なら無制限)
+	keepAlive        time.Duration   // keepAlive: SSE ping interval, 0 for none (SSEのping間隔、0なら無し)
+	idleTimeout      time.Duration   // idleTimeout: stdio input idle limit, 0 for none (stdio入力のアイドル上限、0なら無し)
+	maxConns         int             // maxConns: connections served at once per listener, 0 for unlimited (リスナー毎の同時接続数、0なら無制限)
+	connWriteTimeout time.Duration   // connWriteTimeout: deadline for each write to a listener connection, 0 for none (リスナー接続への各書き込みの期限、0なら無し)
+	toolTimeout      time.Duration   // toolTimeout: default tool execution timeout (デフォルトのツール実行タイムアウト)
+	singleFlight     bool            // singleFlight: share in-flight idempotent calls (実行中の冪等な呼び出しを共有)
+	cache            Cache           // cache: result cache, nil when disabled (結果キャッシュ、無効時はnil)
+	pageSize         int             // pageSize: list page size, 0 for unlimited (一覧のページサイズ、0なら無制限)
+	cursorKey        []byte          // cursorKey: signs pagination cursors (ページネーションカーソルの署名鍵)
+	metrics          *metrics        // metrics: request metrics (リクエストのメトリクス)
+	started          time.Time       // started: when the server was created, for uptime (稼働時間のためのサーバー作成時刻)
+	statsMethod      bool            // statsMethod: expose server/stats (server/statsを公開)
+	slowThreshold    time.Duration   // slowThreshold: WARN-log requests at least this slow, 0 for none (この時間以上のリクエストをWARNで記録、0なら無し)
+	logger           *slog.Logger    // logger: structured logger (構造化ロガー)
+	maxTools         int             // maxTools: registration cap, 0 for unlimited (登録上限、0なら無制限)
+	maxResources     int             // maxResources: registration cap, 0 for unlimited (登録上限、0なら無制限)
+	duplicatePolicy  DuplicatePolicy // duplicatePolicy: handling of re-registered tool names (同名ツール再登録時の扱い)
+	sessions         *sessionStore   // sessions: HTTP sessions (HTTPセッション)
+	auditLogger      AuditLogger     // auditLogger: tool call audit trail, nil to disable (ツール呼び出しの監査証跡、nilなら無効)
+	debug            bool            // debug: expose diagnostics such as stack traces (スタックトレースなどの診断情報を公開)
+	flights          flightGroup     // flights: in-flight tool calls (実行中のツール呼び出し)
+	conn             *connSession    // conn: state for requests outside any connection (接続に属さないリクエスト用の状態)
+	ready            atomic.Bool     // ready: some client completed the handshake (いずれかのクライアントがハンドシェイクを完了)
+
+	activeMu  sync.Mutex               // activeMu: guards activeIDs (activeIDsを保護)
+	activeIDs map[inFlightKey]struct{} // activeIDs: ids of requests being handled (処理中のリクエストのid)
+
+	connsMu sync.RWMutex   // connsMu: guards conns, so a blocked write does not stall lookups (connsを保護、書き込みの停滞が参照を止めないよう分離)
+	conns   []*connSession // conns: running connections (実行中の接続)
+
+	maxResponseBytes int                 // maxResponseBytes: encoded response limit, 0 for unlimited (エンコード後のレスポンス上限、0なら無制限)
+	responsePolicy   ResponseLimitPolicy // responsePolicy: handling of oversized responses (上限を超えたレスポンスの扱い)
+
+	pool *requestPool // pool: bounded worker pool, nil for unlimited (上限付きのワーカープール、nilなら無制限)
+
+	framing Framing // framing: how RunIO splits its input into messages (RunIOが入力をメッセージへ分割する方法)
+	codec   Codec   // codec: wire encoding of stream transports, nil for newline-delimited JSON (ストリームのトランスポートの通信時エンコーディング、nilなら改行区切りJSON)
 }
 
 // Tool represents an MCP tool
@@ -56,8 +203,100 @@ type Tool struct {
 	Name        string      `json:"name"`        // name: tool name (ツール名)
 	Description string      `json:"description"` // description: tool description (ツール説明)
 	InputSchema interface{} `json:"inputSchema"` // inputSchema: input validation schema (入力検証スキーマ)
+
+	// OutputSchema, when set, is the schema results' structuredContent must satisfy
+	// OutputSchema: 設定時、結果のstructuredContentが満たすべきスキーマ
+	OutputSchema interface{} `json:"outputSchema,omitempty"` // outputSchema: 出力スキーマ
+
+	Handler ToolHandler          `json:"-"` // handler: tool implementation (ツール実装)
+	Stream  StreamingToolHandler `json:"-"` // stream: incremental implementation, used instead of Handler (逐次出力する実装、Handlerの代わりに使う)
+	Timeout time.Duration        `json:"-"` // timeout: overrides the server default when positive (正の値ならサーバーのデフォルトを上書き)
+
+	// MaxConcurrent caps simultaneous calls when positive; BusyPolicy handles the rest
+	// MaxConcurrent: 正の値なら同時呼び出し数を制限し、超えた分はBusyPolicyに従う
+	MaxConcurrent int        `json:"-"` // maxConcurrent: 最大同時実行数
+	BusyPolicy    BusyPolicy `json:"-"` // busyPolicy: queue or reject when full (満杯時に待つか拒否するか)
+
+	Annotations *ToolAnnotations `json:"annotations,omitempty"` // annotations: behavior hints for clients (クライアント向けの挙動ヒント)
+
+	// Redact names argument fields, such as API keys or passwords, whose values are logged as "***"
+	// Redact: APIキーやパスワードなど、値を"***"としてログに記録する引数フィールドの名前
+	Redact []string `json:"-"` // redact: 伏せ字にする
+
+	schema *argumentSchema // schema: compiled InputSchema, set on registration (登録時に設定されるコンパイル済みInputSchema)
+	output *argumentSchema // output: compiled OutputSchema, set on registration (登録時に設定されるコンパイル済みOutputSchema)
+	slots  chan struct{}   // slots: concurrency semaphore shared by copies, set on registration (コピー間で共有される同時実行のセマフォ、登録時に設定)
 }
 
+// ToolAnnotations are hints describing a tool's behavior
+// ToolAnnotations: ツールの挙動を説明するヒント
+// Nil hints are omitted so clients apply the protocol defaults.
+// nilのヒントは省略され、クライアントはプロトコルのデフォルト値を適用する
+// hint: ヒント、手がかり
+type ToolAnnotations struct {
+	Title           string `json:"title,omitempty"`           // title: human-readable title (人が読むためのタイトル)
+	ReadOnlyHint    *bool  `json:"readOnlyHint,omitempty"`    // readOnly: does not modify its environment (環境を変更しない)
+	DestructiveHint *bool  `json:"destructiveHint,omitempty"` // destructive: may delete or overwrite data (データを削除・上書きする可能性)
+	IdempotentHint  *bool  `json:"idempotentHint,omitempty"`  // idempotent: repeated calls have no extra effect (繰り返しても追加の影響がない)
+	OpenWorldHint   *bool  `json:"openWorldHint,omitempty"`   // openWorld: interacts with external entities (外部とやり取りする)
+}
+
+// ResourceAnnotations tell clients who a resource is for and how important it is
+// ResourceAnnotations: リソースの対象者と重要度をクライアントに伝えるヒント
+// audience: 対象者、priority: 優先度
+type ResourceAnnotations struct {
+	Audience []string `json:"audience,omitempty"` // audience: "user" and/or "assistant" (対象: userまたはassistant)
+	Priority *float64 `json:"priority,omitempty"` // priority: 0 (least) to 1 (most important) (0が最低、1が最重要)
+}
+
+// compileTool prepares a tool's input and output schemas for validation
+// compileTool: 検証のためにツールの入力・出力スキーマを準備する関数
+func compileTool(tool Tool) (Tool, error) {
+	schema, err := compileSchema(tool.InputSchema)
+	if err != nil {
+		return tool, fmt.Errorf("tool %s: %w: %w", tool.Name, ErrInvalidSchema, err)
+	}
+	output, err := compileSchema(tool.OutputSchema)
+	if err != nil {
+		return tool, fmt.Errorf("tool %s: output schema: %w: %w", tool.Name, ErrInvalidSchema, err)
+	}
+	tool.schema = schema
+	tool.output = output
+	if tool.MaxConcurrent > 0 {
+		tool.slots = make(chan struct{}, tool.MaxConcurrent)
+	}
+	return tool, nil
+}
+
+// Bool returns a pointer to v, for filling annotation hints
+// Bool: アノテーションのヒントを埋めるためにvへのポインタを返す関数
+func Bool(v bool) *bool {
+	return &v
+}
+
+// interchangeable reports whether calls with equal arguments may share a result
+// interchangeable: 同じ引数の呼び出しが結果を共有できるかを判定する関数
+// Read-only and idempotent tools qualify, unless destructive: an idempotent write
+// must still run every time, since the data may have changed in between.
+// 読み取り専用または冪等なツールが該当する。ただし破壊的なものは除く:
+// 間にデータが変わっている可能性があるため、冪等な書き込みでも毎回実行しなければならない
+func (t Tool) interchangeable() bool {
+	a := t.Annotations
+	if a == nil || t.Stream != nil {
+		return false // streamed output cannot be replayed: ストリーミング出力は再生できない
+	}
+	if a.DestructiveHint != nil && *a.DestructiveHint {
+		return false // never skip a write: 書き込みを省略しない
+	}
+	return (a.ReadOnlyHint != nil && *a.ReadOnlyHint) || (a.IdempotentHint != nil && *a.IdempotentHint)
+}
+
+// ToolHandler executes a tool call with its decoded arguments
+// ToolHandler: デコード済み引数でツール呼び出しを実行する関数型
+// A returned error is reported to the client as the tool's error result.
+// 返されたエラーはツールのエラー結果としてクライアントに報告される
+type ToolHandler func(ctx context.Context, arguments interface{}) (map[string]interface{}, error)
+
 // Resource represents an MCP resource
 // Resource: MCPリソースを表現する構造体
 // resource: リソース、資源
@@ -66,38 +305,200 @@ type Resource struct {
 	Name        string `json:"name"`        // name: resource name (リソース名)
 	Description string `json:"description"` // description: resource description (リソース説明)
 	MimeType    string `json:"mimeType"`    // mimeType: MIME type (MIMEタイプ)
+
+	Annotations *ResourceAnnotations `json:"annotations,omitempty"` // annotations: hints for clients (クライアント向けのヒント)
+
+	// Cacheable allows resources/read results to be served from the cache
+	// Cacheable: resources/readの結果をキャッシュから返すことを許可する
+	Cacheable bool `json:"-"`
+
+	// Charset converts a file resource from this charset (or CharsetAuto) to UTF-8
+	// Charset: ファイルリソースをこの文字コード (またはCharsetAuto) からUTF-8に変換する
+	Charset string `json:"-"`
+}
+
+// Content represents the contents of a read resource
+// Content: 読み取ったリソースの内容を表現する構造体
+// contents: 内容、中身
+type Content struct {
+	URI      string `json:"uri"`            // uri: resource URI (リソースURI)
+	MimeType string `json:"mimeType"`       // mimeType: MIME type (MIMEタイプ)
+	Text     string `json:"text,omitempty"` // text: text content (テキスト内容)
+	Blob     string `json:"blob,omitempty"` // blob: base64 binary content (base64バイナリ内容)
+
+	Meta map[string]interface{} `json:"_meta,omitempty"` // _meta: extension metadata (拡張メタデータ)
 }
 
 // NewMCPServer creates a new MCP server instance
 // NewMCPServer: 新しいMCPサーバーインスタンスを作成する関数
 // creates: 作成する、生成する
-func NewMCPServer(name, version string) *MCPServer {
-	return &MCPServer{
-		name:      name,
-		version:   version,
+// Without WithName and WithVersion it reports itself as MCPServer 1.0.0.
+// WithNameとWithVersionを指定しない場合はMCPServer 1.0.0として名乗る
+func NewMCPServer(opts ...Option) *MCPServer {
+	s := &MCPServer{
+		name:      defaultServerName,
+		version:   defaultServerVersion,
 		tools:     make(map[string]Tool),     // make: マップを初期化
 		resources: make(map[string]Resource), // initialize: 初期化する
+		prompts:   make(map[string]Prompt),
+		methods:   make(map[string]Handler),
+
+		activeIDs:        make(map[inFlightKey]struct{}),
+		rootDir:          ".",
+		maxBodyBytes:     defaultMaxBodyBytes,
+		maxDepth:         defaultMaxDepth,
+		compressMinBytes: defaultCompressMinBytes,
+		toolTimeout:      defaultToolTimeout,
+		breakerThreshold: defaultBreakerThreshold,
+		breakerCooldown:  defaultBreakerCooldown,
+		connWriteTimeout: defaultConnWriteTimeout,
+		cursorKey:        newCursorKey(),
+		logger:           slog.Default(),
+		sessions:         newSessionStore(defaultSessionIdleTimeout),
+		metrics:          newMetrics(),
+		started:          time.Now(),
+	}
+
+	// Apply options: オプションを適用
+	// apply: 適用する
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.configMethods {
+		s.enableConfigMethods()
 	}
+	if s.statsMethod {
+		s.methods["server/stats"] = s.handleServerStats
+	}
+	s.logger = slog.New(correlationHandler{Handler: s.logger.Handler()}) // after WithLogger: WithLoggerの後
+
+	s.conn = s.newConnSession(nil) // batching is known now: バッチ化の設定が確定した
+
+	// Built-in providers depend on options: 組み込みプロバイダーはオプションに依存する
+	https := HTTPSProvider{ETags: NewLRUCache(defaultETagEntries, 0)}
+	if len(s.httpsAllowHosts) > 0 {
+		https.Client = SafeHTTPClient(s.httpsAllowHosts...) // trusted internal hosts: 信頼済みの内部ホスト
+	}
+	s.defaultProviders = []ResourceProvider{
+		ArchiveProvider{Root: s.rootDir},                           // file: zipアーカイブのメンバー
+		FileProvider{Root: s.rootDir, MaxBytes: s.maxContentBytes}, // file: ファイル
+		https,          // https: 安全なHTTP
+		DataProvider{}, // data: インラインデータ
+	}
+	// So are the built-in tools: 組み込みツールも同様
+	for _, build := range s.builtinTools {
+		s.RegisterTool(build(s.rootDir))
+	}
+	return s
 }
 
 // RegisterTool registers a new tool with the server
 // RegisterTool: サーバーに新しいツールを登録する関数
 // registers: 登録する、記録する
+// Registration errors are logged; use TryRegisterTool to handle them.
+// With WithStrictSchemas, an invalid schema panics instead so it fails at startup.
+// 登録エラーはログに記録される。エラーを処理するにはTryRegisterToolを使う。
+// WithStrictSchemasを指定すると、不正なスキーマは起動時に失敗するようパニックを起こす
 func (s *MCPServer) RegisterTool(tool Tool) {
+	err := s.TryRegisterTool(tool)
+	if s.strictSchemas && errors.Is(err, ErrInvalidSchema) {
+		panic(fmt.Sprintf("mcp: %v", err))
+	}
+	if err != nil {
+		s.logger.Error("tool registration failed", "tool", tool.Name, "error", err)
+	}
+}
+
+// TryRegisterTool registers a new tool, reporting why registration was refused
+// TryRegisterTool: 新しいツールを登録し、拒否された場合はその理由を返す関数
+// refused: 拒否された
+func (s *MCPServer) TryRegisterTool(tool Tool) error {
+	tool, err := compileTool(tool)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	_, replacing := s.tools[tool.Name]
+	if replacing {
+		switch s.duplicatePolicy {
+		case DuplicateReject:
+			s.mu.Unlock()
+			return fmt.Errorf("%w: %s", ErrDuplicateTool, tool.Name)
+		case DuplicatePanic:
+			s.mu.Unlock()
+			panic(fmt.Sprintf("mcp: duplicate tool registration: %s", tool.Name))
+		}
+	}
+	if !replacing && s.maxTools > 0 && len(s.tools) >= s.maxTools {
+		s.mu.Unlock()
+		return fmt.Errorf("%w: limit is %d", ErrTooManyTools, s.maxTools)
+	}
 	s.tools[tool.Name] = tool // assign: 割り当てる
+	s.mu.Unlock()
+
+	// Tell running clients: 実行中のクライアントへ通知
+	s.NotifyToolsListChanged()
+	return nil
+}
+
+// UnregisterTool removes a tool from the server
+// UnregisterTool: サーバーからツールを削除する関数
+// removes: 削除する、取り除く
+func (s *MCPServer) UnregisterTool(name string) {
+	s.mu.Lock()
+	_, existed := s.tools[name]
+	delete(s.tools, name) // delete: 削除
+	s.mu.Unlock()
+
+	if existed {
+		s.NotifyToolsListChanged()
+	}
 }
 
 // RegisterResource registers a new resource with the server
 // RegisterResource: サーバーに新しいリソースを登録する関数
+// Registration errors are logged; use TryRegisterResource to handle them.
+// 登録エラーはログに記録される。エラーを処理するにはTryRegisterResourceを使う
 func (s *MCPServer) RegisterResource(resource Resource) {
+	if err := s.TryRegisterResource(resource); err != nil {
+		s.logger.Error("resource registration failed", "uri", resource.URI, "error", err)
+	}
+}
+
+// TryRegisterResource registers a new resource, reporting why registration was refused
+// TryRegisterResource: 新しいリソースを登録し、拒否された場合はその理由を返す関数
+func (s *MCPServer) TryRegisterResource(resource Resource) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, replacing := s.resources[resource.URI]
+	if !replacing && s.maxResources > 0 && len(s.resources) >= s.maxResources {
+		return fmt.Errorf("%w: limit is %d", ErrTooManyResources, s.maxResources)
+	}
 	s.resources[resource.URI] = resource
+	return nil
 }
 
 // HandleRequest processes incoming JSON-RPC requests
 // HandleRequest: 受信したJSON-RPCリクエストを処理する関数
 // processes: 処理する、加工する
 // incoming: 入ってくる、受信する
-func (s *MCPServer) HandleRequest(req *JSONRPCRequest) *JSONRPCResponse {
+func (s *MCPServer) HandleRequest(ctx context.Context, req *JSONRPCRequest) (resp *JSONRPCResponse) {
+	// Correlate every log line of this request: このリクエストの全てのログ行を関連付ける
+	ctx = withCorrelation(ctx, req)
+
+	// Metrics, entry/exit and slow-request logs, after any panic is recovered
+	// メトリクス、開始・終了と遅いリクエストのログ (パニック回復の後に実行)
+	done := s.track(ctx, req)
+	defer func() { done(resp) }()
+
+	// Keep serving when a handler panics: ハンドラーがパニックしても処理を継続
+	defer func() {
+		if v := recover(); v != nil {
+			resp = s.panicResponse(req.ID, s.recovered(ctx, req.Method, v))
+		}
+	}()
+
 	// Input validation: セキュリティのための入力検証
 	// validation: 検証、妥当性確認
 	if req.JSONRPC != "2.0" {
@@ -111,52 +512,255 @@ func (s *MCPServer) HandleRequest(req *JSONRPCRequest) *JSONRPCResponse {
 		}
 	}
 
+	// Reject ids reused while in flight: 処理中のidの再利用を拒否
+	release, ok := s.claimID(ctx, req.ID)
+	if !ok {
+		return &JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error: &JSONRPCError{
+				Code:    -32600,                           // Invalid Request (無効なリクエスト)
+				Message: "duplicate in-flight request id", // duplicate: 重複した
+			},
+		}
+	}
+	defer release()
+
+	// Client deadline: クライアント指定の期限
+	// deadline: 期限、締め切り
+	timeout, err := requestTimeout(req.Meta())
+	if err != nil {
+		return &JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error: &JSONRPCError{
+				Code:    -32602,              // Invalid params (無効なパラメータ)
+				Message: "Invalid timeoutMs", // timeout: タイムアウト
+				Data:    map[string]interface{}{"reason": err.Error()},
+			},
+		}
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	ctx, meta := withMeta(ctx, req)
+	resp = s.dispatchQueued(ctx, req)
+	if resp.Error == nil {
+		meta.attach(resp)
+	}
+
+	// Report an exceeded client deadline as a timeout: 超過したクライアント期限をタイムアウトとして報告
+	if resp.Error != nil && timeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return &JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error: &JSONRPCError{
+				Code:    -32001,              // Request timeout (リクエストタイムアウト)
+				Message: "Request timed out", // timed out: 時間切れ
+				Data:    map[string]interface{}{"timeoutMs": timeout.Milliseconds()},
+			},
+		}
+	}
+	return resp
+}
+
+// requestTimeout reads the optional client timeout from params._meta.timeoutMs
+// requestTimeout: params._meta.timeoutMsから任意のクライアントタイムアウトを読み取る関数
+// Zero means the client did not ask for one and server defaults apply.
+// 0はクライアントが指定していないことを意味し、サーバーのデフォルトが適用される
+func requestTimeout(meta map[string]interface{}) (time.Duration, error) {
+	raw, present := meta["timeoutMs"]
+	if !present {
+		return 0, nil
+	}
+	ms, ok := raw.(float64)
+	if !ok || ms <= 0 {
+		return 0, fmt.Errorf("timeoutMs must be a positive number, got %v", raw)
+	}
+	return time.Duration(ms * float64(time.Millisecond)), nil
+}
+
+// builtinMethods lists the MCP methods handled by dispatch
+// builtinMethods: dispatchが処理するMCPメソッドの一覧
+// Keep it in sync with the switch in dispatch.
+// dispatchのswitchと同期させておくこと
+var builtinMethods = []string{
+	"initialize",
+	"tools/list",
+	"tools/get",
+	"tools/call",
+	"resources/list",
+	"resources/read",
+	"resources/subscribe",
+	"resources/unsubscribe",
+	"prompts/list",
+	"prompts/get",
+}
+
+// Methods returns the sorted names of the JSON-RPC methods the server supports
+// Methods: サーバーが対応するJSON-RPCメソッド名を並べ替えて返す関数
+// introspection: 内省、自己検査
+// Custom methods registered with RegisterMethod are included.
+// RegisterMethodで登録したカスタムメソッドも含む
+func (s *MCPServer) Methods() []string {
+	methods := append([]string(nil), builtinMethods...)
+	s.mu.RLock()
+	for name := range s.methods {
+		methods = append(methods, name)
+	}
+	s.mu.RUnlock()
+	sort.Strings(methods)
+	return methods
+}
+
+// Handler implements a custom JSON-RPC method
+// Handler: カスタムJSON-RPCメソッドを実装する関数型
+// Returning a *JSONRPCError sends it as is; other errors become -32603.
+// *JSONRPCErrorを返すとそのまま送信され、それ以外のエラーは-32603になる
+type Handler func(ctx context.Context, params interface{}) (interface{}, error)
+
+// RegisterMethod adds a JSON-RPC method outside the MCP set, such as x/refreshCache
+// RegisterMethod: x/refreshCacheのようなMCP以外のJSON-RPCメソッドを追加する関数
+// Built-in methods cannot be overridden; re-registering a custom method replaces it.
+// 組み込みメソッドは上書きできない。カスタムメソッドの再登録は置き換えになる
+func (s *MCPServer) RegisterMethod(name string, handler Handler) error {
+	for _, builtin := range builtinMethods {
+		if name == builtin {
+			return fmt.Errorf("%w: %s", ErrBuiltinMethod, name)
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.methods[name] = handler
+	return nil
+}
+
+// callMethod runs a custom method and wraps its outcome in a response
+// callMethod: カスタムメソッドを実行し、結果をレスポンスにまとめる関数
+func (s *MCPServer) callMethod(ctx context.Context, req *JSONRPCRequest, handler Handler) *JSONRPCResponse {
+	result, err := handler(ctx, req.Params)
+	var rpcErr *JSONRPCError
+	if errors.As(err, &rpcErr) {
+		return &JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error:   rpcErr,
+		}
+	}
+	if err != nil {
+		return &JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error: &JSONRPCError{
+				Code:    -32603,      // Internal error (内部エラー)
+				Message: err.Error(), // message: エラーメッセージ
+			},
+		}
+	}
+	return &JSONRPCResponse{
+		JSONRPC: "2.0",
+		ID:      req.ID,
+		Result:  result,
+	}
+}
+
+// dispatch routes a validated request to its method handler
+// dispatch: 検証済みリクエストをメソッドハンドラーへ振り分ける関数
+func (s *MCPServer) dispatch(ctx context.Context, req *JSONRPCRequest) *JSONRPCResponse {
 	// Method dispatch: メソッドの振り分け
 	// dispatch: 振り分ける、発送する
 	switch req.Method {
 	case "initialize":
-		return s.handleInitialize(req)
+		return s.handleInitialize(ctx, req)
 	case "tools/list":
-		return s.handleToolsList(req)
+		return s.handleToolsList(ctx, req)
+	case "tools/get":
+		return s.handleToolsGet(ctx, req)
 	case "tools/call":
-		return s.handleToolsCall(req)
+		return s.handleToolsCall(ctx, req)
 	case "resources/list":
-		return s.handleResourcesList(req)
+		return s.handleResourcesList(ctx, req)
 	case "resources/read":
-		return s.handleResourcesRead(req)
-	default:
-		return &JSONRPCResponse{
-			JSONRPC: "2.0",
-			ID:      req.ID,
-			Error: &JSONRPCError{
-				Code:    -32601,             // Method not found (メソッドが見つからない)
-				Message: "Method not found", // found: 見つかった
-			},
-		}
+		return s.handleResourcesRead(ctx, req)
+	case "resources/subscribe":
+		return s.handleResourcesSubscribe(ctx, req, true)
+	case "resources/unsubscribe":
+		return s.handleResourcesSubscribe(ctx, req, false)
+	case "prompts/list":
+		return s.handlePromptsList(req)
+	case "prompts/get":
+		return s.handlePromptsGet(ctx, req)
+	}
+
+	// Custom methods: カスタムメソッド
+	s.mu.RLock()
+	handler, ok := s.methods[req.Method]
+	s.mu.RUnlock()
+	if ok {
+		return s.callMethod(ctx, req, handler)
+	}
+
+	// Suggest a likely typo fix: ありそうな誤字の修正を提案
+	var data interface{}
+	if suggestion := suggestMethod(req.Method, s.Methods()); suggestion != "" {
+		data = map[string]interface{}{"method": req.Method, "suggestion": suggestion} // did you mean: もしかして
+	}
+	return &JSONRPCResponse{
+		JSONRPC: "2.0",
+		ID:      req.ID,
+		Error: &JSONRPCError{
+			Code:    -32601,             // Method not found (メソッドが見つからない)
+			Message: "Method not found", // found: 見つかった
+			Data:    data,
+		},
 	}
 }
 
 // handleInitialize handles the initialize method
 // handleInitialize: initializeメソッドを処理する関数
 // handles: 処理する、扱う
-func (s *MCPServer) handleInitialize(req *JSONRPCRequest) *JSONRPCResponse {
+func (s *MCPServer) handleInitialize(ctx context.Context, req *JSONRPCRequest) *JSONRPCResponse {
 	// Server capabilities: サーバーの機能
 	// capabilities: 機能、能力
-	result := map[string]interface{}{
-		"protocolVersion": "2024-11-05", // protocol: プロトコル
-		"capabilities": map[string]interface{}{
-			"tools": map[string]interface{}{
-				"listChanged": true, // listChanged: リスト変更通知
+	// Fixed field order keeps the output stable: 固定のフィールド順で出力を安定させる
+	result := &InitializeResult{
+		ProtocolVersion: latestProtocolVersion, // protocol: プロトコル
+		Capabilities: ServerCapabilities{
+			Tools: &ListChangedCapability{
+				ListChanged: true, // listChanged: リスト変更通知
+			},
+			Resources: &ResourcesCapability{
+				Subscribe:   true, // subscribe: 購読する
+				ListChanged: true,
 			},
-			"resources": map[string]interface{}{
-				"subscribe":   true, // subscribe: 購読する
-				"listChanged": true,
+			Prompts: &ListChangedCapability{
+				ListChanged: true, // prompts: プロンプト
 			},
 		},
-		"serverInfo": map[string]interface{}{
-			"name":    s.name,    // name: 名前
-			"version": s.version, // version: バージョン
+		ServerInfo: ServerInfo{
+			Name:    s.name,    // name: 名前
+			Version: s.version, // version: バージョン
 		},
+		// Usage guidance for the client, omitted when empty
+		// クライアント向けの利用方法の案内 (空なら省略)
+		Instructions: s.instructions, // instructions: 指示、説明
+	}
+
+	// Remember the client and agree on a version: クライアントを記憶し、バージョンを合意
+	params, _ := req.Params.(map[string]interface{})
+	requested, _ := params["protocolVersion"].(string)
+	result.ProtocolVersion = negotiateVersion(requested)
+	c := s.connFor(ctx)
+	c.protocolVersion.Store(result.ProtocolVersion)
+	if params != nil {
+		if info, ok := params["clientInfo"].(map[string]interface{}); ok {
+			if name, ok := info["name"].(string); ok {
+				c.client.Store(name)
+			}
+		}
 	}
 
 	return &JSONRPCResponse{
@@ -166,24 +770,121 @@ func (s *MCPServer) handleInitialize(req *JSONRPCRequest) *JSONRPCResponse {
 	}
 }
 
+// HandleNotification processes a JSON-RPC notification from the client
+// HandleNotification: クライアントからのJSON-RPC通知を処理する関数
+// Notifications never get a response, so unknown ones are ignored.
+// notifications/initialized completes the handshake of the connection carrying ctx:
+// only then does the server send it notifications, and report ready.
+// 通知には決して応答しないため、未知の通知は無視する。
+// notifications/initializedはctxを運ぶ接続のハンドシェイクを完了させ、
+// その後に初めてサーバーはその接続へ通知を送信し、準備完了を報告する
+func (s *MCPServer) HandleNotification(ctx context.Context, req *JSONRPCRequest) {
+	switch req.Method {
+	case "notifications/initialized":
+		// Mark ready: 準備完了としてマーク
+		s.connFor(ctx).initialized.Store(true)
+		s.ready.Store(true)
+	}
+}
+
+// clientID returns the client name the connection carrying ctx announced in initialize, if any
+// clientID: ctxを運ぶ接続がinitializeで通知したクライアント名を返す関数
+func (s *MCPServer) clientID(ctx context.Context) string {
+	return s.connFor(ctx).clientName()
+}
+
+// Tool returns the globally registered tool with the given name
+// Tool: 指定した名前でグローバルに登録されたツールを返す関数
+func (s *MCPServer) Tool(name string) (Tool, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	tool, ok := s.tools[name]
+	return tool, ok
+}
+
+// handleToolsGet handles the tools/get method
+// handleToolsGet: tools/getメソッドを処理する関数
+// It returns one tool's definition, cheaper than tools/list for large registries.
+// 1つのツールの定義を返す。大きなレジストリではtools/listより軽い
+func (s *MCPServer) handleToolsGet(ctx context.Context, req *JSONRPCRequest) *JSONRPCResponse {
+	params, _ := req.Params.(map[string]interface{})
+	toolName, ok := params["name"].(string)
+	if !ok {
+		return &JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error: &JSONRPCError{
+				Code:    -32602,                  // Invalid params (無効なパラメータ)
+				Message: "Tool name is required", // required: 必須の
+			},
+		}
+	}
+
+	tool, exists := s.lookupTool(ctx, toolName)
+	if !exists {
+		return &JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error: &JSONRPCError{
+				Code:    -32602,           // Invalid params (無効なパラメータ)
+				Message: "Tool not found", // found: 見つかった
+				Data:    map[string]interface{}{"tool": toolName},
+			},
+		}
+	}
+
+	return &JSONRPCResponse{
+		JSONRPC: "2.0",
+		ID:      req.ID,
+		Result: &ToolsGetResult{
+			Tool: s.adaptTool(ctx, tool), // tool: ツール定義
+		},
+	}
+}
+
 // handleToolsList handles the tools/list method
 // handleToolsList: tools/listメソッドを処理する関数
-func (s *MCPServer) handleToolsList(req *JSONRPCRequest) *JSONRPCResponse {
-	tools := make([]Tool, 0, len(s.tools)) // make: スライスを作成
-	for _, tool := range s.tools {         // range: 範囲、レンジ
-		tools = append(tools, tool) // append: 追加する
+func (s *MCPServer) handleToolsList(ctx context.Context, req *JSONRPCRequest) *JSONRPCResponse {
+	after, err := s.cursorParam(req.Params)
+	if err != nil {
+		return &JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error: &JSONRPCError{
+				Code:    -32602,           // Invalid params (無効なパラメータ)
+				Message: "Invalid cursor", // cursor: カーソル
+			},
+		}
+	}
+
+	// Global tools plus the session's: グローバルなツールとセッションのツール
+	visible := s.visibleTools(ctx)
+	tools := make([]Tool, 0, len(visible)) // make: スライスを作成
+	for _, tool := range visible {         // range: 範囲、レンジ
+		tools = append(tools, s.adaptTool(ctx, tool)) // append: 追加する
+	}
+
+	// Sort by name and select the page: 名前順に並べてページを選択
+	sort.Slice(tools, func(i, j int) bool { return tools[i].Name < tools[j].Name })
+	names := make([]string, len(tools))
+	for i, tool := range tools {
+		names[i] = tool.Name
 	}
+	start, end, nextCursor := s.paginate(names, after)
 
 	return &JSONRPCResponse{
 		JSONRPC: "2.0",
 		ID:      req.ID,
-		Result:  map[string]interface{}{"tools": tools},
+		Result: &ToolsListResult{
+			Tools:      tools[start:end],
+			NextCursor: nextCursor, // nextCursor: 次ページのカーソル
+		},
 	}
 }
 
 // handleToolsCall handles the tools/call method
 // handleToolsCall: tools/callメソッドを処理する関数
-func (s *MCPServer) handleToolsCall(req *JSONRPCRequest) *JSONRPCResponse {
+func (s *MCPServer) handleToolsCall(ctx context.Context, req *JSONRPCRequest) *JSONRPCResponse {
 	params, ok := req.Params.(map[string]interface{}) // type assertion: 型アサーション
 	if !ok {
 		return &JSONRPCResponse{
@@ -210,46 +911,242 @@ func (s *MCPServer) handleToolsCall(req *JSONRPCRequest) *JSONRPCResponse {
 
 	// Security: ツール名の検証
 	// security: セキュリティ、安全性
-	if _, exists := s.tools[toolName]; !exists {
+	// An unknown tool is an invalid parameter, not an unknown method
+	// 未知のツールはメソッド不明ではなく無効なパラメータとして扱う
+	tool, exists := s.lookupTool(ctx, toolName)
+	if !exists {
 		return &JSONRPCResponse{
 			JSONRPC: "2.0",
 			ID:      req.ID,
 			Error: &JSONRPCError{
-				Code:    -32601,
+				Code:    -32602,           // Invalid params (無効なパラメータ)
 				Message: "Tool not found", // found: 見つかった
+				Data:    map[string]interface{}{"tool": toolName},
+			},
+		}
+	}
+
+	// Opt-in coercion of common mismatches: よくある型の不一致の変換 (オプトイン)
+	arguments := params["arguments"]
+	if s.coerceArguments {
+		var changes []Coercion
+		if arguments, changes = tool.schema.coerce(arguments); changes != nil {
+			SetResultMeta(ctx, "coercions", changes) // coercions: 行った変換
+		}
+	}
+
+	// Validate arguments against the input schema: 入力スキーマで引数を検証
+	var violation *schemaViolation
+	if err := tool.schema.validate(arguments); errors.As(err, &violation) {
+		return &JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error: &JSONRPCError{
+				Code:    -32602,              // Invalid params (無効なパラメータ)
+				Message: "Invalid arguments", // arguments: 引数
+				Data: map[string]interface{}{
+					"tool":    toolName,
+					"path":    violation.Path,    // path: 違反した位置
+					"keyword": violation.Keyword, // keyword: 違反したキーワード
+					"reason":  violation.Reason,  // reason: 理由
+
+					"constraint": violation.Constraint, // constraint: 違反した制約
+				},
+			},
+		}
+	} else if err != nil {
+		return &JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error: &JSONRPCError{
+				Code:    -32603,           // Internal error (内部エラー)
+				Message: "Internal error", // internal: 内部の
+				Data:    map[string]interface{}{"tool": toolName, "reason": err.Error()},
 			},
 		}
 	}
 
 	// Execute tool: ツールを実行
 	// execute: 実行する、遂行する
-	result := s.executeTool(toolName, params["arguments"])
+	start := time.Now()
+	result, err := s.callTool(ctx, tool, arguments)
+	s.audit(ctx, tool, arguments, start, result, err)
+	var panicErr *panicError
+	if errors.As(err, &panicErr) {
+		return s.panicResponse(req.ID, panicErr)
+	}
+	var rpcErr *JSONRPCError
+	if errors.As(err, &rpcErr) {
+		// Error chosen by the handler: ハンドラーが選んだエラー
+		return &JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error:   rpcErr,
+		}
+	}
+	if errors.Is(err, ErrToolBusy) {
+		return &JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error: &JSONRPCError{
+				Code:    -32003,      // Tool busy (ツールが使用中)
+				Message: "Tool busy", // busy: 使用中
+				Data:    map[string]interface{}{"tool": toolName, "maxConcurrent": tool.MaxConcurrent},
+			},
+		}
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return &JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error: &JSONRPCError{
+				Code:    -32001,                     // Request timeout (リクエストタイムアウト)
+				Message: "Tool execution timed out", // timed out: 時間切れ
+				Data:    map[string]interface{}{"tool": toolName},
+			},
+		}
+	}
+	if errors.Is(err, context.Canceled) {
+		return &JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error: &JSONRPCError{
+				Code:    -32800,              // Request cancelled (リクエストがキャンセルされた)
+				Message: "Request cancelled", // cancelled: キャンセルされた
+				Data:    map[string]interface{}{"tool": toolName},
+			},
+		}
+	}
+	if err != nil {
+		return &JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error: &JSONRPCError{
+				Code:    -32603,           // Internal error (内部エラー)
+				Message: "Internal error", // internal: 内部の
+				Data:    map[string]interface{}{"tool": toolName, "reason": err.Error()},
+			},
+		}
+	}
+
+	// Check the content array's shape: content配列の形を検証
+	if err := validateContent(result); errors.As(err, &violation) {
+		s.logger.ErrorContext(ctx, "tool returned malformed content", "tool", toolName, "path", violation.Path, "reason", violation.Reason)
+		return &JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error: &JSONRPCError{
+				Code:    -32603,                // Internal error (内部エラー)
+				Message: "Invalid tool output", // output: 出力
+				Data: map[string]interface{}{
+					"tool":    toolName,
+					"path":    violation.Path,
+					"keyword": violation.Keyword,
+					"reason":  violation.Reason,
+				},
+			},
+		}
+	} else if err != nil {
+		return &JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error: &JSONRPCError{
+				Code:    -32603,           // Internal error (内部エラー)
+				Message: "Internal error", // internal: 内部の
+				Data:    map[string]interface{}{"tool": toolName, "reason": err.Error()},
+			},
+		}
+	}
+
+	// Check structured output against the output schema: 構造化出力を出力スキーマで検証
+	if err := tool.output.validateOutput(result); errors.As(err, &violation) {
+		s.logger.ErrorContext(ctx, "tool output violates its schema", "tool", toolName, "path", violation.Path, "reason", violation.Reason)
+		return &JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error: &JSONRPCError{
+				Code:    -32603,                // Internal error (内部エラー)
+				Message: "Invalid tool output", // output: 出力
+				Data: map[string]interface{}{
+					"tool":    toolName,
+					"path":    violation.Path,
+					"keyword": violation.Keyword,
+					"reason":  violation.Reason,
+				},
+			},
+		}
+	} else if err != nil {
+		return &JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error: &JSONRPCError{
+				Code:    -32603,           // Internal error (内部エラー)
+				Message: "Internal error", // internal: 内部の
+				Data:    map[string]interface{}{"tool": toolName, "reason": err.Error()},
+			},
+		}
+	}
 
 	return &JSONRPCResponse{
 		JSONRPC: "2.0",
 		ID:      req.ID,
-		Result:  result,
+		Result:  s.adaptToolResult(ctx, result), // gated by version: バージョンで制限
 	}
 }
 
 // handleResourcesList handles the resources/list method
 // handleResourcesList: resources/listメソッドを処理する関数
-func (s *MCPServer) handleResourcesList(req *JSONRPCRequest) *JSONRPCResponse {
-	resources := make([]Resource, 0, len(s.resources))
-	for _, resource := range s.resources {
+func (s *MCPServer) handleResourcesList(ctx context.Context, req *JSONRPCRequest) *JSONRPCResponse {
+	after, err := s.cursorParam(req.Params)
+	if err != nil {
+		return &JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error: &JSONRPCError{
+				Code:    -32602,           // Invalid params (無効なパラメータ)
+				Message: "Invalid cursor", // cursor: カーソル
+			},
+		}
+	}
+
+	visible, err := s.visibleResources(ctx)
+	if err != nil {
+		return &JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error: &JSONRPCError{
+				Code:    -32603,                     // Internal error (内部エラー)
+				Message: "Failed to list resources", // failed: 失敗した
+				Data:    map[string]interface{}{"reason": err.Error()},
+			},
+		}
+	}
+	resources := make([]Resource, 0, len(visible))
+	for _, resource := range visible {
 		resources = append(resources, resource)
 	}
 
+	// Sort by URI and select the page: URI順に並べてページを選択
+	sort.Slice(resources, func(i, j int) bool { return resources[i].URI < resources[j].URI })
+	uris := make([]string, len(resources))
+	for i, resource := range resources {
+		uris[i] = resource.URI
+	}
+	start, end, nextCursor := s.paginate(uris, after)
+
 	return &JSONRPCResponse{
 		JSONRPC: "2.0",
 		ID:      req.ID,
-		Result:  map[string]interface{}{"resources": resources},
+		Result: &ResourcesListResult{
+			Resources:  resources[start:end],
+			NextCursor: nextCursor,
+		},
 	}
 }
 
 // handleResourcesRead handles the resources/read method
 // handleResourcesRead: resources/readメソッドを処理する関数
-func (s *MCPServer) handleResourcesRead(req *JSONRPCRequest) *JSONRPCResponse {
+func (s *MCPServer) handleResourcesRead(ctx context.Context, req *JSONRPCRequest) *JSONRPCResponse {
 	params, ok := req.Params.(map[string]interface{})
 	if !ok {
 		return &JSONRPCResponse{
@@ -276,154 +1173,520 @@ func (s *MCPServer) handleResourcesRead(req *JSONRPCRequest) *JSONRPCResponse {
 
 	// Security: URI validation
 	// validation: 検証、妥当性確認
-	if !strings.HasPrefix(uri, "file://") && !strings.HasPrefix(uri, "https://") {
+	parsed, err := s.parseResourceURI(uri)
+	if err != nil {
+		message := "Invalid URI" // invalid: 無効な
+		if errors.Is(err, errUnsupportedScheme) {
+			message = "Invalid URI scheme" // scheme: スキーム、仕組み
+		}
+		return &JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error: &JSONRPCError{
+				Code:    -32602,
+				Message: message,
+				Data:    map[string]interface{}{"uri": uri, "reason": err.Error()}, // reason: 理由
+			},
+		}
+	}
+	uri = parsed.String() // normalized URI: 正規化済みURI
+
+	// Optional byte range: 任意のバイト範囲
+	readRange, err := parseReadRange(params)
+	if err != nil {
 		return &JSONRPCResponse{
 			JSONRPC: "2.0",
 			ID:      req.ID,
 			Error: &JSONRPCError{
 				Code:    -32602,
-				Message: "Invalid URI scheme", // scheme: スキーム、仕組み
+				Message: "Invalid range", // range: 範囲
+				Data:    map[string]interface{}{"uri": uri, "reason": err.Error()},
+			},
+		}
+	}
+
+	// Optional content negotiation: 任意のコンテンツネゴシエーション
+	// negotiation: 交渉、取り決め
+	accept, err := parseAccept(params)
+	if err == nil && accept != nil && readRange != nil {
+		err = errors.New("accept cannot be combined with range")
+	}
+	if err != nil {
+		return &JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error: &JSONRPCError{
+				Code:    -32602,
+				Message: "Invalid accept", // accept: 受け付ける
+				Data:    map[string]interface{}{"uri": uri, "reason": err.Error()},
 			},
 		}
 	}
 
 	// Read resource: リソースを読み取り
-	content := s.readResource(uri)
+	var content Content
+	if accept != nil {
+		content, err = s.readNegotiated(ctx, parsed, accept)
+	} else if readRange != nil {
+		content, err = s.readResourceRange(ctx, parsed, *readRange)
+	} else {
+		content, err = s.readCachedResource(ctx, parsed)
+	}
+	if errors.Is(err, errRangeNotSupported) {
+		return &JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error: &JSONRPCError{
+				Code:    -32602,
+				Message: "Range reads are not supported for this resource", // supported: 対応している
+				Data:    map[string]interface{}{"uri": uri},
+			},
+		}
+	}
+	if errors.Is(err, ErrNotAcceptable) {
+		return &JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error: &JSONRPCError{
+				Code:    -32002,                         // No acceptable representation (受け付け可能な表現が無い)
+				Message: "No acceptable representation", // representation: 表現
+				Data:    map[string]interface{}{"uri": uri, "accept": accept},
+			},
+		}
+	}
+	if errors.Is(err, errInvalidURI) {
+		// Malformed URI detected by the handler: ハンドラーが検出した不正なURI
+		return &JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error: &JSONRPCError{
+				Code:    -32602,
+				Message: "Invalid URI",
+				Data:    map[string]interface{}{"uri": uri, "reason": err.Error()},
+			},
+		}
+	}
+	var coded CodedError
+	if errors.As(err, &coded) {
+		// Provider-chosen code: プロバイダーが選んだコード
+		message := "Failed to read resource"
+		if coded.RPCCode() == CodeResourceNotFound {
+			message = "Resource not found" // not found: 見つからない
+		}
+		return &JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error: &JSONRPCError{
+				Code:    coded.RPCCode(),
+				Message: message,
+				Data:    map[string]interface{}{"uri": uri, "reason": err.Error()},
+			},
+		}
+	}
+	if err != nil {
+		return &JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error: &JSONRPCError{
+				Code:    -32603,                    // Internal error (内部エラー)
+				Message: "Failed to read resource", // failed: 失敗した
+				Data:    map[string]interface{}{"uri": uri, "reason": err.Error()},
+			},
+		}
+	}
+
+	// Protect the client from huge contents: 巨大な内容からクライアントを保護
+	if s.maxContentBytes > 0 {
+		content = truncateContent(content, s.maxContentBytes)
+	}
 
 	return &JSONRPCResponse{
 		JSONRPC: "2.0",
 		ID:      req.ID,
-		Result: map[string]interface{}{
-			"contents": []map[string]interface{}{
-				{
-					"uri":      uri,
-					"mimeType": "text/plain", // mimeType: MIMEタイプ
-					"text":     content,      // text: テキスト
-				},
-			},
+		Result: &ReadResourceResult{
+			Contents: []Content{content}, // contents: 内容
 		},
 	}
 }
 
+// callTool executes a tool, consulting the cache and single-flight group for read-only or idempotent tools
+// callTool: 読み取り専用または冪等なツールではキャッシュとシングルフライトを参照しながらツールを実行する関数
+func (s *MCPServer) callTool(ctx context.Context, tool Tool, arguments interface{}) (map[string]interface{}, error) {
+	if !tool.interchangeable() {
+		return s.runTool(ctx, tool, arguments)
+	}
+
+	// Cache lookup: キャッシュ参照
+	// Session tools are keyed per session: セッションのツールはセッション毎のキーを持つ
+	key := toolCacheKey(toolScope(ctx, tool.Name), tool.Name, arguments)
+	if s.cache != nil {
+		if cached, ok := s.cache.Get(key); ok {
+			return cached.(map[string]interface{}), nil
+		}
+	}
+
+	var result map[string]interface{}
+	var err error
+	if s.singleFlight {
+		// Share one execution among identical calls: 同一の呼び出し間で実行を1回に共有
+		// It runs under its own timeout; each caller gets a copy of its result metadata.
+		// 実行は独自のタイムアウトの下で行われ、各呼び出し元は結果メタデータのコピーを受け取る
+		var meta map[string]interface{}
+		result, meta, err, _ = s.flights.Do(ctx, key, func(ctx context.Context) (map[string]interface{}, error) {
+			return s.runTool(ctx, tool, arguments)
+		})
+		for k, v := range meta {
+			SetResultMeta(ctx, k, v)
+		}
+	} else {
+		result, err = s.runTool(ctx, tool, arguments)
+	}
+
+	// Cache successful results only: 成功した結果のみキャッシュ
+	if _, failed := result["error"]; s.cache != nil && err == nil && !failed {
+		s.cache.Set(key, result)
+	}
+	return result, err
+}
+
+// runTool executes a tool under the server or per-tool timeout
+// runTool: サーバーまたはツール個別のタイムアウトの下でツールを実行する関数
+// The handler runs on its own goroutine so a handler that ignores ctx cannot
+// block the response; such a handler is leaked and keeps its goroutine until it returns.
+// ハンドラーは専用のgoroutineで実行されるため、ctxを無視するハンドラーでも応答を妨げない。
+// その場合ハンドラーはリークし、戻るまでgoroutineを消費し続ける
+func (s *MCPServer) runTool(ctx context.Context, tool Tool, arguments interface{}) (map[string]interface{}, error) {
+	timeout := s.currentToolTimeout()
+	if tool.Timeout > 0 {
+		timeout = tool.Timeout // override: 上書き
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	// Wait for or reject on a full tool, within the timeout: タイムアウト内で満杯のツールを待つか拒否する
+	release, err := tool.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Buffered so a late handler can still deliver and exit
+	// 遅れたハンドラーでも結果を送って終了できるようにバッファ付きにする
+	type outcome struct {
+		result map[string]interface{}
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		defer release() // held until the handler returns, even if leaked: リークしてもハンドラーが戻るまで保持
+		// A panic on this goroutine would crash the process: このgoroutineでのパニックはプロセスを落とす
+		defer func() {
+			if v := recover(); v != nil {
+				done <- outcome{err: s.recovered(ctx, "tool "+tool.Name, v)}
+			}
+		}()
+		result, err := s.executeTool(ctx, tool, arguments)
+		done <- outcome{result: result, err: err}
+	}()
+
+	select {
+	case o := <-done:
+		return o.result, o.err
+	case <-ctx.Done():
+		// leaked: リークした
+		s.logger.WarnContext(ctx, "tool handler did not return; its goroutine is leaked until it returns",
+			"tool", tool.Name, "timeout", timeout, "reason", ctx.Err())
+		return nil, ctx.Err()
+	}
+}
+
 // executeTool executes a specific tool
 // executeTool: 特定のツールを実行する関数
 // specific: 特定の、具体的な
-func (s *MCPServer) executeTool(toolName string, arguments interface{}) map[string]interface{} {
-	// Example tool execution: ツール実行の例
-	// execution: 実行、遂行
-	switch toolName {
-	case "echo":
-		args, ok := arguments.(map[string]interface{})
-		if !ok {
-			return map[string]interface{}{
-				"error": "Invalid arguments", // arguments: 引数
-			}
-		}
-		message, ok := args["message"].(string)
-		if !ok {
-			return map[string]interface{}{
-				"error": "Message is required",
-			}
-		}
-		return map[string]interface{}{
-			"content": []map[string]interface{}{
-				{
-					"type": "text",
-					"text": fmt.Sprintf("Echo: %s", message), // sprintf: 文字列フォーマット
-				},
-			},
-		}
+// Handler errors become an error result, except a *JSONRPCError, which is
+// returned so the call fails with that error, as for custom methods.
+// ハンドラーのエラーはエラー結果になるが、*JSONRPCErrorはカスタムメソッドと同様に
+// そのエラーで呼び出しを失敗させるため返される
+func (s *MCPServer) executeTool(ctx context.Context, tool Tool, arguments interface{}) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	var err error
+	switch {
+	case tool.Stream != nil:
+		// Streaming handler: ストリーミングハンドラー
+		result, err = s.executeStreamingTool(ctx, tool, arguments)
+	case tool.Handler != nil:
+		// Registered handler: 登録されたハンドラー
+		result, err = tool.Handler(ctx, arguments)
 	default:
+		// Tools without a handler cannot run: ハンドラーのないツールは実行できない
 		return map[string]interface{}{
-			"error": "Unknown tool", // unknown: 不明な、未知の
-		}
+			"error": "Tool has no handler", // handler: ハンドラー
+		}, nil
+	}
+
+	var rpcErr *JSONRPCError
+	if errors.As(err, &rpcErr) {
+		return nil, rpcErr
 	}
+	if err != nil {
+		return map[string]interface{}{
+			"error": err.Error(),
+		}, nil
+	}
+	return result, nil
 }
 
 // readResource reads a resource by URI
 // readResource: URIによってリソースを読み取る関数
-func (s *MCPServer) readResource(uri string) string {
-	// Example resource reading: リソース読み取りの例
-	// reading: 読み取り、読書
-	switch {
-	case strings.HasPrefix(uri, "file://"):
-		// File system access: ファイルシステムアクセス
-		// access: アクセス、接近
-		return fmt.Sprintf("Content of %s", uri)
-	case strings.HasPrefix(uri, "https://"):
-		// HTTP request: HTTPリクエスト
-		// request: リクエスト、要求
-		return fmt.Sprintf("Web content of %s", uri)
-	default:
-		return "Unknown resource type" // type: タイプ、種類
+// It reads through the first resource provider that can handle the URI.
+// URIを処理できる最初のリソースプロバイダーで読み取る
+func (s *MCPServer) readResource(ctx context.Context, u *url.URL) (Content, error) {
+	provider := s.providerFor(u.String())
+	if provider == nil {
+		return Content{}, fmt.Errorf("%w: %q", errUnsupportedScheme, u.Scheme)
 	}
+
+	content, err := provider.Read(ctx, u.String())
+	if err != nil {
+		return Content{}, err
+	}
+
+	// Fill defaults: デフォルト値を補完
+	if content.URI == "" {
+		content.URI = u.String()
+	}
+	if content.MimeType == "" {
+		content.MimeType = "text/plain" // plain: プレーン、平文
+	}
+
+	// Opt-in charset conversion for files: ファイルの文字コード変換 (オプトイン)
+	if u.Scheme == "file" {
+		if charset := s.charsetFor(u.String()); charset != "" {
+			return convertCharset(content, charset)
+		}
+	}
+	return content, nil
 }
 
-// Run starts the MCP server
-// Run: MCPサーバーを開始する関数
+// Run starts the MCP server on the process standard streams
+// Run: 標準入出力でMCPサーバーを開始する関数
 // starts: 開始する、始める
+// While it runs, os.Stdout and the standard logger point at stderr (see guardStdout).
+// 実行中はos.Stdoutと標準ロガーが標準エラーを指す (guardStdoutを参照)
 func (s *MCPServer) Run() {
-	scanner := bufio.NewScanner(os.Stdin) // scanner: スキャナー、読み取り器
+	// Keep everything but protocol messages off stdout: プロトコルのメッセージ以外を標準出力から締め出す
+	stdout, restore := guardStdout()
+	defer restore()
+	if err := s.RunIO(context.Background(), os.Stdin, stdout); err != nil {
+		s.logger.Error("server stopped", "error", err) // server: サーバー
+	}
+}
+
+// RunIO serves line-delimited JSON-RPC requests read from in and writes responses to out
+// RunIO: inから読み取った行区切りJSON-RPCリクエストを処理し、outへレスポンスを書き込む関数
+// With WithCodec, messages are read and written in the codec's encoding instead, and
+// with WithFraming(FramingJSONStream) a request may span lines.
+// WithCodecを指定すると、メッセージはコーデックのエンコーディングで読み書きされ、
+// WithFraming(FramingJSONStream)を指定するとリクエストは複数行にわたってよい
+// The loop ends when in reaches EOF, ctx is cancelled, or no line arrives within the
+// idle timeout. Lines are read on a separate goroutine, which stays blocked in in.Read
+// after an early return until in is closed.
+// ループはinがEOFに達するか、ctxがキャンセルされるか、アイドルタイムアウト内に行が届かないと終了する。
+// 行は別のgoroutineで読み取られ、早期終了後はinが閉じられるまでin.Readでブロックしたままになる
+func (s *MCPServer) RunIO(ctx context.Context, in io.Reader, out io.Writer) error {
+	// Route responses and notifications through out: レスポンスと通知をoutへ流す
+	c := s.newConnSession(out)
+	c.codec = s.codec
+	c.serial = true // each request is handled before the next is read: 次を読む前に各リクエストを処理する
+	defer s.addConn(c)()
 
-	for scanner.Scan() { // scan: スキャンする、読み取る
-		line := scanner.Text() // text: テキスト、文字列
+	// Handshake, subscriptions and request ids belong to this connection
+	// ハンドシェイク、購読、リクエストidはこの接続に属する
+	ctx = withIDScope(withConn(ctx, c), c)
 
-		// Skip empty lines: 空行をスキップ
-		// skip: スキップする、飛ばす
-		// empty: 空の、からの
-		if strings.TrimSpace(line) == "" {
-			continue // continue: 続ける、継続する
+	// Read lines on a goroutine: goroutineで行を読み取る
+	lines := make(chan string)
+	stop := make(chan struct{})
+	defer close(stop)
+	var scanErr error
+	go func() {
+		defer close(lines)
+		if s.streamsJSON() {
+			scanErr = readJSONStream(in, lines, stop, s.maxDepth, s.maxBodyBytes) // opt-in: オプトイン
+			return
 		}
+		scanner := bufio.NewScanner(in) // scanner: スキャナー、読み取り器
+		scanner.Split(splitFunc(s.codec))
+		for scanner.Scan() { // scan: スキャンする、読み取る
+			select {
+			case lines <- scanner.Text(): // text: テキスト、文字列
+			case <-stop:
+				return
+			}
+		}
+		scanErr = scanner.Err() // read after lines is closed: linesが閉じた後に参照される
+	}()
+
+	// Idle timer, restarted after each line: 各行の後に再開するアイドルタイマー
+	var idle <-chan time.Time
+	timer := time.NewTimer(s.idleTimeout)
+	defer timer.Stop()
+	if s.idleTimeout > 0 {
+		idle = timer.C
+	}
 
-		var req JSONRPCRequest
-		if err := json.Unmarshal([]byte(line), &req); err != nil {
-			// Log error: エラーをログに記録
-			log.Printf("JSON parsing error: %v", err) // parsing: 解析
-			continue
+	for {
+		select {
+		case <-ctx.Done():
+			// Stop on cancellation: キャンセル時に停止
+			// cancellation: キャンセル、取り消し
+			return ctx.Err()
+		case <-idle:
+			return fmt.Errorf("%w: no input for %s", ErrIdleTimeout, s.idleTimeout)
+		case line, ok := <-lines:
+			if !ok {
+				if scanErr != nil {
+					return fmt.Errorf("scanner error: %w", scanErr) // scanner: スキャナー
+				}
+				return nil
+			}
+			if err := s.serveLine(ctx, c, line); err != nil {
+				return err
+			}
+			timer.Reset(s.idleTimeout) // handling time does not count: 処理時間は数えない
 		}
+	}
+}
 
-		// Process request: リクエストを処理
-		// process: 処理する、加工する
-		resp := s.HandleRequest(&req)
+// serveLine handles one input line, writing its response if any to c
+// serveLine: 入力の1行を処理し、レスポンスがあればcへ書き込む関数
+// Only a failed write is returned; bad input is answered or logged.
+// 書き込みの失敗のみを返す。不正な入力には応答するかログに記録する
+func (s *MCPServer) serveLine(ctx context.Context, c *connSession, line string) error {
+	if c.codec != nil {
+		// Transcode to JSON: JSONへ変換する
+		data, err := decodeWire(c.codec, []byte(line))
+		if err != nil {
+			s.logger.WarnContext(ctx, "message decoding failed", "error", err) // decoding: デコード
+			return nil
+		}
+		line = string(data)
+	}
+	// Tolerate CRLF line endings: CRLFの行末を許容する
+	// The scanner drops one \r before the newline; any left, as from "\r\r\n", is trimmed too.
+	// スキャナーは改行前の\rを1つ取り除く。"\r\r\n"などで残った分もここで取り除く
+	line = strings.TrimRight(line, "\r")
+	line = s.sanitize(line) // opt-in: オプトイン
+	// Skip empty lines: 空行をスキップ
+	// skip: スキップする、飛ばす
+	// empty: 空の、からの
+	if strings.TrimSpace(line) == "" {
+		return nil
+	}
 
-		// Send response: レスポンスを送信
-		// send: 送信する、送る
-		if respData, err := json.Marshal(resp); err == nil {
-			fmt.Println(string(respData)) // println: 行を出力
-		} else {
-			log.Printf("JSON marshaling error: %v", err) // marshaling: マーシャリング
+	s.metrics.observeRequest(len(line))
+	req, err := decodeRequest([]byte(line), s.maxDepth)
+	if errors.Is(err, errInvalidRequestID) || errors.Is(err, errNotObject) || errors.Is(err, errTooDeep) {
+		// Invalid id or non-object message: 無効なid、またはオブジェクトではないメッセージ
+		if err := s.writeResponse(ctx, c, &JSONRPCResponse{
+			JSONRPC: "2.0",
+			Error: &JSONRPCError{
+				Code:    -32600,      // Invalid Request (無効なリクエスト)
+				Message: err.Error(), // message: エラーメッセージ
+			},
+		}); err != nil {
+			return fmt.Errorf("write response: %w", err)
 		}
+		return nil
+	} else if err != nil {
+		// Log error: エラーをログに記録
+		s.logger.WarnContext(ctx, "JSON parsing failed", "error", err) // parsing: 解析
+		return nil
 	}
 
-	if err := scanner.Err(); err != nil {
-		log.Printf("Scanner error: %v", err) // scanner: スキャナー
+	// Notifications get no response: 通知には応答しない
+	if req.ID.IsZero() {
+		s.HandleNotification(ctx, &req)
+		return nil
 	}
+
+	// Process request: リクエストを処理
+	// The correlation id also covers writing the response: 相関IDはレスポンスの書き込みも対象とする
+	ctx = withCorrelation(ctx, &req)
+	// process: 処理する、加工する
+	resp := s.HandleRequest(ctx, &req)
+
+	// Send response: レスポンスを送信
+	// send: 送信する、送る
+	if err := s.writeResponse(ctx, c, resp); err != nil {
+		return fmt.Errorf("write response: %w", err)
+	}
+	return nil
 }
 
 // main function: メイン関数
 // main: メイン、主要な
 // function: 関数、機能
 func main() {
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run parses the flags and serves until the chosen transport stops
+// run: フラグを解析し、選択したトランスポートが停止するまでサービスを提供する関数
+// Errors are returned rather than fatal, so deferred cleanup such as closing the
+// audit log runs before main exits.
+// エラーは致命的に終了せず返すため、監査ログを閉じるなどの遅延処理はmainの終了前に実行される
+func run() error {
+	// Parse flags: フラグを解析
+	// flag: フラグ、コマンドラインオプション
+	httpAddr := flag.String("http", "", "serve JSON-RPC over HTTP on this address instead of stdio")
+	unixPath := flag.String("unix", "", "serve line-delimited JSON-RPC on this Unix domain socket instead of stdio")
+	tcpAddr := flag.String("tcp", "", "serve line-delimited JSON-RPC on TCP at this address instead of stdio")
+	maxConns := flag.Int("max-connections", 0, "limit concurrent -unix or -tcp connections (0 for no limit)")
+	auditPath := flag.String("audit-log", "", "append a JSON-lines audit record of every tool call to this file")
+	idleTimeout := flag.Duration("idle-timeout", 0, "exit the stdio loop after this long without input (0 waits forever)")
+	sanitizeInput := flag.Bool("sanitize-input", false, "strip a leading BOM and control characters from stdio input lines")
+	codecName := flag.String("codec", "json", "wire encoding of stdio, -unix and -tcp messages: json or msgpack")
+	jsonStream := flag.Bool("json-stream", false, "accept JSON requests spanning several lines on stdio, -unix and -tcp")
+	flag.Parse()
+
+	// Audit trail: 監査証跡
+	var opts []Option
+	if *sanitizeInput {
+		opts = append(opts, WithInputSanitizing())
+	}
+	if *maxConns > 0 {
+		opts = append(opts, WithMaxConnections(*maxConns))
+	}
+	if *jsonStream {
+		opts = append(opts, WithFraming(FramingJSONStream))
+	}
+	switch *codecName {
+	case "json":
+	case "msgpack":
+		opts = append(opts, WithCodec(MsgpackCodec{}))
+	default:
+		return fmt.Errorf("unknown codec %q", *codecName)
+	}
+	if *auditPath != "" {
+		auditLog, err := OpenAuditLog(*auditPath)
+		if err != nil {
+			return err
+		}
+		defer auditLog.Close()
+		opts = append(opts, WithAuditLogger(auditLog))
+	}
+
 	// Create server: サーバーを作成
 	// create: 作成する、生成する
-	server := NewMCPServer("CustomMCPServer", "1.0.0")
-
-	// Register tools: ツールを登録
+	// Register the example tools: 例示用ツールを登録
 	// register: 登録する、記録する
-	server.RegisterTool(Tool{
-		Name:        "echo",
-		Description: "Echo back the provided message", // provided: 提供された
-		InputSchema: map[string]interface{}{
-			"type": "object", // object: オブジェクト、物体
-			"properties": map[string]interface{}{
-				"message": map[string]interface{}{
-					"type":        "string",
-					"description": "Message to echo back", // echo: エコー、反響
-				},
-			},
-			"required": []string{"message"}, // required: 必須の
-		},
-	})
+	opts = append(opts, WithName("CustomMCPServer"), WithVersion("1.0.0"), WithExampleTools(), WithIdleTimeout(*idleTimeout))
+	server := NewMCPServer(opts...)
 
 	// Register resources: リソースを登録
 	server.RegisterResource(Resource{
@@ -435,5 +1698,30 @@ func main() {
 
 	// Start server: サーバーを開始
 	// start: 開始する、始める
+	if *httpAddr != "" {
+		// HTTP transport: HTTPトランスポート
+		mux := http.NewServeMux() // mux: マルチプレクサ、振り分け器
+		mux.Handle("/", server)
+		mux.HandleFunc("/healthz", server.HandleHealthz) // healthz: 生存確認
+		mux.HandleFunc("/readyz", server.HandleReadyz)   // readyz: 準備完了確認
+
+		log.Printf("Listening on %s", *httpAddr) // listening: 待ち受け中
+		return http.ListenAndServe(*httpAddr, mux)
+	}
+	if *unixPath != "" || *tcpAddr != "" {
+		// Socket transports, closed on interrupt: ソケットのトランスポート (割り込みで閉じる)
+		listen, addr := server.ListenUnix, *unixPath
+		if *tcpAddr != "" {
+			listen, addr = server.ListenTCP, *tcpAddr
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		log.Printf("Listening on %s", addr)
+		if err := listen(ctx, addr); err != nil && !errors.Is(err, context.Canceled) {
+			return err
+		}
+		return nil
+	}
 	server.Run()
+	return nil
 }
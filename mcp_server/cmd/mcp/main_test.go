@@ -0,0 +1,593 @@
+package main
+
+import (
+	"bytes"         // bytes: captured log output (取得したログ出力)
+	"context"       // context: RunIO lifetime (RunIOの存続期間)
+	"encoding/json" // encoding/json: decoding response lines (レスポンス行のデコード)
+	"errors"        // errors: error inspection (エラー検査)
+	"fmt"           // fmt: building request lines (リクエスト行の組み立て)
+	"io"            // io: an input that never arrives (届かない入力)
+	"log/slog"      // log/slog: logger capturing warnings (警告を取得するロガー)
+	"sort"          // sort: method order (メソッドの順序)
+	"strings"       // strings: in-memory input and output (メモリ内の入出力)
+	"testing"       // testing: test framework (テストフレームワーク)
+	"time"          // time: tool timeouts (ツールのタイムアウト)
+)
+
+// newEchoServer returns a server with the echo tool registered
+// newEchoServer: echoツールを登録したサーバーを返す関数
+func newEchoServer() *MCPServer {
+	s := NewMCPServer()
+	s.RegisterTool(EchoTool())
+	return s
+}
+
+// decodeLines decodes each line of out as a JSON-RPC response
+// decodeLines: outの各行をJSON-RPCレスポンスとしてデコードする関数
+func decodeLines(t *testing.T, out string) []map[string]interface{} {
+	t.Helper()
+	var msgs []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\n"), "\n") {
+		var msg map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &msg); err != nil {
+			t.Fatalf("line %q: %v", line, err)
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs
+}
+
+// TestRunIO checks that RunIO answers each request line read from an io.Reader
+// on the given io.Writer, in order, and returns nil at the end of input
+// TestRunIO: RunIOがio.Readerから読み取った各リクエスト行に指定したio.Writer上で
+// 順に応答し、入力の終わりでnilを返すことを確認するテスト
+func TestRunIO(t *testing.T) {
+	in := strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"initialize"}
+{"jsonrpc":"2.0","method":"notifications/initialized"}
+{"jsonrpc":"2.0","id":2,"method":"tools/list"}
+{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"echo","arguments":{"message":"hi"}}}
+`)
+	var out strings.Builder
+	if err := newEchoServer().RunIO(context.Background(), in, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs := decodeLines(t, out.String())
+	if len(msgs) != 3 {
+		t.Fatalf("got %d responses, want 3 (the notification gets none):\n%s", len(msgs), out.String())
+	}
+	for i, msg := range msgs {
+		if msg["id"] != float64(i+1) || msg["error"] != nil {
+			t.Fatalf("response %d: %v", i, msg)
+		}
+	}
+	content := msgs[2]["result"].(map[string]interface{})["content"].([]interface{})
+	if text := content[0].(map[string]interface{})["text"]; text != "Echo: hi" {
+		t.Fatalf("echo text: got %v", text)
+	}
+}
+
+// TestRunIOCanceled checks that RunIO stops when its context is canceled
+// TestRunIOCanceled: コンテキストがキャンセルされるとRunIOが停止することを確認するテスト
+func TestRunIOCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	in, _ := io.Pipe() // never written: 書き込まれない
+	var out strings.Builder
+	if err := newEchoServer().RunIO(ctx, in, &out); err != context.Canceled {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+}
+
+// TestRunIOIdleTimeout checks that RunIO returns ErrIdleTimeout once the input stalls
+// for the idle timeout, and that each line restarts the timer
+// TestRunIOIdleTimeout: 入力がアイドルタイムアウトの間止まるとRunIOがErrIdleTimeoutを返し、
+// 各行でタイマーが再開することを確認するテスト
+func TestRunIOIdleTimeout(t *testing.T) {
+	s := NewMCPServer(WithIdleTimeout(100 * time.Millisecond))
+	in, feed := io.Pipe()
+	defer feed.Close()
+	var out strings.Builder
+	done := make(chan error, 1)
+	start := time.Now()
+	go func() { done <- s.RunIO(context.Background(), in, &out) }()
+
+	time.Sleep(60 * time.Millisecond)
+	if _, err := io.WriteString(feed, `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`+"\n"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrIdleTimeout) {
+			t.Fatalf("got %v, want ErrIdleTimeout", err)
+		}
+		if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+			t.Fatalf("returned after %s; the line should have restarted the timer", elapsed)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("RunIO did not return after the idle timeout")
+	}
+	if msgs := decodeLines(t, out.String()); len(msgs) != 1 || msgs[0]["id"] != float64(1) {
+		t.Fatalf("responses: %s", out.String())
+	}
+}
+
+// TestToolTimeout checks that a handler ignoring its context is abandoned with
+// -32001 once the tool timeout expires and the leak is logged through the server
+// logger, that a per-tool timeout overrides the default, and that a caller
+// cancelling gets -32800 rather than a timeout
+// TestToolTimeout: ctxを無視するハンドラーがツールタイムアウト後に-32001で打ち切られ、
+// リークがサーバーのロガーに記録されること、ツール個別のタイムアウトがデフォルトを上書きすること、
+// 呼び出し側のキャンセルがタイムアウトではなく-32800になることを確認するテスト
+func TestToolTimeout(t *testing.T) {
+	var logs bytes.Buffer
+	s := NewMCPServer(WithToolTimeout(50*time.Millisecond), WithLogger(slog.New(slog.NewTextHandler(&logs, nil))))
+	block := make(chan struct{})
+	defer close(block) // release the leaked handlers: リークしたハンドラーを解放
+	s.RegisterTool(Tool{
+		Name: "hang",
+		Handler: func(ctx context.Context, arguments interface{}) (map[string]interface{}, error) {
+			<-block // ignores ctx: ctxを無視する
+			return nil, nil
+		},
+	})
+	s.RegisterTool(Tool{
+		Name:    "slow",
+		Timeout: time.Second,
+		Handler: func(ctx context.Context, arguments interface{}) (map[string]interface{}, error) {
+			time.Sleep(100 * time.Millisecond) // longer than the default: デフォルトより長い
+			return map[string]interface{}{"content": []interface{}{}}, nil
+		},
+	})
+	c := NewClient(s)
+	wantCode := func(err error, code int) {
+		t.Helper()
+		var rpcErr *JSONRPCError
+		if !errors.As(err, &rpcErr) || rpcErr.Code != code {
+			t.Fatalf("got %v, want code %d", err, code)
+		}
+	}
+
+	_, err := c.CallTool("hang", nil)
+	wantCode(err, -32001)
+	if !strings.Contains(logs.String(), "tool handler did not return") {
+		t.Fatalf("no leak warning in logs:\n%s", logs.String())
+	}
+
+	if _, err := c.CallTool("slow", nil); err != nil {
+		t.Fatalf("per-tool timeout: %v", err)
+	}
+
+	// The caller gives up before the tool timeout: ツールタイムアウトの前に呼び出し側が諦める
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	time.AfterFunc(10*time.Millisecond, stop)
+	err = c.callContext(ctx, "tools/call", map[string]interface{}{"name": "hang"}, nil)
+	wantCode(err, -32800)
+}
+
+// TestUnknownToolAndMethod checks that an unknown tool gets -32602 naming the tool
+// while an unknown method gets -32601
+// TestUnknownToolAndMethod: 不明なツールにはツール名付きの-32602、
+// 不明なメソッドには-32601が返ることを確認するテスト
+func TestUnknownToolAndMethod(t *testing.T) {
+	s := newEchoServer()
+	resp := s.HandleRequest(context.Background(), &JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      IntID(1),
+		Method:  "tools/call",
+		Params:  map[string]interface{}{"name": "missing"},
+	})
+	if resp.Error == nil || resp.Error.Code != -32602 {
+		t.Fatalf("unknown tool: got %+v, want -32602", resp.Error)
+	}
+	if data, _ := resp.Error.Data.(map[string]interface{}); data["tool"] != "missing" {
+		t.Fatalf("unknown tool data: got %v, want tool=missing", resp.Error.Data)
+	}
+
+	resp = s.HandleRequest(context.Background(), &JSONRPCRequest{JSONRPC: "2.0", ID: IntID(2), Method: "missing/method"})
+	if resp.Error == nil || resp.Error.Code != -32601 {
+		t.Fatalf("unknown method: got %+v, want -32601", resp.Error)
+	}
+}
+
+// TestRequestTimeoutMeta checks that params._meta.timeoutMs bounds a request with
+// -32001 and that an invalid value gets -32602
+// TestRequestTimeoutMeta: params._meta.timeoutMsがリクエストを-32001で打ち切り、
+// 無効な値には-32602が返ることを確認するテスト
+func TestRequestTimeoutMeta(t *testing.T) {
+	s := NewMCPServer()
+	s.RegisterTool(Tool{
+		Name: "slow",
+		Handler: func(ctx context.Context, arguments interface{}) (map[string]interface{}, error) {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(5 * time.Second):
+				return map[string]interface{}{"content": []interface{}{}}, nil
+			}
+		},
+	})
+	call := func(timeoutMs interface{}) *JSONRPCResponse {
+		return s.HandleRequest(context.Background(), &JSONRPCRequest{
+			JSONRPC: "2.0",
+			ID:      IntID(1),
+			Method:  "tools/call",
+			Params:  map[string]interface{}{"name": "slow", "_meta": map[string]interface{}{"timeoutMs": timeoutMs}},
+		})
+	}
+
+	start := time.Now()
+	resp := call(30.0)
+	if resp.Error == nil || resp.Error.Code != -32001 {
+		t.Fatalf("got %+v, want -32001", resp.Error)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("timed out after %s, want about 30ms", elapsed)
+	}
+	if data, _ := resp.Error.Data.(map[string]interface{}); data["timeoutMs"] != int64(30) {
+		t.Fatalf("got data %v, want timeoutMs 30", resp.Error.Data)
+	}
+
+	for _, bad := range []interface{}{-1.0, 0.0, "30"} {
+		if resp := call(bad); resp.Error == nil || resp.Error.Code != -32602 {
+			t.Fatalf("timeoutMs %v: got %+v, want -32602", bad, resp.Error)
+		}
+	}
+}
+
+// TestRegistrationCaps checks that WithMaxTools and WithMaxResources refuse new
+// entries beyond the cap while still allowing replacements
+// TestRegistrationCaps: WithMaxToolsとWithMaxResourcesが上限を超える新規登録を拒否し、
+// 置き換えは許可することを確認するテスト
+func TestRegistrationCaps(t *testing.T) {
+	s := NewMCPServer(WithMaxTools(2), WithMaxResources(1))
+	for _, name := range []string{"a", "b", "a"} {
+		if err := s.TryRegisterTool(Tool{Name: name}); err != nil {
+			t.Fatalf("tool %s: %v", name, err)
+		}
+	}
+	if err := s.TryRegisterTool(Tool{Name: "c"}); !errors.Is(err, ErrTooManyTools) {
+		t.Fatalf("third tool: got %v, want ErrTooManyTools", err)
+	}
+
+	for _, uri := range []string{"data:,1", "data:,1"} {
+		if err := s.TryRegisterResource(Resource{URI: uri}); err != nil {
+			t.Fatalf("resource %s: %v", uri, err)
+		}
+	}
+	if err := s.TryRegisterResource(Resource{URI: "data:,2"}); !errors.Is(err, ErrTooManyResources) {
+		t.Fatalf("second resource: got %v, want ErrTooManyResources", err)
+	}
+}
+
+// TestDuplicatePolicy checks that a duplicate tool registration replaces the tool by
+// default, is refused with DuplicateReject and panics with DuplicatePanic
+// TestDuplicatePolicy: 重複したツール登録がデフォルトでは置き換え、DuplicateRejectでは拒否、
+// DuplicatePanicではパニックになることを確認するテスト
+func TestDuplicatePolicy(t *testing.T) {
+	s := NewMCPServer()
+	s.RegisterTool(Tool{Name: "a", Description: "old"})
+	if err := s.TryRegisterTool(Tool{Name: "a", Description: "new"}); err != nil {
+		t.Fatalf("default policy: %v", err)
+	}
+	if tool := s.tools["a"]; tool.Description != "new" {
+		t.Fatalf("default policy kept %q, want the replacement", tool.Description)
+	}
+
+	s = NewMCPServer(WithDuplicatePolicy(DuplicateReject))
+	s.RegisterTool(Tool{Name: "a"})
+	if err := s.TryRegisterTool(Tool{Name: "a"}); !errors.Is(err, ErrDuplicateTool) {
+		t.Fatalf("DuplicateReject: got %v, want ErrDuplicateTool", err)
+	}
+
+	s = NewMCPServer(WithDuplicatePolicy(DuplicatePanic))
+	s.RegisterTool(Tool{Name: "a"})
+	defer func() {
+		if recover() == nil {
+			t.Fatal("DuplicatePanic: duplicate registration did not panic")
+		}
+	}()
+	s.RegisterTool(Tool{Name: "a"})
+}
+
+// TestToolAnnotations checks that tools/list reports the hints that were set,
+// keeping an explicit false, and omits unset hints and empty annotations
+// TestToolAnnotations: tools/listが設定されたヒントを明示的なfalseも含めて報告し、
+// 未設定のヒントと空のアノテーションを省略することを確認するテスト
+func TestToolAnnotations(t *testing.T) {
+	s := NewMCPServer()
+	s.RegisterTool(Tool{Name: "plain"})
+	s.RegisterTool(Tool{Name: "reader", Annotations: &ToolAnnotations{ReadOnlyHint: Bool(true), DestructiveHint: Bool(false)}})
+	resp := s.HandleRequest(context.Background(), &JSONRPCRequest{JSONRPC: "2.0", ID: IntID(1), Method: "tools/list"})
+	data, err := json.Marshal(resp.Result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result struct {
+		Tools []map[string]json.RawMessage `json:"tools"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatal(err)
+	}
+	annotations := map[string]string{}
+	for _, tool := range result.Tools {
+		var name string
+		json.Unmarshal(tool["name"], &name)
+		annotations[name] = string(tool["annotations"])
+	}
+	if got := annotations["plain"]; got != "" {
+		t.Fatalf("plain: got annotations %s, want none", got)
+	}
+	if got := annotations["reader"]; got != `{"readOnlyHint":true,"destructiveHint":false}` {
+		t.Fatalf("reader: got annotations %s", got)
+	}
+
+	// Round trip through the client: クライアント経由の往復
+	tools, err := NewClient(s).ListTools()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tool := range tools {
+		if tool.Name != "reader" {
+			continue
+		}
+		a := tool.Annotations
+		if a == nil || a.ReadOnlyHint == nil || !*a.ReadOnlyHint || a.DestructiveHint == nil || *a.DestructiveHint ||
+			a.IdempotentHint != nil || a.OpenWorldHint != nil {
+			t.Fatalf("reader: decoded annotations %+v", a)
+		}
+	}
+}
+
+// TestResourceAnnotations checks that resources/list reports audience and priority,
+// keeping a zero priority, and omits empty annotations
+// TestResourceAnnotations: resources/listが対象者と優先度をゼロの優先度も含めて報告し、
+// 空のアノテーションを省略することを確認するテスト
+func TestResourceAnnotations(t *testing.T) {
+	s := NewMCPServer()
+	lowest := 0.0
+	s.RegisterResource(Resource{URI: "data:,a", Name: "a", Annotations: &ResourceAnnotations{Audience: []string{"user", "assistant"}, Priority: &lowest}})
+	s.RegisterResource(Resource{URI: "data:,b", Name: "b"})
+	resp := s.HandleRequest(context.Background(), &JSONRPCRequest{JSONRPC: "2.0", ID: IntID(1), Method: "resources/list"})
+	data, err := json.Marshal(resp.Result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result struct {
+		Resources []map[string]json.RawMessage `json:"resources"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatal(err)
+	}
+	annotations := map[string]string{}
+	for _, resource := range result.Resources {
+		var uri string
+		json.Unmarshal(resource["uri"], &uri)
+		annotations[uri] = string(resource["annotations"])
+	}
+	if got := annotations["data:,a"]; got != `{"audience":["user","assistant"],"priority":0}` {
+		t.Fatalf("data:,a: got annotations %s", got)
+	}
+	if got := annotations["data:,b"]; got != "" {
+		t.Fatalf("data:,b: got annotations %s, want none", got)
+	}
+
+	// Round trip through the client: クライアント経由の往復
+	resources, err := NewClient(s).ListResources()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, resource := range resources {
+		if resource.URI != "data:,a" {
+			continue
+		}
+		a := resource.Annotations
+		if a == nil || len(a.Audience) != 2 || a.Audience[0] != "user" || a.Priority == nil || *a.Priority != 0 {
+			t.Fatalf("data:,a: decoded annotations %+v", a)
+		}
+	}
+}
+
+// TestInitializeInstructions checks that initialize only reports instructions when configured
+// TestInitializeInstructions: initializeが設定された場合のみinstructionsを報告することを確認するテスト
+func TestInitializeInstructions(t *testing.T) {
+	for want, s := range map[string]*MCPServer{
+		"":         NewMCPServer(),
+		"use echo": NewMCPServer(WithInstructions("use echo")),
+	} {
+		var result map[string]interface{}
+		if err := NewClient(s).call("initialize", nil, &result); err != nil {
+			t.Fatal(err)
+		}
+		got, present := result["instructions"]
+		if present != (want != "") || (present && got != want) {
+			t.Fatalf("got instructions %v (present %v), want %q", got, present, want)
+		}
+	}
+}
+
+// TestInitializeResultStable checks that the encoded initialize result is byte-identical
+// across calls, with fields in protocol order
+// TestInitializeResultStable: エンコードしたinitializeの結果が呼び出し間でバイト単位で同一で、
+// フィールドがプロトコルの順序であることを確認するテスト
+func TestInitializeResultStable(t *testing.T) {
+	s := newEchoServer()
+	encode := func() string {
+		resp := s.HandleRequest(context.Background(), &JSONRPCRequest{JSONRPC: "2.0", ID: IntID(1), Method: "initialize"})
+		data, err := json.Marshal(resp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	first := encode()
+	for i := 0; i < 10; i++ {
+		if again := encode(); again != first {
+			t.Fatalf("encoding %d differs:\n%s\n%s", i, first, again)
+		}
+	}
+	want := `{"jsonrpc":"2.0","id":1,"result":{"protocolVersion":"` + protocolVersions[len(protocolVersions)-1] + `",` +
+		`"capabilities":{"tools":{"listChanged":true},"resources":{"subscribe":true,"listChanged":true},"prompts":{"listChanged":true}},` +
+		`"serverInfo":{"name":"MCPServer","version":"1.0.0"}}}`
+	if first != want {
+		t.Fatalf("initialize:\ngot  %s\nwant %s", first, want)
+	}
+}
+
+// TestMethods checks that Methods lists every built-in method in sorted order and
+// that each listed method is dispatched rather than answered with -32601
+// TestMethods: Methodsが全ての組み込みメソッドを並べ替えて列挙し、列挙された各メソッドが
+// -32601ではなく処理されることを確認するテスト
+func TestMethods(t *testing.T) {
+	s := NewMCPServer()
+	methods := s.Methods()
+	if !sort.StringsAreSorted(methods) {
+		t.Fatalf("methods not sorted: %v", methods)
+	}
+	listed := map[string]bool{}
+	for _, method := range methods {
+		listed[method] = true
+		resp := s.HandleRequest(context.Background(), &JSONRPCRequest{JSONRPC: "2.0", ID: IntID(1), Method: method, Params: map[string]interface{}{}})
+		if resp.Error != nil && resp.Error.Code == -32601 {
+			t.Fatalf("listed method %s is not found", method)
+		}
+	}
+	for _, method := range []string{"initialize", "tools/list", "tools/call", "resources/read", "prompts/get"} {
+		if !listed[method] {
+			t.Fatalf("%s missing from %v", method, methods)
+		}
+	}
+}
+
+// TestRegisterMethod checks that custom methods are dispatched and listed, that a
+// *JSONRPCError they return is sent as is while other errors become -32603, and
+// that built-in methods cannot be overridden
+// TestRegisterMethod: カスタムメソッドが処理・列挙され、返した*JSONRPCErrorはそのまま、
+// その他のエラーは-32603として送られ、組み込みメソッドは上書きできないことを確認するテスト
+func TestRegisterMethod(t *testing.T) {
+	s := NewMCPServer()
+	for name, handler := range map[string]Handler{
+		"x/refresh": func(ctx context.Context, params interface{}) (interface{}, error) {
+			return map[string]interface{}{"ok": true}, nil
+		},
+		"x/coded": func(ctx context.Context, params interface{}) (interface{}, error) {
+			return nil, &JSONRPCError{Code: -32050, Message: "nope"}
+		},
+		"x/plain": func(ctx context.Context, params interface{}) (interface{}, error) {
+			return nil, errors.New("broken")
+		},
+	} {
+		if err := s.RegisterMethod(name, handler); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.RegisterMethod("initialize", nil); !errors.Is(err, ErrBuiltinMethod) {
+		t.Fatalf("overriding initialize: got %v, want ErrBuiltinMethod", err)
+	}
+
+	c := NewClient(s)
+	var result map[string]interface{}
+	if err := c.call("x/refresh", nil, &result); err != nil || result["ok"] != true {
+		t.Fatalf("x/refresh: got %v, %v", result, err)
+	}
+	for method, code := range map[string]int{"x/coded": -32050, "x/plain": -32603} {
+		var rpcErr *JSONRPCError
+		if err := c.call(method, nil, nil); !errors.As(err, &rpcErr) || rpcErr.Code != code {
+			t.Fatalf("%s: got %v, want code %d", method, err, code)
+		}
+	}
+
+	listed := strings.Join(s.Methods(), ",")
+	for _, method := range []string{"x/refresh", "x/coded", "x/plain"} {
+		if !strings.Contains(listed, method) {
+			t.Fatalf("%s missing from %s", method, listed)
+		}
+	}
+}
+
+// TestRunIONonObject checks that bare strings, numbers, booleans and null are
+// answered with -32600 and a null id, and that the loop keeps serving afterwards
+// TestRunIONonObject: 裸の文字列・数値・真偽値・nullに-32600とnullのidで応答し、
+// その後もループが処理を続けることを確認するテスト
+func TestRunIONonObject(t *testing.T) {
+	in := strings.NewReader("42\n\"hello\"\ntrue\nnull\n" + `{"jsonrpc":"2.0","id":1,"method":"tools/list"}` + "\n")
+	var out strings.Builder
+	if err := NewMCPServer().RunIO(context.Background(), in, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs := decodeLines(t, out.String())
+	if len(msgs) != 5 {
+		t.Fatalf("got %d responses, want 5:\n%s", len(msgs), out.String())
+	}
+	for i, msg := range msgs[:4] {
+		rpcErr, _ := msg["error"].(map[string]interface{})
+		if msg["id"] != nil || rpcErr["code"] != float64(-32600) || rpcErr["message"] != "request must be a JSON object" {
+			t.Errorf("response %d: %v", i, msg)
+		}
+	}
+	if last := msgs[4]; last["id"] != float64(1) || last["error"] != nil {
+		t.Fatalf("request after the rejected lines: %v", last)
+	}
+}
+
+// TestToolsGet checks that tools/get and the Tool accessor return one tool's
+// definition, and that an unknown or missing name gets -32602
+// TestToolsGet: tools/getとToolアクセサーが1つのツールの定義を返し、
+// 不明または欠けた名前が-32602になることを確認するテスト
+func TestToolsGet(t *testing.T) {
+	s := newEchoServer()
+	c := NewClient(s)
+	tool, err := c.GetTool("echo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tool.Name != "echo" || tool.Description == "" || tool.InputSchema == nil || tool.Annotations == nil {
+		t.Fatalf("echo: got %+v", tool)
+	}
+	_, err = c.GetTool("nope")
+	wantRPCCode(t, err, -32602)
+	resp := s.HandleRequest(context.Background(), &JSONRPCRequest{JSONRPC: "2.0", ID: IntID(1), Method: "tools/get"})
+	if resp.Error == nil || resp.Error.Code != -32602 {
+		t.Fatalf("tools/get without a name: got %+v", resp.Error)
+	}
+
+	if tool, ok := s.Tool("echo"); !ok || tool.Name != "echo" {
+		t.Fatalf("Tool(echo): got %+v, %v", tool, ok)
+	}
+	if _, ok := s.Tool("nope"); ok {
+		t.Fatal("Tool(nope) found a tool")
+	}
+}
+
+// TestRunIOCRLF checks that LF, CRLF and doubled CR line endings are all parsed,
+// that a line holding only CRLF is skipped, and that no CR reaches the arguments
+// TestRunIOCRLF: LF、CRLF、CRが重なった行末がいずれも解析され、CRLFだけの行は飛ばされ、
+// 引数にCRが残らないことを確認するテスト
+func TestRunIOCRLF(t *testing.T) {
+	call := func(id int, message string) string {
+		return fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":"tools/call","params":{"name":"echo","arguments":{"message":"%s"}}}`, id, message)
+	}
+	in := strings.NewReader(call(1, "lf") + "\n" + call(2, "crlf") + "\r\n" + "\r\n" + call(3, "crcrlf") + "\r\r\n")
+	var out strings.Builder
+	if err := newEchoServer().RunIO(context.Background(), in, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs := decodeLines(t, out.String())
+	if len(msgs) != 3 {
+		t.Fatalf("got %d responses, want 3:\n%s", len(msgs), out.String())
+	}
+	for i, want := range []string{"lf", "crlf", "crcrlf"} {
+		result, _ := msgs[i]["result"].(map[string]interface{})
+		content, _ := result["content"].([]interface{})
+		if len(content) != 1 || content[0].(map[string]interface{})["text"] != "Echo: "+want {
+			t.Errorf("response %d: %v", i, msgs[i])
+		}
+	}
+	if strings.Contains(out.String(), `\r`) {
+		t.Fatalf("carriage return in output:\n%s", out.String())
+	}
+}
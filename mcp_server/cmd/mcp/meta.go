@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"       // context: request-scoped values (リクエストスコープの値)
+	"encoding/json" // encoding/json: typed result conversion (型付き結果の変換)
+	"sync"          // sync: guards result metadata (結果メタデータの保護)
+)
+
+// Meta returns the request's params._meta object, or nil when absent
+// Meta: リクエストのparams._metaオブジェクトを返す関数 (無ければnil)
+// MCP reserves _meta for extensions such as progress tokens and trace context.
+// MCPは進捗トークンやトレースコンテキストなどの拡張のために_metaを予約している
+func (r *JSONRPCRequest) Meta() map[string]interface{} {
+	return paramsMeta(r.Params)
+}
+
+// paramsMeta extracts the _meta object from decoded params
+// paramsMeta: デコード済みパラメータから_metaオブジェクトを取り出す関数
+func paramsMeta(params interface{}) map[string]interface{} {
+	p, _ := params.(map[string]interface{})
+	meta, _ := p["_meta"].(map[string]interface{})
+	return meta
+}
+
+// metaKey is the context key for per-request metadata
+// metaKey: リクエスト単位のメタデータ用コンテキストキー
+type metaKey struct{}
+
+// metaState holds the incoming _meta and the _meta to attach to the result
+// metaState: 受信した_metaと結果に付与する_metaを保持する構造体
+type metaState struct {
+	request map[string]interface{} // request: incoming params._meta (受信したparams._meta)
+
+	mu     sync.Mutex             // mu: guards result (resultを保護)
+	result map[string]interface{} // result: entries for result._meta (result._metaに付与する項目)
+}
+
+// withMeta returns a context carrying the request's metadata
+// withMeta: リクエストのメタデータを持つコンテキストを返す関数
+func withMeta(ctx context.Context, req *JSONRPCRequest) (context.Context, *metaState) {
+	state := &metaState{request: req.Meta()}
+	return context.WithValue(ctx, metaKey{}, state), state
+}
+
+// RequestMeta returns the params._meta of the request being handled
+// RequestMeta: 処理中のリクエストのparams._metaを返す関数
+func RequestMeta(ctx context.Context) map[string]interface{} {
+	state, _ := ctx.Value(metaKey{}).(*metaState)
+	if state == nil {
+		return nil
+	}
+	return state.request
+}
+
+// SetResultMeta attaches key to the _meta of the result being built
+// SetResultMeta: 作成中の結果の_metaにkeyを付与する関数
+// Keys a handler already placed in its result's _meta take precedence.
+// ハンドラーが結果の_metaに直接設定したキーが優先される
+func SetResultMeta(ctx context.Context, key string, value interface{}) {
+	state, _ := ctx.Value(metaKey{}).(*metaState)
+	if state == nil {
+		return
+	}
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	if state.result == nil {
+		state.result = make(map[string]interface{})
+	}
+	state.result[key] = value
+}
+
+// attach merges the collected result metadata into resp
+// attach: 収集した結果メタデータをレスポンスへマージする関数
+// The result map is copied, since it may be shared through the cache.
+// Typed results are converted to a map first so _meta can be added.
+// 結果のマップはキャッシュ経由で共有されている可能性があるためコピーする。
+// 型付きの結果は_metaを追加できるよう先にマップへ変換する
+func (m *metaState) attach(resp *JSONRPCResponse) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.result) == 0 || resp.Result == nil {
+		return
+	}
+	result, ok := resp.Result.(map[string]interface{})
+	if !ok {
+		if result, ok = resultMap(resp.Result); !ok {
+			return
+		}
+	}
+
+	merged := make(map[string]interface{}, len(result)+1)
+	for k, v := range result {
+		merged[k] = v
+	}
+	meta := make(map[string]interface{}, len(m.result))
+	for k, v := range m.result {
+		meta[k] = v
+	}
+	// Existing entries win: 既存の項目を優先
+	if existing, ok := result["_meta"].(map[string]interface{}); ok {
+		for k, v := range existing {
+			meta[k] = v
+		}
+	}
+	merged["_meta"] = meta
+	resp.Result = merged
+}
+
+// resultMap converts a typed result to its JSON object form
+// resultMap: 型付きの結果をJSONオブジェクト形式へ変換する関数
+func resultMap(result interface{}) (map[string]interface{}, bool) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, false
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil || m == nil {
+		return nil, false // not an object: オブジェクトではない
+	}
+	return m, true
+}
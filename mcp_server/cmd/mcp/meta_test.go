@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"       // context: handler signatures (ハンドラーのシグネチャ)
+	"encoding/json" // encoding/json: inspecting responses (レスポンスの検査)
+	"net/url"       // net/url: scheme handler signature (スキームハンドラーのシグネチャ)
+	"strings"       // strings: response matching (レスポンスの照合)
+	"testing"       // testing: test framework (テストフレームワーク)
+)
+
+// TestRequestAndResultMeta checks that handlers read params._meta and that result
+// metadata they set is emitted as result._meta, with the handler's own entries winning
+// TestRequestAndResultMeta: ハンドラーがparams._metaを読み取れ、設定した結果メタデータが
+// result._metaとして出力され、ハンドラー自身の項目が優先されることを確認するテスト
+func TestRequestAndResultMeta(t *testing.T) {
+	s := NewMCPServer()
+	var got map[string]interface{}
+	s.RegisterTool(Tool{
+		Name: "m",
+		Handler: func(ctx context.Context, arguments interface{}) (map[string]interface{}, error) {
+			got = RequestMeta(ctx)
+			SetResultMeta(ctx, "traceId", "abc")
+			SetResultMeta(ctx, "own", "lost")
+			return map[string]interface{}{"content": []interface{}{}, "_meta": map[string]interface{}{"own": "kept"}}, nil
+		},
+	})
+	resp := s.HandleRequest(context.Background(), &JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      IntID(1),
+		Method:  "tools/call",
+		Params:  map[string]interface{}{"name": "m", "_meta": map[string]interface{}{"progressToken": "p1"}},
+	})
+	if got["progressToken"] != "p1" {
+		t.Fatalf("RequestMeta: got %v, want progressToken p1", got)
+	}
+	data, err := json.Marshal(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"_meta":{"own":"kept","traceId":"abc"}`) || !strings.Contains(string(data), `"content":[]`) {
+		t.Fatalf("got %s, want content kept and _meta merged", data)
+	}
+}
+
+// TestResultMetaTypedResult checks that result metadata is added to typed results
+// such as resources/read without dropping their fields
+// TestResultMetaTypedResult: resources/readのような型付きの結果にも、
+// そのフィールドを失わずに結果メタデータが追加されることを確認するテスト
+func TestResultMetaTypedResult(t *testing.T) {
+	s := NewMCPServer()
+	s.RegisterSchemeHandler("mem", func(ctx context.Context, u *url.URL) (Content, error) {
+		SetResultMeta(ctx, "source", "mem")
+		return Content{MimeType: "text/plain", Text: "x"}, nil
+	})
+	var result struct {
+		Contents []Content              `json:"contents"`
+		Meta     map[string]interface{} `json:"_meta"`
+	}
+	if err := NewClient(s).call("resources/read", map[string]interface{}{"uri": "mem://a"}, &result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Contents) != 1 || result.Contents[0].Text != "x" || result.Meta["source"] != "mem" {
+		t.Fatalf("got %+v, want the content and _meta.source", result)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context" // context: carries the invocation depth (呼び出しの深さの受け渡し)
+	"fmt"     // fmt: formatted errors (フォーマット済みエラー)
+)
+
+// metaInvokeName is the name of the meta/invoke tool
+// metaInvokeName: meta/invokeツールの名前
+const metaInvokeName = "meta/invoke"
+
+// defaultMetaInvokeDepth limits nested meta/invoke calls when no limit is given
+// defaultMetaInvokeDepth: 上限の指定が無い場合のmeta/invokeの入れ子の上限
+const defaultMetaInvokeDepth = 3
+
+// metaDepthKey is the context key for the number of enclosing meta/invoke calls
+// metaDepthKey: 外側のmeta/invoke呼び出しの数を表すコンテキストキー
+type metaDepthKey struct{}
+
+// metaDepth returns how many meta/invoke calls enclose ctx
+// metaDepth: ctxを囲むmeta/invoke呼び出しの数を返す関数
+func metaDepth(ctx context.Context) int {
+	depth, _ := ctx.Value(metaDepthKey{}).(int)
+	return depth
+}
+
+// metaInvokeTool returns the meta/invoke tool, which calls another registered tool
+// metaInvokeTool: 登録済みの別のツールを呼び出すmeta/invokeツールを返す関数
+// The call goes through tools/call, so lookup, argument validation, output checks,
+// concurrency limits and auditing apply as for a client call, and a JSON-RPC error
+// from the target fails meta/invoke with the same error. meta/invoke refuses to call
+// itself, and a chain of tools calling back through the server may nest it at most
+// maxDepth times (3 when zero).
+// 呼び出しはtools/callを経由するため、検索、引数の検証、出力の検査、同時実行の制限、監査は
+// クライアントからの呼び出しと同様に適用され、対象のJSON-RPCエラーは同じエラーでmeta/invokeを失敗させる。
+// meta/invokeは自身を呼び出すことを拒否し、サーバー経由で呼び戻すツールの連鎖でも
+// 入れ子は最大maxDepth回 (0なら3) まで
+func (s *MCPServer) metaInvokeTool(maxDepth int) Tool {
+	if maxDepth <= 0 {
+		maxDepth = defaultMetaInvokeDepth
+	}
+	return Tool{
+		Name:        metaInvokeName,
+		Description: "Call another registered tool by name", // invoke: 呼び出す
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"name": map[string]interface{}{
+					"type":        "string",
+					"description": "Name of the tool to call",
+				},
+				"arguments": map[string]interface{}{
+					"type":        "object",
+					"description": "Arguments for the tool",
+				},
+			},
+			"required": []string{"name"},
+		},
+		Handler: func(ctx context.Context, arguments interface{}) (map[string]interface{}, error) {
+			args, _ := arguments.(map[string]interface{})
+			name, _ := args["name"].(string)
+
+			// Recursion guard: 再帰の防止
+			if name == metaInvokeName {
+				return nil, fmt.Errorf("%s cannot call itself", metaInvokeName)
+			}
+			depth := metaDepth(ctx) + 1
+			if depth > maxDepth {
+				return nil, fmt.Errorf("%s nested deeper than %d calls", metaInvokeName, maxDepth)
+			}
+			ctx = context.WithValue(ctx, metaDepthKey{}, depth)
+
+			params := map[string]interface{}{"name": name}
+			if inner, ok := args["arguments"]; ok {
+				params["arguments"] = inner
+			}
+			resp := s.handleToolsCall(ctx, &JSONRPCRequest{
+				JSONRPC: "2.0",
+				Method:  "tools/call",
+				Params:  params,
+			})
+			if resp.Error != nil {
+				return nil, resp.Error // fail with the target's error: 対象のエラーで失敗する
+			}
+			result, _ := resp.Result.(map[string]interface{})
+			return result, nil
+		},
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"    // bytes: captured log and audit output (取り込んだログと監査の出力)
+	"context"  // context: handler signature (ハンドラーのシグネチャ)
+	"fmt"      // fmt: rendering results and error data (結果とエラーデータの文字列化)
+	"log/slog" // log/slog: structured logger under test (テスト対象の構造化ロガー)
+	"strings"  // strings: searching the output (出力の検索)
+	"testing"  // testing: test framework (テストフレームワーク)
+	"time"     // time: slow request threshold (遅いリクエストのしきい値)
+)
+
+// TestMetaInvokeRedactsNestedArguments checks that arguments passed through
+// meta/invoke are masked with the target tool's Redact fields in logs and audit records
+// TestMetaInvokeRedactsNestedArguments: meta/invokeを経由して渡される引数が、
+// ログと監査記録で対象のツールのRedactフィールドにより伏せ字になることを確認するテスト
+func TestMetaInvokeRedactsNestedArguments(t *testing.T) {
+	var logs, audit bytes.Buffer
+	s := NewMCPServer(
+		WithMetaInvokeTool(0),
+		WithSlowRequestThreshold(time.Nanosecond),
+		WithLogger(slog.New(slog.NewJSONHandler(&logs, nil))),
+		WithDebug(true),
+		WithAuditLogger(NewJSONLAuditLogger(&audit)),
+	)
+	s.RegisterTool(Tool{Name: "login", Redact: []string{"password"}, Handler: func(ctx context.Context, arguments interface{}) (map[string]interface{}, error) {
+		return ToolResult(TextContent("ok")), nil
+	}})
+
+	args := map[string]interface{}{"name": "login", "arguments": map[string]interface{}{"user": "bob", "password": "hunter2"}}
+	if _, err := NewClient(s).CallTool(metaInvokeName, args); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(logs.String(), "slow request") || !strings.Contains(audit.String(), metaInvokeName) {
+		t.Fatalf("missing output:\n%s\n%s", logs.String(), audit.String())
+	}
+	if strings.Contains(logs.String(), "hunter2") || strings.Contains(audit.String(), "hunter2") {
+		t.Fatalf("password leaked:\n%s\n%s", logs.String(), audit.String())
+	}
+	if args["arguments"].(map[string]interface{})["password"] != "hunter2" {
+		t.Fatal("caller's arguments were modified")
+	}
+}
+
+// TestMetaInvoke checks that meta/invoke returns the target tool's result and
+// JSON-RPC error, refuses to call itself, and stops tools that call back through it at the depth limit
+// TestMetaInvoke: meta/invokeが対象のツールの結果とJSON-RPCエラーを返し、自身の呼び出しを拒否し、
+// それを経由して呼び戻すツールを深さの上限で止めることを確認するテスト
+func TestMetaInvoke(t *testing.T) {
+	s := NewMCPServer(WithExampleTools(), WithMetaInvokeTool(2))
+	var relays int
+	s.RegisterTool(Tool{Name: "relay", Handler: func(ctx context.Context, arguments interface{}) (map[string]interface{}, error) {
+		relays++
+		resp := s.handleToolsCall(ctx, &JSONRPCRequest{JSONRPC: "2.0", Method: "tools/call", Params: map[string]interface{}{
+			"name": metaInvokeName, "arguments": map[string]interface{}{"name": "relay"},
+		}})
+		if resp.Error != nil {
+			return nil, resp.Error
+		}
+		return resp.Result.(map[string]interface{}), nil
+	}})
+	call := func(args map[string]interface{}) *JSONRPCResponse {
+		return s.HandleRequest(context.Background(), &JSONRPCRequest{JSONRPC: "2.0", ID: IntID(1), Method: "tools/call", Params: map[string]interface{}{
+			"name": metaInvokeName, "arguments": args,
+		}})
+	}
+
+	resp := call(map[string]interface{}{"name": "echo", "arguments": map[string]interface{}{"message": "hi"}})
+	if resp.Error != nil || !strings.Contains(fmt.Sprint(resp.Result), "hi") {
+		t.Fatalf("echo: got %+v", resp)
+	}
+	if resp := call(map[string]interface{}{"name": "missing"}); resp.Error == nil || resp.Error.Code != -32602 {
+		t.Fatalf("missing tool: got %+v, want the target's -32602", resp)
+	}
+	if resp := call(map[string]interface{}{"name": metaInvokeName}); !strings.Contains(fmt.Sprint(resp.Result), "cannot call itself") {
+		t.Fatalf("self call: got %+v", resp)
+	}
+	if resp := call(map[string]interface{}{"name": "relay"}); !strings.Contains(fmt.Sprint(resp.Result), "deeper than 2") {
+		t.Fatalf("relay loop: got %+v", resp)
+	}
+	if relays != 2 {
+		t.Fatalf("relay ran %d times, want 2", relays)
+	}
+}
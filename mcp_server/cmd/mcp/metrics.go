@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"     // context: method handler signature (メソッドハンドラーのシグネチャ)
+	"sync"        // sync: guards the counters (カウンターの保護)
+	"sync/atomic" // sync/atomic: in-flight gauge (処理中の数)
+	"time"        // time: request durations (リクエストの処理時間)
+)
+
+// sizeBuckets are the upper bounds of the byte-size histogram buckets
+// sizeBuckets: バイトサイズのヒストグラムの各バケットの上限
+// A final bucket counts everything larger.
+// 最後のバケットはそれより大きいものをすべて数える
+var sizeBuckets = []int64{256, 1 << 10, 4 << 10, 16 << 10, 64 << 10, 256 << 10, 1 << 20}
+
+// Histogram counts observations into buckets bounded by Bounds
+// Histogram: Boundsで区切られたバケットへ観測値を数える構造体
+// Counts[i] holds values up to Bounds[i]; the last count holds the rest.
+// Counts[i]はBounds[i]以下の値を数え、最後のカウントは残りを数える
+type Histogram struct {
+	Bounds []int64 `json:"bounds"` // bounds: bucket upper bounds (バケットの上限)
+	Counts []int64 `json:"counts"` // counts: per-bucket counts (バケット毎の数)
+	Count  int64   `json:"count"`  // count: observations (観測数)
+	Sum    int64   `json:"sum"`    // sum: total of observed values (観測値の合計)
+}
+
+// newHistogram creates an empty histogram over bounds
+// newHistogram: boundsに対する空のヒストグラムを作成する関数
+func newHistogram(bounds []int64) Histogram {
+	return Histogram{Bounds: bounds, Counts: make([]int64, len(bounds)+1)}
+}
+
+// observe adds one value
+// observe: 値を1つ追加する関数
+func (h *Histogram) observe(v int64) {
+	i := 0
+	for i < len(h.Bounds) && v > h.Bounds[i] {
+		i++
+	}
+	h.Counts[i]++
+	h.Count++
+	h.Sum += v
+}
+
+// clone returns a copy that no longer shares counts with h
+// clone: hとカウントを共有しないコピーを返す関数
+func (h Histogram) clone() Histogram {
+	h.Counts = append([]int64(nil), h.Counts...)
+	return h
+}
+
+// MetricsSnapshot is a point-in-time copy of the server's metrics
+// MetricsSnapshot: サーバーのメトリクスのある時点のコピー
+type MetricsSnapshot struct {
+	Requests      map[string]int64 `json:"requests"`      // requests: handled requests by method (メソッド毎の処理済みリクエスト数)
+	Errors        map[string]int64 `json:"errors"`        // errors: error responses by method (メソッド毎のエラーレスポンス数)
+	SlowRequests  int64            `json:"slowRequests"`  // slowRequests: requests over the slow threshold (しきい値を超えたリクエスト数)
+	InFlight      int64            `json:"inFlight"`      // inFlight: requests being handled now (現在処理中のリクエスト数)
+	QueueDepth    int64            `json:"queueDepth"`    // queueDepth: requests waiting for a worker (ワーカーを待っているリクエスト数)
+	RequestBytes  Histogram        `json:"requestBytes"`  // requestBytes: request sizes (リクエストサイズ)
+	ResponseBytes Histogram        `json:"responseBytes"` // responseBytes: response sizes (レスポンスサイズ)
+}
+
+// metrics collects request counts, sizes and timings
+// metrics: リクエストの数・サイズ・時間を収集する構造体
+type metrics struct {
+	inFlight atomic.Int64 // inFlight: requests being handled (処理中のリクエスト数)
+
+	mu            sync.Mutex       // mu: guards the fields below (以下のフィールドを保護)
+	requests      map[string]int64 // requests: by method (メソッド毎)
+	errors        map[string]int64 // errors: by method (メソッド毎)
+	slow          int64            // slow: slow requests (遅いリクエスト数)
+	requestBytes  Histogram        // requestBytes: request sizes (リクエストサイズ)
+	responseBytes Histogram        // responseBytes: response sizes (レスポンスサイズ)
+}
+
+// newMetrics creates an empty collector
+// newMetrics: 空のコレクターを作成する関数
+func newMetrics() *metrics {
+	return &metrics{
+		requests:      make(map[string]int64),
+		errors:        make(map[string]int64),
+		requestBytes:  newHistogram(sizeBuckets),
+		responseBytes: newHistogram(sizeBuckets),
+	}
+}
+
+// finish records a handled request
+// finish: 処理済みのリクエストを記録する関数
+func (m *metrics) finish(method string, failed, slow bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests[method]++
+	if failed {
+		m.errors[method]++
+	}
+	if slow {
+		m.slow++
+	}
+}
+
+// observeRequest and observeResponse record message sizes in bytes
+// observeRequest / observeResponse: メッセージのバイトサイズを記録する関数
+func (m *metrics) observeRequest(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requestBytes.observe(int64(n))
+}
+
+func (m *metrics) observeResponse(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.responseBytes.observe(int64(n))
+}
+
+// Metrics returns a snapshot of the server's request metrics
+// Metrics: サーバーのリクエストメトリクスのスナップショットを返す関数
+func (s *MCPServer) Metrics() MetricsSnapshot {
+	m := s.metrics
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	snap := MetricsSnapshot{
+		Requests:      make(map[string]int64, len(m.requests)),
+		Errors:        make(map[string]int64, len(m.errors)),
+		SlowRequests:  m.slow,
+		InFlight:      m.inFlight.Load(),
+		QueueDepth:    int64(s.pool.depth()),
+		RequestBytes:  m.requestBytes.clone(),
+		ResponseBytes: m.responseBytes.clone(),
+	}
+	for method, n := range m.requests {
+		snap.Requests[method] = n
+	}
+	for method, n := range m.errors {
+		snap.Errors[method] = n
+	}
+	return snap
+}
+
+// track counts req as in flight and returns the function that records its outcome
+// track: reqを処理中として数え、結果を記録する関数を返す関数
+// Entry and exit are logged at DEBUG. Requests slower than the slow threshold are
+// logged at WARN, tool calls with their tool and arguments after redaction.
+// 開始と終了はDEBUGで記録される。遅いリクエストのしきい値を超えたものはWARNで記録され、
+// ツール呼び出しは伏せ字処理後のツール名と引数も記録される
+func (s *MCPServer) track(ctx context.Context, req *JSONRPCRequest) func(resp *JSONRPCResponse) {
+	start := time.Now()
+	s.metrics.inFlight.Add(1)
+	s.logger.DebugContext(ctx, "request started", "method", req.Method, "id", req.ID.String()) // started: 開始した
+	return func(resp *JSONRPCResponse) {
+		s.metrics.inFlight.Add(-1)
+		elapsed := time.Since(start)
+		attrs := []interface{}{
+			"method", req.Method,
+			"id", req.ID.String(),
+			"duration", elapsed,
+		}
+		slow := s.slowThreshold > 0 && elapsed >= s.slowThreshold
+		if slow {
+			s.logger.WarnContext(ctx, "slow request", append(attrs, s.toolCallAttrs(ctx, req)...)...) // slow: 遅い
+		}
+		if resp != nil && resp.Error != nil {
+			attrs = append(attrs, "code", resp.Error.Code) // error code: エラーコード
+		}
+		s.logger.DebugContext(ctx, "request finished", attrs...) // finished: 終了した
+		s.metrics.finish(req.Method, resp == nil || resp.Error != nil, slow)
+	}
+}
+
+// toolCallAttrs returns the log attributes identifying a tools/call request
+// toolCallAttrs: tools/callリクエストを識別するログ属性を返す関数
+// Arguments are redacted with the tool's Redact fields; other methods have none.
+// 引数はツールのRedactフィールドで伏せ字にする。他のメソッドでは属性は無い
+func (s *MCPServer) toolCallAttrs(ctx context.Context, req *JSONRPCRequest) []interface{} {
+	params, _ := req.Params.(map[string]interface{})
+	name, ok := params["name"].(string)
+	if req.Method != "tools/call" || !ok {
+		return nil
+	}
+	tool, _ := s.lookupTool(ctx, name) // session tools carry their own Redact: セッションのツールは独自のRedactを持つ
+	return []interface{}{"tool", name, "arguments", s.redactArguments(ctx, tool, params["arguments"])}
+}
+
+// handleServerStats reports uptime, registry sizes and request counts for server/stats
+// handleServerStats: server/statsのために稼働時間、レジストリのサイズ、リクエスト数を報告する関数
+// Requests counts completed requests, so the stats request itself is only in flight.
+// Requestsは完了したリクエストを数えるため、statsリクエスト自体は処理中としてのみ数えられる
+func (s *MCPServer) handleServerStats(ctx context.Context, params interface{}) (interface{}, error) {
+	snap := s.Metrics()
+	result := &ServerStatsResult{
+		UptimeSeconds: time.Since(s.started).Seconds(), // uptime: 稼働時間
+		InFlight:      snap.InFlight,
+	}
+	for _, n := range snap.Requests {
+		result.Requests += n
+	}
+
+	s.mu.RLock()
+	result.Tools = len(s.tools)
+	result.Resources = len(s.resources)
+	result.Prompts = len(s.prompts)
+	s.mu.RUnlock()
+	return result, nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"    // bytes: captured log and output (取得したログと出力)
+	"context"  // context: RunIO lifetime (RunIOの存続期間)
+	"log/slog" // log/slog: logger capturing warnings (警告を取得するロガー)
+	"strings"  // strings: input and log matching (入力とログの照合)
+	"testing"  // testing: test framework (テストフレームワーク)
+	"time"     // time: slow threshold (遅延のしきい値)
+)
+
+// TestHistogramObserve checks that values land in the first bucket whose bound they do not exceed
+// TestHistogramObserve: 値が上限を超えない最初のバケットに入ることを確認するテスト
+func TestHistogramObserve(t *testing.T) {
+	h := newHistogram([]int64{10, 100})
+	for _, v := range []int64{0, 10, 11, 100, 101, 5000} {
+		h.observe(v)
+	}
+	if got := h.Counts; len(got) != 3 || got[0] != 2 || got[1] != 2 || got[2] != 2 {
+		t.Fatalf("counts: got %v, want [2 2 2]", got)
+	}
+	if h.Count != 6 || h.Sum != 5222 {
+		t.Fatalf("count and sum: got %d and %d, want 6 and 5222", h.Count, h.Sum)
+	}
+	clone := h.clone()
+	h.observe(1)
+	if clone.Counts[0] != 2 {
+		t.Fatal("the clone shares counts with the histogram")
+	}
+}
+
+// TestSlowRequestLog checks that only requests over the threshold are logged at WARN
+// with their method and id, and that requests and byte sizes are counted
+// TestSlowRequestLog: しきい値を超えたリクエストだけがメソッドとID付きでWARNに記録され、
+// リクエストとバイトサイズが数えられることを確認するテスト
+func TestSlowRequestLog(t *testing.T) {
+	var logs bytes.Buffer
+	s := NewMCPServer(WithSlowRequestThreshold(20*time.Millisecond), WithLogger(slog.New(slog.NewTextHandler(&logs, nil))))
+	s.RegisterTool(Tool{Name: "slow", Handler: func(ctx context.Context, arguments interface{}) (map[string]interface{}, error) {
+		time.Sleep(40 * time.Millisecond)
+		return ToolResult(), nil
+	}})
+	in := strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}` + "\n" +
+		`{"jsonrpc":"2.0","id":"s1","method":"tools/call","params":{"name":"slow"}}` + "\n")
+	var out bytes.Buffer
+	if err := s.RunIO(context.Background(), in, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	log := logs.String()
+	if strings.Count(log, "slow request") != 1 || !strings.Contains(log, "level=WARN") ||
+		!strings.Contains(log, "method=tools/call") || !strings.Contains(log, "id=s1") {
+		t.Fatalf("log: %s", log)
+	}
+	m := s.Metrics()
+	if m.Requests["tools/list"] != 1 || m.Requests["tools/call"] != 1 || m.SlowRequests != 1 || m.InFlight != 0 {
+		t.Fatalf("counts: %+v", m)
+	}
+	if m.RequestBytes.Count != 2 || m.RequestBytes.Sum == 0 || m.ResponseBytes.Count != 2 || m.ResponseBytes.Sum == 0 {
+		t.Fatalf("sizes: requests %+v, responses %+v", m.RequestBytes, m.ResponseBytes)
+	}
+}
+
+// TestServerStats checks that server/stats is opt-in and reports registry sizes,
+// handled requests and the stats request itself as in flight
+// TestServerStats: server/statsがオプトインで、レジストリのサイズ、処理済みのリクエスト数、
+// 処理中としてstatsリクエスト自体を報告することを確認するテスト
+func TestServerStats(t *testing.T) {
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/list"}` + "\n" +
+		`{"jsonrpc":"2.0","id":2,"method":"resources/list"}` + "\n" +
+		`{"jsonrpc":"2.0","id":3,"method":"server/stats"}` + "\n"
+	run := func(s *MCPServer) map[string]interface{} {
+		t.Helper()
+		var out bytes.Buffer
+		if err := s.RunIO(context.Background(), strings.NewReader(input), &out); err != nil {
+			t.Fatal(err)
+		}
+		return decodeLines(t, out.String())[2]
+	}
+
+	if resp := run(NewMCPServer()); resp["error"].(map[string]interface{})["code"] != float64(-32601) {
+		t.Fatalf("without the option: %v", resp)
+	}
+
+	s := NewMCPServer(WithStatsMethod(), WithExampleTools())
+	s.RegisterResource(Resource{URI: "data:,hi", Name: "hi"})
+	stats := run(s)["result"].(map[string]interface{})
+	if stats["tools"] != float64(1) || stats["resources"] != float64(1) || stats["prompts"] != float64(0) ||
+		stats["requests"] != float64(2) || stats["inFlight"] != float64(1) || stats["uptimeSeconds"].(float64) <= 0 {
+		t.Fatalf("stats: %v", stats)
+	}
+}
@@ -0,0 +1,333 @@
+package main
+
+import (
+	"bytes"           // bytes: JSON number decoding (JSONの数値のデコード)
+	"encoding/binary" // encoding/binary: big-endian lengths and numbers (ビッグエンディアンの長さと数値)
+	"encoding/json"   // encoding/json: bridging to JSON values (JSON値との橋渡し)
+	"errors"          // errors: error values (エラー値)
+	"fmt"             // fmt: error formatting (エラーの整形)
+	"math"            // math: float bits (浮動小数点のビット表現)
+	"sort"            // sort: stable map key order (安定したマップのキー順)
+)
+
+// msgpackMaxDepth bounds the nesting MsgpackCodec decodes, as encoding/json does
+// msgpackMaxDepth: encoding/jsonと同様に、MsgpackCodecがデコードするネストの深さの上限
+const msgpackMaxDepth = 10000
+
+// errMsgpackShort reports a MessagePack value cut off before its end
+// errMsgpackShort: 途中で切れたMessagePackの値を表すエラー
+var errMsgpackShort = errors.New("msgpack: unexpected end of data")
+
+// MsgpackCodec encodes messages as MessagePack, a compact binary form of JSON values
+// MsgpackCodec: メッセージをMessagePack (JSON値のコンパクトなバイナリ形式) としてエンコードするコーデック
+// Values are bridged through their JSON form, so struct tags and custom JSON
+// marshalers apply. Map keys are written sorted, integers in their smallest form,
+// and binary data decodes to base64 as encoding/json does; extension types are rejected.
+// 値はJSON形式を経由して橋渡しされるため、構造体タグや独自のJSONマーシャラーが適用される。
+// マップのキーは並べ替えて、整数は最小の形式で書き込み、バイナリはencoding/jsonと同様にbase64へデコードされる。
+// 拡張型は拒否する
+type MsgpackCodec struct{}
+
+func (MsgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber() // keep integers exact: 整数を正確に保つ
+	var generic interface{}
+	if err := dec.Decode(&generic); err != nil {
+		return nil, err
+	}
+	return appendMsgpack(nil, generic)
+}
+
+func (MsgpackCodec) Unmarshal(data []byte, v interface{}) error {
+	generic, n, err := decodeMsgpack(data, 0)
+	if err != nil {
+		return err
+	}
+	if n != len(data) {
+		return fmt.Errorf("msgpack: %d trailing bytes", len(data)-n)
+	}
+	bridged, err := json.Marshal(generic)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(bridged, v)
+}
+
+// Split frames one MessagePack value at a time, for use with bufio.Scanner
+// Split: bufio.Scanner用に、MessagePackの値を1つずつ区切る関数
+func (MsgpackCodec) Split(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if len(data) == 0 {
+		return 0, nil, nil
+	}
+	_, n, err := decodeMsgpack(data, 0)
+	if errors.Is(err, errMsgpackShort) && !atEOF {
+		return 0, nil, nil // need more data: データがさらに必要
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+	return n, data[:n], nil
+}
+
+// appendMsgpack appends the MessagePack encoding of a decoded JSON value to buf
+// appendMsgpack: デコード済みのJSON値のMessagePackエンコーディングをbufへ追加する関数
+func appendMsgpack(buf []byte, v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case nil:
+		return append(buf, 0xc0), nil
+	case bool:
+		if v {
+			return append(buf, 0xc3), nil
+		}
+		return append(buf, 0xc2), nil
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return appendMsgpackInt(buf, i), nil
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return nil, fmt.Errorf("msgpack: number %s: %w", v, err)
+		}
+		buf = append(buf, 0xcb)
+		return binary.BigEndian.AppendUint64(buf, math.Float64bits(f)), nil
+	case string:
+		n := len(v)
+		switch {
+		case n < 32:
+			buf = append(buf, 0xa0|byte(n)) // fixstr
+		case n <= math.MaxUint8:
+			buf = append(buf, 0xd9, byte(n))
+		case n <= math.MaxUint16:
+			buf = binary.BigEndian.AppendUint16(append(buf, 0xda), uint16(n))
+		default:
+			buf = binary.BigEndian.AppendUint32(append(buf, 0xdb), uint32(n))
+		}
+		return append(buf, v...), nil
+	case []interface{}:
+		buf = appendMsgpackHeader(buf, len(v), 0x90, 0xdc)
+		for _, elem := range v {
+			var err error
+			if buf, err = appendMsgpack(buf, elem); err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+	case map[string]interface{}:
+		buf = appendMsgpackHeader(buf, len(v), 0x80, 0xde)
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys) // byte-stable output: バイト単位で安定した出力
+		for _, k := range keys {
+			var err error
+			if buf, err = appendMsgpack(buf, k); err != nil {
+				return nil, err
+			}
+			if buf, err = appendMsgpack(buf, v[k]); err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+	}
+	return nil, fmt.Errorf("msgpack: unsupported type %T", v)
+}
+
+// appendMsgpackHeader appends an array or map header; fix is the fixarray or fixmap
+// prefix and wide the 16-bit form, followed by the 32-bit form
+// appendMsgpackHeader: 配列またはマップのヘッダーを追加する関数
+// (fixはfixarrayまたはfixmapの接頭辞、wideは16ビット形式で、その次が32ビット形式)
+func appendMsgpackHeader(buf []byte, n int, fix, wide byte) []byte {
+	switch {
+	case n < 16:
+		return append(buf, fix|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, wide), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(buf, wide+1), uint32(n))
+	}
+}
+
+// appendMsgpackInt appends i in its smallest MessagePack integer form
+// appendMsgpackInt: iを最小のMessagePack整数形式で追加する関数
+func appendMsgpackInt(buf []byte, i int64) []byte {
+	switch {
+	case i >= 0 && i <= 0x7f:
+		return append(buf, byte(i)) // positive fixint
+	case i < 0 && i >= -32:
+		return append(buf, byte(i)) // negative fixint
+	case i >= math.MinInt8 && i <= math.MaxInt8:
+		return append(buf, 0xd0, byte(i))
+	case i >= math.MinInt16 && i <= math.MaxInt16:
+		return binary.BigEndian.AppendUint16(append(buf, 0xd1), uint16(i))
+	case i >= math.MinInt32 && i <= math.MaxInt32:
+		return binary.BigEndian.AppendUint32(append(buf, 0xd2), uint32(i))
+	default:
+		return binary.BigEndian.AppendUint64(append(buf, 0xd3), uint64(i))
+	}
+}
+
+// decodeMsgpack decodes the MessagePack value at the start of data into a JSON-compatible value
+// decodeMsgpack: data先頭のMessagePackの値をJSON互換の値へデコードする関数
+// It returns the value and the number of bytes it used; errMsgpackShort means data
+// ends inside the value.
+// 値と使用したバイト数を返す。errMsgpackShortはdataが値の途中で終わっていることを意味する
+func decodeMsgpack(data []byte, depth int) (interface{}, int, error) {
+	if depth > msgpackMaxDepth {
+		return nil, 0, errors.New("msgpack: exceeded max depth")
+	}
+	if len(data) == 0 {
+		return nil, 0, errMsgpackShort
+	}
+	b := data[0]
+	switch {
+	case b <= 0x7f:
+		return int64(b), 1, nil // positive fixint
+	case b >= 0xe0:
+		return int64(int8(b)), 1, nil // negative fixint
+	case b&0xe0 == 0xa0:
+		return decodeMsgpackString(data, 1, int(b&0x1f)) // fixstr
+	case b&0xf0 == 0x90:
+		return decodeMsgpackArray(data, 1, int(b&0x0f), depth) // fixarray
+	case b&0xf0 == 0x80:
+		return decodeMsgpackMap(data, 1, int(b&0x0f), depth) // fixmap
+	}
+
+	switch b {
+	case 0xc0:
+		return nil, 1, nil
+	case 0xc2:
+		return false, 1, nil
+	case 0xc3:
+		return true, 1, nil
+	case 0xc4, 0xc5, 0xc6: // bin 8/16/32
+		n, size, err := msgpackLength(data, 1<<(b-0xc4))
+		if err != nil {
+			return nil, 0, err
+		}
+		if len(data) < size+n {
+			return nil, 0, errMsgpackShort
+		}
+		return append([]byte(nil), data[size:size+n]...), size + n, nil
+	case 0xca: // float 32
+		if len(data) < 5 {
+			return nil, 0, errMsgpackShort
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(data[1:]))), 5, nil
+	case 0xcb: // float 64
+		if len(data) < 9 {
+			return nil, 0, errMsgpackShort
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(data[1:])), 9, nil
+	case 0xcc, 0xcd, 0xce, 0xcf: // uint 8/16/32/64
+		width := 1 << (b - 0xcc)
+		if len(data) < 1+width {
+			return nil, 0, errMsgpackShort
+		}
+		return msgpackUint(data[1 : 1+width]), 1 + width, nil
+	case 0xd0, 0xd1, 0xd2, 0xd3: // int 8/16/32/64
+		width := 1 << (b - 0xd0)
+		if len(data) < 1+width {
+			return nil, 0, errMsgpackShort
+		}
+		u := msgpackUint(data[1 : 1+width])
+		shift := 64 - 8*width
+		return int64(u<<shift) >> shift, 1 + width, nil // sign-extend: 符号拡張
+	case 0xd9, 0xda, 0xdb: // str 8/16/32
+		n, size, err := msgpackLength(data, 1<<(b-0xd9))
+		if err != nil {
+			return nil, 0, err
+		}
+		return decodeMsgpackString(data, size, n)
+	case 0xdc, 0xdd: // array 16/32
+		n, size, err := msgpackLength(data, 2<<(b-0xdc))
+		if err != nil {
+			return nil, 0, err
+		}
+		return decodeMsgpackArray(data, size, n, depth)
+	case 0xde, 0xdf: // map 16/32
+		n, size, err := msgpackLength(data, 2<<(b-0xde))
+		if err != nil {
+			return nil, 0, err
+		}
+		return decodeMsgpackMap(data, size, n, depth)
+	}
+	return nil, 0, fmt.Errorf("msgpack: unsupported type byte 0x%02x", b)
+}
+
+// msgpackUint reads a big-endian unsigned integer of 1, 2, 4 or 8 bytes
+// msgpackUint: 1・2・4・8バイトのビッグエンディアン符号なし整数を読み取る関数
+func msgpackUint(b []byte) uint64 {
+	var u uint64
+	for _, c := range b {
+		u = u<<8 | uint64(c)
+	}
+	return u
+}
+
+// msgpackLength reads the width-byte length after the type byte, returning it and the header size
+// msgpackLength: 型バイトに続くwidthバイトの長さを読み取り、長さとヘッダーのサイズを返す関数
+func msgpackLength(data []byte, width int) (n, size int, err error) {
+	if len(data) < 1+width {
+		return 0, 0, errMsgpackShort
+	}
+	return int(msgpackUint(data[1 : 1+width])), 1 + width, nil
+}
+
+// decodeMsgpackString decodes an n-byte string starting at offset
+// decodeMsgpackString: offsetから始まるnバイトの文字列をデコードする関数
+func decodeMsgpackString(data []byte, offset, n int) (interface{}, int, error) {
+	if len(data)-offset < n {
+		return nil, 0, errMsgpackShort
+	}
+	return string(data[offset : offset+n]), offset + n, nil
+}
+
+// decodeMsgpackArray decodes n elements starting at offset
+// decodeMsgpackArray: offsetから始まるn個の要素をデコードする関数
+func decodeMsgpackArray(data []byte, offset, n, depth int) (interface{}, int, error) {
+	if n > len(data)-offset {
+		return nil, 0, errMsgpackShort // each element takes at least a byte: 各要素は最低1バイト
+	}
+	arr := make([]interface{}, n)
+	for i := range arr {
+		v, used, err := decodeMsgpack(data[offset:], depth+1)
+		if err != nil {
+			return nil, 0, err
+		}
+		arr[i] = v
+		offset += used
+	}
+	return arr, offset, nil
+}
+
+// decodeMsgpackMap decodes n string-keyed entries starting at offset
+// decodeMsgpackMap: offsetから始まる、文字列をキーとするn個の項目をデコードする関数
+func decodeMsgpackMap(data []byte, offset, n, depth int) (interface{}, int, error) {
+	if n > (len(data)-offset)/2 {
+		return nil, 0, errMsgpackShort // each entry takes at least two bytes: 各項目は最低2バイト
+	}
+	m := make(map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		k, used, err := decodeMsgpack(data[offset:], depth+1)
+		if err != nil {
+			return nil, 0, err
+		}
+		key, ok := k.(string)
+		if !ok {
+			return nil, 0, fmt.Errorf("msgpack: map key of type %T", k)
+		}
+		offset += used
+		v, used, err := decodeMsgpack(data[offset:], depth+1)
+		if err != nil {
+			return nil, 0, err
+		}
+		m[key] = v
+		offset += used
+	}
+	return m, offset, nil
+}
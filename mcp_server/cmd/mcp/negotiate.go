@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context" // context: cancellation and deadlines (キャンセルと期限)
+	"errors"  // errors: error values (エラー値)
+	"mime"    // mime: media type parsing (メディアタイプ解析)
+	"net/url" // net/url: parsed URIs (解析済みURI)
+	"strings" // strings: wildcard matching (ワイルドカードの照合)
+)
+
+// Negotiator is implemented by providers that serve a resource in several representations
+// Negotiator: リソースを複数の表現で提供できるプロバイダーが実装するインターフェース
+// ReadAccept returns the best representation for accept, listed in order of preference,
+// or ErrNotAcceptable when none matches. NegotiateType picks the type.
+// ReadAcceptは優先順に並んだacceptに最も合う表現を返し、一致しない場合はErrNotAcceptableを返す。型の選択にはNegotiateTypeを使う
+// representation: 表現
+type Negotiator interface {
+	ReadAccept(ctx context.Context, uri string, accept []string) (Content, error)
+}
+
+// ErrNotAcceptable reports that no representation matches the accepted MIME types
+// ErrNotAcceptable: 受け付け可能なMIMEタイプに合う表現が無いことを表すエラー
+var ErrNotAcceptable = errors.New("no acceptable representation")
+
+// NegotiateType returns the available type satisfying the most preferred accepted type
+// NegotiateType: 最も優先される受け付け可能な型を満たす、available内の型を返す関数
+// Accepted entries may be wildcards such as "text/*" or "*/*"; parameters are ignored.
+// 受け付けるエントリには"text/*"や"*/*"のようなワイルドカードを使え、パラメータは無視される
+func NegotiateType(accept, available []string) (string, bool) {
+	for _, want := range accept {
+		want = baseMediaType(want)
+		for _, have := range available {
+			if mediaTypeMatches(want, baseMediaType(have)) {
+				return have, true
+			}
+		}
+	}
+	return "", false
+}
+
+// baseMediaType lowercases a media type and drops its parameters
+// baseMediaType: メディアタイプを小文字にし、パラメータを取り除く関数
+func baseMediaType(t string) string {
+	if base, _, err := mime.ParseMediaType(t); err == nil {
+		return base
+	}
+	return strings.ToLower(strings.TrimSpace(t))
+}
+
+// mediaTypeMatches reports whether have satisfies the possibly wildcard want
+// mediaTypeMatches: haveがワイルドカードを含み得るwantを満たすかを判定する関数
+func mediaTypeMatches(want, have string) bool {
+	if want == "*/*" || want == have {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(want, "/*")
+	return ok && strings.HasPrefix(have, prefix+"/")
+}
+
+// parseAccept extracts the optional _meta.accept list from resources/read params
+// parseAccept: resources/readのパラメータから任意の_meta.acceptリストを取り出す関数
+func parseAccept(params map[string]interface{}) ([]string, error) {
+	raw, present := paramsMeta(params)["accept"]
+	if !present {
+		return nil, nil
+	}
+	items, ok := raw.([]interface{})
+	if !ok || len(items) == 0 {
+		return nil, errors.New("accept must be a non-empty array of MIME types")
+	}
+	accept := make([]string, 0, len(items))
+	for _, item := range items {
+		t, ok := item.(string)
+		if !ok || t == "" {
+			return nil, errors.New("accept must be a non-empty array of MIME types")
+		}
+		accept = append(accept, t)
+	}
+	return accept, nil
+}
+
+// readNegotiated reads a resource in a representation from accept
+// readNegotiated: acceptに含まれる表現でリソースを読み取る関数
+// Providers without Negotiator serve their single representation if it is accepted.
+// Negotiatorを持たないプロバイダーは、受け付けられる場合に限り唯一の表現を返す
+func (s *MCPServer) readNegotiated(ctx context.Context, u *url.URL, accept []string) (Content, error) {
+	negotiator, ok := s.providerFor(u.String()).(Negotiator)
+	if !ok {
+		content, err := s.readResource(ctx, u)
+		if err != nil {
+			return Content{}, err
+		}
+		if _, ok := NegotiateType(accept, []string{content.MimeType}); !ok {
+			return Content{}, ErrNotAcceptable
+		}
+		return content, nil
+	}
+
+	content, err := negotiator.ReadAccept(ctx, u.String(), accept)
+	if err != nil {
+		return Content{}, err
+	}
+	if content.URI == "" {
+		content.URI = u.String()
+	}
+	return content, nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context" // context: provider signature (プロバイダーのシグネチャ)
+	"strings" // strings: URI prefix matching (URIの接頭辞の照合)
+	"testing" // testing: test framework (テストフレームワーク)
+)
+
+// reportProvider serves report: URIs as JSON or CSV
+// reportProvider: report:のURIをJSONまたはCSVとして提供するプロバイダー
+type reportProvider struct{}
+
+func (reportProvider) CanHandle(uri string) bool { return strings.HasPrefix(uri, "report:") }
+
+func (reportProvider) Read(ctx context.Context, uri string) (Content, error) {
+	return Content{MimeType: "application/json", Text: `{"a":1}`}, nil
+}
+
+func (p reportProvider) ReadAccept(ctx context.Context, uri string, accept []string) (Content, error) {
+	t, ok := NegotiateType(accept, []string{"application/json", "text/csv"})
+	if !ok {
+		return Content{}, ErrNotAcceptable
+	}
+	if t == "text/csv" {
+		return Content{MimeType: t, Text: "a\n1\n"}, nil
+	}
+	return p.Read(ctx, uri)
+}
+
+// TestNegotiateType checks preference order, wildcards and ignored parameters
+// TestNegotiateType: 優先順位、ワイルドカード、無視されるパラメータを確認するテスト
+func TestNegotiateType(t *testing.T) {
+	available := []string{"application/json", "text/csv"}
+	for _, tt := range []struct {
+		accept []string
+		want   string
+	}{
+		{[]string{"text/csv", "application/json"}, "text/csv"},
+		{[]string{"application/*"}, "application/json"},
+		{[]string{"TEXT/CSV; charset=utf-8"}, "text/csv"},
+		{[]string{"image/png", "*/*"}, "application/json"},
+		{[]string{"image/png"}, ""},
+	} {
+		got, ok := NegotiateType(tt.accept, available)
+		if got != tt.want || ok != (tt.want != "") {
+			t.Errorf("accept %v: got %q, %v; want %q", tt.accept, got, ok, tt.want)
+		}
+	}
+}
+
+// TestReadResourceAccept checks that resources/read honours _meta.accept for negotiating
+// providers and single-representation resources, answering -32002 when nothing matches
+// TestReadResourceAccept: resources/readが交渉するプロバイダーと単一表現のリソースで
+// _meta.acceptに従い、一致しない場合は-32002で応答することを確認するテスト
+func TestReadResourceAccept(t *testing.T) {
+	s := NewMCPServer()
+	s.RegisterResourceProvider(reportProvider{})
+	read := func(uri string, accept ...interface{}) *JSONRPCResponse {
+		params := map[string]interface{}{"uri": uri}
+		if accept != nil {
+			params["_meta"] = map[string]interface{}{"accept": accept}
+		}
+		return s.HandleRequest(context.Background(), &JSONRPCRequest{JSONRPC: "2.0", ID: IntID(1), Method: "resources/read", Params: params})
+	}
+	mimeType := func(resp *JSONRPCResponse) string {
+		t.Helper()
+		if resp.Error != nil {
+			t.Fatal(resp.Error)
+		}
+		return resp.Result.(*ReadResourceResult).Contents[0].MimeType
+	}
+
+	if got := mimeType(read("report:q1", "text/csv", "application/json")); got != "text/csv" {
+		t.Fatalf("report as CSV: got %s", got)
+	}
+	if got := mimeType(read("report:q1")); got != "application/json" {
+		t.Fatalf("report without accept: got %s", got)
+	}
+	if got := mimeType(read("data:,hi", "text/*")); got != "text/plain" {
+		t.Fatalf("data URI as text/*: got %s", got)
+	}
+	for _, uri := range []string{"report:q1", "data:,hi"} {
+		if resp := read(uri, "image/png"); resp.Error == nil || resp.Error.Code != -32002 {
+			t.Errorf("%s as image/png: got %+v, want -32002", uri, resp.Error)
+		}
+	}
+	if resp := read("report:q1", 42); resp.Error == nil || resp.Error.Code != -32602 {
+		t.Fatalf("malformed accept: got %+v, want -32602", resp.Error)
+	}
+}
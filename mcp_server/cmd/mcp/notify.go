@@ -0,0 +1,245 @@
+package main
+
+import (
+	"bytes"         // bytes: encoding buffer (エンコード用バッファ)
+	"context"       // context: the request a response answers (レスポンスが答えるリクエスト)
+	"encoding/json" // encoding/json: JSON encoding and decoding (JSONエンコード・デコード)
+	"time"          // time: batch flush timer (バッチ送出のタイマー)
+)
+
+// JSONRPCNotification represents a JSON-RPC 2.0 notification (a request without an id)
+// JSONRPCNotification: JSON-RPC 2.0通知 (idを持たないリクエスト) を表現する構造体
+// notification: 通知
+type JSONRPCNotification struct {
+	JSONRPC string      `json:"jsonrpc"`          // jsonrpc: JSON-RPC protocol version
+	Method  string      `json:"method"`           // method: notification method name (通知メソッド名)
+	Params  interface{} `json:"params,omitempty"` // params: notification parameters (通知パラメータ)
+}
+
+// addConn adds a running connection and returns the function that removes it
+// addConn: 実行中の接続を追加し、それを取り除く関数を返す関数
+// Notifications still batched for the connection are flushed when it is removed.
+// 接続を取り除く際、その接続向けにバッチ中の通知を送出する
+func (s *MCPServer) addConn(c *connSession) (remove func()) {
+	s.connsMu.Lock()
+	defer s.connsMu.Unlock()
+	s.conns = append(s.conns, c)
+	return func() {
+		s.connsMu.Lock()
+		for i, other := range s.conns {
+			if other == c {
+				s.conns = append(s.conns[:i:i], s.conns[i+1:]...)
+				break
+			}
+		}
+		s.connsMu.Unlock()
+
+		c.writeMu.Lock()
+		defer c.writeMu.Unlock()
+		if err := c.flushLocked(); err != nil {
+			s.logger.Warn("notification write failed", "error", err)
+		}
+	}
+}
+
+// writeResponse writes a response to c, the connection that sent the request
+// writeResponse: リクエストを送った接続cへレスポンスを書き込む関数
+// Writes are serialized so responses and notifications never interleave; batched
+// notifications are flushed first and the size is recorded.
+// 書き込みは直列化されるため、レスポンスと通知が混ざることはない。
+// バッチ中の通知を先に送出し、サイズを記録する
+func (s *MCPServer) writeResponse(ctx context.Context, c *connSession, resp *JSONRPCResponse) error {
+	data, err := s.encodeResponse(ctx, resp, false)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "response encoding failed", "id", resp.ID.String(), "error", err) // encoding: エンコード
+		return nil
+	}
+	s.metrics.observeResponse(len(data))
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if err := c.flushLocked(); err != nil {
+		return err
+	}
+	return c.writeLocked(data)
+}
+
+// marshal encodes v with the server's JSON format settings
+// marshal: サーバーのJSON形式設定でvをエンコードする関数
+// Indentation applies only when indent is set, since newline-framed transports
+// cannot carry multi-line messages.
+// 改行で区切るトランスポートは複数行のメッセージを運べないため、インデントはindent指定時のみ適用する
+func (s *MCPServer) marshal(v interface{}, indent bool) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(!s.jsonNoEscapeHTML)
+	if indent && s.jsonIndent != "" {
+		enc.SetIndent("", s.jsonIndent)
+	}
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil // Encode appends a newline: Encodeは改行を付加する
+}
+
+// writeLocked writes a JSON message to the connection in its wire encoding; the caller holds c.writeMu
+// writeLocked: 接続へJSONメッセージを通信時のエンコーディングで書き込む関数 (呼び出し側がc.writeMuを保持)
+func (c *connSession) writeLocked(data []byte) error {
+	if c.out == nil {
+		return nil // nowhere to write: 書き込み先が無い
+	}
+	data, err := encodeWire(c.codec, data)
+	if err != nil {
+		return err
+	}
+	_, err = c.out.Write(data)
+	return err
+}
+
+// notifyBatch collects notifications to write as one JSON-RPC batch array
+// notifyBatch: 1つのJSON-RPCバッチ配列として書き込む通知を集める構造体
+// It is guarded by the connection's writeMu; responses are never put in a batch.
+// 接続のwriteMuで保護される。レスポンスがバッチに入ることはない
+type notifyBatch struct {
+	window time.Duration // window: longest a notification waits (通知が待つ最長時間)
+	max    int           // max: notifications that trigger an immediate flush (即時送出する通知数)
+
+	pending []json.RawMessage // pending: batched notifications in order (バッチ中の通知、順序どおり)
+	timer   *time.Timer       // timer: flushes after window, nil when idle (window後に送出、待機中でなければnil)
+}
+
+// batchLocked adds an encoded notification to the connection's batch; the caller holds c.writeMu
+// batchLocked: エンコード済みの通知を接続のバッチへ追加する関数 (呼び出し側がc.writeMuを保持)
+func (s *MCPServer) batchLocked(c *connSession, data []byte) error {
+	b := c.batch
+	b.pending = append(b.pending, data)
+	if len(b.pending) >= b.max {
+		return c.flushLocked() // size threshold: サイズのしきい値
+	}
+	if b.timer == nil {
+		b.timer = time.AfterFunc(b.window, func() {
+			c.writeMu.Lock()
+			defer c.writeMu.Unlock()
+			if err := c.flushLocked(); err != nil {
+				s.logger.Warn("notification write failed", "error", err)
+			}
+		})
+	}
+	return nil
+}
+
+// flushLocked writes the connection's pending notifications; the caller holds c.writeMu
+// flushLocked: 接続のバッチ中の通知を書き込む関数 (呼び出し側がc.writeMuを保持)
+// A single notification is written on its own rather than as a one-element batch.
+// 通知が1件だけの場合は1要素のバッチではなく単独で書き込む
+func (c *connSession) flushLocked() error {
+	b := c.batch
+	if b == nil || len(b.pending) == 0 {
+		return nil
+	}
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	pending := b.pending
+	b.pending = nil
+	if len(pending) == 1 {
+		return c.writeLocked(pending[0])
+	}
+	data, err := json.Marshal(pending)
+	if err != nil {
+		return err
+	}
+	return c.writeLocked(data)
+}
+
+// notify sends a server-initiated notification to every initialized connection
+// notify: 初期化済みの全ての接続へサーバー発の通知を送信する関数
+func (s *MCPServer) notify(method string, params interface{}) {
+	s.notifyWhere(nil, method, params)
+}
+
+// notifyWhere sends a notification to the initialized connections match accepts, or all when match is nil
+// notifyWhere: matchが受け入れる初期化済みの接続 (nilなら全て) へ通知を送信する関数
+// A connection gets no notifications until it sends notifications/initialized. With
+// WithNotificationBatching they are collected per connection and written together.
+// 接続はnotifications/initializedを送信するまで通知を受け取らない。
+// WithNotificationBatchingを指定すると接続毎に集められ、まとめて書き込まれる
+func (s *MCPServer) notifyWhere(match func(c *connSession) bool, method string, params interface{}) {
+	data, err := s.marshal(&JSONRPCNotification{
+		JSONRPC: "2.0",
+		Method:  method,
+		Params:  params,
+	}, false)
+	if err != nil {
+		s.logger.Error("notification encoding failed", "method", method, "error", err) // encoding: エンコード
+		return
+	}
+
+	s.connsMu.RLock()
+	conns := append([]*connSession(nil), s.conns...)
+	s.connsMu.RUnlock()
+
+	for _, c := range conns {
+		if !c.initialized.Load() || (match != nil && !match(c)) {
+			continue
+		}
+		c.writeMu.Lock()
+		if c.batch != nil {
+			err = s.batchLocked(c, data)
+		} else {
+			err = c.writeLocked(data)
+		}
+		c.writeMu.Unlock()
+		if err != nil {
+			s.logger.Warn("notification write failed", "method", method, "error", err) // write: 書き込み
+		}
+	}
+}
+
+// NotifyToolsListChanged tells the client that the tool list has changed
+// NotifyToolsListChanged: ツール一覧が変更されたことをクライアントへ通知する関数
+func (s *MCPServer) NotifyToolsListChanged() {
+	s.notify("notifications/tools/list_changed", nil)
+}
+
+// NotifyResourceUpdated reports that the resource at uri has changed
+// NotifyResourceUpdated: uriのリソースが変更されたことを報告する関数
+// Cached content for uri is invalidated, and clients subscribed to uri or to a
+// pattern matching it are notified.
+// With WithUpdateQueue the notification is queued and coalesced instead of written at once.
+// uriのキャッシュ内容は無効化され、uriまたはそれに一致するパターンを購読中のクライアントへ通知される。
+// WithUpdateQueueを指定すると、通知は即座に書き込まれずキューに入れられてまとめられる
+func (s *MCPServer) NotifyResourceUpdated(uri string) {
+	if s.cache != nil {
+		s.cache.Delete(resourceCacheKey(uri)) // invalidate: 無効化する
+	}
+
+	switch {
+	case !s.anySubscribed(uri):
+	case s.updates != nil:
+		s.queueUpdate(uri)
+	default:
+		s.notifyResourceUpdated(uri)
+	}
+}
+
+// notifyResourceUpdated sends notifications/resources/updated to the connections subscribed to uri
+// notifyResourceUpdated: uriを購読中の接続へnotifications/resources/updatedを送信する関数
+func (s *MCPServer) notifyResourceUpdated(uri string) {
+	s.notifyWhere(func(c *connSession) bool { return c.subscribed(uri) },
+		"notifications/resources/updated", map[string]interface{}{"uri": uri})
+}
+
+// anySubscribed reports whether a running connection subscribed to uri
+// anySubscribed: 実行中のいずれかの接続がuriを購読しているかを判定する関数
+func (s *MCPServer) anySubscribed(uri string) bool {
+	s.connsMu.RLock()
+	defer s.connsMu.RUnlock()
+	for _, c := range s.conns {
+		if c.subscribed(uri) {
+			return true
+		}
+	}
+	return false
+}
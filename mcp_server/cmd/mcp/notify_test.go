@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"   // bytes: output buffer (出力バッファ)
+	"context" // context: RunIO lifetime (RunIOの存続期間)
+	"io"      // io: piped input (パイプ経由の入力)
+	"strings" // strings: counting notifications (通知の計数)
+	"sync"    // sync: guards the shared output buffer (共有出力バッファの保護)
+	"testing" // testing: test framework (テストフレームワーク)
+	"time"    // time: batch window (バッチの時間枠)
+)
+
+// syncBuffer is a bytes.Buffer safe for one writer and concurrent readers
+// syncBuffer: 書き込みと並行した読み取りが安全なbytes.Buffer
+type syncBuffer struct {
+	mu  sync.Mutex   // mu: guards buf (bufを保護)
+	buf bytes.Buffer // buf: written output (書き込まれた出力)
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// handshake runs s over a pipe and completes the initialize handshake
+// handshake: パイプ経由でsを実行し、initializeハンドシェイクを完了する関数
+// It returns the output and a stop function that ends RunIO and waits for it.
+// 出力と、RunIOを終了させて待機する停止関数を返す
+func handshake(t *testing.T, s *MCPServer) (out *syncBuffer, stop func()) {
+	t.Helper()
+	pr, pw := io.Pipe()
+	out = &syncBuffer{}
+	done := make(chan error, 1)
+	go func() { done <- s.RunIO(context.Background(), pr, out) }()
+
+	io.WriteString(pw, `{"jsonrpc":"2.0","id":1,"method":"initialize"}`+"\n")
+	eventually(t, func() bool { return strings.Contains(out.String(), `"id":1`) })
+	io.WriteString(pw, `{"jsonrpc":"2.0","method":"notifications/initialized"}`+"\n")
+	eventually(t, func() bool { return s.ready.Load() })
+
+	return out, func() {
+		pw.Close()
+		if err := <-done; err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// TestNotifyToolsListChanged checks that registering and removing tools notifies
+// initialized clients only, and that removing an unknown tool sends nothing
+// TestNotifyToolsListChanged: ツールの登録と削除が初期化済みのクライアントのみに通知され、
+// 不明なツールの削除では何も送られないことを確認するテスト
+func TestNotifyToolsListChanged(t *testing.T) {
+	s := newEchoServer()
+	var early syncBuffer
+	remove := s.addConn(s.newConnSession(&early)) // never initialized: 初期化されない
+	defer remove()
+
+	out, stop := handshake(t, s)
+	s.RegisterTool(Tool{Name: "extra"})
+	s.UnregisterTool("extra")
+	s.UnregisterTool("missing")
+	stop()
+
+	if n := strings.Count(out.String(), "notifications/tools/list_changed"); n != 2 {
+		t.Fatalf("got %d list_changed notifications, want 2:\n%s", n, out.String())
+	}
+	if early.String() != "" {
+		t.Fatalf("uninitialized connection got %q", early.String())
+	}
+}
+
+// TestNotificationBatching checks that notifications are written as one batch array
+// after the window or at the size threshold, and that a response flushes them first
+// TestNotificationBatching: 通知が時間枠の経過後またはサイズのしきい値で1つのバッチ配列として
+// 書き込まれ、レスポンスの前に送出されることを確認するテスト
+func TestNotificationBatching(t *testing.T) {
+	s := NewMCPServer(WithNotificationBatching(30*time.Millisecond, 3))
+	var out syncBuffer
+	c := s.newConnSession(&out)
+	c.initialized.Store(true)
+	defer s.addConn(c)()
+
+	// Timer: タイマー
+	s.NotifyToolsListChanged()
+	s.NotifyPromptsListChanged()
+	if out.String() != "" {
+		t.Fatalf("written before the window: %q", out.String())
+	}
+	eventually(t, func() bool { return out.String() != "" })
+	want := `[{"jsonrpc":"2.0","method":"notifications/tools/list_changed"},{"jsonrpc":"2.0","method":"notifications/prompts/list_changed"}]` + "\n"
+	if out.String() != want {
+		t.Fatalf("timer flush:\ngot  %q\nwant %q", out.String(), want)
+	}
+
+	// Size threshold: サイズのしきい値
+	out.buf.Reset()
+	for i := 0; i < 3; i++ {
+		s.NotifyToolsListChanged()
+	}
+	if got := out.String(); strings.Count(got, "\n") != 1 || strings.Count(got, "list_changed") != 3 {
+		t.Fatalf("size flush: %q", got)
+	}
+
+	// A response flushes pending notifications first: レスポンスは保留中の通知を先に送出する
+	out.buf.Reset()
+	s.NotifyToolsListChanged()
+	if err := s.writeResponse(context.Background(), c, &JSONRPCResponse{JSONRPC: "2.0", ID: IntID(1), Result: EmptyResult{}}); err != nil {
+		t.Fatal(err)
+	}
+	want = `{"jsonrpc":"2.0","method":"notifications/tools/list_changed"}` + "\n" + `{"jsonrpc":"2.0","id":1,"result":{}}` + "\n"
+	if out.String() != want {
+		t.Fatalf("response flush:\ngot  %q\nwant %q", out.String(), want)
+	}
+}
+
+// TestInitializedNotification checks that notifications/initialized and other client
+// notifications get no response, and that list_changed flows only after the handshake
+// TestInitializedNotification: notifications/initializedや他のクライアント通知に応答せず、
+// list_changedがハンドシェイク後にだけ流れることを確認するテスト
+func TestInitializedNotification(t *testing.T) {
+	s := newEchoServer()
+	pr, pw := io.Pipe()
+	out := &syncBuffer{}
+	done := make(chan error, 1)
+	go func() { done <- s.RunIO(context.Background(), pr, out) }()
+
+	io.WriteString(pw, `{"jsonrpc":"2.0","id":1,"method":"initialize"}`+"\n")
+	eventually(t, func() bool { return strings.Contains(out.String(), `"id":1`) })
+	s.NotifyToolsListChanged() // before initialized: initialized前
+	io.WriteString(pw, `{"jsonrpc":"2.0","method":"notifications/initialized"}`+"\n")
+	io.WriteString(pw, `{"jsonrpc":"2.0","method":"notifications/unknown"}`+"\n")
+	eventually(t, func() bool { return s.ready.Load() })
+	s.NotifyToolsListChanged()
+	pw.Close()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	lines := decodeLines(t, out.String())
+	if len(lines) != 2 || lines[0]["id"] != float64(1) || lines[1]["method"] != "notifications/tools/list_changed" {
+		t.Fatalf("output:\n%s", out.String())
+	}
+}
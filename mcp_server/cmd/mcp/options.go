@@ -0,0 +1,581 @@
+package main
+
+import (
+	"log/slog" // log/slog: structured logging (構造化ログ)
+	"time"     // time: durations (時間)
+)
+
+// Option configures an MCPServer at construction time
+// Option: 構築時にMCPServerを設定する関数型
+// configures: 設定する、構成する
+type Option func(*MCPServer)
+
+// WithName sets the server name reported in initialize
+// WithName: initializeで報告するサーバー名を設定するオプション
+func WithName(name string) Option {
+	return func(s *MCPServer) {
+		s.name = name
+	}
+}
+
+// WithVersion sets the server version reported in initialize
+// WithVersion: initializeで報告するサーバーバージョンを設定するオプション
+func WithVersion(version string) Option {
+	return func(s *MCPServer) {
+		s.version = version
+	}
+}
+
+// WithMaxBodyBytes limits the size of HTTP request bodies
+// WithMaxBodyBytes: HTTPリクエストボディのサイズを制限するオプション
+// It also limits each message read with FramingJSONStream. A non-positive n keeps
+// the default of 4MB.
+// FramingJSONStreamで読み取る各メッセージも制限する。nが0以下の場合はデフォルトの4MBを維持する
+func WithMaxBodyBytes(n int64) Option {
+	return func(s *MCPServer) {
+		if n > 0 {
+			s.maxBodyBytes = n
+		}
+	}
+}
+
+// WithMaxResponseBytes limits the encoded size of each response to n bytes
+// WithMaxResponseBytes: 各レスポンスのエンコード後のサイズをnバイトに制限するオプション
+// An oversized response is logged as a warning and handled by policy: ResponseTruncate
+// cuts content text and sets _meta.truncated, ResponseError answers -32000 instead.
+// It guards against a runaway tool or a huge resource; streamed events are not counted.
+// A non-positive n disables the limit, which is the default.
+// 上限を超えたレスポンスは警告としてログに記録され、policyに従って処理される。ResponseTruncateは
+// contentのテキストを切り詰めて_meta.truncatedを設定し、ResponseErrorは代わりに-32000で応答する。
+// 暴走したツールや巨大なリソースから保護する。ストリーミングされたイベントは数えない。nが0以下なら無制限 (デフォルト)
+func WithMaxResponseBytes(n int, policy ResponseLimitPolicy) Option {
+	return func(s *MCPServer) {
+		s.maxResponseBytes = n
+		s.responsePolicy = policy
+	}
+}
+
+// WithMaxDepth limits how deeply objects and arrays may nest in a request
+// WithMaxDepth: リクエスト内のオブジェクトと配列のネストの深さを制限するオプション
+// Deeper requests are rejected with -32600 before they are unmarshaled, protecting
+// the decoder and the schema validator. The default is 100; 0 disables the limit.
+// それより深いリクエストはアンマーシャル前に-32600で拒否され、デコーダーとスキーマ検証を保護する。
+// デフォルトは100で、0なら制限しない
+func WithMaxDepth(n int) Option {
+	return func(s *MCPServer) {
+		if n >= 0 {
+			s.maxDepth = n
+		}
+	}
+}
+
+// WithCodec sets the wire encoding of the stream transports: Run, RunIO and the sockets
+// WithCodec: ストリームのトランスポート (Run、RunIO、ソケット) の通信時エンコーディングを設定するオプション
+// Codecs that split their own messages, like MsgpackCodec, are read and written
+// without newlines; others keep one message per line. HTTP always uses JSON.
+// MsgpackCodecのように自らメッセージを区切るコーデックは改行なしで読み書きされ、
+// それ以外は1行に1メッセージのまま。HTTPは常にJSONを使う
+func WithCodec(codec Codec) Option {
+	return func(s *MCPServer) {
+		s.codec = codec
+	}
+}
+
+// WithFraming sets how the stream transports split their input into messages
+// WithFraming: ストリームのトランスポートが入力をメッセージへ分割する方法を設定するオプション
+// FramingJSONStream reads successive JSON values wherever the newlines fall, so
+// pretty-printed requests work. A message nested deeper than the depth limit or larger
+// than the body limit (see WithMaxBodyBytes) ends the connection while it is read,
+// since the stream cannot resynchronize. Responses stay one per line, and a codec
+// such as MsgpackCodec keeps its own framing.
+// FramingJSONStreamは改行の位置に関わらず連続したJSON値を読み取るため、整形されたリクエストも扱える。
+// 深さの上限より深くネストしたメッセージやボディの上限 (WithMaxBodyBytesを参照) より大きいメッセージは、
+// ストリームが再同期できないため読み取り中に接続を終了させる。
+// レスポンスは1行に1つのままで、MsgpackCodecなどのコーデックは独自の区切り方を保つ
+func WithFraming(framing Framing) Option {
+	return func(s *MCPServer) {
+		s.framing = framing
+	}
+}
+
+// WithCompression sets the smallest HTTP response body that is gzip-compressed
+// WithCompression: gzip圧縮するHTTPレスポンスボディの最小サイズを設定するオプション
+// Compression applies only when the client's Accept-Encoding allows gzip; event streams
+// are always compressed for such clients. The default is 1KB; a negative n disables it.
+// 圧縮はクライアントのAccept-Encodingがgzipを許可する場合のみ適用され、その場合イベントストリームは常に圧縮される。
+// デフォルトは1KBで、nが負の場合は無効化する
+func WithCompression(minBytes int) Option {
+	return func(s *MCPServer) {
+		s.compressMinBytes = minBytes
+	}
+}
+
+// WithReplayProtection rejects HTTP requests that reuse an Mcp-Nonce header within window
+// WithReplayProtection: window内にMcp-Nonceヘッダーを再利用したHTTPリクエストを拒否するオプション
+// Every request must carry the header. Each nonce is remembered for the whole window;
+// while maxEntries live nonces are held, further requests get 503, so size it for the
+// traffic expected within window.
+// 全てのリクエストはこのヘッダーを持つ必要がある。各nonceは時間枠の間ずっと記憶され、
+// 有効なnonceをmaxEntries件保持している間は以降のリクエストに503を返すため、
+// window内に想定される通信量に合わせて設定する
+func WithReplayProtection(window time.Duration, maxEntries int) Option {
+	return func(s *MCPServer) {
+		s.nonces = newNonceStore(window, maxEntries)
+	}
+}
+
+// WithJSONFormat controls how responses are encoded, mainly for debugging
+// WithJSONFormat: 主にデバッグ用に、レスポンスのエンコード方法を制御するオプション
+// With escapeHTML false, <, > and & are written as is instead of as \u003c and so on.
+// A non-empty indent pretty-prints plain HTTP responses; stdio and event streams stay
+// one message per line.
+// escapeHTMLがfalseの場合、<、>、&は\u003cなどではなくそのまま書き込まれる。indentが空でない場合は
+// 通常のHTTPレスポンスを整形する。stdioとイベントストリームは1行1メッセージのまま
+func WithJSONFormat(indent string, escapeHTML bool) Option {
+	return func(s *MCPServer) {
+		s.jsonIndent = indent
+		s.jsonNoEscapeHTML = !escapeHTML
+	}
+}
+
+// WithToolTimeout sets the default tool execution timeout
+// WithToolTimeout: デフォルトのツール実行タイムアウトを設定するオプション
+// Zero disables the timeout. Handlers that ignore their context keep running on a
+// leaked goroutine after the timeout fires.
+// 0でタイムアウトを無効化する。コンテキストを無視するハンドラーはタイムアウト後もリークしたgoroutine上で動き続ける
+func WithToolTimeout(d time.Duration) Option {
+	return func(s *MCPServer) {
+		s.toolTimeout = d
+	}
+}
+
+// WithIdleTimeout ends the stdio loop after d without any input
+// WithIdleTimeout: 入力が無いままdが経過したらstdioループを終了するオプション
+// RunIO then returns ErrIdleTimeout. Zero (the default) waits forever.
+// その場合RunIOはErrIdleTimeoutを返す。0 (デフォルト) の場合は無期限に待つ
+func WithIdleTimeout(d time.Duration) Option {
+	return func(s *MCPServer) {
+		s.idleTimeout = d
+	}
+}
+
+// WithKeepAlive sends an SSE comment every interval on open event streams
+// WithKeepAlive: 開いているイベントストリームにinterval毎にSSEコメントを送るオプション
+// It keeps proxies from closing streams for slow tool calls. Zero (the default)
+// disables it; stdio never pings.
+// 遅いツール呼び出しのストリームがプロキシに切断されるのを防ぐ。0 (デフォルト) で無効、stdioではpingしない
+func WithKeepAlive(interval time.Duration) Option {
+	return func(s *MCPServer) {
+		s.keepAlive = interval
+	}
+}
+
+// WithSlowRequestThreshold logs requests taking at least d at WARN with their method, id and duration
+// WithSlowRequestThreshold: d以上かかったリクエストをメソッド・ID・処理時間と共にWARNで記録するオプション
+// Zero (the default) disables the log.
+// 0 (デフォルト) でログを無効化する
+// pathological: 異常な
+func WithSlowRequestThreshold(d time.Duration) Option {
+	return func(s *MCPServer) {
+		s.slowThreshold = d
+	}
+}
+
+// WithSingleFlight deduplicates concurrent identical calls to read-only or idempotent tools
+// WithSingleFlight: 読み取り専用または冪等なツールへの同一の同時呼び出しを重複排除するオプション
+// Tools opt in through their readOnlyHint or idempotentHint annotation.
+// ツールはreadOnlyHintまたはidempotentHintアノテーションで対象になる
+// Calls with the same tool name and arguments share one execution and result.
+// The first caller's context governs the shared execution.
+// 同じツール名と引数の呼び出しは1回の実行と結果を共有する。共有実行は最初の呼び出し元のコンテキストに従う
+func WithSingleFlight() Option {
+	return func(s *MCPServer) {
+		s.singleFlight = true
+	}
+}
+
+// WithCache enables result caching for cacheable resources and read-only or idempotent tools
+// WithCache: キャッシュ可能なリソースと読み取り専用または冪等なツールの結果キャッシュを有効にするオプション
+// Use NewLRUCache for the built-in in-memory implementation.
+// 組み込みのメモリ内実装にはNewLRUCacheを使用する
+func WithCache(cache Cache) Option {
+	return func(s *MCPServer) {
+		s.cache = cache
+	}
+}
+
+// WithPageSize enables pagination of tools/list and resources/list
+// WithPageSize: tools/listとresources/listのページネーションを有効にするオプション
+// Zero (the default) returns every entry in one page.
+// 0 (デフォルト) の場合は全エントリを1ページで返す
+func WithPageSize(n int) Option {
+	return func(s *MCPServer) {
+		s.pageSize = n
+	}
+}
+
+// WithMaxConnections caps the connections ListenUnix and ListenTCP serve at once
+// WithMaxConnections: ListenUnixとListenTCPが同時に処理する接続数を制限するオプション
+// The limit applies per listener; extra connections get a -32000 error line and are
+// closed. Zero (the default) means no limit.
+// 上限はリスナー毎に適用され、超えた接続は-32000のエラー行を受け取って閉じられる。0 (デフォルト) は無制限
+func WithMaxConnections(n int) Option {
+	return func(s *MCPServer) {
+		s.maxConns = n
+	}
+}
+
+// WithConnWriteTimeout bounds each write to a ListenUnix or ListenTCP connection
+// WithConnWriteTimeout: ListenUnixとListenTCPの接続への各書き込みの時間を制限するオプション
+// A client that stops reading fails the write after d and its connection is closed.
+// Zero or negative disables the deadline. The default is 10 seconds.
+// 読み取りを止めたクライアントへの書き込みはd後に失敗し、その接続は閉じられる。
+// 0以下で期限を無効にする。デフォルトは10秒
+func WithConnWriteTimeout(d time.Duration) Option {
+	return func(s *MCPServer) {
+		s.connWriteTimeout = d
+	}
+}
+
+// WithDefaultResourceProvider reads resources that no other provider handles
+// WithDefaultResourceProvider: 他のどのプロバイダーも処理しないリソースを読み取るオプション
+// URIs with an otherwise unknown scheme go to provider instead of failing with
+// "Invalid URI scheme", for example to return friendly "unsupported" content or to
+// proxy them. Its CanHandle is not consulted.
+// 未知のスキームのURIは"Invalid URI scheme"で失敗する代わりにproviderへ渡される。
+// 例えば分かりやすい"未対応"の内容を返したり、転送したりするために使う。CanHandleは参照されない
+func WithDefaultResourceProvider(provider ResourceProvider) Option {
+	return func(s *MCPServer) {
+		s.fallbackProvider = provider
+	}
+}
+
+// WithRootDir sets the directory that file:// resource URIs resolve against
+// WithRootDir: file://リソースURIの基準となるディレクトリを設定するオプション
+// Reads cannot escape this directory. The default is the working directory.
+// 読み取りはこのディレクトリの外へ出られない。デフォルトは作業ディレクトリ
+func WithRootDir(dir string) Option {
+	return func(s *MCPServer) {
+		s.rootDir = dir
+	}
+}
+
+// WithHTTPSAllowHosts lets the built-in https provider reach trusted internal hosts
+// WithHTTPSAllowHosts: 組み込みのhttpsプロバイダーが信頼済みの内部ホストへ接続できるようにするオプション
+// The provider otherwise refuses private, loopback and link-local addresses; hosts
+// are host names or IP literals, matched without regard to case.
+// それ以外ではプライベート・ループバック・リンクローカルのアドレスを拒否する。
+// hostsはホスト名またはIPリテラルで、大文字小文字を区別せずに照合する
+func WithHTTPSAllowHosts(hosts ...string) Option {
+	return func(s *MCPServer) {
+		s.httpsAllowHosts = append(s.httpsAllowHosts, hosts...)
+	}
+}
+
+// WithConfigMethods exposes config/get and config/set for runtime tuning
+// WithConfigMethods: 実行時の調整のためにconfig/getとconfig/setを公開するオプション
+// The built-in settings are logLevel, toolTimeout and sessionIdleTimeout; add more
+// with RegisterSetting. Nothing outside the registry can be read or written.
+// 組み込みの設定はlogLevel、toolTimeout、sessionIdleTimeoutで、RegisterSettingで追加できる。
+// レジストリ外の値は読み書きできない
+func WithConfigMethods() Option {
+	return func(s *MCPServer) {
+		s.configMethods = true
+	}
+}
+
+// WithStatsMethod exposes server/stats, a non-standard method for debugging
+// WithStatsMethod: デバッグ用の非標準メソッドserver/statsを公開するオプション
+// It reports uptime, registry sizes and request counts; see ServerStatsResult.
+// 稼働時間、レジストリのサイズ、リクエスト数を報告する。ServerStatsResultを参照
+func WithStatsMethod() Option {
+	return func(s *MCPServer) {
+		s.statsMethod = true
+	}
+}
+
+// WithFileWriteTool registers the opt-in fs/write tool, sandboxed to the root directory
+// WithFileWriteTool: ルートディレクトリ内に限定されたオプトインのfs/writeツールを登録するオプション
+// See FileWriteTool for the limits; it follows WithRootDir regardless of option order.
+// 制限はFileWriteToolを参照。オプションの順序に関係なくWithRootDirに従う
+func WithFileWriteTool(maxBytes int64, extensions ...string) Option {
+	return func(s *MCPServer) {
+		s.builtinTools = append(s.builtinTools, func(root string) Tool {
+			return FileWriteTool(root, maxBytes, extensions)
+		})
+	}
+}
+
+// WithFileListTool registers the opt-in fs/list tool, sandboxed to the root directory
+// WithFileListTool: ルートディレクトリ内に限定されたオプトインのfs/listツールを登録するオプション
+// See FileListTool for the limits; it follows WithRootDir regardless of option order.
+// 制限はFileListToolを参照。オプションの順序に関係なくWithRootDirに従う
+func WithFileListTool(maxEntries int) Option {
+	return func(s *MCPServer) {
+		s.builtinTools = append(s.builtinTools, func(root string) Tool {
+			return FileListTool(root, maxEntries)
+		})
+	}
+}
+
+// WithFileSearchTool registers the opt-in fs/search tool, sandboxed to the root directory
+// WithFileSearchTool: ルートディレクトリ内に限定されたオプトインのfs/searchツールを登録するオプション
+// See FileSearchTool for the limits; it follows WithRootDir regardless of option order.
+// 制限はFileSearchToolを参照。オプションの順序に関係なくWithRootDirに従う
+func WithFileSearchTool(maxResults int, maxFileBytes int64) Option {
+	return func(s *MCPServer) {
+		s.builtinTools = append(s.builtinTools, func(root string) Tool {
+			return FileSearchTool(root, maxResults, maxFileBytes)
+		})
+	}
+}
+
+// WithWebFetchTool registers the opt-in web/fetch tool
+// WithWebFetchTool: オプトインのweb/fetchツールを登録するオプション
+// See WebFetchTool for the limits and the SSRF guard.
+// 制限とSSRF対策はWebFetchToolを参照
+func WithWebFetchTool(maxBytes int64) Option {
+	return func(s *MCPServer) {
+		s.builtinTools = append(s.builtinTools, func(string) Tool {
+			return WebFetchTool(maxBytes)
+		})
+	}
+}
+
+// WithMetaInvokeTool registers the opt-in meta/invoke tool, which calls other tools
+// WithMetaInvokeTool: 他のツールを呼び出すオプトインのmeta/invokeツールを登録するオプション
+// Nested calls are limited to maxDepth, 3 when zero; see metaInvokeTool.
+// 入れ子の呼び出しはmaxDepth (0なら3) までに制限される。metaInvokeToolを参照
+func WithMetaInvokeTool(maxDepth int) Option {
+	return func(s *MCPServer) {
+		s.builtinTools = append(s.builtinTools, func(string) Tool {
+			return s.metaInvokeTool(maxDepth)
+		})
+	}
+}
+
+// WithLogger sets the structured logger used for diagnostics
+// WithLogger: 診断に使用する構造化ロガーを設定するオプション
+func WithLogger(logger *slog.Logger) Option {
+	return func(s *MCPServer) {
+		if logger != nil {
+			s.logger = logger
+		}
+	}
+}
+
+// WithDebug exposes diagnostics such as panic stack traces in error data
+// WithDebug: パニックのスタックトレースなどの診断情報をエラーデータに含めるオプション
+// Leave it off in production; stack traces reveal implementation details.
+// 本番環境では無効にすること。スタックトレースは実装の詳細を露出する
+func WithDebug(debug bool) Option {
+	return func(s *MCPServer) {
+		s.debug = debug
+	}
+}
+
+// WithMaxTools caps the number of registered tools
+// WithMaxTools: 登録できるツール数の上限を設定するオプション
+// Zero (the default) means unlimited. Replacing an existing tool does not count.
+// 0 (デフォルト) は無制限。既存ツールの置き換えは数に含めない
+func WithMaxTools(n int) Option {
+	return func(s *MCPServer) {
+		s.maxTools = n
+	}
+}
+
+// WithMaxResources caps the number of registered resources
+// WithMaxResources: 登録できるリソース数の上限を設定するオプション
+// Zero (the default) means unlimited. Replacing an existing resource does not count.
+// 0 (デフォルト) は無制限。既存リソースの置き換えは数に含めない
+func WithMaxResources(n int) Option {
+	return func(s *MCPServer) {
+		s.maxResources = n
+	}
+}
+
+// WithDuplicatePolicy sets how re-registering an existing tool name is handled
+// WithDuplicatePolicy: 既存ツール名の再登録時の扱いを設定するオプション
+// The default, DuplicateOverwrite, replaces the earlier tool.
+// デフォルトのDuplicateOverwriteは以前のツールを置き換える
+func WithDuplicatePolicy(p DuplicatePolicy) Option {
+	return func(s *MCPServer) {
+		s.duplicatePolicy = p
+	}
+}
+
+// WithAuditLogger records every tool invocation with logger
+// WithAuditLogger: すべてのツール呼び出しをloggerへ記録するオプション
+// Arguments are stored as a hash unless debug mode is enabled.
+// デバッグモードが有効でない限り、引数はハッシュとして保存される
+func WithAuditLogger(logger AuditLogger) Option {
+	return func(s *MCPServer) {
+		s.auditLogger = logger
+	}
+}
+
+// WithInstructions sets the instructions returned by initialize
+// WithInstructions: initializeで返す利用方法の説明を設定するオプション
+// They tell the client how to use the server; empty omits the field.
+// クライアントにサーバーの使い方を伝える。空ならフィールドを省略する
+func WithInstructions(instructions string) Option {
+	return func(s *MCPServer) {
+		s.instructions = instructions
+	}
+}
+
+// WithSessionIdleTimeout sets how long an unused HTTP session stays valid
+// WithSessionIdleTimeout: 使われていないHTTPセッションが有効なままでいる時間を設定するオプション
+// Zero or negative disables expiry. The default is 30 minutes.
+// 0以下で期限切れを無効にする。デフォルトは30分
+func WithSessionIdleTimeout(d time.Duration) Option {
+	return func(s *MCPServer) {
+		s.sessions.idle = d
+	}
+}
+
+// WithMaxSessions caps the number of live HTTP sessions
+// WithMaxSessions: 有効なHTTPセッション数の上限を設定するオプション
+// initialize is refused with 503 while the cap is reached. Zero or negative removes
+// the cap. The default is 10000.
+// 上限に達している間、initializeは503で拒否される。0以下で上限を外す。デフォルトは10000
+func WithMaxSessions(n int) Option {
+	return func(s *MCPServer) {
+		s.sessions.max = n
+	}
+}
+
+// WithMaxContentBytes limits the size of each resources/read content
+// WithMaxContentBytes: resources/readの各内容のサイズを制限するオプション
+// Larger contents are truncated and marked with _meta.truncated and _meta.size.
+// Zero (the default) means unlimited.
+// それより大きい内容は切り詰められ、_meta.truncatedと_meta.sizeが付く。0 (デフォルト) は無制限
+func WithMaxContentBytes(n int64) Option {
+	return func(s *MCPServer) {
+		s.maxContentBytes = n
+	}
+}
+
+// WithArgumentCoercion converts common argument type mismatches before validation
+// WithArgumentCoercion: 検証の前によくある引数の型の不一致を変換するオプション
+// Numeric strings become numbers, numbers become strings and scalars become
+// one-element arrays where the schema asks for it; conversions are listed in _meta.coercions.
+// スキーマが求める場合、数値の文字列は数値に、数値は文字列に、スカラーは1要素の配列になる。
+// 行った変換は_meta.coercionsに列挙される
+func WithArgumentCoercion() Option {
+	return func(s *MCPServer) {
+		s.coerceArguments = true
+	}
+}
+
+// WithCharset converts every file resource from charset to UTF-8 text
+// WithCharset: すべてのファイルリソースをcharsetからUTF-8テキストに変換するオプション
+// Pass CharsetAuto to detect UTF-8, Shift_JIS, EUC-JP or Latin-1 per file; the charset
+// used is reported in _meta.charset. Resource.Charset overrides it per resource.
+// CharsetAutoを渡すとファイルごとにUTF-8・Shift_JIS・EUC-JP・Latin-1を検出する。使用した文字コードは
+// _meta.charsetで報告される。リソースごとにはResource.Charsetで上書きできる
+func WithCharset(charset string) Option {
+	return func(s *MCPServer) {
+		s.charset = charset
+	}
+}
+
+// WithInputSanitizing strips a leading UTF-8 BOM and stray control characters from stdio lines
+// WithInputSanitizing: stdioの行から先頭のUTF-8 BOMと余分な制御文字を取り除くオプション
+// Such bytes otherwise make the line fail to parse; each change is logged at WARN.
+// これらのバイトがあると行の解析に失敗するため取り除き、変更はWARNで記録する
+func WithInputSanitizing() Option {
+	return func(s *MCPServer) {
+		s.sanitizeInput = true
+	}
+}
+
+// WithStrictSchemas makes RegisterTool panic when a tool's schema is invalid
+// WithStrictSchemas: ツールのスキーマが不正な場合にRegisterToolをパニックさせるオプション
+// Schemas are always linted at registration; by default the error is only logged,
+// and TryRegisterTool returns it wrapping ErrInvalidSchema.
+// スキーマは常に登録時に検査される。デフォルトではエラーはログに記録されるだけで、
+// TryRegisterToolはErrInvalidSchemaをラップしたエラーを返す
+func WithStrictSchemas() Option {
+	return func(s *MCPServer) {
+		s.strictSchemas = true
+	}
+}
+
+// WithUpdateQueue queues resources/updated notifications, coalescing repeats per URI
+// WithUpdateQueue: resources/updated通知をキューに入れ、URI毎の重複をまとめるオプション
+// At most maxPending URIs wait at once; beyond that the oldest is dropped with a warning.
+// This keeps bursts of changes from flooding a slow client.
+// 同時に待機できるURIは最大maxPending件で、超えると最も古いものを警告付きで破棄する。
+// 大量の変更が遅いクライアントへ殺到するのを防ぐ
+func WithUpdateQueue(maxPending int) Option {
+	return func(s *MCPServer) {
+		if maxPending > 0 {
+			s.updates = newUpdateQueue(maxPending)
+		}
+	}
+}
+
+// WithDownstreamBreaker sets the circuit breaker used for each downstream added later
+// WithDownstreamBreaker: 以後追加する各下流に使うサーキットブレーカーを設定するオプション
+// A breaker opens after threshold consecutive transport failures and lets a trial call
+// through after cooldown. The default is 5 failures and 30s; threshold <= 0 disables it.
+// ブレーカーは連続threshold回のトランスポート失敗で開き、cooldown後に試行呼び出しを通す。
+// デフォルトは5回と30秒で、threshold <= 0で無効になる
+func WithDownstreamBreaker(threshold int, cooldown time.Duration) Option {
+	return func(s *MCPServer) {
+		s.breakerThreshold = threshold
+		s.breakerCooldown = cooldown
+	}
+}
+
+// WithWorkers limits how many requests are handled at once
+// WithWorkers: 同時に処理するリクエスト数を制限するオプション
+// Further requests wait in the queue set by WithQueue. Without this option a queue
+// gets one worker per CPU; with neither, requests are not limited.
+// それ以上のリクエストはWithQueueで設定したキューで待つ。このオプションが無い場合、キューには
+// CPU毎に1つのワーカーが付き、どちらも無い場合はリクエストを制限しない
+func WithWorkers(n int) Option {
+	return func(s *MCPServer) {
+		if n > 0 {
+			s.requestPool().workers = n
+		}
+	}
+}
+
+// WithQueue lets up to size requests wait for a busy worker pool, handling overflow by policy
+// WithQueue: 使用中のワーカープールをsize件までのリクエストが待てるようにし、溢れた分をpolicyで扱うオプション
+// OverflowBlock waits for room until the request's deadline, OverflowReject answers
+// -32000 Server busy at once, and OverflowDropOldest answers the oldest waiting request
+// that way to make room. OverflowSync has a RunIO connection's read loop handle the
+// request itself outside the pool, trading that connection's throughput for
+// reliability; over HTTP it answers -32000 like OverflowReject. Queued requests are
+// served in order; Metrics reports the depth.
+// OverflowBlockはリクエストの期限まで空きを待ち、OverflowRejectは即座に-32000 Server busyで応答し、
+// OverflowDropOldestは空きを作るため最も古い待機中のリクエストへそのように応答する。
+// OverflowSyncはその接続のスループットと引き換えに信頼性を優先し、RunIOの接続の読み取りループが
+// プールの外で自ら処理する。HTTPではOverflowRejectと同様に-32000で応答する。
+// 待機中のリクエストは順に処理され、Metricsがその数を報告する
+func WithQueue(size int, policy OverflowPolicy) Option {
+	return func(s *MCPServer) {
+		if size >= 0 {
+			p := s.requestPool()
+			p.size, p.policy = size, policy
+		}
+	}
+}
+
+// WithNotificationBatching writes notifications together as JSON-RPC batch arrays
+// WithNotificationBatching: 通知をJSON-RPCのバッチ配列としてまとめて書き込むオプション
+// A batch is flushed window after its first notification, once it holds maxBatch
+// notifications, or before any other message, so ordering is preserved and responses
+// are never batched. It reduces write overhead on slow stdio transports.
+// バッチは最初の通知からwindow経過後、maxBatch件に達した時、または他のメッセージの前に送出されるため、
+// 順序は保たれ、レスポンスがバッチに入ることはない。遅いstdioトランスポートでの書き込み負荷を減らす
+func WithNotificationBatching(window time.Duration, maxBatch int) Option {
+	return func(s *MCPServer) {
+		if window > 0 && maxBatch > 1 {
+			s.batchWindow, s.batchMax = window, maxBatch
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"         // bytes: captured log and stdio output (取得したログとstdio出力)
+	"context"       // context: RunIO lifetime (RunIOの存続期間)
+	"log/slog"      // log/slog: logger receiving registration errors (登録エラーを受け取るロガー)
+	"os"            // os: a file under the root directory (ルートディレクトリ下のファイル)
+	"path/filepath" // path/filepath: building test file paths (テスト用ファイルパスの組み立て)
+	"strings"       // strings: input and output matching (入出力の照合)
+	"testing"       // testing: test framework (テストフレームワーク)
+	"time"          // time: tool timeout (ツールのタイムアウト)
+)
+
+// TestOptions checks that NewMCPServer applies name, version, root directory,
+// logger and tool timeout options, and keeps the defaults without them
+// TestOptions: NewMCPServerが名前、バージョン、ルートディレクトリ、ロガー、ツールタイムアウトの
+// オプションを適用し、指定が無ければデフォルトを保つことを確認するテスト
+func TestOptions(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("hi"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var logs bytes.Buffer
+	s := NewMCPServer(
+		WithName("custom"),
+		WithVersion("9.9.9"),
+		WithRootDir(dir),
+		WithLogger(slog.New(slog.NewTextHandler(&logs, nil))),
+		WithToolTimeout(time.Second),
+	)
+	c := NewClient(s)
+	result, err := c.Initialize()
+	if err != nil || result.ServerInfo.Name != "custom" || result.ServerInfo.Version != "9.9.9" {
+		t.Fatalf("initialize: got %+v, %v", result, err)
+	}
+	if got := s.currentToolTimeout(); got != time.Second {
+		t.Fatalf("tool timeout: got %s, want 1s", got)
+	}
+	if read, err := c.ReadResource("file:///a.txt"); err != nil || read.Contents[0].Text != "hi" {
+		t.Fatalf("read under root: got %+v, %v", read, err)
+	}
+	s.RegisterTool(Tool{Name: "bad", InputSchema: map[string]interface{}{"pattern": "("}})
+	if !strings.Contains(logs.String(), "tool registration failed") {
+		t.Fatalf("registration error not logged:\n%s", logs.String())
+	}
+
+	d := NewMCPServer()
+	if result, err := NewClient(d).Initialize(); err != nil || result.ServerInfo.Name != "MCPServer" {
+		t.Fatalf("default name: got %+v, %v", result, err)
+	}
+	if got := d.currentToolTimeout(); got != defaultToolTimeout {
+		t.Fatalf("default tool timeout: got %s, want %s", got, defaultToolTimeout)
+	}
+}
+
+// TestJSONFormat checks that WithJSONFormat leaves <, > and & unescaped when asked and
+// indents plain HTTP responses while stdio keeps one message per line
+// TestJSONFormat: WithJSONFormatが指定時に<、>、&をエスケープせず、通常のHTTPレスポンスを
+// インデントする一方でstdioは1行1メッセージのままであることを確認するテスト
+func TestJSONFormat(t *testing.T) {
+	const list = `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`
+	for _, escapeHTML := range []bool{true, false} {
+		s := NewMCPServer(WithJSONFormat("  ", escapeHTML))
+		s.RegisterTool(Tool{Name: "html", Description: "<b>&</b>"})
+
+		var out bytes.Buffer
+		if err := s.RunIO(context.Background(), strings.NewReader(list+"\n"), &out); err != nil {
+			t.Fatal(err)
+		}
+		if raw := strings.Contains(out.String(), "<b>&</b>"); raw == escapeHTML || strings.Count(out.String(), "\n") != 1 {
+			t.Errorf("stdio with escapeHTML %v: %s", escapeHTML, out.String())
+		}
+
+		w := postJSON(s, list)
+		if raw := strings.Contains(w.Body.String(), "<b>&</b>"); raw == escapeHTML || !strings.Contains(w.Body.String(), "\n  \"jsonrpc\"") {
+			t.Errorf("HTTP with escapeHTML %v: %s", escapeHTML, w.Body)
+		}
+	}
+
+	// The default is compact and escaped: デフォルトは詰めてエスケープする
+	if body := postJSON(NewMCPServer(), list).Body.String(); strings.Contains(body, "\n ") {
+		t.Errorf("default HTTP response is indented: %s", body)
+	}
+}
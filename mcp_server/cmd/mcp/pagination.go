@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"           // bytes: byte slice helpers (バイト列ヘルパー)
+	"crypto/hmac"     // crypto/hmac: message authentication (メッセージ認証)
+	"crypto/rand"     // crypto/rand: secure random numbers (安全な乱数)
+	"crypto/sha256"   // crypto/sha256: SHA-256 hashing (SHA-256ハッシュ)
+	"encoding/base64" // encoding/base64: base64 encoding (base64エンコード)
+	"errors"          // errors: error values (エラー値)
+	"sort"            // sort: sorting and binary search (並べ替えと二分探索)
+	"strings"         // strings: string manipulation functions (文字列操作関数)
+)
+
+// cursorVersion is embedded in every cursor so the format can evolve
+// cursorVersion: 形式を将来変更できるよう全カーソルに埋め込まれるバージョン
+const cursorVersion byte = 1
+
+// errInvalidCursor reports a cursor that is malformed, tampered with or from another version
+// errInvalidCursor: 不正・改ざん・別バージョンのカーソルを表すエラー
+// tampered: 改ざんされた
+var errInvalidCursor = errors.New("invalid cursor")
+
+// newCursorKey returns a random key for signing cursors
+// newCursorKey: カーソル署名用のランダムな鍵を返す関数
+func newCursorKey() []byte {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic("crypto/rand failed: " + err.Error()) // panic: 回復不能なエラー
+	}
+	return key
+}
+
+// encodeCursor returns an opaque cursor meaning "resume after lastKey"
+// encodeCursor: 「lastKeyの次から再開」を意味する不透明なカーソルを返す関数
+// Cursors carry a sort key rather than an index, so they stay valid when entries
+// are added or removed between pages.
+// カーソルはインデックスではなくソートキーを持つため、ページ間でエントリが増減しても有効なままである
+func (s *MCPServer) encodeCursor(lastKey string) string {
+	payload := append([]byte{cursorVersion}, lastKey...)
+	mac := hmac.New(sha256.New, s.cursorKey)
+	mac.Write(payload)
+	return base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// decodeCursor verifies a cursor and returns the sort key it resumes after
+// decodeCursor: カーソルを検証し、再開位置のソートキーを返す関数
+func (s *MCPServer) decodeCursor(cursor string) (string, error) {
+	encodedPayload, encodedMAC, ok := strings.Cut(cursor, ".")
+	if !ok {
+		return "", errInvalidCursor
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil || len(payload) == 0 || payload[0] != cursorVersion {
+		return "", errInvalidCursor
+	}
+	sum, err := base64.RawURLEncoding.DecodeString(encodedMAC)
+	if err != nil {
+		return "", errInvalidCursor
+	}
+
+	// Detect tampering: 改ざんを検出
+	mac := hmac.New(sha256.New, s.cursorKey)
+	mac.Write(payload)
+	if !hmac.Equal(sum, mac.Sum(nil)) {
+		return "", errInvalidCursor
+	}
+	return string(bytes.TrimPrefix(payload, []byte{cursorVersion})), nil
+}
+
+// cursorParam extracts the optional cursor from list params
+// cursorParam: 一覧系パラメータから任意のカーソルを取り出す関数
+// An empty string means the first page.
+// 空文字列は最初のページを意味する
+func (s *MCPServer) cursorParam(params interface{}) (string, error) {
+	p, _ := params.(map[string]interface{})
+	raw, present := p["cursor"]
+	if !present || raw == nil {
+		return "", nil
+	}
+	cursor, ok := raw.(string)
+	if !ok {
+		return "", errInvalidCursor
+	}
+	return s.decodeCursor(cursor)
+}
+
+// paginate selects the page of sorted keys that follows after
+// paginate: ソート済みキーのうちafterに続くページを選択する関数
+// It returns the page bounds and the cursor for the next page ("" on the last page).
+// ページの範囲と次ページのカーソル (最終ページでは"") を返す
+func (s *MCPServer) paginate(sortedKeys []string, after string) (start, end int, nextCursor string) {
+	if after != "" {
+		// First key strictly greater than after; deleted keys are skipped naturally
+		// afterより厳密に大きい最初のキー。削除されたキーは自然にスキップされる
+		start = sort.SearchStrings(sortedKeys, after)
+		if start < len(sortedKeys) && sortedKeys[start] == after {
+			start++
+		}
+	}
+	end = len(sortedKeys)
+	if s.pageSize > 0 && end-start > s.pageSize {
+		end = start + s.pageSize
+		nextCursor = s.encodeCursor(sortedKeys[end-1])
+	}
+	return start, end, nextCursor
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context" // context: request contexts (リクエストコンテキスト)
+	"strings" // strings: joining page names (ページ名の連結)
+	"testing" // testing: test framework (テストフレームワーク)
+)
+
+// listTools requests one tools/list page and returns its tool names and next cursor
+// listTools: tools/listの1ページを要求し、ツール名と次のカーソルを返す関数
+func listTools(t *testing.T, s *MCPServer, cursor interface{}) (names string, next string) {
+	t.Helper()
+	params := map[string]interface{}{}
+	if cursor != nil {
+		params["cursor"] = cursor
+	}
+	resp := s.HandleRequest(context.Background(), &JSONRPCRequest{JSONRPC: "2.0", ID: IntID(1), Method: "tools/list", Params: params})
+	if resp.Error != nil {
+		t.Fatal(resp.Error)
+	}
+	result := resp.Result.(*ToolsListResult)
+	var list []string
+	for _, tool := range result.Tools {
+		list = append(list, tool.Name)
+	}
+	return strings.Join(list, ","), result.NextCursor
+}
+
+// TestToolsListPagination checks that cursors resume after the last key seen even
+// when tools are added or removed between pages
+// TestToolsListPagination: ページ間でツールが追加・削除されても、カーソルが
+// 最後に見たキーの次から再開することを確認するテスト
+func TestToolsListPagination(t *testing.T) {
+	s := NewMCPServer(WithPageSize(2))
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		s.RegisterTool(Tool{Name: name})
+	}
+
+	names, cursor := listTools(t, s, nil)
+	if names != "a,b" || cursor == "" {
+		t.Fatalf("page 1: got %q, cursor %q", names, cursor)
+	}
+	s.UnregisterTool("b") // the cursor's own key: カーソル自身のキー
+	s.UnregisterTool("c")
+	s.RegisterTool(Tool{Name: "aa"}) // before the cursor, not repeated: カーソルより前のため再掲されない
+	names, cursor = listTools(t, s, cursor)
+	if names != "d,e" || cursor != "" {
+		t.Fatalf("page 2: got %q, cursor %q", names, cursor)
+	}
+}
+
+// TestToolsListInvalidCursor checks that tampered, malformed and foreign cursors get -32602
+// TestToolsListInvalidCursor: 改ざん・不正な形式・他のサーバーのカーソルが-32602になることを確認するテスト
+func TestToolsListInvalidCursor(t *testing.T) {
+	s := NewMCPServer(WithPageSize(1))
+	s.RegisterTool(Tool{Name: "a"})
+	s.RegisterTool(Tool{Name: "b"})
+	_, cursor := listTools(t, s, nil)
+	payload, mac, _ := strings.Cut(cursor, ".")
+
+	for name, bad := range map[string]interface{}{
+		"tampered":   payload + "x." + mac,
+		"no mac":     payload,
+		"not base64": "!!!.!!!",
+		"not string": 1.0,
+		"foreign":    NewMCPServer().encodeCursor("a"), // signed with another key: 別の鍵で署名
+	} {
+		t.Run(name, func(t *testing.T) {
+			resp := s.HandleRequest(context.Background(), &JSONRPCRequest{
+				JSONRPC: "2.0",
+				ID:      IntID(1),
+				Method:  "tools/list",
+				Params:  map[string]interface{}{"cursor": bad},
+			})
+			if resp.Error == nil || resp.Error.Code != -32602 {
+				t.Fatalf("got %+v, want -32602", resp.Error)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"       // context: request correlation (リクエストの関連付け)
+	"fmt"           // fmt: formatted I/O (フォーマット済みI/O)
+	"runtime/debug" // runtime/debug: stack traces (スタックトレース)
+)
+
+// panicError carries a recovered handler panic
+// panicError: 回復したハンドラーのパニックを保持するエラー
+// recovered: 回復した
+type panicError struct {
+	value interface{} // value: value passed to panic (panicに渡された値)
+	stack []byte      // stack: goroutine stack at the panic (パニック時のスタック)
+}
+
+func (e *panicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.value)
+}
+
+// recovered converts a recovered panic value into a panicError and logs it
+// recovered: 回復したパニック値をpanicErrorに変換してログに記録する関数
+// It must be called from the deferred function that called recover.
+// recoverを呼び出した遅延関数の中から呼び出す必要がある
+func (s *MCPServer) recovered(ctx context.Context, handler string, value interface{}) *panicError {
+	err := &panicError{value: value, stack: debug.Stack()}
+	s.logger.ErrorContext(ctx, "handler panicked", // panicked: パニックした
+		"handler", handler,
+		"panic", fmt.Sprint(value),
+		"stack", string(err.stack),
+	)
+	return err
+}
+
+// panicResponse builds the -32603 response for a recovered panic
+// panicResponse: 回復したパニックに対する-32603レスポンスを生成する関数
+// The stack trace is only included when debug mode is enabled.
+// スタックトレースはデバッグモードが有効な場合のみ含める
+func (s *MCPServer) panicResponse(id RequestID, err *panicError) *JSONRPCResponse {
+	var data interface{}
+	if s.debug {
+		data = map[string]interface{}{"stack": string(err.stack)} // stack: スタック
+	}
+	return &JSONRPCResponse{
+		JSONRPC: "2.0",
+		ID:      id,
+		Error: &JSONRPCError{
+			Code:    -32603,      // Internal error (内部エラー)
+			Message: err.Error(), // message: パニックの内容
+			Data:    data,
+		},
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"    // bytes: captured log output (取得したログ出力)
+	"context"  // context: handler signatures (ハンドラーのシグネチャ)
+	"log/slog" // log/slog: logger capturing the panic (パニックを取得するロガー)
+	"strings"  // strings: log matching (ログの照合)
+	"testing"  // testing: test framework (テストフレームワーク)
+)
+
+// TestHandlerPanic checks that panicking tool and prompt handlers become -32603
+// responses, are logged, include the stack only in debug mode, and leave the
+// server serving
+// TestHandlerPanic: パニックしたツールとプロンプトのハンドラーが-32603レスポンスになり、
+// ログに記録され、デバッグモードでのみスタックを含み、サーバーが処理を続けることを確認するテスト
+func TestHandlerPanic(t *testing.T) {
+	for _, debug := range []bool{false, true} {
+		var logs bytes.Buffer
+		s := NewMCPServer(WithDebug(debug), WithLogger(slog.New(slog.NewTextHandler(&logs, nil))))
+		s.RegisterTool(Tool{
+			Name: "boom",
+			Handler: func(ctx context.Context, arguments interface{}) (map[string]interface{}, error) {
+				panic("kaboom")
+			},
+		})
+		s.RegisterPrompt(Prompt{
+			Name: "boom",
+			Handler: func(ctx context.Context, arguments map[string]string) ([]PromptMessage, error) {
+				panic("prompt kaboom")
+			},
+		})
+
+		for method, want := range map[string]string{
+			"tools/call":  "panic: kaboom",
+			"prompts/get": "panic: prompt kaboom",
+		} {
+			resp := s.HandleRequest(context.Background(), &JSONRPCRequest{
+				JSONRPC: "2.0",
+				ID:      IntID(1),
+				Method:  method,
+				Params:  map[string]interface{}{"name": "boom"},
+			})
+			if resp.Error == nil || resp.Error.Code != -32603 || resp.Error.Message != want {
+				t.Fatalf("debug %v, %s: got %+v, want -32603 %q", debug, method, resp.Error, want)
+			}
+			data, _ := resp.Error.Data.(map[string]interface{})
+			if _, hasStack := data["stack"]; hasStack != debug {
+				t.Fatalf("debug %v, %s: stack in data is %v", debug, method, hasStack)
+			}
+		}
+		if n := strings.Count(logs.String(), "handler panicked"); n != 2 {
+			t.Fatalf("debug %v: got %d panic logs, want 2:\n%s", debug, n, logs.String())
+		}
+		if _, err := NewClient(s).ListTools(); err != nil {
+			t.Fatalf("debug %v: server stopped serving: %v", debug, err)
+		}
+	}
+}
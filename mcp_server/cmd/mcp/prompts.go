@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context" // context: cancellation and deadlines (キャンセルと期限)
+	"fmt"     // fmt: formatted I/O (フォーマット済みI/O)
+	"sort"    // sort: sorting (並べ替え)
+)
+
+// Prompt represents an MCP prompt template
+// Prompt: MCPプロンプトテンプレートを表現する構造体
+// template: テンプレート、ひな形
+type Prompt struct {
+	Name        string           `json:"name"`                  // name: prompt name (プロンプト名)
+	Description string           `json:"description,omitempty"` // description: prompt description (プロンプト説明)
+	Arguments   []PromptArgument `json:"arguments,omitempty"`   // arguments: accepted arguments (受け付ける引数)
+
+	Handler PromptHandler `json:"-"` // handler: builds the prompt messages (プロンプトメッセージを生成)
+}
+
+// PromptArgument describes one argument accepted by a prompt
+// PromptArgument: プロンプトが受け付ける引数を1つ記述する構造体
+type PromptArgument struct {
+	Name        string `json:"name"`                  // name: argument name (引数名)
+	Description string `json:"description,omitempty"` // description: argument description (引数説明)
+	Required    bool   `json:"required,omitempty"`    // required: 必須かどうか
+}
+
+// PromptMessage is one message produced by prompts/get
+// PromptMessage: prompts/getが生成するメッセージ1件
+type PromptMessage struct {
+	Role    string                 `json:"role"`    // role: "user" or "assistant" (役割)
+	Content map[string]interface{} `json:"content"` // content: message content (メッセージ内容)
+}
+
+// PromptHandler renders a prompt's messages from its arguments
+// PromptHandler: 引数からプロンプトのメッセージを生成する関数型
+type PromptHandler func(ctx context.Context, arguments map[string]string) ([]PromptMessage, error)
+
+// RegisterPrompt registers a new prompt with the server
+// RegisterPrompt: サーバーに新しいプロンプトを登録する関数
+func (s *MCPServer) RegisterPrompt(prompt Prompt) {
+	s.mu.Lock()
+	s.prompts[prompt.Name] = prompt
+	s.mu.Unlock()
+
+	s.NotifyPromptsListChanged()
+}
+
+// UnregisterPrompt removes a prompt from the server
+// UnregisterPrompt: サーバーからプロンプトを削除する関数
+func (s *MCPServer) UnregisterPrompt(name string) {
+	s.mu.Lock()
+	_, existed := s.prompts[name]
+	delete(s.prompts, name)
+	s.mu.Unlock()
+
+	if existed {
+		s.NotifyPromptsListChanged()
+	}
+}
+
+// NotifyPromptsListChanged tells the client that the prompt list has changed
+// NotifyPromptsListChanged: プロンプト一覧が変更されたことをクライアントへ通知する関数
+func (s *MCPServer) NotifyPromptsListChanged() {
+	s.notify("notifications/prompts/list_changed", nil)
+}
+
+// handlePromptsList handles the prompts/list method
+// handlePromptsList: prompts/listメソッドを処理する関数
+func (s *MCPServer) handlePromptsList(req *JSONRPCRequest) *JSONRPCResponse {
+	s.mu.RLock()
+	prompts := make([]Prompt, 0, len(s.prompts))
+	for _, prompt := range s.prompts {
+		prompts = append(prompts, prompt)
+	}
+	s.mu.RUnlock()
+
+	// Stable order: 安定した順序
+	sort.Slice(prompts, func(i, j int) bool { return prompts[i].Name < prompts[j].Name })
+
+	return &JSONRPCResponse{
+		JSONRPC: "2.0",
+		ID:      req.ID,
+		Result:  &PromptsListResult{Prompts: prompts},
+	}
+}
+
+// handlePromptsGet handles the prompts/get method
+// handlePromptsGet: prompts/getメソッドを処理する関数
+func (s *MCPServer) handlePromptsGet(ctx context.Context, req *JSONRPCRequest) *JSONRPCResponse {
+	params, ok := req.Params.(map[string]interface{})
+	if !ok {
+		return &JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error: &JSONRPCError{
+				Code:    -32602,               // Invalid params (無効なパラメータ)
+				Message: "Invalid parameters", // parameters: パラメータ
+			},
+		}
+	}
+
+	name, ok := params["name"].(string)
+	if !ok {
+		return &JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error: &JSONRPCError{
+				Code:    -32602,
+				Message: "Prompt name is required", // required: 必須の
+			},
+		}
+	}
+
+	s.mu.RLock()
+	prompt, exists := s.prompts[name]
+	s.mu.RUnlock()
+	if !exists {
+		return &JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error: &JSONRPCError{
+				Code:    -32602,
+				Message: "Prompt not found", // found: 見つかった
+				Data:    map[string]interface{}{"prompt": name},
+			},
+		}
+	}
+
+	// Collect string arguments: 文字列引数を収集
+	arguments := make(map[string]string)
+	if raw, ok := params["arguments"].(map[string]interface{}); ok {
+		for key, value := range raw {
+			arguments[key] = fmt.Sprint(value)
+		}
+	}
+	for _, arg := range prompt.Arguments {
+		if _, ok := arguments[arg.Name]; arg.Required && !ok {
+			return &JSONRPCResponse{
+				JSONRPC: "2.0",
+				ID:      req.ID,
+				Error: &JSONRPCError{
+					Code:    -32602,
+					Message: "Missing required argument", // missing: 不足している
+					Data:    map[string]interface{}{"argument": arg.Name},
+				},
+			}
+		}
+	}
+
+	var messages []PromptMessage
+	if prompt.Handler != nil {
+		var err error
+		messages, err = prompt.Handler(ctx, arguments)
+		if err != nil {
+			return &JSONRPCResponse{
+				JSONRPC: "2.0",
+				ID:      req.ID,
+				Error: &JSONRPCError{
+					Code:    -32603,                          // Internal error (内部エラー)
+					Message: "Prompt failed: " + err.Error(), // failed: 失敗した
+				},
+			}
+		}
+	}
+
+	return &JSONRPCResponse{
+		JSONRPC: "2.0",
+		ID:      req.ID,
+		Result: &GetPromptResult{
+			Description: prompt.Description, // description: 説明
+			Messages:    messages,           // messages: メッセージ
+		},
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"strings" // strings: counting notifications (通知の計数)
+	"testing" // testing: test framework (テストフレームワーク)
+)
+
+// TestNotifyPromptsListChanged checks that initialize advertises prompts listChanged
+// and that registering and removing prompts notifies initialized clients, while
+// removing an unknown prompt sends nothing
+// TestNotifyPromptsListChanged: initializeがpromptsのlistChangedを告知し、プロンプトの登録と削除が
+// 初期化済みのクライアントへ通知され、不明なプロンプトの削除では何も送られないことを確認するテスト
+func TestNotifyPromptsListChanged(t *testing.T) {
+	s := newEchoServer()
+	out, stop := handshake(t, s)
+	if !strings.Contains(out.String(), `"prompts":{"listChanged":true}`) {
+		t.Fatalf("initialize does not advertise prompts listChanged:\n%s", out.String())
+	}
+
+	s.RegisterPrompt(Prompt{Name: "greet"})
+	s.UnregisterPrompt("greet")
+	s.UnregisterPrompt("missing")
+	stop()
+
+	if n := strings.Count(out.String(), "notifications/prompts/list_changed"); n != 2 {
+		t.Fatalf("got %d list_changed notifications, want 2:\n%s", n, out.String())
+	}
+}
@@ -0,0 +1,606 @@
+package main
+
+import (
+	"context"         // context: cancellation and deadlines (キャンセルと期限)
+	"encoding/base64" // encoding/base64: base64 encoding (base64エンコード)
+	"errors"          // errors: blocked address detection (拒否されたアドレスの判定)
+	"fmt"             // fmt: formatted I/O (フォーマット済みI/O)
+	"io"              // io: basic I/O interfaces (基本的なI/Oインターフェース)
+	"io/fs"           // io/fs: embedded and virtual file systems (埋め込み・仮想ファイルシステム)
+	"math/rand/v2"    // math/rand/v2: retry jitter (リトライのジッター)
+	"mime"            // mime: media type parsing (メディアタイプ解析)
+	"net/http"        // net/http: fetching and content sniffing (取得とコンテンツ判定)
+	"net/url"         // net/url: URL parsing (URL解析)
+	"os"              // os: file access (ファイルアクセス)
+	"path"            // path: slash-separated paths (スラッシュ区切りパス)
+	"path/filepath"   // path/filepath: file extensions (ファイル拡張子)
+	"strings"         // strings: string manipulation functions (文字列操作関数)
+	"time"            // time: retry backoff (リトライの待機)
+	"unicode/utf8"    // unicode/utf8: UTF-8 validation (UTF-8検証)
+)
+
+// ResourceProvider supplies resource contents for the URIs it can handle
+// ResourceProvider: 処理可能なURIに対してリソース内容を提供するインターフェース
+// The server consults providers in order and reads through the first that can handle a URI.
+// サーバーはプロバイダーを順に参照し、URIを処理できる最初のプロバイダーで読み取る
+type ResourceProvider interface {
+	// CanHandle reports whether the provider serves uri
+	// CanHandle: プロバイダーがuriを提供できるかどうかを返す
+	CanHandle(uri string) bool
+
+	// Read returns the contents of uri
+	// Read: uriの内容を返す
+	Read(ctx context.Context, uri string) (Content, error)
+}
+
+// RegisterResourceProvider adds a provider consulted before the built-in ones
+// RegisterResourceProvider: 組み込みプロバイダーより先に参照されるプロバイダーを追加する関数
+// Custom providers are consulted in registration order.
+// カスタムプロバイダーは登録順に参照される
+func (s *MCPServer) RegisterResourceProvider(provider ResourceProvider) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.providers = append(s.providers, provider)
+}
+
+// ResourceLister enumerates resources on demand for resources/list
+// ResourceLister: resources/listのためにリソースをオンデマンドで列挙する関数型
+// Use it when there are too many dynamic resources to register one by one.
+// 動的なリソースが多すぎて1件ずつ登録できない場合に使う
+// enumerates: 列挙する
+type ResourceLister func(ctx context.Context) ([]Resource, error)
+
+// RegisterResourceLister adds a lister whose resources are merged into resources/list
+// RegisterResourceLister: resources/listに統合されるリソースを返すリスターを追加する関数
+// Listed resources are paginated together with the registered ones.
+// 列挙されたリソースは登録済みのリソースと一緒にページ分割される
+func (s *MCPServer) RegisterResourceLister(lister ResourceLister) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listers = append(s.listers, lister)
+}
+
+// providerFor returns the first provider that can handle uri, then the fallback provider, or nil
+// providerFor: uriを処理できる最初のプロバイダー、次にフォールバックのプロバイダーを返す関数 (無ければnil)
+func (s *MCPServer) providerFor(uri string) ResourceProvider {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if content, ok := s.static[uri]; ok {
+		return staticResource(content) // served from memory: メモリから提供
+	}
+	for _, provider := range s.providers {
+		if provider.CanHandle(uri) {
+			return provider
+		}
+	}
+	for _, provider := range s.defaultProviders {
+		if provider.CanHandle(uri) {
+			return provider
+		}
+	}
+	return s.fallbackProvider
+}
+
+// RegisterStaticResource registers a resource whose content is held in memory
+// RegisterStaticResource: 内容をメモリ上に保持するリソースを登録する関数
+// Reads are answered from the copy taken here, ahead of every provider and without
+// touching the filesystem. The content is text when mime is a textual type and
+// content is UTF-8, otherwise a base64 blob; an empty mime is detected from content.
+// 読み取りはここで取ったコピーから、全てのプロバイダーより先に、ファイルシステムに触れずに応答される。
+// mimeがテキスト系でcontentがUTF-8ならtext、それ以外はbase64のblobになる。mimeが空ならcontentから判定する
+func (s *MCPServer) RegisterStaticResource(uri, name, mime string, content []byte) {
+	u, err := normalizeURI(uri)
+	if err != nil {
+		s.logger.Error("resource registration failed", "uri", uri, "error", err)
+		return
+	}
+	if mime == "" {
+		mime = http.DetectContentType(content) // detect: 検出する
+	}
+	encoded := encodeContent(mime, content) // text and blob copy the bytes: textとblobはバイト列をコピーする
+	encoded.URI = u.String()
+
+	if err := s.TryRegisterResource(Resource{URI: encoded.URI, Name: name, MimeType: encoded.MimeType}); err != nil {
+		s.logger.Error("resource registration failed", "uri", uri, "error", err)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.static == nil {
+		s.static = make(map[string]Content)
+	}
+	s.static[encoded.URI] = encoded
+}
+
+// staticResource serves one registered in-memory content
+// staticResource: 登録された1件のメモリ上の内容を提供するプロバイダー
+type staticResource Content
+
+func (r staticResource) CanHandle(uri string) bool {
+	return uri == r.URI
+}
+
+func (r staticResource) Read(ctx context.Context, uri string) (Content, error) {
+	return Content(r), nil
+}
+
+// hasScheme reports whether uri uses scheme, ignoring case
+// hasScheme: uriが指定したスキームを使っているかを大文字小文字を無視して判定する関数
+func hasScheme(uri, scheme string) bool {
+	prefix, _, ok := strings.Cut(uri, ":")
+	return ok && strings.EqualFold(prefix, scheme)
+}
+
+// schemeProvider adapts a SchemeHandler to the ResourceProvider interface
+// schemeProvider: SchemeHandlerをResourceProviderインターフェースに適合させる構造体
+// adapts: 適合させる
+type schemeProvider struct {
+	scheme  string        // scheme: handled URI scheme (処理するURIスキーム)
+	handler SchemeHandler // handler: scheme handler (スキームハンドラー)
+}
+
+func (p *schemeProvider) CanHandle(uri string) bool {
+	return hasScheme(uri, p.scheme)
+}
+
+func (p *schemeProvider) Read(ctx context.Context, uri string) (Content, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return Content{}, fmt.Errorf("%w: %v", errInvalidURI, err)
+	}
+	return p.handler(ctx, u)
+}
+
+// FileProvider is the built-in provider for file:// URIs
+// FileProvider: file:// URIの組み込みプロバイダー
+// URI paths resolve against Root and cannot escape it, even through symlinks.
+// URIのパスはRootを基準に解決され、シンボリックリンク経由でもRootの外へは出られない
+// Files longer than MaxBytes are read only up to it and flagged as truncated.
+// MaxBytesより長いファイルはそこまでだけ読み取り、切り詰めたことを示す
+type FileProvider struct {
+	Root     string // root: sandbox directory (サンドボックスのディレクトリ)
+	MaxBytes int64  // maxBytes: read limit, 0 for unlimited (読み取り上限、0なら無制限)
+}
+
+func (p FileProvider) CanHandle(uri string) bool {
+	return hasScheme(uri, "file")
+}
+
+func (p FileProvider) Read(ctx context.Context, uri string) (Content, error) {
+	// File system access: ファイルシステムアクセス
+	// access: アクセス、接近
+	f, err := p.open(ctx, uri)
+	if err != nil {
+		return Content{}, err
+	}
+	defer f.Close()
+
+	if p.MaxBytes <= 0 {
+		data, err := io.ReadAll(f)
+		if err != nil {
+			return Content{}, fmt.Errorf("read %s: %w", uri, err)
+		}
+		return fileContent(f.Name(), data), nil
+	}
+
+	// One byte past the limit tells whether the file is longer: 上限を1バイト超えて読めばファイルが長いかが分かる
+	data, err := io.ReadAll(io.LimitReader(f, p.MaxBytes+1))
+	if err != nil {
+		return Content{}, fmt.Errorf("read %s: %w", uri, err)
+	}
+	if int64(len(data)) <= p.MaxBytes {
+		return fileContent(f.Name(), data), nil
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return Content{}, fmt.Errorf("stat %s: %w", uri, err)
+	}
+	content := fileContent(f.Name(), cutUTF8(data, int(p.MaxBytes)))
+	content.Meta = map[string]interface{}{
+		"truncated": true,        // truncated: 切り詰められた
+		"size":      info.Size(), // size: 全体サイズ
+	}
+	return content, nil
+}
+
+// cutUTF8 cuts data to at most max bytes, backing up to a rune start when that keeps it valid UTF-8
+// cutUTF8: dataを最大maxバイトに切り詰める関数 (有効なUTF-8を保てる場合は文字の先頭まで戻る)
+// data must be longer than max. Binary data is cut at max exactly.
+// dataはmaxより長いこと。バイナリデータはちょうどmaxで切る
+func cutUTF8(data []byte, max int) []byte {
+	cut := max
+	for cut > 0 && max-cut < utf8.UTFMax && !utf8.RuneStart(data[cut]) {
+		cut-- // back up to a rune start: 文字の先頭まで戻る
+	}
+	if utf8.Valid(data[:cut]) {
+		return data[:cut]
+	}
+	return data[:max]
+}
+
+// ReadRange seeks to r.Offset and reads at most r.Length bytes
+// ReadRange: r.Offsetへシークし、最大r.Lengthバイトを読み取る関数
+// The served range and total size are reported in the content's _meta.
+// 実際に返した範囲と全体サイズはコンテンツの_metaで報告する
+func (p FileProvider) ReadRange(ctx context.Context, uri string, r ReadRange) (Content, error) {
+	f, err := p.open(ctx, uri)
+	if err != nil {
+		return Content{}, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return Content{}, fmt.Errorf("stat %s: %w", uri, err)
+	}
+
+	// Clamp to the file size: ファイルサイズに収める
+	// clamp: 範囲内に収める
+	size := info.Size()
+	offset := min(r.Offset, size)
+	length := size - offset
+	if r.Length > 0 && r.Length < length {
+		length = r.Length
+	}
+
+	data := make([]byte, length)
+	if _, err := f.ReadAt(data, offset); err != nil && err != io.EOF {
+		return Content{}, fmt.Errorf("read %s: %w", uri, err)
+	}
+
+	content := fileContent(f.Name(), data)
+	content.Meta = map[string]interface{}{
+		"range": map[string]interface{}{"offset": offset, "length": length}, // range: 範囲
+		"size":  size,                                                       // size: 全体サイズ
+	}
+	return content, nil
+}
+
+// open resolves uri inside Root and opens the file
+// open: uriをRoot内で解決してファイルを開く関数
+func (p FileProvider) open(ctx context.Context, uri string) (*os.File, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidURI, err)
+	}
+
+	// Security: os.Root refuses paths that escape the sandbox
+	// セキュリティ: os.Rootはサンドボックス外へのパスを拒否する
+	root, err := os.OpenRoot(p.Root)
+	if err != nil {
+		return nil, fmt.Errorf("open root: %w", err)
+	}
+	defer root.Close()
+
+	name := strings.TrimPrefix(path.Clean("/"+u.Path), "/")
+	if name == "" {
+		name = "."
+	}
+	f, err := root.Open(name)
+	return f, notFound(err)
+}
+
+// FSProvider serves resources from an fs.FS, such as an embed.FS compiled into the binary
+// FSProvider: バイナリに埋め込んだembed.FSなどのfs.FSからリソースを提供するプロバイダー
+// A URI maps to the path formed by its host and path, with Prefix removed:
+// with Prefix "static", file:///static/docs/a.md reads docs/a.md from FS.
+// URIはホストとパスを繋げたパスからPrefixを除いたパスに対応する。
+// Prefixが"static"の場合、file:///static/docs/a.mdはFSのdocs/a.mdを読み取る
+// Register it with RegisterResourceProvider so it is consulted before the OS file system.
+// OSのファイルシステムより先に参照されるよう、RegisterResourceProviderで登録する
+type FSProvider struct {
+	FS     fs.FS  // fs: file system to read from (読み取り元のファイルシステム)
+	Scheme string // scheme: URI scheme served, "file" when empty (提供するURIスキーム、空なら"file")
+	Prefix string // prefix: leading path segments that select FS, "" for all (FSを選ぶ先頭のパス、""なら全て)
+}
+
+func (p FSProvider) CanHandle(uri string) bool {
+	scheme := p.Scheme
+	if scheme == "" {
+		scheme = "file"
+	}
+	if !hasScheme(uri, scheme) {
+		return false
+	}
+	_, err := p.path(uri)
+	return err == nil
+}
+
+func (p FSProvider) Read(ctx context.Context, uri string) (Content, error) {
+	if err := ctx.Err(); err != nil {
+		return Content{}, err
+	}
+	name, err := p.path(uri)
+	if err != nil {
+		return Content{}, err
+	}
+	data, err := fs.ReadFile(p.FS, name)
+	if err != nil {
+		return Content{}, notFound(fmt.Errorf("read %s: %w", uri, err))
+	}
+	return fileContent(name, data), nil
+}
+
+// path maps uri to a path inside FS
+// path: uriをFS内のパスに対応付ける関数
+func (p FSProvider) path(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", errInvalidURI, err)
+	}
+	name := strings.TrimPrefix(path.Clean("/"+u.Host+"/"+u.Path), "/")
+	if prefix := strings.Trim(p.Prefix, "/"); prefix != "" {
+		rest, ok := strings.CutPrefix(name, prefix)
+		if !ok || (rest != "" && rest[0] != '/') {
+			return "", fmt.Errorf("%w: %s is outside %s", errInvalidURI, uri, prefix)
+		}
+		name = strings.TrimPrefix(rest, "/")
+	}
+	if name == "" {
+		name = "."
+	}
+	if !fs.ValidPath(name) {
+		return "", fmt.Errorf("%w: %s", errInvalidURI, uri)
+	}
+	return name, nil
+}
+
+// fileContent builds resource content for file data, choosing text or blob by type
+// fileContent: ファイルデータからリソース内容を生成し、種類に応じてtextかblobを選ぶ関数
+func fileContent(name string, data []byte) Content {
+	mediaType := mime.TypeByExtension(filepath.Ext(name)) // extension: 拡張子
+	if mediaType == "" {
+		mediaType = http.DetectContentType(data) // detect: 検出する
+	}
+	return encodeContent(mediaType, data)
+}
+
+// encodeContent stores data as text when it is textual UTF-8, otherwise as a base64 blob
+// encodeContent: テキスト系のUTF-8ならtextとして、それ以外はbase64のblobとしてdataを格納する関数
+func encodeContent(mediaType string, data []byte) Content {
+	if parsed, _, err := mime.ParseMediaType(mediaType); err == nil {
+		mediaType = parsed
+	}
+
+	content := Content{MimeType: mediaType}
+	if isTextMimeType(mediaType) && utf8.Valid(data) {
+		content.Text = string(data)
+	} else {
+		content.Blob = base64.StdEncoding.EncodeToString(data)
+	}
+	return content
+}
+
+// defaultHTTPSMaxBytes caps the body size HTTPSProvider reads (10MB)
+// defaultHTTPSMaxBytes: HTTPSProviderが読み取るボディサイズの上限 (10MB)
+const defaultHTTPSMaxBytes = 10 << 20
+
+// defaultHTTPSAttempts and defaultHTTPSBackoff shape HTTPSProvider's retries
+// defaultHTTPSAttempts / defaultHTTPSBackoff: HTTPSProviderのリトライの既定値
+const (
+	defaultHTTPSAttempts = 3
+	defaultHTTPSBackoff  = 100 * time.Millisecond
+)
+
+// defaultETagEntries bounds the built-in HTTPS provider's ETag cache
+// defaultETagEntries: 組み込みHTTPSプロバイダーのETagキャッシュの上限
+const defaultETagEntries = 256
+
+// HTTPSProvider is the built-in provider for https:// URIs
+// HTTPSProvider: https:// URIの組み込みプロバイダー
+// Fetches are bound to the request context, so cancellation and deadlines abort them.
+// 取得はリクエストのコンテキストに結び付くため、キャンセルや期限で中断される
+// With ETags set, responses carrying an ETag are remembered and revalidated with
+// If-None-Match; a 304 returns the remembered content with _meta.notModified.
+// ETagsを設定すると、ETag付きのレスポンスを記憶してIf-None-Matchで再検証し、
+// 304の場合は記憶した内容を_meta.notModified付きで返す
+// revalidated: 再検証された
+// Transient failures (connection errors and 502/503/504) are retried with exponential
+// backoff and jitter; other statuses fail at once.
+// 一時的な失敗 (接続エラーと502/503/504) はジッター付きの指数バックオフでリトライし、その他のステータスは即座に失敗する
+// transient: 一時的な
+// Without a Client, connections to private, loopback and link-local addresses are
+// refused (see SafeHTTPClient); a Client set explicitly is used as is.
+// Clientが無い場合、プライベート・ループバック・リンクローカルのアドレスへの接続は拒否される
+// (SafeHTTPClientを参照)。明示的に設定したClientはそのまま使われる
+type HTTPSProvider struct {
+	Client      *http.Client  // client: HTTP client, a SafeHTTPClient when nil (HTTPクライアント、nilならSafeHTTPClient)
+	MaxBytes    int64         // maxBytes: body limit, 10MB when zero (ボディ上限、0なら10MB)
+	ETags       Cache         // etags: bounded ETag store, nil to disable (上限付きETagストア、nilなら無効)
+	MaxAttempts int           // maxAttempts: tries per read, 3 when zero, 1 to disable retries (読み取り毎の試行回数、0なら3、1でリトライ無効)
+	Backoff     time.Duration // backoff: first retry delay, doubled each time, 100ms when zero (最初のリトライ待機、毎回倍増、0なら100ms)
+}
+
+// etagEntry is a remembered response for conditional requests
+// etagEntry: 条件付きリクエストのために記憶したレスポンス
+type etagEntry struct {
+	etag    string  // etag: entity tag (エンティティタグ)
+	content Content // content: decoded body (デコード済みボディ)
+}
+
+func (HTTPSProvider) CanHandle(uri string) bool {
+	return hasScheme(uri, "https")
+}
+
+func (p HTTPSProvider) Read(ctx context.Context, uri string) (Content, error) {
+	// HTTP request: HTTPリクエスト
+	// request: リクエスト、要求
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return Content{}, fmt.Errorf("%w: %v", errInvalidURI, err)
+	}
+	client := p.Client
+	if client == nil {
+		client = defaultSafeClient // SSRF guard: SSRF対策
+	}
+
+	// Conditional request: 条件付きリクエスト
+	var cached *etagEntry
+	if p.ETags != nil {
+		if v, ok := p.ETags.Get(uri); ok {
+			cached = v.(*etagEntry)
+			req.Header.Set("If-None-Match", cached.etag)
+		}
+	}
+
+	resp, err := p.do(client, req)
+	if err != nil {
+		return Content{}, fmt.Errorf("fetch %s: %w", uri, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		content := cached.content
+		content.Meta = map[string]interface{}{"notModified": true} // notModified: 未変更
+		return content, nil
+	}
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone {
+		return Content{}, WithRPCCode(fmt.Errorf("fetch %s: %s", uri, resp.Status), CodeResourceNotFound)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return Content{}, fmt.Errorf("fetch %s: unexpected status %s", uri, resp.Status)
+	}
+
+	// Security: 大きすぎるボディを拒否
+	limit := p.MaxBytes
+	if limit <= 0 {
+		limit = defaultHTTPSMaxBytes
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return Content{}, fmt.Errorf("fetch %s: %w", uri, err)
+	}
+	if int64(len(data)) > limit {
+		return Content{}, fmt.Errorf("fetch %s: body exceeds %d bytes", uri, limit)
+	}
+
+	mediaType := resp.Header.Get("Content-Type")
+	if mediaType == "" {
+		mediaType = http.DetectContentType(data)
+	}
+	content := encodeContent(mediaType, data)
+	if etag := resp.Header.Get("ETag"); etag != "" && p.ETags != nil {
+		p.ETags.Set(uri, &etagEntry{etag: etag, content: content})
+	}
+	return content, nil
+}
+
+// do sends req, retrying transient failures until the attempts run out or ctx ends
+// do: reqを送信し、試行回数が尽きるかctxが終了するまで一時的な失敗をリトライする関数
+// After the last attempt the final error or response is returned as is.
+// 最後の試行の後は、最終的なエラーまたはレスポンスをそのまま返す
+func (p HTTPSProvider) do(client *http.Client, req *http.Request) (*http.Response, error) {
+	attempts := p.MaxAttempts
+	if attempts <= 0 {
+		attempts = defaultHTTPSAttempts
+	}
+	backoff := p.Backoff
+	if backoff <= 0 {
+		backoff = defaultHTTPSBackoff
+	}
+
+	ctx := req.Context()
+	for attempt := 1; ; attempt++ {
+		resp, err := client.Do(req)
+		if err == nil && !retryableStatus(resp.StatusCode) {
+			return resp, nil
+		}
+		if attempt >= attempts || ctx.Err() != nil || errors.Is(err, errBlockedAddress) {
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body) // reuse the connection: 接続を再利用
+			resp.Body.Close()
+		}
+
+		// Exponential backoff with jitter: ジッター付きの指数バックオフ
+		delay := backoff << (attempt - 1)
+		delay = delay/2 + rand.N(delay/2+1)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// retryableStatus reports whether status signals a transient upstream failure
+// retryableStatus: statusが上流の一時的な失敗を示すかを判定する関数
+func retryableStatus(status int) bool {
+	switch status {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// DataProvider is the built-in provider for data: URIs
+// DataProvider: data: URIの組み込みプロバイダー
+type DataProvider struct{}
+
+func (DataProvider) CanHandle(uri string) bool {
+	return hasScheme(uri, "data")
+}
+
+func (DataProvider) Read(ctx context.Context, uri string) (Content, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return Content{}, fmt.Errorf("%w: %v", errInvalidURI, err)
+	}
+	return decodeDataURI(u)
+}
+
+// decodeDataURI decodes a parsed data: URI (RFC 2397)
+// decodeDataURI: 解析済みのdata: URI (RFC 2397) をデコードする関数
+// Textual payloads are returned as text, everything else as a base64 blob.
+// テキスト系のペイロードはtextとして、それ以外はbase64のblobとして返す
+func decodeDataURI(u *url.URL) (Content, error) {
+	// data:[<mediatype>][;base64],<data>
+	raw := u.Opaque
+	if u.Fragment != "" {
+		raw += "#" + u.EscapedFragment() // fragment: フラグメント
+	}
+	header, payload, ok := strings.Cut(raw, ",")
+	if !ok {
+		return Content{}, fmt.Errorf("%w: data URI has no comma", errInvalidURI)
+	}
+
+	// Parse media type and base64 flag: メディアタイプとbase64フラグを解析
+	params := strings.Split(header, ";")
+	isBase64 := false
+	if n := len(params); n > 1 && strings.EqualFold(params[n-1], "base64") {
+		isBase64 = true
+		params = params[:n-1]
+	}
+	mediaType := "text/plain" // default: RFC 2397のデフォルト
+	if params[0] != "" || len(params) > 1 {
+		parsed, _, err := mime.ParseMediaType(strings.Join(params, ";"))
+		if err != nil {
+			return Content{}, fmt.Errorf("%w: bad media type: %v", errInvalidURI, err)
+		}
+		mediaType = parsed
+	}
+
+	// Decode payload: ペイロードをデコード
+	unescaped, err := url.PathUnescape(payload)
+	if err != nil {
+		return Content{}, fmt.Errorf("%w: bad percent-encoding: %v", errInvalidURI, err)
+	}
+	data := []byte(unescaped)
+	if isBase64 {
+		data, err = base64.StdEncoding.DecodeString(unescaped)
+		if err != nil {
+			return Content{}, fmt.Errorf("%w: bad base64 payload: %v", errInvalidURI, err)
+		}
+	}
+
+	content := Content{URI: u.String(), MimeType: mediaType}
+	if isTextMimeType(mediaType) && utf8.Valid(data) {
+		content.Text = string(data)
+	} else {
+		content.Blob = base64.StdEncoding.EncodeToString(data)
+	}
+	return content, nil
+}
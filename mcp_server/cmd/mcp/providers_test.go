@@ -0,0 +1,491 @@
+package main
+
+import (
+	"context"           // context: provider signature (プロバイダーのシグネチャ)
+	"errors"            // errors: error inspection (エラー検査)
+	"net/http"          // net/http: test HTTPS handlers (テスト用HTTPSハンドラー)
+	"net/http/httptest" // net/http/httptest: local HTTPS server (ローカルのHTTPSサーバー)
+	"net/url"           // net/url: parsing test URIs (テスト用URIの解析)
+	"os"                // os: files under the root directory (ルートディレクトリ下のファイル)
+	"path/filepath"     // path/filepath: building test file paths (テスト用ファイルパスの組み立て)
+	"strings"           // strings: prefix matching (接頭辞の照合)
+	"sync/atomic"       // sync/atomic: counting server requests (サーバーへのリクエストの計数)
+	"testing"           // testing: test framework (テストフレームワーク)
+	"testing/fstest"    // testing/fstest: in-memory file systems (メモリ内のファイルシステム)
+	"time"              // time: request deadlines (リクエストの期限)
+)
+
+// prefixProvider serves every URI starting with prefix as text naming the provider
+// prefixProvider: prefixで始まる全てのURIを、プロバイダー名を示すテキストとして提供する構造体
+type prefixProvider struct {
+	prefix string // prefix: handled URI prefix (扱うURIの接頭辞)
+	name   string // name: returned as the text (テキストとして返す)
+}
+
+func (p prefixProvider) CanHandle(uri string) bool {
+	return strings.HasPrefix(uri, p.prefix)
+}
+
+func (p prefixProvider) Read(ctx context.Context, uri string) (Content, error) {
+	return Content{URI: uri, MimeType: "text/plain", Text: p.name}, nil
+}
+
+// TestRegisterResourceProvider checks that custom providers serve new schemes and are
+// consulted in registration order, before the built-in providers
+// TestRegisterResourceProvider: カスタムプロバイダーが新しいスキームを提供し、
+// 組み込みプロバイダーより先に登録順で参照されることを確認するテスト
+func TestRegisterResourceProvider(t *testing.T) {
+	s := newEchoServer()
+	c := NewClient(s)
+	if _, err := c.ReadResource("vault://k"); err == nil {
+		t.Fatal("vault: served before registration")
+	}
+
+	s.RegisterResourceProvider(prefixProvider{prefix: "vault:", name: "first"})
+	s.RegisterResourceProvider(prefixProvider{prefix: "vault:", name: "second"})
+	s.RegisterResourceProvider(prefixProvider{prefix: "data:", name: "custom"})
+	for uri, want := range map[string]string{
+		"vault://k":  "first",  // registration order: 登録順
+		"data:,text": "custom", // before the built-in: 組み込みより先
+	} {
+		result, err := c.ReadResource(uri)
+		if err != nil {
+			t.Fatalf("%s: %v", uri, err)
+		}
+		if got := result.Contents[0].Text; got != want {
+			t.Fatalf("%s: got %q, want %q", uri, got, want)
+		}
+	}
+
+	if !(FileProvider{}).CanHandle("FILE:///x") || (FileProvider{}).CanHandle("https://x") {
+		t.Fatal("FileProvider must match the file scheme case-insensitively and nothing else")
+	}
+}
+
+// TestDecodeDataURI checks that data: URIs decode to text or a base64 blob by media type
+// TestDecodeDataURI: data: URIがメディアタイプに応じてテキストまたはbase64のblobにデコードされることを確認するテスト
+func TestDecodeDataURI(t *testing.T) {
+	tests := []struct {
+		uri      string // uri: 入力のURI
+		mimeType string // mimeType: 期待するメディアタイプ
+		text     string // text: 期待するテキスト
+		blob     string // blob: 期待するblob
+	}{
+		{uri: "data:,plain", mimeType: "text/plain", text: "plain"},
+		{uri: "data:text/plain,hello%20world", mimeType: "text/plain", text: "hello world"},
+		{uri: "data:text/plain;base64,aGk=", mimeType: "text/plain", text: "hi"},
+		{uri: "data:application/json;charset=utf-8,%7B%7D", mimeType: "application/json", text: "{}"},
+		{uri: "data:image/png;base64,iVBORw0KGgo=", mimeType: "image/png", blob: "iVBORw0KGgo="},
+		{uri: "data:text/plain;base64,/w==", mimeType: "text/plain", blob: "/w=="}, // invalid UTF-8: 無効なUTF-8
+	}
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			u, err := url.Parse(tt.uri)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := decodeDataURI(u)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.MimeType != tt.mimeType || got.Text != tt.text || got.Blob != tt.blob {
+				t.Fatalf("got %+v, want mimeType %q text %q blob %q", got, tt.mimeType, tt.text, tt.blob)
+			}
+		})
+	}
+}
+
+// TestDecodeDataURIInvalid checks that malformed data: URIs are rejected as invalid
+// TestDecodeDataURIInvalid: 不正なdata: URIが無効として拒否されることを確認するテスト
+func TestDecodeDataURIInvalid(t *testing.T) {
+	for _, uri := range []string{
+		"data:text/plain",              // no comma: カンマが無い
+		"data:image/png;base64,@@@",    // bad base64: 不正なbase64
+		"data:text/plain,%zz",          // bad percent-encoding: 不正なパーセントエンコーディング
+		"data:text/plain;=bad,payload", // bad media type: 不正なメディアタイプ
+	} {
+		t.Run(uri, func(t *testing.T) {
+			u, err := url.Parse(uri)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := decodeDataURI(u); !errors.Is(err, errInvalidURI) {
+				t.Fatalf("got %v, want errInvalidURI", err)
+			}
+		})
+	}
+
+	_, err := NewClient(newEchoServer()).ReadResource("data:text/plain")
+	wantRPCCode(t, err, -32602)
+}
+
+// TestFileProviderReadRange checks that _meta.range reads a clamped slice of a file,
+// reports the served range and size, and rejects a negative offset
+// TestFileProviderReadRange: _meta.rangeがファイルの範囲内に収めた一部を読み取り、返した範囲と
+// サイズを報告し、負のオフセットを拒否することを確認するテスト
+func TestFileProviderReadRange(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "f.txt"), []byte("0123456789"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := NewMCPServer(WithRootDir(dir))
+	read := func(r map[string]interface{}) *JSONRPCResponse {
+		return s.HandleRequest(context.Background(), &JSONRPCRequest{
+			JSONRPC: "2.0",
+			ID:      IntID(1),
+			Method:  "resources/read",
+			Params:  map[string]interface{}{"uri": "file:///f.txt", "_meta": map[string]interface{}{"range": r}},
+		})
+	}
+
+	for _, tt := range []struct {
+		r              map[string]interface{} // r: requested range (要求する範囲)
+		text           string                 // text: expected text (期待するテキスト)
+		offset, length int64                  // offset, length: expected served range (期待する返却範囲)
+	}{
+		{r: map[string]interface{}{"offset": 3.0, "length": 4.0}, text: "3456", offset: 3, length: 4},
+		{r: map[string]interface{}{"offset": 8.0, "length": 10.0}, text: "89", offset: 8, length: 2},
+		{r: map[string]interface{}{"offset": 30.0}, text: "", offset: 10, length: 0},
+	} {
+		resp := read(tt.r)
+		if resp.Error != nil {
+			t.Fatalf("%v: %v", tt.r, resp.Error)
+		}
+		content := resp.Result.(*ReadResourceResult).Contents[0]
+		served, _ := content.Meta["range"].(map[string]interface{})
+		if content.Text != tt.text || content.Meta["size"] != int64(10) ||
+			served["offset"] != tt.offset || served["length"] != tt.length {
+			t.Fatalf("%v: got text %q, meta %v", tt.r, content.Text, content.Meta)
+		}
+	}
+
+	if resp := read(map[string]interface{}{"offset": -1.0}); resp.Error == nil || resp.Error.Code != -32602 {
+		t.Fatalf("negative offset: got %+v, want -32602", resp.Error)
+	}
+}
+
+// TestFileProviderMaxBytes checks that a file longer than MaxBytes is read only up to
+// it, cut on a rune start when it is text, and flagged with _meta.truncated and size
+// TestFileProviderMaxBytes: MaxBytesより長いファイルがそこまでだけ読み取られ、テキストなら
+// 文字の先頭で切られ、_meta.truncatedとsizeが付くことを確認するテスト
+func TestFileProviderMaxBytes(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "f.txt"), []byte("aébc"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "f.bin"), []byte{0, 1, 2, 3}, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	p := FileProvider{Root: dir, MaxBytes: 2}
+
+	text, err := p.Read(context.Background(), "file:///f.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text.Text != "a" || text.Meta["truncated"] != true || text.Meta["size"] != int64(5) {
+		t.Fatalf("text: got %q, meta %v", text.Text, text.Meta)
+	}
+	blob, err := p.Read(context.Background(), "file:///f.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if blob.Blob != "AAE=" || blob.Meta["truncated"] != true || blob.Meta["size"] != int64(4) {
+		t.Fatalf("blob: got %q, meta %v", blob.Blob, blob.Meta)
+	}
+	if whole, err := (FileProvider{Root: dir, MaxBytes: 5}).Read(context.Background(), "file:///f.txt"); err != nil || whole.Text != "aébc" || whole.Meta != nil {
+		t.Fatalf("within the limit: got %+v, %v", whole, err)
+	}
+}
+
+// TestFileProviderSandbox checks that neither dot segments nor symlinks escape the root directory
+// TestFileProviderSandbox: ドットセグメントもシンボリックリンクもルートディレクトリの外へ出られないことを確認するテスト
+func TestFileProviderSandbox(t *testing.T) {
+	dir := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "secret.txt")
+	if err := os.WriteFile(outside, []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(dir, "link.txt")); err != nil {
+		t.Skip("symlinks unavailable:", err)
+	}
+	c := NewClient(NewMCPServer(WithRootDir(dir)))
+	for _, uri := range []string{"file:///link.txt", "file:///../" + filepath.Base(filepath.Dir(outside)) + "/secret.txt"} {
+		if result, err := c.ReadResource(uri); err == nil {
+			t.Fatalf("%s: read %q outside the root", uri, result.Contents[0].Text)
+		}
+	}
+}
+
+// TestResourceReadContext checks that resources/read passes the request context to
+// providers, so a slow HTTPS read ends at the caller's deadline and a cancelled
+// file read fails without touching the file
+// TestResourceReadContext: resources/readがリクエストのコンテキストをプロバイダーへ渡し、
+// 遅いHTTPSの読み取りが呼び出し元の期限で終わり、キャンセル済みのファイル読み取りが
+// ファイルに触れずに失敗することを確認するテスト
+func TestResourceReadContext(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ok" {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			w.Write([]byte("hello"))
+			return
+		}
+		select { // hang until the client gives up: クライアントが諦めるまで待つ
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	s := NewMCPServer(WithRootDir(t.TempDir()))
+	s.RegisterResourceProvider(HTTPSProvider{Client: srv.Client()})
+	read := func(ctx context.Context, uri string) *JSONRPCResponse {
+		return s.HandleRequest(ctx, &JSONRPCRequest{JSONRPC: "2.0", ID: IntID(1), Method: "resources/read", Params: map[string]interface{}{"uri": uri}})
+	}
+
+	resp := read(context.Background(), srv.URL+"/ok")
+	if resp.Error != nil {
+		t.Fatal(resp.Error)
+	}
+	if got := resp.Result.(*ReadResourceResult).Contents[0]; got.Text != "hello" || got.MimeType != "text/plain" {
+		t.Fatalf("got %+v, want hello as text/plain", got)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if resp := read(ctx, srv.URL+"/slow"); resp.Error == nil {
+		t.Fatal("slow read succeeded past the deadline")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("slow read ended after %s, want about 50ms", elapsed)
+	}
+
+	cancelled, cancelNow := context.WithCancel(context.Background())
+	cancelNow()
+	if _, err := (FileProvider{Root: t.TempDir()}).Read(cancelled, "file:///a.txt"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled file read: got %v, want context.Canceled", err)
+	}
+}
+
+// TestHTTPSProviderETag checks that a remembered ETag is sent as If-None-Match and
+// a 304 answer is served from the remembered content with _meta.notModified
+// TestHTTPSProviderETag: 記憶したETagがIf-None-Matchとして送られ、304の応答には
+// 記憶した内容が_meta.notModified付きで返されることを確認するテスト
+func TestHTTPSProviderETag(t *testing.T) {
+	var notModified atomic.Int32
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("body"))
+	}))
+	defer srv.Close()
+	p := HTTPSProvider{Client: srv.Client(), ETags: NewLRUCache(2, 0)}
+
+	first, err := p.Read(context.Background(), srv.URL+"/x")
+	if err != nil || first.Text != "body" || first.Meta != nil {
+		t.Fatalf("first read: got %+v, %v", first, err)
+	}
+	second, err := p.Read(context.Background(), srv.URL+"/x")
+	if err != nil || second.Text != "body" || second.Meta["notModified"] != true || notModified.Load() != 1 {
+		t.Fatalf("second read: got %+v, %v after %d conditional hits", second, err, notModified.Load())
+	}
+
+	// Without an ETag store every read is unconditional: ETagストアが無ければ常に無条件で読む
+	p.ETags = nil
+	if third, err := p.Read(context.Background(), srv.URL+"/x"); err != nil || third.Meta != nil || notModified.Load() != 1 {
+		t.Fatalf("read without ETags: got %+v, %v", third, err)
+	}
+}
+
+// TestRegisterResourceLister checks that listed resources are merged with registered
+// ones, which win on a URI collision, paginated together, and that a lister error
+// fails the list with -32603
+// TestRegisterResourceLister: 列挙されたリソースが登録済みのもの (URI衝突時は登録済みが優先) と
+// 統合されて一緒にページ分割され、リスターのエラーで一覧が-32603になることを確認するテスト
+func TestRegisterResourceLister(t *testing.T) {
+	s := NewMCPServer(WithPageSize(2))
+	s.RegisterResource(Resource{URI: "data:,b", Name: "registered"})
+	s.RegisterResourceLister(func(ctx context.Context) ([]Resource, error) {
+		var listed []Resource
+		for _, c := range "abc" {
+			listed = append(listed, Resource{URI: "data:," + string(c), Name: "listed"})
+		}
+		return listed, nil
+	})
+	list := func(cursor string) *JSONRPCResponse {
+		params := map[string]interface{}{}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+		return s.HandleRequest(context.Background(), &JSONRPCRequest{JSONRPC: "2.0", ID: IntID(1), Method: "resources/list", Params: params})
+	}
+
+	var all []string
+	pages := 0
+	for cursor := ""; ; {
+		pages++
+		resp := list(cursor)
+		if resp.Error != nil {
+			t.Fatal(resp.Error)
+		}
+		result := resp.Result.(*ResourcesListResult)
+		for _, resource := range result.Resources {
+			all = append(all, resource.URI+"="+resource.Name)
+		}
+		if cursor = result.NextCursor; cursor == "" {
+			break
+		}
+	}
+	if got, want := strings.Join(all, " "), "data:,a=listed data:,b=registered data:,c=listed"; got != want || pages != 2 {
+		t.Fatalf("resources: got %q over %d pages, want %q over 2", got, pages, want)
+	}
+
+	s.RegisterResourceLister(func(ctx context.Context) ([]Resource, error) {
+		return nil, errors.New("backend down")
+	})
+	if resp := list(""); resp.Error == nil || resp.Error.Code != -32603 {
+		t.Fatalf("failing lister: got %+v", resp.Error)
+	}
+}
+
+// TestHTTPSProviderRetry checks that transient 5xx answers are retried until success,
+// that a 404 is not retried, that MaxAttempts caps the tries, and that the backoff
+// wait ends with the context
+// TestHTTPSProviderRetry: 一時的な5xx応答が成功するまでリトライされ、404はリトライされず、
+// MaxAttemptsが試行回数を制限し、待機がコンテキストの終了で打ち切られることを確認するテスト
+func TestHTTPSProviderRetry(t *testing.T) {
+	var hits, failures atomic.Int32
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if r.URL.Path == "/gone" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if failures.Add(-1) >= 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+	p := HTTPSProvider{Client: srv.Client(), Backoff: 5 * time.Millisecond}
+	read := func(ctx context.Context, p HTTPSProvider, path string, fail int32) (Content, error) {
+		hits.Store(0)
+		failures.Store(fail)
+		return p.Read(ctx, srv.URL+path)
+	}
+
+	if content, err := read(context.Background(), p, "/x", 2); err != nil || content.Text != "ok" || hits.Load() != 3 {
+		t.Fatalf("after two 503s: got %+v, %v in %d tries", content, err, hits.Load())
+	}
+	if _, err := read(context.Background(), p, "/gone", 0); err == nil || hits.Load() != 1 {
+		t.Fatalf("404: got %v in %d tries, want an error in 1", err, hits.Load())
+	}
+	p.MaxAttempts = 1
+	if _, err := read(context.Background(), p, "/x", 1); err == nil || hits.Load() != 1 {
+		t.Fatalf("MaxAttempts 1: got %v in %d tries, want an error in 1", err, hits.Load())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	slow := HTTPSProvider{Client: srv.Client(), Backoff: time.Second}
+	if _, err := read(ctx, slow, "/x", 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("backoff past the deadline: got %v, want context.DeadlineExceeded", err)
+	}
+}
+
+// TestFSProvider checks that file URIs under the prefix are read from the fs.FS, that a
+// missing file is not found, that dot segments cannot leave the prefix, and that a
+// custom scheme is served
+// TestFSProvider: 接頭辞配下のfile URIがfs.FSから読まれ、存在しないファイルが見つからず、
+// ドットセグメントで接頭辞の外に出られず、独自のスキームが提供されることを確認するテスト
+func TestFSProvider(t *testing.T) {
+	files := fstest.MapFS{"docs/a.md": {Data: []byte("# hi")}}
+	s := NewMCPServer(WithRootDir(t.TempDir()))
+	s.RegisterResourceProvider(FSProvider{FS: files, Prefix: "static"})
+	s.RegisterResourceProvider(FSProvider{FS: files, Scheme: "embed"})
+	c := NewClient(s)
+
+	for _, uri := range []string{"file:///static/docs/a.md", "embed:///docs/a.md"} {
+		rr, err := c.ReadResource(uri)
+		if err != nil || rr.Contents[0].Text != "# hi" || rr.Contents[0].MimeType != "text/markdown" {
+			t.Errorf("%s: got %+v, %v", uri, rr, err)
+		}
+	}
+	_, err := c.ReadResource("file:///static/docs/missing.md")
+	wantRPCCode(t, err, CodeResourceNotFound)
+
+	p := FSProvider{FS: files, Prefix: "static"}
+	for uri, want := range map[string]bool{
+		"file:///static/docs/a.md":        true,
+		"file:///staticx/docs/a.md":       false,
+		"file:///static/../etc/passwd":    false,
+		"https://example.com/static/a.md": false,
+	} {
+		if got := p.CanHandle(uri); got != want {
+			t.Errorf("CanHandle(%s): got %v, want %v", uri, got, want)
+		}
+	}
+}
+
+// TestDefaultResourceProvider checks that the default provider reads URIs with
+// otherwise unknown schemes, whatever its CanHandle says, and that known schemes
+// are unaffected
+// TestDefaultResourceProvider: デフォルトのプロバイダーがCanHandleに関係なく未知のスキームの
+// URIを読み取り、既知のスキームには影響しないことを確認するテスト
+func TestDefaultResourceProvider(t *testing.T) {
+	c := NewClient(NewMCPServer())
+	_, err := c.ReadResource("custom://thing")
+	wantRPCCode(t, err, -32602)
+
+	c = NewClient(NewMCPServer(WithDefaultResourceProvider(prefixProvider{prefix: "never:", name: "fallback"})))
+	for uri, want := range map[string]string{
+		"custom://thing": "fallback",
+		"data:,x":        "x",
+	} {
+		read, err := c.ReadResource(uri)
+		if err != nil || read.Contents[0].Text != want {
+			t.Errorf("%s: got %+v, %v; want %s", uri, read, err, want)
+		}
+	}
+}
+
+// TestRegisterStaticResource checks that in-memory resources are listed and read from
+// their normalized URI as text or blob, ahead of providers for the same URI, and that
+// later changes to the registered slice are not seen
+// TestRegisterStaticResource: メモリ上のリソースが一覧に含まれ、正規化済みのURIからtextまたはblobとして
+// 同じURIのプロバイダーより先に読み取られ、登録したスライスの後の変更が見えないことを確認するテスト
+func TestRegisterStaticResource(t *testing.T) {
+	s := NewMCPServer()
+	s.RegisterResourceProvider(prefixProvider{prefix: "mem:", name: "provider"})
+	text := []byte("héllo")
+	s.RegisterStaticResource("mem://notes/./hello.txt", "hello", "text/plain; charset=utf-8", text)
+	s.RegisterStaticResource("mem://bin", "bin", "", []byte{0, 1, 2, 0xff})
+	text[0] = 'j'
+	c := NewClient(s)
+
+	resources, err := c.ListResources()
+	if err != nil || len(resources) != 2 {
+		t.Fatalf("list: got %+v, %v", resources, err)
+	}
+	for uri, want := range map[string]Content{
+		"mem://notes/hello.txt": {URI: "mem://notes/hello.txt", MimeType: "text/plain", Text: "héllo"},
+		"mem://bin":             {URI: "mem://bin", MimeType: "application/octet-stream", Blob: "AAEC/w=="},
+		"mem://other":           {URI: "mem://other", MimeType: "text/plain", Text: "provider"},
+	} {
+		read, err := c.ReadResource(uri)
+		if err != nil || len(read.Contents) != 1 {
+			t.Fatalf("%s: got %+v, %v", uri, read, err)
+		}
+		if got := read.Contents[0]; got.URI != want.URI || got.MimeType != want.MimeType || got.Text != want.Text || got.Blob != want.Blob {
+			t.Errorf("%s: got %+v, want %+v", uri, got, want)
+		}
+	}
+}
@@ -0,0 +1,305 @@
+package main
+
+import (
+	"context" // context: routed call cancellation (転送する呼び出しのキャンセル)
+	"errors"  // errors: error inspection (エラーの検査)
+	"fmt"     // fmt: error wrapping (エラーのラップ)
+	"strings" // strings: name validation (名前の検証)
+	"sync"    // sync: guards routes (ルートの保護)
+)
+
+// AddDownstream exposes the tools and resources of the server behind client as this server's own
+// AddDownstream: clientの先にあるサーバーのツールとリソースをこのサーバーのものとして公開する関数
+// Tools are registered as prefix.name, and calls to them are routed to the downstream
+// server under their original name. Resources keep their URIs, gain a prefixed name,
+// and are read through the downstream. client should already be initialized.
+// Calls pass through a circuit breaker per downstream (see WithDownstreamBreaker).
+// Adding another client under the same prefix makes it a replica: a tool or resource
+// offered by several downstreams goes to a healthy one, round-robin, and fails over
+// to the next when the chosen one cannot be reached. The lists are snapshots, read
+// page by page. When a registration is refused, those already made are undone.
+// ツールはprefix.nameとして登録され、その呼び出しは元の名前で下流サーバーへ転送される。
+// リソースはURIを保ち、名前にprefixが付き、下流経由で読み取られる。clientは初期化済みであること。
+// 呼び出しは下流ごとのサーキットブレーカーを通る (WithDownstreamBreakerを参照)。
+// 同じprefixで別のclientを追加するとレプリカになり、複数の下流が提供するツールやリソースは
+// 正常な下流へラウンドロビンで振り分けられ、選んだ下流に到達できなければ次へフェイルオーバーする。
+// 一覧はページごとに読み取るスナップショットである。登録が拒否された場合、既に行った登録は取り消される
+// downstream: 下流、aggregate: 集約する、replica: 複製
+func (s *MCPServer) AddDownstream(prefix string, client *Client) error {
+	if prefix == "" || strings.Contains(prefix, ".") {
+		return fmt.Errorf("invalid downstream prefix %q", prefix)
+	}
+
+	client.SetLogger(s.logger.With("downstream", prefix))
+	tools, err := client.ListTools()
+	if err != nil {
+		return fmt.Errorf("list %s tools: %w", prefix, err)
+	}
+	resources, err := client.ListResources()
+	if err != nil {
+		return fmt.Errorf("list %s resources: %w", prefix, err)
+	}
+
+	d := &downstream{
+		prefix:  prefix,
+		client:  client,
+		breaker: newCircuitBreaker(s.breakerThreshold, s.breakerCooldown),
+	}
+
+	// One AddDownstream at a time: AddDownstreamは1つずつ実行
+	s.proxy.mu.Lock()
+	defer s.proxy.mu.Unlock()
+	if s.proxy.tools == nil {
+		s.proxy.tools = make(map[string]*route)
+		s.proxy.resources = &downstreamProvider{routes: make(map[string]*route)}
+		s.RegisterResourceProvider(s.proxy.resources)
+	}
+
+	// Undo steps, run in reverse when a registration is refused: 登録が拒否されたとき逆順に実行する取り消し手順
+	var undo []func()
+	rollback := func(err error) error {
+		for i := len(undo) - 1; i >= 0; i-- {
+			undo[i]()
+		}
+		return err
+	}
+
+	for _, tool := range tools {
+		name := prefix + "." + tool.Name // namespaced: 名前空間付き
+		if r, ok := s.proxy.tools[name]; ok {
+			r.add(d) // replica: レプリカ
+			undo = append(undo, func() { r.remove(d) })
+			continue
+		}
+		r := &route{downstreams: []*downstream{d}}
+		routed := Tool{
+			Name:         name,
+			Description:  tool.Description,
+			InputSchema:  tool.InputSchema,
+			OutputSchema: tool.OutputSchema,
+			Annotations:  tool.Annotations,
+			Handler:      routeTool(r, tool.Name),
+		}
+		if err := s.TryRegisterTool(routed); err != nil {
+			return rollback(err)
+		}
+		s.proxy.tools[name] = r
+		undo = append(undo, func() {
+			delete(s.proxy.tools, name)
+			s.UnregisterTool(name)
+		})
+	}
+
+	for _, resource := range resources {
+		uri := resource.URI
+		replica := s.proxy.resources.add(uri, d)
+		undo = append(undo, func() { s.proxy.resources.remove(uri, d) })
+		if replica {
+			continue // replica: レプリカ
+		}
+		resource.Name = prefix + "." + resource.Name
+		if err := s.TryRegisterResource(resource); err != nil {
+			return rollback(err)
+		}
+		undo = append(undo, func() {
+			s.mu.Lock()
+			delete(s.resources, uri)
+			s.mu.Unlock()
+		})
+	}
+	return nil
+}
+
+// proxy holds the routes to downstream servers
+// proxy: 下流サーバーへのルートを保持する構造体
+type proxy struct {
+	mu        sync.Mutex          // mu: serializes AddDownstream (AddDownstreamを直列化)
+	tools     map[string]*route   // tools: routes by prefixed tool name (接頭辞付きツール名ごとのルート)
+	resources *downstreamProvider // resources: routes by resource URI (リソースURIごとのルート)
+}
+
+// downstream is a server behind a client, guarded by a circuit breaker
+// downstream: サーキットブレーカーで保護された、クライアントの先にあるサーバー
+type downstream struct {
+	prefix  string          // prefix: name prefix of its tools (ツール名の接頭辞)
+	client  *Client         // client: downstream connection (下流への接続)
+	breaker *circuitBreaker // breaker: nil when disabled (無効ならnil)
+}
+
+// errUnavailable returns the error for a downstream whose breaker is open
+// errUnavailable: ブレーカーが開いている下流を表すエラーを返す関数
+func (d *downstream) errUnavailable() *JSONRPCError {
+	return &JSONRPCError{
+		Code:    -32000, // Server error (サーバーエラー)
+		Message: "downstream unavailable",
+		Data:    map[string]interface{}{"downstream": d.prefix},
+	}
+}
+
+// send sends a request to the downstream and records the outcome in its breaker
+// send: 下流へリクエストを送信し、結果をブレーカーに記録する関数
+// Transport failures count against the breaker; a JSON-RPC error means the
+// downstream is up and answering, so it counts as a success. A call canceled by
+// its caller says nothing about the downstream and is not counted.
+// トランスポートの失敗はブレーカーに数えられ、JSON-RPCエラーは下流が応答している
+// ことを示すため成功として扱う。呼び出し元がキャンセルした呼び出しは下流について何も示さないため数えない
+func (d *downstream) send(ctx context.Context, method string, params interface{}, result interface{}) error {
+	err := d.client.callContext(ctx, method, params, result)
+	var rpcErr *JSONRPCError
+	if errors.Is(err, context.Canceled) {
+		d.breaker.abandon()
+	} else {
+		d.breaker.record(err == nil || errors.As(err, &rpcErr))
+	}
+	return err
+}
+
+// route picks among the downstreams offering the same tool or resource
+// route: 同じツールやリソースを提供する下流の中から選ぶ構造体
+type route struct {
+	mu          sync.Mutex    // mu: guards the fields below (以下のフィールドを保護)
+	downstreams []*downstream // downstreams: replicas in the order added (追加順のレプリカ)
+	next        int           // next: round-robin position (ラウンドロビンの位置)
+}
+
+// add appends a replica to the route
+// add: ルートにレプリカを追加する関数
+func (r *route) add(d *downstream) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.downstreams = append(r.downstreams, d)
+}
+
+// remove drops a replica from the route and returns how many remain
+// remove: ルートからレプリカを取り除き、残りの数を返す関数
+func (r *route) remove(d *downstream) (left int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, have := range r.downstreams {
+		if have == d {
+			r.downstreams = append(r.downstreams[:i:i], r.downstreams[i+1:]...)
+			break
+		}
+	}
+	return len(r.downstreams)
+}
+
+// order returns the downstreams to try: healthy ones in round-robin order, then the rest
+// order: 試す順の下流を返す関数 (正常なものをラウンドロビン順に、その後に残り)
+// Unhealthy downstreams stay last so a call still fails fast when none is healthy.
+// 正常な下流が無い場合も即座に失敗するよう、異常な下流は最後に残す
+func (r *route) order() []*downstream {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var healthy, unhealthy []*downstream
+	for _, d := range r.downstreams {
+		if d.breaker.healthy() {
+			healthy = append(healthy, d)
+		} else {
+			unhealthy = append(unhealthy, d)
+		}
+	}
+	if len(healthy) > 1 {
+		start := r.next % len(healthy)
+		healthy = append(healthy[start:], healthy[:start]...)
+		r.next++
+	}
+	return append(healthy, unhealthy...)
+}
+
+// call sends a request to the first downstream in order that can take it
+// call: 順番に、受け付けられる最初の下流へリクエストを送信する関数
+// It fails over on an open breaker or a transport failure; a JSON-RPC error from a
+// downstream is its answer and is returned as is. The last error is returned when
+// every downstream fails.
+// ブレーカーが開いている場合やトランスポートの失敗ではフェイルオーバーし、
+// 下流からのJSON-RPCエラーはその応答としてそのまま返す。全ての下流が失敗した場合は最後のエラーを返す
+// fail over: フェイルオーバーする、代替に切り替える
+func (r *route) call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	var err error
+	for _, d := range r.order() {
+		if !d.breaker.allow() {
+			err = d.errUnavailable()
+			continue
+		}
+		err = d.send(ctx, method, params, result)
+		var rpcErr *JSONRPCError
+		if err == nil || errors.As(err, &rpcErr) || ctx.Err() != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// routeTool returns a handler that calls the named tool through r
+// routeTool: rを通して指定したツールを呼び出すハンドラーを返す関数
+// The downstream result is passed through unchanged.
+// 下流の結果はそのまま返す
+func routeTool(r *route, name string) ToolHandler {
+	return func(ctx context.Context, arguments interface{}) (map[string]interface{}, error) {
+		params := map[string]interface{}{
+			"name":      name,      // name: 下流でのツール名
+			"arguments": arguments, // arguments: 引数
+		}
+		var result map[string]interface{}
+		if err := r.call(ctx, "tools/call", params, &result); err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+}
+
+// downstreamProvider reads downstream servers' resources through their routes
+// downstreamProvider: 下流サーバーのリソースをルート経由で読み取るプロバイダー
+type downstreamProvider struct {
+	mu     sync.RWMutex      // mu: guards routes (routesを保護)
+	routes map[string]*route // routes: routes by resource URI (リソースURIごとのルート)
+}
+
+// add routes uri to d and reports whether uri was already routed
+// add: uriをdへルーティングし、既にルートがあったかを返す関数
+func (p *downstreamProvider) add(uri string, d *downstream) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if r, ok := p.routes[uri]; ok {
+		r.add(d)
+		return true
+	}
+	p.routes[uri] = &route{downstreams: []*downstream{d}}
+	return false
+}
+
+// remove drops d from the route for uri, and the route itself once it has no downstreams
+// remove: uriのルートからdを取り除き、下流が無くなればルート自体も削除する関数
+func (p *downstreamProvider) remove(uri string, d *downstream) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	r, ok := p.routes[uri]
+	if !ok {
+		return
+	}
+	if r.remove(d) == 0 {
+		delete(p.routes, uri)
+	}
+}
+
+func (p *downstreamProvider) CanHandle(uri string) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.routes[uri] != nil
+}
+
+func (p *downstreamProvider) Read(ctx context.Context, uri string) (Content, error) {
+	p.mu.RLock()
+	r := p.routes[uri]
+	p.mu.RUnlock()
+
+	var result ReadResourceResult
+	if err := r.call(ctx, "resources/read", map[string]interface{}{"uri": uri}, &result); err != nil {
+		return Content{}, err
+	}
+	if len(result.Contents) == 0 {
+		return Content{}, fmt.Errorf("read %s: downstream returned no contents", uri)
+	}
+	return result.Contents[0], nil
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context" // context: handler signatures (ハンドラーのシグネチャ)
+	"errors"  // errors: error matching (エラーの照合)
+	"net/url" // net/url: scheme handler URIs (スキームハンドラーのURI)
+	"sort"    // sort: stable tool name order (安定したツール名の順序)
+	"strings" // strings: joining tool names (ツール名の連結)
+	"testing" // testing: test framework (テストフレームワーク)
+	"time"    // time: breaker cooldown (ブレーカーのクールダウン)
+)
+
+// downstreamServer returns an initialized in-process client for a server with a
+// "search" tool answering with reply and a mem: resource under host
+// downstreamServer: replyで応答する"search"ツールとhost配下のmem:リソースを持つ
+// サーバーへの初期化済みのプロセス内クライアントを返す関数
+func downstreamServer(t *testing.T, host, reply string) *Client {
+	t.Helper()
+	s := NewMCPServer()
+	s.RegisterTool(Tool{Name: "search", Handler: func(ctx context.Context, arguments interface{}) (map[string]interface{}, error) {
+		return ToolResult(TextContent(reply)), nil
+	}})
+	s.RegisterResource(Resource{URI: "mem://" + host + "/readme", Name: "readme"})
+	s.RegisterSchemeHandler("mem", func(ctx context.Context, u *url.URL) (Content, error) {
+		return Content{URI: u.String(), MimeType: "text/plain", Text: reply}, nil
+	})
+	c := NewClient(s)
+	if _, err := c.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// TestAddDownstream checks that tools and resources of two downstreams are exposed
+// under their prefixes and that calls and reads are routed to the right one
+// TestAddDownstream: 2つの下流のツールとリソースが接頭辞付きで公開され、
+// 呼び出しと読み取りが正しい下流へ転送されることを確認するテスト
+func TestAddDownstream(t *testing.T) {
+	s := NewMCPServer()
+	for prefix, reply := range map[string]string{"github": "from github", "jira": "from jira"} {
+		if err := s.AddDownstream(prefix, downstreamServer(t, prefix, reply)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c := NewClient(s)
+
+	tools, err := c.ListTools()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, tool := range tools {
+		names = append(names, tool.Name)
+	}
+	sort.Strings(names)
+	if got := strings.Join(names, ","); got != "github.search,jira.search" {
+		t.Fatalf("tools: got %s", got)
+	}
+
+	for prefix, want := range map[string]string{"github": "from github", "jira": "from jira"} {
+		result, err := c.CallTool(prefix+".search", nil)
+		if err != nil || result.Content[0]["text"] != want {
+			t.Errorf("%s.search: %+v, %v", prefix, result, err)
+		}
+		read, err := c.ReadResource("mem://" + prefix + "/readme")
+		if err != nil || read.Contents[0].Text != want {
+			t.Errorf("%s readme: %+v, %v", prefix, read, err)
+		}
+	}
+
+	var rpcErr *JSONRPCError
+	if _, err := c.CallTool("search", nil); !errors.As(err, &rpcErr) || rpcErr.Code != -32602 {
+		t.Fatalf("unprefixed tool: got %v, want -32602", err)
+	}
+	for _, prefix := range []string{"", "a.b"} {
+		if err := s.AddDownstream(prefix, downstreamServer(t, "x", "x")); err == nil {
+			t.Errorf("prefix %q accepted", prefix)
+		}
+	}
+}
+
+// TestAddDownstreamPaginated checks that every page of a downstream's tools and
+// resources is exposed, not only the first
+// TestAddDownstreamPaginated: 下流のツールとリソースが最初のページだけでなく
+// 全ページ分公開されることを確認するテスト
+func TestAddDownstreamPaginated(t *testing.T) {
+	down := NewMCPServer(WithPageSize(2))
+	names := []string{"a", "b", "c", "d", "e"}
+	for _, name := range names {
+		down.RegisterTool(Tool{Name: name})
+		down.RegisterResource(Resource{URI: "mem://docs/" + name, Name: name})
+	}
+	client := NewClient(down)
+	if _, err := client.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewMCPServer()
+	if err := s.AddDownstream("docs", client); err != nil {
+		t.Fatal(err)
+	}
+	c := NewClient(s)
+	tools, err := c.ListTools()
+	if err != nil {
+		t.Fatal(err)
+	}
+	resources, err := c.ListResources()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tools) != len(names) || len(resources) != len(names) {
+		t.Fatalf("got %d tools and %d resources, want %d of each", len(tools), len(resources), len(names))
+	}
+}
+
+// TestAddDownstreamRollback checks that a refused resource registration undoes the
+// tools already registered, so a failed AddDownstream leaves nothing behind
+// TestAddDownstreamRollback: リソースの登録が拒否されると登録済みのツールも取り消され、
+// 失敗したAddDownstreamが何も残さないことを確認するテスト
+func TestAddDownstreamRollback(t *testing.T) {
+	s := NewMCPServer(WithMaxResources(1))
+	s.RegisterResource(Resource{URI: "data:,full", Name: "full"}) // fills the limit: 上限まで埋める
+	if err := s.AddDownstream("docs", downstreamServer(t, "docs", "x")); !errors.Is(err, ErrTooManyResources) {
+		t.Fatalf("got %v, want ErrTooManyResources", err)
+	}
+
+	c := NewClient(s)
+	if tools, err := c.ListTools(); err != nil || len(tools) != 0 {
+		t.Fatalf("tools left behind: %+v, %v", tools, err)
+	}
+	if _, err := c.ReadResource("mem://docs/readme"); err == nil {
+		t.Fatal("resource route left behind")
+	}
+	if len(s.proxy.tools) != 0 {
+		t.Fatalf("tool routes left behind: %v", s.proxy.tools)
+	}
+}
+
+// TestDownstreamReplicas checks that a tool offered by replicas under one prefix is
+// called round-robin and fails over to the other replica when one goes down
+// TestDownstreamReplicas: 同じ接頭辞のレプリカが提供するツールがラウンドロビンで呼び出され、
+// 片方が停止するともう片方へフェイルオーバーすることを確認するテスト
+func TestDownstreamReplicas(t *testing.T) {
+	s := NewMCPServer(WithDownstreamBreaker(1, time.Hour))
+	var kills []func()
+	for _, reply := range []string{"a", "b"} {
+		ds := NewMCPServer()
+		ds.RegisterTool(Tool{Name: "search", Handler: func(ctx context.Context, arguments interface{}) (map[string]interface{}, error) {
+			return ToolResult(TextContent(reply)), nil
+		}})
+		client, kill := streamDownstream(t, ds)
+		kills = append(kills, kill)
+		if err := s.AddDownstream("search", client); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c := NewClient(s)
+	call := func() string {
+		t.Helper()
+		result, err := c.CallTool("search.search", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result.Content[0]["text"].(string)
+	}
+
+	if got := call() + call() + call() + call(); got != "abab" {
+		t.Fatalf("round-robin: got %s, want abab", got)
+	}
+	kills[0]()
+	if got := call() + call() + call(); got != "bbb" {
+		t.Fatalf("failover: got %s, want bbb", got)
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context" // context: waiting for a worker (ワーカーの待機)
+	"errors"  // errors: error values (エラー値)
+	"runtime" // runtime: default worker count (デフォルトのワーカー数)
+	"sync"    // sync: guards the queue (キューの保護)
+)
+
+// OverflowPolicy decides what a request does when every worker is busy and the queue is full
+// OverflowPolicy: 全てのワーカーが使用中でキューが満杯のときのリクエストの挙動を決める型
+type OverflowPolicy int
+
+const (
+	OverflowBlock      OverflowPolicy = iota // block: wait for room until the request's deadline (リクエストの期限まで空きを待つ、デフォルト)
+	OverflowReject                           // reject: fail at once with -32000 Server busy (-32000 Server busyで即座に失敗する)
+	OverflowDropOldest                       // drop oldest: answer the oldest queued request with -32000 and queue this one (最も古い待機中のリクエストに-32000で応答し、これをキューに入れる)
+	OverflowSync                             // sync: a stream connection's read loop handles the request itself; elsewhere as reject (ストリーム接続の読み取りループが自ら処理する。それ以外ではrejectと同じ)
+)
+
+// ErrServerBusy reports that a request found no free worker and no room in the queue
+// ErrServerBusy: 空いたワーカーもキューの空きも無かったことを表すエラー
+var ErrServerBusy = errors.New("server busy")
+
+// requestPool limits how many requests are handled at once, queueing the rest
+// requestPool: 同時に処理するリクエスト数を制限し、残りをキューに入れる構造体
+// Requests run on their caller's goroutine once they hold a worker; a finishing
+// request hands its worker straight to the oldest queued one, so order is kept.
+// リクエストはワーカーを得ると呼び出し元のgoroutineで実行される。
+// 終了したリクエストはワーカーを最も古い待機中のリクエストへ直接渡すため、順序が保たれる
+type requestPool struct {
+	workers int            // workers: requests handled at once (同時に処理するリクエスト数)
+	size    int            // size: requests that may wait (待機できるリクエスト数)
+	policy  OverflowPolicy // policy: behavior when the queue is full (キューが満杯のときの挙動)
+
+	mu      sync.Mutex    // mu: guards the fields below (以下のフィールドを保護)
+	running int           // running: workers in use (使用中のワーカー数)
+	queue   []chan error  // queue: waiting requests, oldest first; nil hands over a worker, ErrServerBusy drops (待機中のリクエスト、古い順。nilでワーカーを渡し、ErrServerBusyで破棄)
+	changed chan struct{} // changed: closed when a worker or queue slot frees, nil when nobody blocks (ワーカーかキューの枠が空くと閉じる、誰も待たなければnil)
+}
+
+// requestPool returns the server's request pool, creating it with one worker per CPU
+// requestPool: サーバーのリクエストプールを返す関数 (無ければCPU毎に1ワーカーで作成)
+func (s *MCPServer) requestPool() *requestPool {
+	if s.pool == nil {
+		s.pool = &requestPool{workers: runtime.GOMAXPROCS(0)}
+	}
+	return s.pool
+}
+
+// enter waits for a worker according to the overflow policy and returns the function that frees it
+// enter: オーバーフローのポリシーに従ってワーカーを待ち、解放する関数を返す関数
+// A nil pool admits every request at once.
+// nilのプールは全てのリクエストを即座に受け入れる
+func (p *requestPool) enter(ctx context.Context) (leave func(), err error) {
+	if p == nil {
+		return func() {}, nil
+	}
+	for {
+		p.mu.Lock()
+		if p.running < p.workers && len(p.queue) == 0 {
+			p.running++
+			p.mu.Unlock()
+			return p.leave, nil
+		}
+		if len(p.queue) < p.size {
+			return p.wait(ctx) // unlocks: ロックを解放する
+		}
+		switch p.policy {
+		case OverflowReject, OverflowSync: // dispatchQueued decides on sync: syncはdispatchQueuedが判断する
+			p.mu.Unlock()
+			return nil, ErrServerBusy
+		case OverflowDropOldest:
+			if len(p.queue) == 0 {
+				p.mu.Unlock()
+				return nil, ErrServerBusy // nothing to drop: 破棄できるものが無い
+			}
+			p.queue[0] <- ErrServerBusy
+			p.queue = p.queue[1:]
+			return p.wait(ctx)
+		}
+
+		// Block until something frees: 何かが空くまでブロックする
+		if p.changed == nil {
+			p.changed = make(chan struct{})
+		}
+		changed := p.changed
+		p.mu.Unlock()
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// wait queues the caller and waits for a worker; the caller holds mu, which wait releases
+// wait: 呼び出し元をキューに入れてワーカーを待つ関数 (呼び出し側がmuを保持し、waitが解放する)
+func (p *requestPool) wait(ctx context.Context) (func(), error) {
+	ready := make(chan error, 1)
+	p.queue = append(p.queue, ready)
+	p.mu.Unlock()
+
+	select {
+	case err := <-ready:
+		if err != nil {
+			return nil, err
+		}
+		return p.leave, nil
+	case <-ctx.Done():
+	}
+
+	p.mu.Lock()
+	for i, other := range p.queue {
+		if other == ready {
+			p.queue = append(p.queue[:i:i], p.queue[i+1:]...)
+			p.signalLocked()
+			p.mu.Unlock()
+			return nil, ctx.Err()
+		}
+	}
+	p.mu.Unlock()
+	// Handed a worker or dropped meanwhile: その間にワーカーを渡されたか破棄された
+	if err := <-ready; err == nil {
+		p.leave()
+	}
+	return nil, ctx.Err()
+}
+
+// leave frees a worker, handing it to the oldest queued request if there is one
+// leave: ワーカーを解放し、待機中のリクエストがあれば最も古いものへ渡す関数
+func (p *requestPool) leave() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.queue) > 0 {
+		p.queue[0] <- nil
+		p.queue = p.queue[1:]
+	} else {
+		p.running--
+	}
+	p.signalLocked()
+}
+
+// signalLocked wakes requests blocked on a full queue; the caller holds mu
+// signalLocked: 満杯のキューでブロック中のリクエストを起こす関数 (呼び出し側がmuを保持)
+func (p *requestPool) signalLocked() {
+	if p.changed != nil {
+		close(p.changed)
+		p.changed = nil
+	}
+}
+
+// depth returns the number of queued requests
+// depth: キューで待機中のリクエスト数を返す関数
+func (p *requestPool) depth() int {
+	if p == nil {
+		return 0
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.queue)
+}
+
+// dispatchQueued dispatches req once it holds a worker
+// dispatchQueued: ワーカーを得てからreqを振り分ける関数
+// Requests that find no worker per the overflow policy, or whose context ends while
+// they wait, are answered with -32000. Under OverflowSync a request from a RunIO
+// connection is handled outside the pool instead: its read loop runs it and reads
+// nothing more meanwhile, so each connection adds at most one such request and the
+// connection limit bounds them. HTTP requests have no loop to slow down and get -32000.
+// オーバーフローのポリシーによりワーカーを得られなかったリクエスト、
+// または待機中にコンテキストが終了したリクエストには-32000で応答する。OverflowSyncでは
+// RunIOの接続からのリクエストは代わりにプールの外で処理される: 読み取りループが自ら実行し、
+// その間は何も読み取らないため、各接続が加えるのは最大1件で、接続数の上限がその数を制限する。
+// HTTPのリクエストには減速させるループが無いため-32000を返す
+func (s *MCPServer) dispatchQueued(ctx context.Context, req *JSONRPCRequest) *JSONRPCResponse {
+	leave, err := s.pool.enter(ctx)
+	if errors.Is(err, ErrServerBusy) && s.pool.policy == OverflowSync && s.connFor(ctx).serial {
+		return s.dispatch(ctx, req) // on the read loop: 読み取りループ上で
+	}
+	if err != nil {
+		return &JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error: &JSONRPCError{
+				Code:    -32000,        // Server error (サーバーエラー)
+				Message: "Server busy", // busy: 使用中
+				Data:    map[string]interface{}{"reason": err.Error()},
+			},
+		}
+	}
+	defer leave()
+	return s.dispatch(ctx, req)
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"           // context: request contexts (リクエストコンテキスト)
+	"net/http"          // net/http: request methods (リクエストメソッド)
+	"net/http/httptest" // net/http/httptest: in-memory HTTP round trips (メモリ内のHTTP往復)
+	"strings"           // strings: request and response bodies (リクエストとレスポンスの本文)
+	"testing"           // testing: test framework (テストフレームワーク)
+	"time"              // time: polling and deadlines (ポーリングと期限)
+)
+
+// eventually fails t unless cond becomes true within a second
+// eventually: condが1秒以内に真にならなければtを失敗させる関数
+func eventually(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met within a second")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// saturatedServer returns a server with one worker and one queue slot under policy,
+// plus a "wait" tool that blocks until release is closed and a "quick" tool that does not
+// saturatedServer: 1ワーカー・キュー1枠でpolicyを使うサーバーと、releaseが閉じるまで
+// ブロックする"wait"ツール、ブロックしない"quick"ツールを返す関数
+func saturatedServer(policy OverflowPolicy) (s *MCPServer, release chan struct{}) {
+	release = make(chan struct{})
+	s = NewMCPServer(WithWorkers(1), WithQueue(1, policy))
+	s.RegisterTool(Tool{Name: "wait", Handler: func(ctx context.Context, arguments interface{}) (map[string]interface{}, error) {
+		<-release
+		return ToolResult(TextContent("ok")), nil
+	}})
+	s.RegisterTool(Tool{Name: "quick", Handler: func(ctx context.Context, arguments interface{}) (map[string]interface{}, error) {
+		return ToolResult(TextContent("ok")), nil
+	}})
+	return s, release
+}
+
+// callAsync calls tool with id on a goroutine and delivers the response on the returned channel
+// callAsync: goroutineでidを付けてtoolを呼び出し、返すチャネルにレスポンスを届ける関数
+func callAsync(s *MCPServer, id int64, tool string) <-chan *JSONRPCResponse {
+	out := make(chan *JSONRPCResponse, 1)
+	go func() {
+		out <- s.HandleRequest(context.Background(), &JSONRPCRequest{
+			JSONRPC: "2.0",
+			ID:      IntID(id),
+			Method:  "tools/call",
+			Params:  map[string]interface{}{"name": tool},
+		})
+	}()
+	return out
+}
+
+// fill occupies the worker with one "wait" call and the queue slot with another
+// fill: 1件の"wait"呼び出しでワーカーを、もう1件でキューの枠を埋める関数
+func fill(t *testing.T, s *MCPServer) (running, queued <-chan *JSONRPCResponse) {
+	t.Helper()
+	running = callAsync(s, 1, "wait")
+	eventually(t, func() bool { s.pool.mu.Lock(); defer s.pool.mu.Unlock(); return s.pool.running == 1 })
+	queued = callAsync(s, 2, "wait")
+	eventually(t, func() bool { return s.pool.depth() == 1 })
+	return running, queued
+}
+
+// wantBusy fails t unless resp is the -32000 Server busy error
+// wantBusy: respが-32000 Server busyエラーでなければtを失敗させる関数
+func wantBusy(t *testing.T, resp *JSONRPCResponse) {
+	t.Helper()
+	if resp.Error == nil || resp.Error.Code != -32000 || resp.Error.Message != "Server busy" {
+		t.Fatalf("got %+v, want -32000 Server busy", resp)
+	}
+}
+
+// wantOK fails t unless resp is a successful result
+// wantOK: respが成功した結果でなければtを失敗させる関数
+func wantOK(t *testing.T, resp *JSONRPCResponse) {
+	t.Helper()
+	if resp.Error != nil {
+		t.Fatalf("got error %+v, want a result", resp.Error)
+	}
+}
+
+// receive returns the response on out, failing t if none arrives within a second
+// receive: outのレスポンスを返す関数 (1秒以内に届かなければtを失敗させる)
+func receive(t *testing.T, out <-chan *JSONRPCResponse) *JSONRPCResponse {
+	t.Helper()
+	select {
+	case resp := <-out:
+		return resp
+	case <-time.After(time.Second):
+		t.Fatal("no response within a second")
+		return nil
+	}
+}
+
+// TestQueueOverflowReject checks that a request finding the queue full fails at once
+// and that the metrics report the waiting request
+// TestQueueOverflowReject: キューが満杯のリクエストが即座に失敗し、
+// メトリクスが待機中のリクエストを報告することを確認するテスト
+func TestQueueOverflowReject(t *testing.T) {
+	s, release := saturatedServer(OverflowReject)
+	running, queued := fill(t, s)
+
+	if depth := s.Metrics().QueueDepth; depth != 1 {
+		t.Fatalf("metrics queue depth: got %d, want 1", depth)
+	}
+	wantBusy(t, receive(t, callAsync(s, 3, "quick")))
+	close(release)
+	wantOK(t, receive(t, running))
+	wantOK(t, receive(t, queued))
+	if depth := s.Metrics().QueueDepth; depth != 0 {
+		t.Fatalf("metrics queue depth after draining: got %d, want 0", depth)
+	}
+}
+
+// TestQueueOverflowDropOldest checks that the oldest queued request is answered to make room
+// TestQueueOverflowDropOldest: 空きを作るため最も古い待機中のリクエストに応答することを確認するテスト
+func TestQueueOverflowDropOldest(t *testing.T) {
+	s, release := saturatedServer(OverflowDropOldest)
+	running, queued := fill(t, s)
+
+	latest := callAsync(s, 3, "quick")
+	wantBusy(t, receive(t, queued))
+	if s.pool.depth() != 1 {
+		t.Fatalf("queue depth: got %d, want 1", s.pool.depth())
+	}
+	close(release)
+	wantOK(t, receive(t, running))
+	wantOK(t, receive(t, latest))
+}
+
+// TestQueueOverflowBlock checks that a request finding the queue full waits for room
+// TestQueueOverflowBlock: キューが満杯のリクエストが空きを待つことを確認するテスト
+func TestQueueOverflowBlock(t *testing.T) {
+	s, release := saturatedServer(OverflowBlock)
+	running, queued := fill(t, s)
+
+	blocked := callAsync(s, 3, "quick")
+	select {
+	case resp := <-blocked:
+		t.Fatalf("answered while the pool was full: %+v", resp)
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	wantOK(t, receive(t, running))
+	wantOK(t, receive(t, queued))
+	wantOK(t, receive(t, blocked))
+}
+
+// TestQueueOverflowBlockDeadline checks that a blocked request gives up at its deadline
+// TestQueueOverflowBlockDeadline: ブロック中のリクエストが期限で諦めることを確認するテスト
+func TestQueueOverflowBlockDeadline(t *testing.T) {
+	s := NewMCPServer(WithWorkers(1), WithQueue(0, OverflowBlock))
+	leave, err := s.pool.enter(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer leave()
+
+	resp := s.HandleRequest(context.Background(), &JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      IntID(1),
+		Method:  "tools/list",
+		Params:  map[string]interface{}{"_meta": map[string]interface{}{"timeoutMs": 30.0}},
+	})
+	if resp.Error == nil || resp.Error.Code != -32001 {
+		t.Fatalf("got %+v, want -32001 Request timed out", resp)
+	}
+}
+
+// TestQueueOverflowSync checks that a RunIO connection handles an overflowing request
+// on its own read loop while HTTP and in-process callers are refused
+// TestQueueOverflowSync: RunIOの接続は溢れたリクエストを自身の読み取りループで処理し、
+// HTTPとプロセス内の呼び出し元は拒否されることを確認するテスト
+func TestQueueOverflowSync(t *testing.T) {
+	s, release := saturatedServer(OverflowSync)
+	defer close(release)
+	fill(t, s)
+
+	var out strings.Builder
+	in := `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"quick"}}` + "\n"
+	if err := s.RunIO(context.Background(), strings.NewReader(in), &out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), `"id":3`) || strings.Contains(out.String(), `"error"`) {
+		t.Fatalf("stream request not handled: %s", out.String())
+	}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"quick"}}`))
+	r.Header.Set("Content-Type", "application/json")
+	s.ServeHTTP(w, r)
+	if !strings.Contains(w.Body.String(), "Server busy") {
+		t.Fatalf("HTTP request not refused: %s", w.Body.String())
+	}
+
+	wantBusy(t, receive(t, callAsync(s, 5, "quick")))
+}
@@ -0,0 +1,73 @@
+package main
+
+import "context" // context: resolves session tools (セッションのツールの解決)
+
+// redactedValue replaces the value of a redacted argument in logs
+// redactedValue: ログ内で伏せ字にした引数の値を置き換える文字列
+const redactedValue = "***"
+
+// redact returns a copy of arguments with the tool's Redact fields masked, for logging
+// redact: ツールのRedactフィールドを伏せ字にした引数のコピーを返す関数 (ログ用)
+// Fields are matched by name at any depth; arguments itself is never modified.
+// フィールドは深さに関係なく名前で照合し、arguments自体は変更しない
+// redact: 伏せ字にする、編集して削る
+func (t Tool) redact(arguments interface{}) interface{} {
+	if len(t.Redact) == 0 {
+		return arguments
+	}
+	names := make(map[string]bool, len(t.Redact))
+	for _, name := range t.Redact {
+		names[name] = true
+	}
+	return redactValue(arguments, names)
+}
+
+// redactArguments returns tool.redact(arguments), also masking the arguments a
+// meta/invoke call passes on with the target tool's Redact fields
+// redactArguments: tool.redact(arguments)を返す関数。meta/invoke呼び出しが受け渡す引数も
+// 対象のツールのRedactフィールドで伏せ字にする
+func (s *MCPServer) redactArguments(ctx context.Context, tool Tool, arguments interface{}) interface{} {
+	arguments = tool.redact(arguments)
+	args, ok := arguments.(map[string]interface{})
+	if tool.Name != metaInvokeName || !ok {
+		return arguments
+	}
+	name, _ := args["name"].(string)
+	target, found := s.lookupTool(ctx, name)
+	inner, nested := args["arguments"]
+	if !found || !nested {
+		return arguments
+	}
+
+	masked := make(map[string]interface{}, len(args)) // copy: argumentsを変更しない
+	for k, v := range args {
+		masked[k] = v
+	}
+	masked["arguments"] = target.redact(inner)
+	return masked
+}
+
+// redactValue masks the named fields in value, copying the objects and arrays it walks
+// redactValue: value内の指定したフィールドを伏せ字にする関数 (たどったオブジェクトと配列はコピーする)
+func redactValue(value interface{}, names map[string]bool) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		masked := make(map[string]interface{}, len(v))
+		for k, field := range v {
+			if names[k] {
+				masked[k] = redactedValue
+			} else {
+				masked[k] = redactValue(field, names)
+			}
+		}
+		return masked
+	case []interface{}:
+		masked := make([]interface{}, len(v))
+		for i, item := range v {
+			masked[i] = redactValue(item, names)
+		}
+		return masked
+	default:
+		return value
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"    // bytes: captured log output (取り込んだログ出力)
+	"context"  // context: session context and handler signature (セッションコンテキストとハンドラーのシグネチャ)
+	"log/slog" // log/slog: structured logger under test (テスト対象の構造化ロガー)
+	"strings"  // strings: searching the logs (ログの検索)
+	"testing"  // testing: test framework (テストフレームワーク)
+	"time"     // time: slow request threshold (遅いリクエストのしきい値)
+)
+
+// TestRedactSessionToolSlowLog checks that the slow-request log masks a session
+// tool's Redact fields, not only those of global tools
+// TestRedactSessionToolSlowLog: 遅いリクエストのログがグローバルなツールだけでなく
+// セッションのツールのRedactフィールドも伏せ字にすることを確認するテスト
+func TestRedactSessionToolSlowLog(t *testing.T) {
+	var logs bytes.Buffer
+	s := NewMCPServer(WithSlowRequestThreshold(time.Nanosecond), WithLogger(slog.New(slog.NewJSONHandler(&logs, nil))))
+	s.RegisterTool(Tool{Name: "login", Handler: func(ctx context.Context, arguments interface{}) (map[string]interface{}, error) {
+		return ToolResult(TextContent("global")), nil
+	}})
+	sess, _ := s.sessions.create(nil)
+	err := sess.RegisterTool(Tool{Name: "login", Redact: []string{"password"}, Handler: func(ctx context.Context, arguments interface{}) (map[string]interface{}, error) {
+		return ToolResult(TextContent("session")), nil
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp := s.HandleRequest(SessionContext(context.Background(), sess), &JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      IntID(1),
+		Method:  "tools/call",
+		Params:  map[string]interface{}{"name": "login", "arguments": map[string]interface{}{"user": "bob", "password": "hunter2"}},
+	})
+	if resp.Error != nil {
+		t.Fatal(resp.Error)
+	}
+	if !strings.Contains(logs.String(), "slow request") {
+		t.Fatalf("no slow request log: %s", logs.String())
+	}
+	if strings.Contains(logs.String(), "hunter2") {
+		t.Fatalf("password leaked: %s", logs.String())
+	}
+}
+
+// TestRedactAuditLog checks that a debug audit record masks Redact fields at any
+// depth while the handler still receives the real arguments
+// TestRedactAuditLog: デバッグ時の監査記録がRedactフィールドを深さに関係なく伏せ字にし、
+// ハンドラーには本来の引数が渡ることを確認するテスト
+func TestRedactAuditLog(t *testing.T) {
+	var buf bytes.Buffer
+	s := NewMCPServer(WithDebug(true), WithAuditLogger(NewJSONLAuditLogger(&buf)))
+	var received interface{}
+	s.RegisterTool(Tool{Name: "login", Redact: []string{"password", "token"}, Handler: func(ctx context.Context, arguments interface{}) (map[string]interface{}, error) {
+		received = arguments
+		return ToolResult(), nil
+	}})
+	args := map[string]interface{}{
+		"user":     "bob",
+		"password": "hunter2",
+		"accounts": []interface{}{map[string]interface{}{"token": "t0ps3cret"}},
+	}
+	if _, err := NewClient(s).CallTool("login", args); err != nil {
+		t.Fatal(err)
+	}
+
+	log := buf.String()
+	if strings.Contains(log, "hunter2") || strings.Contains(log, "t0ps3cret") ||
+		strings.Count(log, `"`+redactedValue+`"`) != 2 || !strings.Contains(log, "bob") {
+		t.Fatalf("audit record: %s", log)
+	}
+	if got := received.(map[string]interface{}); got["password"] != "hunter2" ||
+		got["accounts"].([]interface{})[0].(map[string]interface{})["token"] != "t0ps3cret" {
+		t.Fatalf("handler got %v", received)
+	}
+}
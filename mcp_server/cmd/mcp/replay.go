@@ -0,0 +1,77 @@
+package main
+
+import (
+	"sync" // sync: guards the nonce table (nonce表の保護)
+	"time" // time: replay window (リプレイ検出の時間枠)
+)
+
+// nonceStore remembers when each HTTP nonce was seen, for replay protection
+// nonceStore: リプレイ防止のため、各HTTPのnonceを見た時刻を記憶する構造体
+// A nonce is never forgotten before its window ends; when the table is full of live
+// nonces, new ones are refused instead of evicting old ones, which could then be replayed.
+// nonceは時間枠が終わる前に忘れられることはない。有効なnonceで表が満杯のときは、
+// 古いものを追い出して再送を許す代わりに新しいものを拒否する
+type nonceStore struct {
+	mu     sync.Mutex           // mu: guards the fields below (以下のフィールドを保護)
+	window time.Duration        // window: how long a nonce stays used (nonceが使用済みのままでいる時間)
+	max    int                  // max: table capacity, 0 for unbounded (表の容量、0なら無制限)
+	seen   map[string]time.Time // seen: first-use time by nonce (nonceごとの初回使用時刻)
+	order  []string             // order: nonces in the order seen, oldest first (見た順のnonce、古い順)
+	now    func() time.Time     // now: clock, replaceable in tests (時計、テストで差し替え可能)
+}
+
+// nonceResult is the outcome of nonceStore.use
+// nonceResult: nonceStore.useの結果
+type nonceResult int
+
+const (
+	nonceFresh    nonceResult = iota // fresh: first use within the window (時間枠内で初回の使用)
+	nonceReplayed                    // replayed: already used within the window (時間枠内で使用済み)
+	nonceFull                        // full: table full of live nonces (有効なnonceで表が満杯)
+)
+
+// newNonceStore creates an empty store remembering at most max nonces for window each
+// newNonceStore: 最大max件のnonceをそれぞれwindowの間記憶する空のストアを作成する関数
+func newNonceStore(window time.Duration, max int) *nonceStore {
+	return &nonceStore{
+		window: window,
+		max:    max,
+		seen:   make(map[string]time.Time),
+		now:    time.Now,
+	}
+}
+
+// use records nonce as seen now and reports whether it was fresh
+// use: nonceを今見たものとして記録し、新規だったかどうかを返す関数
+func (st *nonceStore) use(nonce string) nonceResult {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	now := st.now()
+
+	// Forget nonces whose window has ended: 時間枠が終わったnonceを忘れる
+	n := 0
+	for n < len(st.order) && now.Sub(st.seen[st.order[n]]) >= st.window {
+		delete(st.seen, st.order[n])
+		n++
+	}
+	st.order = st.order[n:]
+
+	if _, ok := st.seen[nonce]; ok {
+		return nonceReplayed
+	}
+	if st.max > 0 && len(st.seen) >= st.max {
+		return nonceFull
+	}
+	st.seen[nonce] = now
+	st.order = append(st.order, nonce)
+	return nonceFresh
+}
+
+// Clear forgets every nonce
+// Clear: 全てのnonceを忘れる関数
+func (st *nonceStore) Clear() {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.seen = make(map[string]time.Time)
+	st.order = nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"net/http"          // net/http: requests and status codes (リクエストとステータスコード)
+	"net/http/httptest" // net/http/httptest: in-memory HTTP round trips (メモリ内のHTTP往復)
+	"strings"           // strings: request bodies (リクエスト本文)
+	"sync"              // sync: concurrent callers (同時呼び出し元)
+	"sync/atomic"       // sync/atomic: counting fresh uses (新規使用の計数)
+	"testing"           // testing: test framework (テストフレームワーク)
+	"time"              // time: replay window (リプレイ検出の時間枠)
+)
+
+// TestNonceStoreWindow checks that a nonce is refused for its whole window and
+// that a full table refuses new nonces instead of forgetting live ones
+// TestNonceStoreWindow: nonceが時間枠の間ずっと拒否され、満杯の表が有効なnonceを
+// 忘れる代わりに新しいnonceを拒否することを確認するテスト
+func TestNonceStoreWindow(t *testing.T) {
+	st := newNonceStore(time.Minute, 2)
+	now := time.Unix(0, 0)
+	st.now = func() time.Time { return now }
+
+	steps := []struct {
+		advance time.Duration
+		nonce   string
+		want    nonceResult
+	}{
+		{0, "a", nonceFresh},
+		{0, "a", nonceReplayed},
+		{30 * time.Second, "b", nonceFresh},
+		{0, "c", nonceFull},     // a and b are still live: aとbはまだ有効
+		{0, "a", nonceReplayed}, // not evicted by c: cによって追い出されない
+		{31 * time.Second, "c", nonceFresh},
+		{0, "c", nonceReplayed},
+		{30 * time.Second, "b", nonceFresh}, // b's window has ended: bの時間枠は終了
+	}
+	for i, step := range steps {
+		now = now.Add(step.advance)
+		if got := st.use(step.nonce); got != step.want {
+			t.Fatalf("step %d (%s): got %d, want %d", i, step.nonce, got, step.want)
+		}
+	}
+}
+
+// TestNonceStoreConcurrent checks that only one of many concurrent uses of a nonce is fresh
+// TestNonceStoreConcurrent: 同じnonceの多数の同時使用のうち1つだけが新規となることを確認するテスト
+func TestNonceStoreConcurrent(t *testing.T) {
+	st := newNonceStore(time.Minute, 0)
+	var wg sync.WaitGroup
+	var fresh atomic.Int32
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if st.use("x") == nonceFresh {
+				fresh.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := fresh.Load(); n != 1 {
+		t.Fatalf("fresh uses: got %d, want 1", n)
+	}
+}
+
+// TestHTTPReplayProtection checks the status codes for fresh, reused and missing nonces
+// TestHTTPReplayProtection: 新規・再利用・欠落したnonceに対するステータスコードを確認するテスト
+func TestHTTPReplayProtection(t *testing.T) {
+	s := NewMCPServer(WithReplayProtection(time.Minute, 10))
+	post := func(nonce string) int {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`))
+		r.Header.Set("Content-Type", "application/json")
+		if nonce != "" {
+			r.Header.Set(nonceHeader, nonce)
+		}
+		s.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	for i, step := range []struct {
+		nonce string
+		want  int
+	}{
+		{"a", http.StatusOK},
+		{"a", http.StatusConflict},
+		{"b", http.StatusOK},
+		{"", http.StatusBadRequest},
+	} {
+		if got := post(step.nonce); got != step.want {
+			t.Fatalf("request %d (%q): got %d, want %d", i, step.nonce, got, step.want)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"         // bytes: byte slice helpers (バイト列ヘルパー)
+	"encoding/json" // encoding/json: JSON encoding and decoding (JSONエンコード・デコード)
+	"errors"        // errors: error values (エラー値)
+	"fmt"           // fmt: formatted I/O (フォーマット済みI/O)
+	"strconv"       // strconv: number parsing (数値解析)
+)
+
+// errInvalidRequestID reports an id that JSON-RPC does not allow on requests
+// errInvalidRequestID: JSON-RPCがリクエストで許可しないidを表すエラー
+var errInvalidRequestID = errors.New("invalid request id")
+
+// RequestID is a JSON-RPC id that preserves integer vs string identity
+// RequestID: 整数と文字列の区別を保持するJSON-RPCのid
+// The zero value represents an absent or null id.
+// ゼロ値はidが存在しない、またはnullであることを表す
+type RequestID struct {
+	value interface{} // value: nil, int64 or string (nil、int64、stringのいずれか)
+}
+
+// IntID returns an integer request id
+// IntID: 整数のリクエストidを返す関数
+func IntID(n int64) RequestID {
+	return RequestID{value: n}
+}
+
+// StringID returns a string request id
+// StringID: 文字列のリクエストidを返す関数
+func StringID(s string) RequestID {
+	return RequestID{value: s}
+}
+
+// IsZero reports whether the id is absent or null
+// IsZero: idが存在しない、またはnullかどうかを判定する関数
+func (id RequestID) IsZero() bool {
+	return id.value == nil
+}
+
+// String formats the id for logs
+// String: ログ用にidを整形する関数
+func (id RequestID) String() string {
+	if id.value == nil {
+		return "null"
+	}
+	return fmt.Sprint(id.value)
+}
+
+// MarshalJSON encodes the id as a JSON number, string or null
+// MarshalJSON: idをJSONの数値・文字列・nullとしてエンコードする関数
+func (id RequestID) MarshalJSON() ([]byte, error) {
+	switch v := id.value.(type) {
+	case nil:
+		return []byte("null"), nil
+	case int64:
+		return strconv.AppendInt(nil, v, 10), nil
+	case string:
+		return json.Marshal(v)
+	default:
+		return nil, fmt.Errorf("%w: unexpected type %T", errInvalidRequestID, v)
+	}
+}
+
+// UnmarshalJSON decodes a JSON id, rejecting fractional numbers and non-scalar values
+// UnmarshalJSON: JSONのidをデコードし、小数や非スカラー値を拒否する関数
+// fractional: 小数の
+func (id *RequestID) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	switch {
+	case bytes.Equal(data, []byte("null")):
+		id.value = nil
+		return nil
+	case len(data) > 0 && data[0] == '"':
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return fmt.Errorf("%w: %v", errInvalidRequestID, err)
+		}
+		id.value = s
+		return nil
+	}
+
+	// Integers only: 整数のみ許可
+	n, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		if _, floatErr := strconv.ParseFloat(string(data), 64); floatErr == nil {
+			return fmt.Errorf("%w: fractional or out-of-range number %s", errInvalidRequestID, data)
+		}
+		return fmt.Errorf("%w: %s", errInvalidRequestID, data)
+	}
+	id.value = n
+	return nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"       // context: RunIO lifetime (RunIOの存続期間)
+	"encoding/json" // encoding/json: id round trips (idの往復)
+	"errors"        // errors: error inspection (エラー検査)
+	"strings"       // strings: in-memory input and output (メモリ内の入出力)
+	"testing"       // testing: test framework (テストフレームワーク)
+)
+
+// TestRequestIDRoundTrip checks that integer, string and null ids keep their type
+// and exact value through JSON, including integers beyond float64 precision
+// TestRequestIDRoundTrip: 整数・文字列・nullのidが、float64の精度を超える整数も含めて
+// JSONを往復しても型と値を保つことを確認するテスト
+func TestRequestIDRoundTrip(t *testing.T) {
+	for _, raw := range []string{`1`, `-7`, `9007199254740993`, `"abc"`, `"1"`, `null`} {
+		var id RequestID
+		if err := json.Unmarshal([]byte(raw), &id); err != nil {
+			t.Fatalf("%s: %v", raw, err)
+		}
+		data, err := json.Marshal(id)
+		if err != nil {
+			t.Fatalf("%s: %v", raw, err)
+		}
+		if string(data) != raw {
+			t.Fatalf("got %s, want %s", data, raw)
+		}
+	}
+	if IntID(1) == StringID("1") {
+		t.Fatal("integer and string ids compare equal")
+	}
+}
+
+// TestRequestIDInvalid checks that fractional, out-of-range and non-scalar ids are rejected
+// TestRequestIDInvalid: 小数・範囲外・非スカラーのidが拒否されることを確認するテスト
+func TestRequestIDInvalid(t *testing.T) {
+	for _, raw := range []string{`1.5`, `1e3`, `99999999999999999999`, `true`, `[1]`, `{}`} {
+		var id RequestID
+		if err := json.Unmarshal([]byte(raw), &id); !errors.Is(err, errInvalidRequestID) {
+			t.Fatalf("%s: got %v, want errInvalidRequestID", raw, err)
+		}
+	}
+}
+
+// TestRunIORequestIDs checks that responses echo ids exactly and a fractional id
+// gets -32600 with a null id
+// TestRunIORequestIDs: レスポンスがidをそのまま返し、小数のidにはnullのidで
+// -32600が返ることを確認するテスト
+func TestRunIORequestIDs(t *testing.T) {
+	in := strings.NewReader(`{"jsonrpc":"2.0","id":9007199254740993,"method":"ping"}
+{"jsonrpc":"2.0","id":"abc","method":"ping"}
+{"jsonrpc":"2.0","id":1.5,"method":"ping"}
+`)
+	var out strings.Builder
+	if err := newEchoServer().RunIO(context.Background(), in, &out); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d responses, want 3:\n%s", len(lines), out.String())
+	}
+	if !strings.Contains(lines[0], `"id":9007199254740993`) {
+		t.Fatalf("large integer id not preserved: %s", lines[0])
+	}
+	if !strings.Contains(lines[1], `"id":"abc"`) {
+		t.Fatalf("string id not preserved: %s", lines[1])
+	}
+	if !strings.Contains(lines[2], `"id":null`) || !strings.Contains(lines[2], `-32600`) {
+		t.Fatalf("fractional id: got %s, want -32600 with a null id", lines[2])
+	}
+}
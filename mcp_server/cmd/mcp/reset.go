@@ -0,0 +1,55 @@
+package main
+
+// cacheClearer is implemented by caches that can drop all their entries, such as LRUCache
+// cacheClearer: LRUCacheのように全エントリを破棄できるキャッシュが実装するインターフェース
+type cacheClearer interface {
+	Clear()
+}
+
+// Reset returns the server to the state NewMCPServer left it in, so one instance can be reused across test cases
+// Reset: サーバーをNewMCPServer直後の状態に戻し、1つのインスタンスをテストケース間で再利用できるようにする関数
+// It drops registered tools, resources, providers, listers, prompts and downstreams,
+// subscriptions, cached results, sessions, metrics and the initialize handshake, then
+// re-registers the built-in tools enabled by options. Options, custom methods and
+// settings are kept, and a cache without a Clear method keeps its entries.
+// Reset is only safe while no requests are in flight and no transport is running.
+// 登録済みのツール、リソース、プロバイダー、リスター、プロンプト、下流と、購読、キャッシュ済みの結果、
+// セッション、メトリクス、initializeハンドシェイクを破棄し、オプションで有効にした組み込みツールを再登録する。
+// オプション、カスタムメソッド、設定は保持され、Clearメソッドを持たないキャッシュはエントリを保持する。
+// 処理中のリクエストが無く、トランスポートが動作していない間のみ安全に呼び出せる
+// reset: 初期状態に戻す、reuse: 再利用する
+func (s *MCPServer) Reset() {
+	s.mu.Lock()
+	s.tools = make(map[string]Tool)
+	s.resources = make(map[string]Resource)
+	s.static = nil
+	s.providers = nil
+	s.listers = nil
+	s.prompts = make(map[string]Prompt)
+	s.mu.Unlock()
+
+	s.proxy.mu.Lock()
+	s.proxy.tools = nil
+	s.proxy.resources = nil
+	s.proxy.mu.Unlock()
+
+	// Caches: キャッシュ
+	if c, ok := s.cache.(cacheClearer); ok {
+		c.Clear()
+	}
+	if s.nonces != nil {
+		s.nonces.Clear()
+	}
+
+	sessions := newSessionStore(s.sessions.idle)
+	sessions.max = s.sessions.max
+	s.sessions = sessions
+	s.metrics = newMetrics()
+	s.conn = s.newConnSession(nil)
+	s.ready.Store(false)
+
+	// Option-defined tools, as in NewMCPServer: NewMCPServerと同様にオプションで定義されたツール
+	for _, build := range s.builtinTools {
+		s.RegisterTool(build(s.rootDir))
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context" // context: request contexts (リクエストコンテキスト)
+	"testing" // testing: test framework (テストフレームワーク)
+)
+
+// TestReset checks that Reset drops registrations and subscriptions while keeping
+// the built-in tools enabled by options, including the example tools
+// TestReset: Resetが登録と購読を破棄し、例示用ツールを含むオプションで
+// 有効にした組み込みツールを保持することを確認するテスト
+func TestReset(t *testing.T) {
+	s := NewMCPServer(WithExampleTools(), WithMetaInvokeTool(0))
+	s.RegisterTool(Tool{Name: "extra"})
+	s.RegisterResource(Resource{URI: "data:,hi", Name: "hi"})
+	s.RegisterPrompt(Prompt{Name: "p"})
+	request := func(method string, params map[string]interface{}) *JSONRPCResponse {
+		return s.HandleRequest(context.Background(), &JSONRPCRequest{JSONRPC: "2.0", ID: IntID(1), Method: method, Params: params})
+	}
+	request("initialize", nil)
+	request("resources/subscribe", map[string]interface{}{"uri": "data:,hi"})
+	if len(s.conn.subscriptions) != 1 {
+		t.Fatal("subscribe did not register")
+	}
+
+	s.Reset()
+
+	c := NewClient(s)
+	tools, err := c.ListTools()
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := map[string]bool{}
+	for _, tool := range tools {
+		names[tool.Name] = true
+	}
+	if len(names) != 2 || !names["echo"] || !names[metaInvokeName] {
+		t.Fatalf("tools after Reset: %v", names)
+	}
+	if result, err := c.CallTool("echo", map[string]interface{}{"message": "hi"}); err != nil || result.Content[0]["text"] != "Echo: hi" {
+		t.Fatalf("echo after Reset: %v, %v", result, err)
+	}
+	if resources, err := c.ListResources(); err != nil || len(resources) != 0 {
+		t.Fatalf("resources after Reset: %v, %v", resources, err)
+	}
+	if resp := request("prompts/get", map[string]interface{}{"name": "p"}); resp.Error == nil {
+		t.Fatal("prompt survived Reset")
+	}
+	if len(s.conn.subscriptions) != 0 {
+		t.Fatal("subscriptions survived Reset")
+	}
+}
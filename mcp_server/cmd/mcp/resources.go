@@ -0,0 +1,377 @@
+package main
+
+import (
+	"context"         // context: cancellation and deadlines (キャンセルと期限)
+	"encoding/base64" // encoding/base64: blob decoding (blobのデコード)
+	"errors"          // errors: error values (エラー値)
+	"fmt"             // fmt: formatted I/O (フォーマット済みI/O)
+	"io/fs"           // io/fs: file system errors (ファイルシステムのエラー)
+	"net/url"         // net/url: URL parsing (URL解析)
+	"path"            // path: slash-separated path manipulation (スラッシュ区切りパス操作)
+	"strings"         // strings: string manipulation functions (文字列操作関数)
+	"unicode/utf8"    // unicode/utf8: rune boundaries (文字境界)
+)
+
+// SchemeHandler reads the resource identified by a parsed URI
+// SchemeHandler: 解析済みURIで識別されるリソースを読み取る関数型
+// Handlers are registered per URI scheme and adapted into resource providers.
+// ハンドラーはURIスキームごとに登録され、リソースプロバイダーとして扱われる
+type SchemeHandler func(ctx context.Context, u *url.URL) (Content, error)
+
+// Resource URI errors: リソースURIのエラー
+var (
+	errInvalidURI        = errors.New("invalid URI")            // invalid: 無効な
+	errUnsupportedScheme = errors.New("unsupported URI scheme") // unsupported: 未対応の
+)
+
+// CodeResourceNotFound is the JSON-RPC error code for a resource that does not exist
+// CodeResourceNotFound: 存在しないリソースを表すJSON-RPCエラーコード
+const CodeResourceNotFound = -32002
+
+// CodedError is implemented by errors that choose their JSON-RPC error code
+// CodedError: JSON-RPCエラーコードを自ら選ぶエラーが実装するインターフェース
+// Provider errors are inspected with errors.As, so wrapped errors keep their code.
+// プロバイダーのエラーはerrors.Asで調べるため、ラップされたエラーもコードを保つ
+type CodedError interface {
+	error
+	RPCCode() int
+}
+
+// codedError attaches a JSON-RPC error code to err
+// codedError: errにJSON-RPCエラーコードを付与する構造体
+type codedError struct {
+	code int   // code: JSON-RPC error code (JSON-RPCエラーコード)
+	err  error // err: underlying error (元のエラー)
+}
+
+func (e *codedError) Error() string { return e.err.Error() }
+func (e *codedError) Unwrap() error { return e.err }
+func (e *codedError) RPCCode() int  { return e.code }
+
+// WithRPCCode returns err carrying code as its JSON-RPC error code
+// WithRPCCode: codeをJSON-RPCエラーコードとして持つerrを返す関数
+// Custom providers can use it, for example with CodeResourceNotFound.
+// カスタムプロバイダーは例えばCodeResourceNotFoundと共に使える
+func WithRPCCode(err error, code int) error {
+	if err == nil {
+		return nil
+	}
+	return &codedError{code: code, err: err}
+}
+
+// notFound marks err as a missing resource when it reports fs.ErrNotExist
+// notFound: errがfs.ErrNotExistを示す場合に、存在しないリソースとして印を付ける関数
+func notFound(err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return WithRPCCode(err, CodeResourceNotFound)
+	}
+	return err
+}
+
+// RegisterSchemeHandler registers handler for resource URIs with the given scheme
+// RegisterSchemeHandler: 指定したスキームのリソースURIに対するハンドラーを登録する関数
+// Registering a built-in scheme such as "file" replaces the default handler, and
+// registering the same scheme again replaces the earlier handler.
+// "file"などの組み込みスキームを登録するとデフォルトのハンドラーを置き換え、
+// 同じスキームを再登録すると以前のハンドラーを置き換える
+func (s *MCPServer) RegisterSchemeHandler(scheme string, handler SchemeHandler) {
+	provider := &schemeProvider{scheme: strings.ToLower(scheme), handler: handler} // lower: 小文字化
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, existing := range s.providers {
+		if sp, ok := existing.(*schemeProvider); ok && sp.scheme == provider.scheme {
+			s.providers[i] = provider // replace: 置き換える
+			return
+		}
+	}
+	s.providers = append(s.providers, provider)
+}
+
+// parseResourceURI parses, validates and normalizes a resource URI
+// parseResourceURI: リソースURIを解析・検証・正規化する関数
+// normalizes: 正規化する
+func (s *MCPServer) parseResourceURI(raw string) (*url.URL, error) {
+	u, err := normalizeURI(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	// Security: 対応するプロバイダーがあるスキームのみ受け付ける
+	if s.providerFor(u.String()) == nil {
+		return nil, fmt.Errorf("%w: %q", errUnsupportedScheme, u.Scheme)
+	}
+	return u, nil
+}
+
+// normalizeURI parses and normalizes a resource URI without checking its scheme is served
+// normalizeURI: スキームが提供されているかは確認せずにリソースURIを解析・正規化する関数
+func normalizeURI(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidURI, err)
+	}
+
+	switch u.Scheme {
+	case "file":
+		// A file URI must name a path: ファイルURIにはパスが必要
+		if u.Path == "" {
+			return nil, fmt.Errorf("%w: file URI has no path", errInvalidURI)
+		}
+	case "https":
+		// An https URI must name a host: https URIにはホストが必要
+		if u.Host == "" {
+			return nil, fmt.Errorf("%w: https URI has no host", errInvalidURI)
+		}
+	}
+
+	// Normalize dot segments and duplicate slashes: ドットセグメントと重複スラッシュを正規化
+	if u.Path != "" {
+		cleaned := path.Clean(u.Path)
+		if strings.HasSuffix(u.Path, "/") && cleaned != "/" {
+			cleaned += "/" // keep trailing slash: 末尾のスラッシュを保持
+		}
+		u.Path = cleaned
+		u.RawPath = ""
+	}
+	return u, nil
+}
+
+// isTextMimeType reports whether a media type carries text
+// isTextMimeType: メディアタイプがテキストを表すかどうかを判定する関数
+func isTextMimeType(mediaType string) bool {
+	switch {
+	case strings.HasPrefix(mediaType, "text/"):
+		return true
+	case mediaType == "application/json", mediaType == "application/xml",
+		strings.HasSuffix(mediaType, "+json"), strings.HasSuffix(mediaType, "+xml"):
+		return true
+	default:
+		return false
+	}
+}
+
+// readCachedResource reads a resource, serving cacheable resources from the cache
+// readCachedResource: キャッシュ可能なリソースはキャッシュから返しつつリソースを読み取る関数
+func (s *MCPServer) readCachedResource(ctx context.Context, u *url.URL) (Content, error) {
+	uri := u.String()
+	s.mu.RLock()
+	cacheable := s.cache != nil && s.resources[uri].Cacheable
+	s.mu.RUnlock()
+	if !cacheable {
+		return s.readResource(ctx, u)
+	}
+
+	key := resourceCacheKey(uri)
+	if cached, ok := s.cache.Get(key); ok {
+		return cached.(Content), nil
+	}
+	content, err := s.readResource(ctx, u)
+	if err == nil {
+		s.cache.Set(key, content)
+	}
+	return content, err
+}
+
+// handleResourcesSubscribe handles resources/subscribe and resources/unsubscribe
+// handleResourcesSubscribe: resources/subscribeとresources/unsubscribeを処理する関数
+// Params name either a uri or a pattern (see matchPattern) covering many URIs.
+// パラメータはuri、または多数のURIを対象とするpattern (matchPatternを参照) のいずれかを指定する
+func (s *MCPServer) handleResourcesSubscribe(ctx context.Context, req *JSONRPCRequest, subscribe bool) *JSONRPCResponse {
+	params, ok := req.Params.(map[string]interface{})
+	if !ok {
+		return &JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error: &JSONRPCError{
+				Code:    -32602,               // Invalid params (無効なパラメータ)
+				Message: "Invalid parameters", // parameters: パラメータ
+			},
+		}
+	}
+	// Pattern subscription: パターンによる購読
+	if pattern, ok := params["pattern"].(string); ok {
+		if err := validatePattern(pattern); err != nil {
+			return &JSONRPCResponse{
+				JSONRPC: "2.0",
+				ID:      req.ID,
+				Error: &JSONRPCError{
+					Code:    -32602,
+					Message: "Invalid pattern", // pattern: パターン
+					Data:    map[string]interface{}{"pattern": pattern, "reason": err.Error()},
+				},
+			}
+		}
+		s.connFor(ctx).subscribe(pattern, true, subscribe)
+		return &JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Result:  &EmptyResult{},
+		}
+	}
+
+	uri, ok := params["uri"].(string)
+	if !ok {
+		return &JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error: &JSONRPCError{
+				Code:    -32602,
+				Message: "URI or pattern is required", // required: 必須の
+			},
+		}
+	}
+
+	s.connFor(ctx).subscribe(uri, false, subscribe) // subscribe or unsubscribe: 購読・購読解除
+
+	return &JSONRPCResponse{
+		JSONRPC: "2.0",
+		ID:      req.ID,
+		Result:  &EmptyResult{},
+	}
+}
+
+// validatePattern checks the syntax of a subscription pattern
+// validatePattern: 購読パターンの構文を検査する関数
+func validatePattern(pattern string) error {
+	head := strings.TrimSuffix(pattern, "**")
+	switch {
+	case head == "":
+		return errors.New("pattern must not be empty")
+	case strings.Contains(head, "**"):
+		return errors.New("** may only end a pattern")
+	}
+	if _, err := path.Match(head, ""); err != nil {
+		return err
+	}
+	return nil
+}
+
+// matchPattern reports whether uri matches a subscription pattern
+// matchPattern: uriが購読パターンに一致するかを判定する関数
+// Patterns use path.Match syntax, where * and ? stop at a slash; a trailing ** matches
+// any remainder, so "file:///logs/**" covers everything under file:///logs/.
+// パターンはpath.Matchの構文で、*と?はスラッシュを越えない。末尾の**は残り全てに一致するため、
+// "file:///logs/**"はfile:///logs/以下の全てを対象とする
+func matchPattern(pattern, uri string) bool {
+	head, prefix := strings.CutSuffix(pattern, "**")
+	if !prefix {
+		ok, _ := path.Match(pattern, uri)
+		return ok
+	}
+	for i := len(uri); i >= 0; i-- {
+		if ok, _ := path.Match(head, uri[:i]); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadRange selects a byte range of a resource
+// ReadRange: リソースのバイト範囲を選択する構造体
+// A zero Length reads to the end of the resource.
+// Lengthが0の場合はリソースの末尾まで読み取る
+type ReadRange struct {
+	Offset int64 `json:"offset"` // offset: starting byte (開始バイト)
+	Length int64 `json:"length"` // length: number of bytes (バイト数)
+}
+
+// RangeReader is implemented by providers that can serve partial reads
+// RangeReader: 部分読み取りに対応するプロバイダーが実装するインターフェース
+// Offsets past the end clamp to an empty body rather than failing.
+// 末尾を超えるオフセットはエラーにせず、空の本文に切り詰める
+type RangeReader interface {
+	ReadRange(ctx context.Context, uri string, r ReadRange) (Content, error)
+}
+
+// errRangeNotSupported reports a range read against a provider without RangeReader
+// errRangeNotSupported: RangeReaderを持たないプロバイダーへの範囲読み取りを表すエラー
+var errRangeNotSupported = errors.New("range reads not supported")
+
+// parseReadRange extracts the optional _meta.range from resources/read params
+// parseReadRange: resources/readのパラメータから任意の_meta.rangeを取り出す関数
+func parseReadRange(params map[string]interface{}) (*ReadRange, error) {
+	raw, present := paramsMeta(params)["range"]
+	if !present {
+		return nil, nil
+	}
+	fields, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("range must be an object")
+	}
+
+	var r ReadRange
+	for name, dst := range map[string]*int64{"offset": &r.Offset, "length": &r.Length} {
+		value, present := fields[name]
+		if !present {
+			continue
+		}
+		n, ok := value.(float64)
+		if !ok || n < 0 || n != float64(int64(n)) {
+			return nil, fmt.Errorf("%s must be a non-negative integer", name)
+		}
+		*dst = int64(n)
+	}
+	return &r, nil
+}
+
+// readResourceRange reads a byte range through a provider implementing RangeReader
+// readResourceRange: RangeReaderを実装したプロバイダーでバイト範囲を読み取る関数
+func (s *MCPServer) readResourceRange(ctx context.Context, u *url.URL, r ReadRange) (Content, error) {
+	provider := s.providerFor(u.String())
+	rangeReader, ok := provider.(RangeReader)
+	if !ok {
+		return Content{}, errRangeNotSupported
+	}
+	content, err := rangeReader.ReadRange(ctx, u.String(), r)
+	if err != nil {
+		return Content{}, err
+	}
+	if content.URI == "" {
+		content.URI = u.String()
+	}
+	return content, nil
+}
+
+// truncateContent cuts content down to max bytes and records the truncation in _meta
+// truncateContent: 内容をmaxバイトまで切り詰め、切り詰めたことを_metaに記録する関数
+// Text is cut on a UTF-8 boundary and blobs on a byte boundary; _meta.size keeps
+// the full size unless the provider already reported it.
+// textはUTF-8の境界で、blobはバイト境界で切る。プロバイダーが報告済みでなければ_meta.sizeに全体サイズを残す
+// truncate: 切り詰める
+func truncateContent(content Content, max int64) Content {
+	var size int64
+	switch {
+	case content.Text != "":
+		size = int64(len(content.Text))
+		if size <= max {
+			return content
+		}
+		cut := int(max)
+		for cut > 0 && !utf8.RuneStart(content.Text[cut]) {
+			cut-- // back up to a rune start: 文字の先頭まで戻る
+		}
+		content.Text = content.Text[:cut]
+	case content.Blob != "":
+		data, err := base64.StdEncoding.DecodeString(content.Blob)
+		if err != nil || int64(len(data)) <= max {
+			return content
+		}
+		size = int64(len(data))
+		content.Blob = base64.StdEncoding.EncodeToString(data[:max])
+	default:
+		return content
+	}
+
+	// Copy _meta, which may be shared with the cache: キャッシュと共有されている可能性があるため_metaをコピー
+	meta := make(map[string]interface{}, len(content.Meta)+2)
+	for k, v := range content.Meta {
+		meta[k] = v
+	}
+	meta["truncated"] = true // truncated: 切り詰められた
+	if _, ok := meta["size"]; !ok {
+		meta["size"] = size // size: 全体サイズ
+	}
+	content.Meta = meta
+	return content
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"       // context: scheme handler signature (スキームハンドラーのシグネチャ)
+	"errors"        // errors: error inspection (エラー検査)
+	"fmt"           // fmt: wrapping provider errors (プロバイダーのエラーのラップ)
+	"net/url"       // net/url: parsed URIs passed to handlers (ハンドラーに渡される解析済みURI)
+	"os"            // os: test files under the root directory (ルートディレクトリ下のテスト用ファイル)
+	"path/filepath" // path/filepath: building test file paths (テスト用ファイルパスの組み立て)
+	"strings"       // strings: matching notifications (通知の照合)
+	"testing"       // testing: test framework (テストフレームワーク)
+)
+
+// wantRPCCode fails the test unless err is a *JSONRPCError with the given code
+// wantRPCCode: errが指定したコードの*JSONRPCErrorでなければテストを失敗させる関数
+func wantRPCCode(t *testing.T, err error, code int) {
+	t.Helper()
+	var rpcErr *JSONRPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Code != code {
+		t.Fatalf("got %v, want code %d", err, code)
+	}
+}
+
+// TestReadResourceURIValidation checks that malformed resource URIs are rejected
+// with -32602 and that dot segments and duplicate slashes are normalized away
+// TestReadResourceURIValidation: 不正なリソースURIが-32602で拒否され、
+// ドットセグメントと重複スラッシュが正規化されることを確認するテスト
+func TestReadResourceURIValidation(t *testing.T) {
+	c := NewClient(newEchoServer())
+	for _, uri := range []string{
+		"file://",       // no path: パスが無い
+		"https://",      // no host: ホストが無い
+		"https:///path", // no host: ホストが無い
+		"ftp://x/y",     // unsupported scheme: 未対応のスキーム
+		"%zz",           // unparsable: 解析できない
+	} {
+		t.Run(uri, func(t *testing.T) {
+			_, err := c.ReadResource(uri)
+			wantRPCCode(t, err, -32602)
+		})
+	}
+
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "b"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "b", "c.txt"), []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	result, err := NewClient(NewMCPServer(WithRootDir(dir))).ReadResource("file:///a/../b//c.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := result.Contents[0]; got.URI != "file:///b/c.txt" || got.Text != "hello" {
+		t.Fatalf("got %+v, want file:///b/c.txt with text hello", got)
+	}
+}
+
+// TestRegisterSchemeHandler checks that a registered scheme is served by its handler,
+// that registering it again replaces the handler, and that other schemes stay rejected
+// TestRegisterSchemeHandler: 登録したスキームがそのハンドラーで提供され、再登録でハンドラーが
+// 置き換わり、その他のスキームは拒否されたままであることを確認するテスト
+func TestRegisterSchemeHandler(t *testing.T) {
+	s := newEchoServer()
+	c := NewClient(s)
+	if _, err := c.ReadResource("mem://bucket/key"); err == nil {
+		t.Fatal("mem: served before registration")
+	}
+
+	s.RegisterSchemeHandler("mem", func(ctx context.Context, u *url.URL) (Content, error) {
+		return Content{MimeType: "text/markdown", Text: "v1:" + u.Host + u.Path}, nil
+	})
+	result, err := c.ReadResource("mem://bucket/key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := result.Contents[0]; got.Text != "v1:bucket/key" || got.MimeType != "text/markdown" {
+		t.Fatalf("got %+v, want v1:bucket/key as text/markdown", got)
+	}
+
+	s.RegisterSchemeHandler("MEM", func(ctx context.Context, u *url.URL) (Content, error) {
+		return Content{MimeType: "text/plain", Text: "v2"}, nil
+	})
+	if result, err = c.ReadResource("mem://bucket/key"); err != nil {
+		t.Fatal(err)
+	}
+	if got := result.Contents[0].Text; got != "v2" {
+		t.Fatalf("got %q after re-registering, want v2", got)
+	}
+
+	_, err = c.ReadResource("other://bucket/key")
+	wantRPCCode(t, err, -32602)
+}
+
+// TestTruncateContent checks that oversized text is cut on a character boundary and
+// blobs on a byte boundary, with _meta.truncated and the full size recorded, while
+// content within the limit is returned unchanged
+// TestTruncateContent: 上限を超えるtextは文字境界で、blobはバイト境界で切られ、_meta.truncatedと
+// 全体サイズが記録され、上限内の内容はそのまま返されることを確認するテスト
+func TestTruncateContent(t *testing.T) {
+	text := Content{Text: "héllo"} // 6 bytes: 6バイト
+	if got := truncateContent(text, 6); got.Text != "héllo" || got.Meta != nil {
+		t.Fatalf("text within limit: got %+v", got)
+	}
+	if got := truncateContent(text, 2); got.Text != "h" || got.Meta["truncated"] != true || got.Meta["size"] != int64(6) {
+		t.Fatalf("text over limit: got %+v", got)
+	}
+
+	blob := Content{Blob: "AAECAwQF"} // bytes 0..5: バイト0〜5
+	if got := truncateContent(blob, 6); got.Blob != "AAECAwQF" || got.Meta != nil {
+		t.Fatalf("blob within limit: got %+v", got)
+	}
+	if got := truncateContent(blob, 5); got.Blob != "AAECAwQ=" || got.Meta["truncated"] != true || got.Meta["size"] != int64(6) {
+		t.Fatalf("blob over limit: got %+v", got)
+	}
+
+	// A size reported by the provider wins: プロバイダーが報告したサイズを優先
+	ranged := Content{Text: "0123", Meta: map[string]interface{}{"size": int64(100)}}
+	if got := truncateContent(ranged, 2); got.Meta["size"] != int64(100) || ranged.Meta["truncated"] != nil {
+		t.Fatalf("provider size: got %+v, original meta %v", got, ranged.Meta)
+	}
+}
+
+// TestReadResourceContentLimit checks that WithMaxContentBytes truncates resources/read contents
+// TestReadResourceContentLimit: WithMaxContentBytesがresources/readの内容を切り詰めることを確認するテスト
+func TestReadResourceContentLimit(t *testing.T) {
+	s := NewMCPServer(WithMaxContentBytes(4))
+	s.RegisterStaticResource("docs://x", "x", "text/plain", []byte("0123456789"))
+	result, err := NewClient(s).ReadResource("docs://x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := result.Contents[0]; got.Text != "0123" || got.Meta["truncated"] != true || got.Meta["size"] != float64(10) {
+		t.Fatalf("got %+v, want 4 bytes with _meta.truncated and size 10", got)
+	}
+}
+
+// TestCodedProviderErrors checks that a missing file answers CodeResourceNotFound,
+// that a wrapped CodedError keeps its code, and that other errors are internal
+// TestCodedProviderErrors: 存在しないファイルがCodeResourceNotFoundで応答し、ラップされた
+// CodedErrorがコードを保ち、その他のエラーは内部エラーになることを確認するテスト
+func TestCodedProviderErrors(t *testing.T) {
+	s := NewMCPServer(WithRootDir(t.TempDir()))
+	s.RegisterSchemeHandler("mem", func(ctx context.Context, u *url.URL) (Content, error) {
+		if u.Host == "coded" {
+			return Content{}, fmt.Errorf("lookup: %w", WithRPCCode(errors.New("quota exceeded"), -32050))
+		}
+		return Content{}, errors.New("backend down")
+	})
+	read := func(uri string) *JSONRPCError {
+		resp := s.HandleRequest(context.Background(), &JSONRPCRequest{JSONRPC: "2.0", ID: IntID(1), Method: "resources/read", Params: map[string]interface{}{"uri": uri}})
+		return resp.Error
+	}
+
+	for uri, want := range map[string]struct {
+		code    int
+		message string
+	}{
+		"file:///missing.txt": {CodeResourceNotFound, "Resource not found"},
+		"mem://coded/x":       {-32050, "Failed to read resource"},
+		"mem://plain/x":       {-32603, "Failed to read resource"},
+	} {
+		if err := read(uri); err == nil || err.Code != want.code || err.Message != want.message {
+			t.Errorf("%s: got %+v, want %d %q", uri, err, want.code, want.message)
+		}
+	}
+	if WithRPCCode(nil, CodeResourceNotFound) != nil {
+		t.Fatal("WithRPCCode(nil) is not nil")
+	}
+}
+
+// TestMatchPattern checks glob and trailing ** matching and pattern validation
+// TestMatchPattern: globと末尾の**による照合、およびパターンの検証を確認するテスト
+func TestMatchPattern(t *testing.T) {
+	for _, tt := range []struct {
+		pattern, uri string
+		want         bool
+	}{
+		{"file:///logs/*", "file:///logs/a.log", true},
+		{"file:///logs/*", "file:///logs/2024/a.log", false},
+		{"file:///logs/**", "file:///logs/2024/a.log", true},
+		{"file:///logs/**", "file:///other/a.log", false},
+		{"file:///logs/*.log", "file:///logs/a.txt", false},
+		{"file:///logs/?.log", "file:///logs/a.log", true},
+	} {
+		if got := matchPattern(tt.pattern, tt.uri); got != tt.want {
+			t.Errorf("matchPattern(%q, %q): got %v", tt.pattern, tt.uri, got)
+		}
+	}
+	for _, pattern := range []string{"", "**", "file:///**/x", "file:///[a"} {
+		if validatePattern(pattern) == nil {
+			t.Errorf("pattern %q accepted", pattern)
+		}
+	}
+}
+
+// TestSubscribePattern checks that a pattern subscription notifies matching URIs only,
+// that invalid patterns get -32602, and that unsubscribing stops the notifications
+// TestSubscribePattern: パターンによる購読が一致するURIだけを通知し、不正なパターンが-32602になり、
+// 購読解除で通知が止まることを確認するテスト
+func TestSubscribePattern(t *testing.T) {
+	s := NewMCPServer()
+	var out syncBuffer
+	c := s.newConnSession(&out)
+	c.initialized.Store(true)
+	defer s.addConn(c)()
+	ctx := withConn(context.Background(), c)
+	request := func(method, pattern string) *JSONRPCResponse {
+		return s.HandleRequest(ctx, &JSONRPCRequest{JSONRPC: "2.0", ID: IntID(1), Method: method, Params: map[string]interface{}{"pattern": pattern}})
+	}
+
+	if resp := request("resources/subscribe", "file:///logs/[a"); resp.Error == nil || resp.Error.Code != -32602 {
+		t.Fatalf("invalid pattern: got %+v, want -32602", resp.Error)
+	}
+	if resp := request("resources/subscribe", "file:///logs/*"); resp.Error != nil {
+		t.Fatal(resp.Error)
+	}
+	s.NotifyResourceUpdated("file:///logs/a.log")
+	s.NotifyResourceUpdated("file:///etc/passwd")
+	if got := out.String(); strings.Count(got, "\n") != 1 || !strings.Contains(got, `"uri":"file:///logs/a.log"`) {
+		t.Fatalf("notifications: %q", got)
+	}
+
+	if resp := request("resources/unsubscribe", "file:///logs/*"); resp.Error != nil {
+		t.Fatal(resp.Error)
+	}
+	s.NotifyResourceUpdated("file:///logs/b.log")
+	if strings.Contains(out.String(), "b.log") {
+		t.Fatal("notified after unsubscribe")
+	}
+}
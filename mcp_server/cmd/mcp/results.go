@@ -0,0 +1,70 @@
+package main
+
+// Typed results of the built-in methods
+// 組み込みメソッドの型付き結果
+// Handlers return these instead of ad-hoc maps so the wire format is explicit
+// and checked at compile time. tools/call results stay maps, since their shape
+// is decided by each tool handler.
+// ハンドラーは場当たり的なマップではなくこれらを返すため、ワイヤー形式が明示され
+// コンパイル時に検査される。tools/callの結果は各ツールハンドラーが形を決めるためマップのまま
+
+// ToolsListResult is the result of the tools/list method
+// ToolsListResult: tools/listメソッドの結果
+type ToolsListResult struct {
+	Tools      []Tool `json:"tools"`                // tools: page of tools (ツールのページ)
+	NextCursor string `json:"nextCursor,omitempty"` // nextCursor: cursor of the next page (次ページのカーソル)
+}
+
+// ToolsGetResult is the result of the tools/get method
+// ToolsGetResult: tools/getメソッドの結果
+type ToolsGetResult struct {
+	Tool Tool `json:"tool"` // tool: tool definition (ツール定義)
+}
+
+// ResourcesListResult is the result of the resources/list method
+// ResourcesListResult: resources/listメソッドの結果
+type ResourcesListResult struct {
+	Resources  []Resource `json:"resources"`            // resources: page of resources (リソースのページ)
+	NextCursor string     `json:"nextCursor,omitempty"` // nextCursor: cursor of the next page (次ページのカーソル)
+}
+
+// PromptsListResult is the result of the prompts/list method
+// PromptsListResult: prompts/listメソッドの結果
+type PromptsListResult struct {
+	Prompts []Prompt `json:"prompts"` // prompts: registered prompts (登録済みプロンプト)
+}
+
+// GetPromptResult is the result of the prompts/get method
+// GetPromptResult: prompts/getメソッドの結果
+type GetPromptResult struct {
+	Description string          `json:"description"` // description: prompt description (プロンプトの説明)
+	Messages    []PromptMessage `json:"messages"`    // messages: rendered messages (生成されたメッセージ)
+}
+
+// EmptyResult is the result of methods that return no data, encoded as {}
+// EmptyResult: データを返さないメソッドの結果 ({}としてエンコードされる)
+type EmptyResult struct{}
+
+// ConfigGetResult is the result of config/get without a name
+// ConfigGetResult: 名前を指定しないconfig/getの結果
+type ConfigGetResult struct {
+	Settings map[string]interface{} `json:"settings"` // settings: current values by name (名前毎の現在値)
+}
+
+// SettingResult is the result of config/get with a name, and of config/set
+// SettingResult: 名前を指定したconfig/get、およびconfig/setの結果
+type SettingResult struct {
+	Name  string      `json:"name"`  // name: setting name (設定名)
+	Value interface{} `json:"value"` // value: current value (現在値)
+}
+
+// ServerStatsResult is the result of the server/stats method
+// ServerStatsResult: server/statsメソッドの結果
+type ServerStatsResult struct {
+	UptimeSeconds float64 `json:"uptimeSeconds"` // uptimeSeconds: seconds since the server was created (サーバー作成からの秒数)
+	Tools         int     `json:"tools"`         // tools: registered tools (登録済みツール数)
+	Resources     int     `json:"resources"`     // resources: registered resources (登録済みリソース数)
+	Prompts       int     `json:"prompts"`       // prompts: registered prompts (登録済みプロンプト数)
+	Requests      int64   `json:"requests"`      // requests: requests handled so far (これまでに処理したリクエスト数)
+	InFlight      int64   `json:"inFlight"`      // inFlight: requests being handled, including this one (このリクエストを含む処理中のリクエスト数)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"       // context: request contexts and prompt handlers (リクエストコンテキストとプロンプトハンドラー)
+	"encoding/json" // encoding/json: encoding results (結果のエンコード)
+	"reflect"       // reflect: result types (結果の型)
+	"testing"       // testing: test framework (テストフレームワーク)
+)
+
+// TestTypedResultShapes checks that each built-in method returns its typed result
+// and that it encodes to the expected wire shape
+// TestTypedResultShapes: 各組み込みメソッドが型付きの結果を返し、
+// それが期待するワイヤー形式にエンコードされることを確認するテスト
+func TestTypedResultShapes(t *testing.T) {
+	s := NewMCPServer()
+	s.RegisterTool(Tool{Name: "t", Description: "a tool"})
+	s.RegisterResource(Resource{URI: "data:,hi", Name: "hi", Description: "greeting", MimeType: "text/plain"})
+	s.RegisterPrompt(Prompt{Name: "p", Description: "a prompt", Handler: func(ctx context.Context, arguments map[string]string) ([]PromptMessage, error) {
+		return []PromptMessage{{Role: "user", Content: TextContent("hello")}}, nil
+	}})
+
+	for _, tt := range []struct {
+		method string
+		params map[string]interface{}
+		typed  interface{}
+		want   string
+	}{
+		{
+			"tools/list", nil,
+			(*ToolsListResult)(nil),
+			`{"tools":[{"name":"t","description":"a tool","inputSchema":null}]}`,
+		},
+		{
+			"tools/get", map[string]interface{}{"name": "t"},
+			(*ToolsGetResult)(nil),
+			`{"tool":{"name":"t","description":"a tool","inputSchema":null}}`,
+		},
+		{
+			"resources/list", nil,
+			(*ResourcesListResult)(nil),
+			`{"resources":[{"uri":"data:,hi","name":"hi","description":"greeting","mimeType":"text/plain"}]}`,
+		},
+		{
+			"prompts/list", nil,
+			(*PromptsListResult)(nil),
+			`{"prompts":[{"name":"p","description":"a prompt"}]}`,
+		},
+		{
+			"prompts/get", map[string]interface{}{"name": "p"},
+			(*GetPromptResult)(nil),
+			`{"description":"a prompt","messages":[{"role":"user","content":{"text":"hello","type":"text"}}]}`,
+		},
+		{
+			"resources/subscribe", map[string]interface{}{"uri": "data:,hi"},
+			(*EmptyResult)(nil),
+			`{}`,
+		},
+	} {
+		resp := s.HandleRequest(context.Background(), &JSONRPCRequest{JSONRPC: "2.0", ID: IntID(1), Method: tt.method, Params: tt.params})
+		if resp.Error != nil {
+			t.Errorf("%s: %v", tt.method, resp.Error)
+			continue
+		}
+		if reflect.TypeOf(resp.Result) != reflect.TypeOf(tt.typed) {
+			t.Errorf("%s: result is %T", tt.method, resp.Result)
+		}
+		data, err := json.Marshal(resp.Result)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != tt.want {
+			t.Errorf("%s:\ngot  %s\nwant %s", tt.method, data, tt.want)
+		}
+	}
+}
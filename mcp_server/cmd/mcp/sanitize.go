@@ -0,0 +1,41 @@
+package main
+
+import (
+	"strings" // strings: line rewriting (行の書き換え)
+)
+
+// utf8BOM is the byte order mark some clients prepend to their output
+// utf8BOM: 一部のクライアントが出力の先頭に付けるバイトオーダーマーク
+const utf8BOM = "\uFEFF"
+
+// sanitizeLine strips a leading BOM and control characters JSON never allows unescaped
+// sanitizeLine: 先頭のBOMと、JSONがエスケープ無しでは許さない制御文字を取り除く関数
+// Tab and carriage return are kept, since they are valid whitespace between tokens.
+// It returns the cleaned line, whether a BOM was removed and how many control
+// characters were dropped.
+// タブとCRはトークン間の空白として有効なため残す。
+// 整形後の行、BOMを取り除いたか、取り除いた制御文字の数を返す
+func sanitizeLine(line string) (clean string, bom bool, dropped int) {
+	line, bom = strings.CutPrefix(line, utf8BOM)
+	clean = strings.Map(func(r rune) rune {
+		if r < 0x20 && r != '\t' && r != '\r' {
+			dropped++
+			return -1 // drop: 取り除く
+		}
+		return r
+	}, line)
+	return clean, bom, dropped
+}
+
+// sanitize applies sanitizeLine when input sanitizing is enabled, logging any change
+// sanitize: 入力の整形が有効な場合にsanitizeLineを適用し、変更があれば記録する関数
+func (s *MCPServer) sanitize(line string) string {
+	if !s.sanitizeInput {
+		return line
+	}
+	clean, bom, dropped := sanitizeLine(line)
+	if bom || dropped > 0 {
+		s.logger.Warn("sanitized input line", "bom", bom, "controlChars", dropped) // sanitized: 整形した
+	}
+	return clean
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"    // bytes: captured log output (取得したログ出力)
+	"context"  // context: RunIO lifetime (RunIOの存続期間)
+	"log/slog" // log/slog: logger capturing warnings (警告を取得するロガー)
+	"strings"  // strings: in-memory input and output (メモリ内の入出力)
+	"testing"  // testing: test framework (テストフレームワーク)
+)
+
+// TestSanitizeLine checks that a leading BOM and control characters are removed
+// while tab and carriage return are kept
+// TestSanitizeLine: 先頭のBOMと制御文字が取り除かれ、タブとCRは残ることを確認するテスト
+func TestSanitizeLine(t *testing.T) {
+	for _, tt := range []struct {
+		line, want string
+		bom        bool
+		dropped    int
+	}{
+		{`{"a":1}`, `{"a":1}`, false, 0},
+		{utf8BOM + `{"a":1}`, `{"a":1}`, true, 0},
+		{"{\"a\":\x00\x01 1}", `{"a": 1}`, false, 2},
+		{"{\t\"a\":1}\r", "{\t\"a\":1}\r", false, 0},
+	} {
+		got, bom, dropped := sanitizeLine(tt.line)
+		if got != tt.want || bom != tt.bom || dropped != tt.dropped {
+			t.Errorf("sanitizeLine(%q): got %q, %v, %d; want %q, %v, %d", tt.line, got, bom, dropped, tt.want, tt.bom, tt.dropped)
+		}
+	}
+}
+
+// TestRunIOSanitizeInput checks that with WithInputSanitizing lines with a BOM or an
+// embedded NUL are served and logged, and that without it they fail to parse
+// TestRunIOSanitizeInput: WithInputSanitizingではBOMや埋め込まれたNULを含む行が処理されて
+// 記録され、無効時は解析に失敗することを確認するテスト
+func TestRunIOSanitizeInput(t *testing.T) {
+	input := utf8BOM + `{"jsonrpc":"2.0","id":1,"method":"tools/list"}` + "\n" +
+		`{"jsonrpc":"2.0","id":2,` + "\x00" + `"method":"tools/list"}` + "\n"
+
+	var logs bytes.Buffer
+	s := NewMCPServer(WithInputSanitizing(), WithLogger(slog.New(slog.NewTextHandler(&logs, nil))))
+	var out strings.Builder
+	if err := s.RunIO(context.Background(), strings.NewReader(input), &out); err != nil {
+		t.Fatal(err)
+	}
+	for i, msg := range decodeLines(t, out.String()) {
+		if msg["id"] != float64(i+1) || msg["error"] != nil {
+			t.Errorf("response %d: %v", i, msg)
+		}
+	}
+	if got := strings.Count(logs.String(), "sanitized input line"); got != 2 {
+		t.Errorf("got %d sanitize warnings, want 2:\n%s", got, logs.String())
+	}
+
+	// Without the option both lines fail to parse: オプション無しでは両方の行が解析に失敗する
+	logs.Reset()
+	out.Reset()
+	plain := NewMCPServer(WithLogger(slog.New(slog.NewTextHandler(&logs, nil))))
+	if err := plain.RunIO(context.Background(), strings.NewReader(input), &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Len() != 0 || strings.Count(logs.String(), "JSON parsing failed") != 2 {
+		t.Fatalf("without sanitizing: output %q, log:\n%s", out.String(), logs.String())
+	}
+}
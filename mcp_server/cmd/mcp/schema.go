@@ -0,0 +1,536 @@
+package main
+
+import (
+	"encoding/json" // encoding/json: schema normalization (スキーマの正規化)
+	"fmt"           // fmt: formatted errors (フォーマット済みエラー)
+	"reflect"       // reflect: enum comparison (enumの比較)
+	"regexp"        // regexp: pattern keyword (patternキーワード)
+	"sort"          // sort: deterministic property order (決定的なプロパティ順)
+	"strings"       // strings: JSON pointer parsing (JSONポインターの解析)
+)
+
+// argumentSchema validates tool arguments against a tool's inputSchema
+// argumentSchema: ツールの引数をinputSchemaに照らして検証する構造体
+// It understands type, properties, required, items, enum, pattern, numeric bounds
+// and local $ref; other keywords are ignored.
+// type、properties、required、items、enum、pattern、数値の範囲、ローカルの$refを解釈し、
+// それ以外のキーワードは無視する
+type argumentSchema struct {
+	root     map[string]interface{}    // root: normalized schema document (正規化されたスキーマ文書)
+	patterns map[string]*regexp.Regexp // patterns: compiled pattern keywords (コンパイル済みのpattern)
+}
+
+// schemaViolation describes the first constraint an argument breaks
+// schemaViolation: 引数が最初に違反した制約を表すエラー
+// violation: 違反
+type schemaViolation struct {
+	Path    string // path: location of the offending value, e.g. arguments.user.name (違反した値の位置)
+	Keyword string // keyword: violated schema keyword (違反したスキーマキーワード)
+	Reason  string // reason: human-readable explanation (人が読むための説明)
+
+	Constraint interface{} // constraint: the violated keyword's value, such as the enum list (enumのリストなど違反したキーワードの値)
+}
+
+func (v *schemaViolation) Error() string {
+	return fmt.Sprintf("%s: %s", v.Path, v.Reason)
+}
+
+// compileSchema normalizes schema, lints its keywords, checks its $refs and compiles its patterns
+// compileSchema: スキーマを正規化し、キーワードを検査し、$refを検査し、patternをコンパイルする関数
+// The schema is round-tripped through JSON so Go-literal and decoded schemas look alike.
+// Goリテラルとデコード済みのスキーマが同じ形になるよう、スキーマをJSON経由で往復させる
+// A nil schema compiles to nil, which accepts any arguments.
+// nilのスキーマはnilにコンパイルされ、任意の引数を受け入れる
+func compileSchema(schema interface{}) (*argumentSchema, error) {
+	if schema == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return nil, fmt.Errorf("marshal input schema: %w", err)
+	}
+	var root map[string]interface{}
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("input schema must be a JSON object: %w", err)
+	}
+
+	if err := lintSchema(root, "#"); err != nil {
+		return nil, err
+	}
+	a := &argumentSchema{root: root, patterns: make(map[string]*regexp.Regexp)}
+	if err := a.prepare(root); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// schemaTypes are the type names JSON Schema defines
+// schemaTypes: JSON Schemaが定義する型名
+var schemaTypes = map[string]bool{
+	"null": true, "boolean": true, "object": true, "array": true,
+	"number": true, "integer": true, "string": true,
+}
+
+// lintSchema checks that the keywords the validator understands are well-formed
+// lintSchema: バリデーターが解釈するキーワードが正しい形であることを検査する関数
+// It follows the schema structure rather than every object, so a property named
+// "type" is not mistaken for the keyword. at is a JSON pointer used in errors.
+// すべてのオブジェクトではなくスキーマの構造に沿って走査するため、"type"という名前の
+// プロパティをキーワードと取り違えない。atはエラーで使うJSONポインター
+func lintSchema(node interface{}, at string) error {
+	n, ok := node.(map[string]interface{})
+	if !ok {
+		if _, isBool := node.(bool); isBool {
+			return nil // true and false are valid schemas: trueとfalseは有効なスキーマ
+		}
+		return fmt.Errorf("schema at %s must be an object", at)
+	}
+
+	switch t := n["type"].(type) {
+	case nil:
+	case string:
+		if !schemaTypes[t] {
+			return fmt.Errorf("unknown type %q at %s", t, at)
+		}
+	case []interface{}:
+		for _, name := range t {
+			if name, ok := name.(string); !ok || !schemaTypes[name] {
+				return fmt.Errorf("unknown type %v at %s", name, at)
+			}
+		}
+	default:
+		return fmt.Errorf("type at %s must be a string or an array of strings", at)
+	}
+	if p, ok := n["pattern"]; ok {
+		if _, ok := p.(string); !ok {
+			return fmt.Errorf("pattern at %s must be a string", at)
+		}
+	}
+	if r, ok := n["$ref"]; ok {
+		if _, ok := r.(string); !ok {
+			return fmt.Errorf("$ref at %s must be a string", at)
+		}
+	}
+	if e, ok := n["enum"]; ok {
+		if _, ok := e.([]interface{}); !ok {
+			return fmt.Errorf("enum at %s must be an array", at)
+		}
+	}
+	if req, ok := n["required"]; ok {
+		names, ok := req.([]interface{})
+		if !ok {
+			return fmt.Errorf("required at %s must be an array of strings", at)
+		}
+		for _, name := range names {
+			if _, ok := name.(string); !ok {
+				return fmt.Errorf("required at %s must be an array of strings", at)
+			}
+		}
+	}
+	for _, key := range []string{"minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum", "minLength", "maxLength", "minItems", "maxItems"} {
+		switch v := n[key].(type) {
+		case nil, float64:
+		case bool:
+			if key != "exclusiveMinimum" && key != "exclusiveMaximum" {
+				return fmt.Errorf("%s at %s must be a number", key, at)
+			}
+			// draft-04 boolean form: draft-04の真偽値形式
+		default:
+			return fmt.Errorf("%s at %s must be a number, got %v", key, at, v)
+		}
+	}
+
+	// Subschemas: サブスキーマ
+	for _, key := range []string{"properties", "definitions", "$defs", "patternProperties"} {
+		if v, ok := n[key]; ok {
+			children, ok := v.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("%s at %s must be an object", key, at)
+			}
+			for name, child := range children {
+				if err := lintSchema(child, at+"/"+key+"/"+name); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	for _, key := range []string{"allOf", "anyOf", "oneOf"} {
+		if v, ok := n[key]; ok {
+			children, ok := v.([]interface{})
+			if !ok {
+				return fmt.Errorf("%s at %s must be an array", key, at)
+			}
+			for i, child := range children {
+				if err := lintSchema(child, fmt.Sprintf("%s/%s/%d", at, key, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	for _, key := range []string{"not", "additionalProperties"} {
+		if v, ok := n[key]; ok {
+			if err := lintSchema(v, at+"/"+key); err != nil {
+				return err
+			}
+		}
+	}
+	if items, ok := n["items"]; ok {
+		if tuple, ok := items.([]interface{}); ok {
+			for i, child := range tuple {
+				if err := lintSchema(child, fmt.Sprintf("%s/items/%d", at, i)); err != nil {
+					return err
+				}
+			}
+		} else if err := lintSchema(items, at+"/items"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// prepare walks the schema, rejecting unresolvable refs, cycles of pure refs and bad patterns
+// prepare: スキーマを走査し、解決できない$ref、$refだけの循環、不正なpatternを拒否する関数
+// Cycles through properties or items are fine, since each step consumes input.
+// propertiesやitemsを経由する循環は、各段階で入力を消費するため問題ない
+func (a *argumentSchema) prepare(node interface{}) error {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		// Compile once at registration: 登録時に一度だけコンパイル
+		if pattern, ok := n["pattern"].(string); ok {
+			if _, done := a.patterns[pattern]; !done {
+				re, err := regexp.Compile(pattern)
+				if err != nil {
+					return fmt.Errorf("invalid pattern %q in input schema: %w", pattern, err)
+				}
+				a.patterns[pattern] = re
+			}
+		}
+		if _, ok := n["$ref"]; ok {
+			seen := make(map[string]bool) // seen: 訪問済みの参照
+			for cur := n; ; {
+				ref, ok := cur["$ref"].(string)
+				if !ok {
+					break
+				}
+				if seen[ref] {
+					return fmt.Errorf("circular $ref %q in input schema", ref) // circular: 循環した
+				}
+				seen[ref] = true
+				target, err := a.resolve(ref)
+				if err != nil {
+					return err
+				}
+				cur = target
+			}
+		}
+		for key, child := range n {
+			if key == "enum" || key == "const" || key == "default" || key == "examples" {
+				continue // literal values, not schemas: スキーマではなく値
+			}
+			if err := a.prepare(child); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, child := range n {
+			if err := a.prepare(child); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// resolve looks up a local reference such as #/definitions/Address
+// resolve: #/definitions/Addressのようなローカル参照を解決する関数
+func (a *argumentSchema) resolve(ref string) (map[string]interface{}, error) {
+	if ref != "#" && !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("unsupported $ref %q: only local references are allowed", ref)
+	}
+	var node interface{} = a.root
+	for _, token := range strings.Split(strings.TrimPrefix(ref, "#"), "/")[1:] {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token) // JSON pointer escapes: JSONポインターのエスケープ
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unresolvable $ref %q", ref)
+		}
+		if node, ok = m[token]; !ok {
+			return nil, fmt.Errorf("unresolvable $ref %q", ref)
+		}
+	}
+	target, ok := node.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("$ref %q does not point to a schema", ref)
+	}
+	return target, nil
+}
+
+// validate checks arguments against the schema
+// validate: 引数をスキーマに照らして検査する関数
+// Missing arguments are treated as an empty object.
+// 引数が省略された場合は空のオブジェクトとして扱う
+func (a *argumentSchema) validate(arguments interface{}) error {
+	if a == nil {
+		return nil
+	}
+	if arguments == nil {
+		arguments = map[string]interface{}{}
+	}
+	return a.validateAt(arguments, "arguments")
+}
+
+// validateAt checks value against the schema, reporting paths under root
+// validateAt: 値をスキーマに照らして検査し、root以下のパスで違反を報告する関数
+func (a *argumentSchema) validateAt(value interface{}, root string) error {
+	// Normalize Go values to their decoded JSON shapes: Goの値をデコード済みJSONの形に正規化
+	data, err := json.Marshal(value)
+	if err != nil {
+		return &schemaViolation{Path: root, Keyword: "type", Reason: "is not JSON-encodable"}
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return fmt.Errorf("decode %s: %w", root, err)
+	}
+	return a.check(a.root, decoded, root)
+}
+
+// validateOutput checks a tool result's structuredContent against the output schema
+// validateOutput: ツール結果のstructuredContentを出力スキーマに照らして検査する関数
+// Error results are not checked, since they carry no structured output.
+// エラー結果は構造化出力を持たないため検査しない
+func (a *argumentSchema) validateOutput(result map[string]interface{}) error {
+	if a == nil || result["error"] != nil || result["isError"] == true {
+		return nil
+	}
+	structured, ok := result["structuredContent"]
+	if !ok {
+		return &schemaViolation{
+			Path:    "structuredContent",
+			Keyword: "required",
+			Reason:  "is required by the output schema", // required: 必須の
+		}
+	}
+	return a.validateAt(structured, "structuredContent")
+}
+
+// check validates value against one schema node
+// check: 1つのスキーマノードに照らして値を検査する関数
+func (a *argumentSchema) check(node map[string]interface{}, value interface{}, path string) error {
+	// Follow references; compileSchema ruled out cycles: 参照をたどる (循環はcompileSchemaで排除済み)
+	for {
+		ref, ok := node["$ref"].(string)
+		if !ok {
+			break
+		}
+		target, err := a.resolve(ref)
+		if err != nil {
+			return err
+		}
+		node = target
+	}
+
+	if err := checkType(node, value, path); err != nil {
+		return err
+	}
+	if err := checkEnum(node, value, path); err != nil {
+		return err
+	}
+	if n, ok := value.(float64); ok {
+		if err := checkRange(node, n, path); err != nil {
+			return err
+		}
+	}
+	if pattern, ok := node["pattern"].(string); ok {
+		if str, ok := value.(string); ok && !a.patterns[pattern].MatchString(str) {
+			return &schemaViolation{
+				Path:       path,
+				Keyword:    "pattern",
+				Reason:     fmt.Sprintf("must match pattern %q", pattern),
+				Constraint: pattern,
+			}
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		required, _ := node["required"].([]interface{})
+		for _, name := range required {
+			name, _ := name.(string)
+			if _, ok := v[name]; !ok {
+				return &schemaViolation{Path: path + "." + name, Keyword: "required", Reason: "is required", Constraint: required}
+			}
+		}
+		// Check properties in name order so the reported violation is stable
+		// 報告される違反が安定するよう、プロパティを名前順に検査する
+		properties, _ := node["properties"].(map[string]interface{})
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			sub, ok := properties[name].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if err := a.check(sub, v[name], path+"."+name); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		items, ok := node["items"].(map[string]interface{})
+		if !ok {
+			break
+		}
+		for i, item := range v {
+			if err := a.check(items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkType enforces the type keyword, which may be a name or a list of names
+// checkType: 名前または名前のリストであるtypeキーワードを検査する関数
+func checkType(node map[string]interface{}, value interface{}, path string) error {
+	var allowed []string
+	switch t := node["type"].(type) {
+	case string:
+		allowed = []string{t}
+	case []interface{}:
+		for _, name := range t {
+			if name, ok := name.(string); ok {
+				allowed = append(allowed, name)
+			}
+		}
+	default:
+		return nil // no type constraint: 型の制約なし
+	}
+
+	for _, name := range allowed {
+		if hasType(value, name) {
+			return nil
+		}
+	}
+	return &schemaViolation{
+		Path:    path,
+		Keyword: "type",
+		Reason:  fmt.Sprintf("must be %s, got %s", strings.Join(allowed, " or "), jsonType(value)),
+
+		Constraint: node["type"],
+	}
+}
+
+// checkEnum requires value to equal one of the enum entries
+// checkEnum: valueがenumのいずれかの値と等しいことを要求する関数
+func checkEnum(node map[string]interface{}, value interface{}, path string) error {
+	allowed, ok := node["enum"].([]interface{})
+	if !ok {
+		return nil
+	}
+	for _, candidate := range allowed {
+		if reflect.DeepEqual(candidate, value) {
+			return nil
+		}
+	}
+	return &schemaViolation{
+		Path:       path,
+		Keyword:    "enum",
+		Reason:     fmt.Sprintf("must be one of %v", allowed),
+		Constraint: allowed,
+	}
+}
+
+// checkRange enforces minimum, maximum, exclusiveMinimum and exclusiveMaximum
+// checkRange: minimum、maximum、exclusiveMinimum、exclusiveMaximumを検査する関数
+// Draft-4 boolean exclusive flags are honored alongside the numeric form.
+// 数値形式に加えて、draft-4の真偽値による排他フラグにも対応する
+// exclusive: 排他的な (境界値を含まない)
+func checkRange(node map[string]interface{}, n float64, path string) error {
+	type bound struct {
+		keyword   string  // keyword: reported keyword (報告するキーワード)
+		limit     float64 // limit: 境界値
+		lower     bool    // lower: minimum side (下限側)
+		exclusive bool    // exclusive: limit itself is out of range (境界値自体が範囲外)
+	}
+	var bounds []bound
+	for _, side := range []struct {
+		inclusive, exclusive string
+		lower                bool
+	}{
+		{"minimum", "exclusiveMinimum", true},
+		{"maximum", "exclusiveMaximum", false},
+	} {
+		if limit, ok := node[side.inclusive].(float64); ok {
+			if flag, _ := node[side.exclusive].(bool); flag { // draft-4
+				bounds = append(bounds, bound{side.exclusive, limit, side.lower, true})
+			} else {
+				bounds = append(bounds, bound{side.inclusive, limit, side.lower, false})
+			}
+		}
+		if limit, ok := node[side.exclusive].(float64); ok {
+			bounds = append(bounds, bound{side.exclusive, limit, side.lower, true})
+		}
+	}
+
+	for _, b := range bounds {
+		var broken bool
+		var op string
+		switch {
+		case b.lower && b.exclusive:
+			broken, op = n <= b.limit, ">"
+		case b.lower:
+			broken, op = n < b.limit, ">="
+		case b.exclusive:
+			broken, op = n >= b.limit, "<"
+		default:
+			broken, op = n > b.limit, "<="
+		}
+		if broken {
+			return &schemaViolation{
+				Path:       path,
+				Keyword:    b.keyword,
+				Reason:     fmt.Sprintf("must be %s %v", op, b.limit),
+				Constraint: b.limit,
+			}
+		}
+	}
+	return nil
+}
+
+// hasType reports whether value is of the named JSON Schema type
+// hasType: valueが指定したJSON Schemaの型かを判定する関数
+func hasType(value interface{}, name string) bool {
+	switch name {
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == float64(int64(n))
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	default:
+		return jsonType(value) == name
+	}
+}
+
+// jsonType names the JSON type of a decoded value
+// jsonType: デコード済みの値のJSON型名を返す関数
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"       // context: request contexts (リクエストコンテキスト)
+	"encoding/json" // encoding/json: decoding test arguments (テスト用引数のデコード)
+	"errors"        // errors: error inspection (エラー検査)
+	"testing"       // testing: test framework (テストフレームワーク)
+)
+
+// violation calls tool with the JSON arguments and returns the -32602 error data,
+// or nil when the arguments are accepted
+// violation: JSON引数でtoolを呼び出し、-32602エラーのデータを返す関数 (受理された場合はnil)
+func violation(t *testing.T, s *MCPServer, tool, arguments string) map[string]interface{} {
+	t.Helper()
+	var args interface{}
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		t.Fatal(err)
+	}
+	resp := s.HandleRequest(context.Background(), &JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      IntID(1),
+		Method:  "tools/call",
+		Params:  map[string]interface{}{"name": tool, "arguments": args},
+	})
+	if resp.Error == nil {
+		return nil
+	}
+	if resp.Error.Code != -32602 {
+		t.Fatalf("%s: got %+v, want -32602", arguments, resp.Error)
+	}
+	return resp.Error.Data.(map[string]interface{})
+}
+
+// schemaServer returns a server with an echo-handled tool "v" using schema
+// schemaServer: schemaを使うechoハンドラーのツール"v"を持つサーバーを返す関数
+func schemaServer(t *testing.T, schema string) *MCPServer {
+	t.Helper()
+	var decoded map[string]interface{}
+	if err := json.Unmarshal([]byte(schema), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	s := NewMCPServer()
+	if err := s.TryRegisterTool(Tool{Name: "v", InputSchema: decoded, Handler: echo}); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// TestSchemaRefAndNesting checks that nested objects and recursive $refs are
+// validated with the path of the offending value
+// TestSchemaRefAndNesting: 入れ子のオブジェクトと再帰的な$refが、
+// 違反した値のパス付きで検証されることを確認するテスト
+func TestSchemaRefAndNesting(t *testing.T) {
+	s := schemaServer(t, `{
+		"type": "object",
+		"definitions": {"node": {
+			"type": "object",
+			"required": ["name"],
+			"properties": {"name": {"type": "string"}, "child": {"$ref": "#/definitions/node"}}
+		}},
+		"properties": {
+			"user": {"type": "object", "required": ["zip"], "properties": {"zip": {"type": "string"}}},
+			"tree": {"$ref": "#/definitions/node"}
+		}
+	}`)
+	for args, path := range map[string]string{
+		`{"user": {}}`: "arguments.user.zip",
+		`{"tree": {"name": "a", "child": {"name": 3}}}`: "arguments.tree.child.name",
+		`{"tree": {"name": "a", "child": {}}}`:          "arguments.tree.child.name",
+	} {
+		if data := violation(t, s, "v", args); data == nil || data["path"] != path {
+			t.Fatalf("%s: got %v, want path %s", args, data, path)
+		}
+	}
+	if data := violation(t, s, "v", `{"message": "m", "tree": {"name": "a", "child": {"name": "b"}}}`); data != nil {
+		t.Fatalf("valid arguments rejected: %v", data)
+	}
+}
+
+// TestSchemaInvalidRefs checks that cyclic and dangling $refs are refused at registration
+// TestSchemaInvalidRefs: 循環した$refと参照先の無い$refが登録時に拒否されることを確認するテスト
+func TestSchemaInvalidRefs(t *testing.T) {
+	s := NewMCPServer()
+	for name, schema := range map[string]map[string]interface{}{
+		"cycle": {"definitions": map[string]interface{}{
+			"a": map[string]interface{}{"$ref": "#/definitions/b"},
+			"b": map[string]interface{}{"$ref": "#/definitions/a"},
+		}},
+		"dangling": {"$ref": "#/nope"},
+	} {
+		if err := s.TryRegisterTool(Tool{Name: name, InputSchema: schema}); err == nil {
+			t.Fatalf("%s $ref accepted", name)
+		}
+	}
+}
+
+// TestSchemaEnumAndPattern checks that enum and pattern violations report their
+// keyword and constraint, and that an invalid pattern is refused at registration
+// TestSchemaEnumAndPattern: enumとpatternの違反がキーワードと制約を報告し、
+// 不正なpatternが登録時に拒否されることを確認するテスト
+func TestSchemaEnumAndPattern(t *testing.T) {
+	s := schemaServer(t, `{"type": "object", "properties": {
+		"color": {"type": "string", "enum": ["red", "blue"]},
+		"code": {"type": "string", "pattern": "^[A-Z]{3}$"}
+	}}`)
+
+	data := violation(t, s, "v", `{"color": "green"}`)
+	if data == nil || data["keyword"] != "enum" || data["path"] != "arguments.color" {
+		t.Fatalf("enum: got %v", data)
+	}
+	if enum, _ := data["constraint"].([]interface{}); len(enum) != 2 {
+		t.Fatalf("enum constraint: got %v, want the allowed values", data["constraint"])
+	}
+	data = violation(t, s, "v", `{"code": "abc"}`)
+	if data == nil || data["keyword"] != "pattern" || data["constraint"] != "^[A-Z]{3}$" {
+		t.Fatalf("pattern: got %v", data)
+	}
+	if data = violation(t, s, "v", `{"message": "m", "color": "red", "code": "ABC"}`); data != nil {
+		t.Fatalf("valid arguments rejected: %v", data)
+	}
+
+	if err := s.TryRegisterTool(Tool{Name: "bad", InputSchema: map[string]interface{}{"pattern": "("}}); err == nil {
+		t.Fatal("invalid pattern accepted")
+	}
+}
+
+// TestSchemaNumericRange checks minimum, maximum and both forms of the exclusive
+// bounds, and that integer rejects fractional numbers
+// TestSchemaNumericRange: minimum、maximum、両形式の排他的境界と、
+// integerが小数を拒否することを確認するテスト
+func TestSchemaNumericRange(t *testing.T) {
+	s := schemaServer(t, `{"type": "object", "properties": {
+		"n": {"type": "integer", "minimum": 1, "maximum": 10},
+		"x": {"type": "number", "exclusiveMinimum": 0, "exclusiveMaximum": 1},
+		"d4": {"type": "number", "minimum": 0, "exclusiveMinimum": true}
+	}}`)
+	for args, keyword := range map[string]string{
+		`{"n": 0}`:   "minimum",
+		`{"n": 11}`:  "maximum",
+		`{"n": 2.5}`: "type",
+		`{"x": 0}`:   "exclusiveMinimum", // draft 6 number form: draft 6の数値形式
+		`{"x": 1}`:   "exclusiveMaximum",
+		`{"d4": 0}`:  "exclusiveMinimum", // draft 4 boolean form: draft 4の真偽値形式
+	} {
+		if data := violation(t, s, "v", args); data == nil || data["keyword"] != keyword {
+			t.Fatalf("%s: got %v, want keyword %s", args, data, keyword)
+		}
+	}
+	if data := violation(t, s, "v", `{"message": "m", "n": 10, "x": 0.5, "d4": 0.1}`); data != nil {
+		t.Fatalf("valid arguments rejected: %v", data)
+	}
+}
+
+// TestOutputSchema checks that structuredContent is validated against a tool's output
+// schema, failing with -32603 and the violation path, and that error results and an
+// invalid output schema are handled
+// TestOutputSchema: structuredContentがツールの出力スキーマで検証され、違反時は-32603と
+// 違反箇所で失敗し、エラー結果と不正な出力スキーマが適切に扱われることを確認するテスト
+func TestOutputSchema(t *testing.T) {
+	output := map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{"n": map[string]interface{}{"type": "number"}},
+		"required":   []string{"n"},
+	}
+	s := NewMCPServer()
+	for name, result := range map[string]map[string]interface{}{
+		"good":    {"content": []interface{}{}, "structuredContent": map[string]interface{}{"n": 3.0}},
+		"bad":     {"content": []interface{}{}, "structuredContent": map[string]interface{}{"n": "x"}},
+		"missing": {"content": []interface{}{}},
+		"failed":  {"content": []interface{}{}, "isError": true},
+	} {
+		if err := s.TryRegisterTool(Tool{Name: name, OutputSchema: output, Handler: func(ctx context.Context, arguments interface{}) (map[string]interface{}, error) {
+			return result, nil
+		}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	call := func(name string) *JSONRPCResponse {
+		return s.HandleRequest(context.Background(), &JSONRPCRequest{JSONRPC: "2.0", ID: IntID(1), Method: "tools/call", Params: map[string]interface{}{"name": name}})
+	}
+
+	for _, name := range []string{"good", "failed"} {
+		if resp := call(name); resp.Error != nil {
+			t.Errorf("%s: %v", name, resp.Error)
+		}
+	}
+	for name, path := range map[string]string{"bad": "structuredContent.n", "missing": "structuredContent"} {
+		resp := call(name)
+		if resp.Error == nil || resp.Error.Code != -32603 || resp.Error.Message != "Invalid tool output" {
+			t.Errorf("%s: got %+v, want -32603", name, resp.Error)
+			continue
+		}
+		if got := resp.Error.Data.(map[string]interface{})["path"]; got != path {
+			t.Errorf("%s: got path %v, want %s", name, got, path)
+		}
+	}
+
+	err := s.TryRegisterTool(Tool{Name: "broken", OutputSchema: map[string]interface{}{"type": 5}})
+	if !errors.Is(err, ErrInvalidSchema) {
+		t.Fatalf("invalid output schema: got %v, want ErrInvalidSchema", err)
+	}
+}
+
+// TestSchemaValidatedAtRegistration checks that malformed schemas are refused with
+// ErrInvalidSchema, that a property named "type" is not mistaken for a keyword, and
+// that WithStrictSchemas turns the refusal into a panic
+// TestSchemaValidatedAtRegistration: 不正なスキーマがErrInvalidSchemaで拒否され、
+// "type"という名前のプロパティがキーワードと誤解されず、WithStrictSchemasでは
+// 拒否がパニックになることを確認するテスト
+func TestSchemaValidatedAtRegistration(t *testing.T) {
+	s := NewMCPServer()
+	for name, schema := range map[string]string{
+		"unknown type":      `{"type": "strng"}`,
+		"bad pattern":       `{"type": "object", "properties": {"a": {"pattern": "("}}}`,
+		"required not list": `{"type": "object", "required": "a"}`,
+	} {
+		var decoded map[string]interface{}
+		if err := json.Unmarshal([]byte(schema), &decoded); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.TryRegisterTool(Tool{Name: "bad", InputSchema: decoded, Handler: echo}); !errors.Is(err, ErrInvalidSchema) {
+			t.Errorf("%s: got %v, want ErrInvalidSchema", name, err)
+		}
+	}
+	schemaServer(t, `{"type": "object", "properties": {"type": {"type": "string"}}}`)
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("WithStrictSchemas did not panic")
+		}
+	}()
+	NewMCPServer(WithStrictSchemas()).RegisterTool(Tool{Name: "bad", InputSchema: map[string]interface{}{"type": "strng"}, Handler: echo})
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"context"     // context: carries the session through a request (リクエスト中のセッション受け渡し)
+	"crypto/rand" // crypto/rand: unguessable session ids (推測不能なセッションID)
+	"errors"      // errors: sentinel errors (番兵エラー)
+	"sync"        // sync: guards the session table (セッション表の保護)
+	"time"        // time: idle expiry (アイドル期限切れ)
+)
+
+// sessionHeader carries the session id on HTTP requests and responses
+// sessionHeader: HTTPのリクエストとレスポンスでセッションIDを運ぶヘッダー
+const sessionHeader = "Mcp-Session-Id"
+
+// defaultSessionIdleTimeout is how long an unused HTTP session stays valid
+// defaultSessionIdleTimeout: 使われていないHTTPセッションが有効なままでいる時間
+const defaultSessionIdleTimeout = 30 * time.Minute
+
+// defaultMaxSessions caps the number of live HTTP sessions
+// defaultMaxSessions: 有効なHTTPセッション数の上限
+const defaultMaxSessions = 10000
+
+// ErrTooManySessions is returned when the session table is full
+// ErrTooManySessions: セッション表が満杯のときに返されるエラー
+var ErrTooManySessions = errors.New("too many sessions")
+
+// Session is one client's HTTP session, established by initialize
+// Session: initializeで確立される、1クライアント分のHTTPセッション
+// Clients resend its id in the Mcp-Session-Id header to resume after reconnecting.
+// クライアントは再接続後に再開するため、そのIDをMcp-Session-Idヘッダーで再送する
+type Session struct {
+	ID string // id: session identifier (セッション識別子)
+
+	conn *connSession // conn: handshake and subscription state (ハンドシェイクと購読の状態)
+
+	mu        sync.Mutex          // mu: guards the fields below (以下のフィールドを保護)
+	lastSeen  time.Time           // lastSeen: time of the latest request (最後のリクエストの時刻)
+	tools     map[string]Tool     // tools: session-only tools (セッション専用のツール)
+	resources map[string]Resource // resources: session-only resources (セッション専用のリソース)
+}
+
+// RegisterTool adds a tool visible only within this session
+// RegisterTool: このセッション内でのみ見えるツールを追加する関数
+// It shadows a global tool of the same name. An invalid input schema is rejected.
+// 同名のグローバルなツールより優先される。不正な入力スキーマは拒否される
+// shadows: 覆い隠す
+func (sess *Session) RegisterTool(tool Tool) error {
+	tool, err := compileTool(tool)
+	if err != nil {
+		return err
+	}
+
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	if sess.tools == nil {
+		sess.tools = make(map[string]Tool)
+	}
+	sess.tools[tool.Name] = tool
+	return nil
+}
+
+// UnregisterTool removes a session-only tool
+// UnregisterTool: セッション専用のツールを削除する関数
+func (sess *Session) UnregisterTool(name string) {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	delete(sess.tools, name)
+}
+
+// RegisterResource adds a resource visible only within this session
+// RegisterResource: このセッション内でのみ見えるリソースを追加する関数
+func (sess *Session) RegisterResource(resource Resource) {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	if sess.resources == nil {
+		sess.resources = make(map[string]Resource)
+	}
+	sess.resources[resource.URI] = resource
+}
+
+// UnregisterResource removes a session-only resource
+// UnregisterResource: セッション専用のリソースを削除する関数
+func (sess *Session) UnregisterResource(uri string) {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	delete(sess.resources, uri)
+}
+
+// visibleTools returns the global tools merged with the session's, session first
+// visibleTools: グローバルなツールとセッションのツールを統合して返す関数 (セッション優先)
+// merged: 統合された
+func (s *MCPServer) visibleTools(ctx context.Context) map[string]Tool {
+	s.mu.RLock()
+	tools := make(map[string]Tool, len(s.tools))
+	for name, tool := range s.tools {
+		tools[name] = tool
+	}
+	s.mu.RUnlock()
+
+	if sess := SessionFromContext(ctx); sess != nil {
+		sess.mu.Lock()
+		for name, tool := range sess.tools {
+			tools[name] = tool // session wins: セッションが優先
+		}
+		sess.mu.Unlock()
+	}
+	return tools
+}
+
+// lookupTool finds a tool by name, preferring the session's registry
+// lookupTool: セッションのレジストリを優先して名前でツールを探す関数
+func (s *MCPServer) lookupTool(ctx context.Context, name string) (Tool, bool) {
+	if sess := SessionFromContext(ctx); sess != nil {
+		sess.mu.Lock()
+		tool, ok := sess.tools[name]
+		sess.mu.Unlock()
+		if ok {
+			return tool, true
+		}
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	tool, ok := s.tools[name]
+	return tool, ok
+}
+
+// toolScope returns the session id when the current session has its own tool named name
+// toolScope: 現在のセッションがnameという独自のツールを持つ場合、そのセッションIDを返す関数
+// Cache and single-flight keys include it so a session tool that shadows a global
+// one never shares results with it or with other sessions.
+// キャッシュとシングルフライトのキーはこれを含むため、グローバルなツールを覆い隠す
+// セッションのツールがそのツールや他のセッションと結果を共有することはない
+func toolScope(ctx context.Context, name string) string {
+	sess := SessionFromContext(ctx)
+	if sess == nil {
+		return ""
+	}
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	if _, ok := sess.tools[name]; !ok {
+		return ""
+	}
+	return sess.ID
+}
+
+// visibleResources returns the listed, global and session resources merged together
+// visibleResources: 列挙された・グローバル・セッションのリソースを統合して返す関数
+// On a URI collision the session wins over registered resources, which win over listers.
+// URIが衝突した場合はセッションが登録済みリソースより、登録済みリソースがリスターより優先される
+func (s *MCPServer) visibleResources(ctx context.Context) (map[string]Resource, error) {
+	s.mu.RLock()
+	listers := append([]ResourceLister(nil), s.listers...)
+	s.mu.RUnlock()
+
+	resources := make(map[string]Resource)
+	for _, list := range listers {
+		listed, err := list(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, resource := range listed {
+			resources[resource.URI] = resource
+		}
+	}
+
+	s.mu.RLock()
+	for uri, resource := range s.resources {
+		resources[uri] = resource
+	}
+	s.mu.RUnlock()
+
+	if sess := SessionFromContext(ctx); sess != nil {
+		sess.mu.Lock()
+		for uri, resource := range sess.resources {
+			resources[uri] = resource
+		}
+		sess.mu.Unlock()
+	}
+	return resources, nil
+}
+
+// sessionStore tracks live sessions and expires idle ones
+// sessionStore: 有効なセッションを管理し、アイドル状態のものを期限切れにする構造体
+type sessionStore struct {
+	mu       sync.Mutex          // mu: guards sessions (sessionsを保護)
+	sessions map[string]*Session // sessions: live sessions by id (IDごとの有効なセッション)
+	idle     time.Duration       // idle: idle timeout (アイドルタイムアウト)
+	now      func() time.Time    // now: clock, replaceable in tests (時計、テストで差し替え可能)
+
+	max   int         // max: session cap, zero or less for none (セッション数の上限、0以下で無制限)
+	sweep *time.Timer // sweep: pending expiry sweep, nil when idle (保留中の期限切れ掃除、無ければnil)
+}
+
+// newSessionStore creates an empty store with the given idle timeout
+// newSessionStore: 指定したアイドルタイムアウトで空のストアを作成する関数
+func newSessionStore(idle time.Duration) *sessionStore {
+	return &sessionStore{
+		sessions: make(map[string]*Session),
+		idle:     idle,
+		now:      time.Now,
+		max:      defaultMaxSessions,
+	}
+}
+
+// create starts a new session holding conn, sweeping expired ones first
+// create: 期限切れのセッションを掃除してから、connを持つ新しいセッションを開始する関数
+// It fails with ErrTooManySessions when the store is still full after the sweep.
+// 掃除後もストアが満杯ならErrTooManySessionsで失敗する
+// sweep: 掃除する
+func (st *sessionStore) create(conn *connSession) (*Session, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.sweepLocked()
+	if st.max > 0 && len(st.sessions) >= st.max {
+		return nil, ErrTooManySessions
+	}
+
+	sess := &Session{ID: rand.Text(), conn: conn, lastSeen: st.now()}
+	st.sessions[sess.ID] = sess
+	st.scheduleLocked()
+	return sess, nil
+}
+
+// sweepLocked removes expired sessions; st.mu must be held
+// sweepLocked: 期限切れのセッションを削除する関数 (st.muを保持していること)
+func (st *sessionStore) sweepLocked() {
+	now := st.now()
+	for id, sess := range st.sessions {
+		if st.expired(sess, now) {
+			delete(st.sessions, id)
+		}
+	}
+}
+
+// scheduleLocked arms a sweep one idle timeout from now while sessions remain
+// scheduleLocked: セッションが残っている間、アイドルタイムアウト後の掃除を予約する関数
+// This frees abandoned sessions even when no new session is created.
+// これにより新しいセッションが作られなくても放棄されたセッションが解放される
+// abandoned: 放棄された
+func (st *sessionStore) scheduleLocked() {
+	if st.sweep != nil || st.idle <= 0 || len(st.sessions) == 0 {
+		return
+	}
+	st.sweep = time.AfterFunc(st.idle, func() {
+		st.mu.Lock()
+		defer st.mu.Unlock()
+		st.sweep = nil
+		st.sweepLocked()
+		st.scheduleLocked()
+	})
+}
+
+// lookup returns the live session for id and marks it as used
+// lookup: idに対応する有効なセッションを返し、使用済みとして記録する関数
+func (st *sessionStore) lookup(id string) (*Session, bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	sess, ok := st.sessions[id]
+	if !ok {
+		return nil, false
+	}
+	now := st.now()
+	if st.expired(sess, now) {
+		delete(st.sessions, id)
+		return nil, false
+	}
+	sess.mu.Lock()
+	sess.lastSeen = now
+	sess.mu.Unlock()
+	return sess, true
+}
+
+// expired reports whether sess has been idle longer than the timeout
+// expired: sessがタイムアウトより長くアイドル状態かを判定する関数
+func (st *sessionStore) expired(sess *Session, now time.Time) bool {
+	if st.idle <= 0 {
+		return false // no expiry: 期限なし
+	}
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	return now.Sub(sess.lastSeen) > st.idle
+}
+
+// Session returns the live HTTP session with the given id
+// Session: 指定したIDの有効なHTTPセッションを返す関数
+// Use it to register session-only tools and resources after initialize.
+// initialize後にセッション専用のツールやリソースを登録するために使う
+func (s *MCPServer) Session(id string) (*Session, bool) {
+	return s.sessions.lookup(id)
+}
+
+// sessionKey is the context key for the current session
+// sessionKey: 現在のセッション用のコンテキストキー
+type sessionKey struct{}
+
+// SessionContext returns a copy of ctx carrying sess
+// SessionContext: sessを持つctxのコピーを返す関数
+// The HTTP transport sets it for requests that present a session id.
+// HTTPトランスポートはセッションIDを提示したリクエストにこれを設定する
+func SessionContext(ctx context.Context, sess *Session) context.Context {
+	return context.WithValue(ctx, sessionKey{}, sess)
+}
+
+// SessionFromContext returns the session carried by ctx, or nil
+// SessionFromContext: ctxが持つセッションを返す関数 (無ければnil)
+func SessionFromContext(ctx context.Context) *Session {
+	sess, _ := ctx.Value(sessionKey{}).(*Session)
+	return sess
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"           // context: session contexts (セッションコンテキスト)
+	"errors"            // errors: error matching (エラーの照合)
+	"net/http"          // net/http: status codes (ステータスコード)
+	"net/http/httptest" // net/http/httptest: in-memory HTTP round trips (メモリ内のHTTP往復)
+	"strings"           // strings: request bodies (リクエスト本文)
+	"testing"           // testing: test framework (テストフレームワーク)
+	"time"              // time: idle expiry (アイドル期限切れ)
+)
+
+// TestSessionStoreCap checks that create refuses new sessions once the cap is reached
+// TestSessionStoreCap: 上限到達後にcreateが新しいセッションを拒否することを確認するテスト
+func TestSessionStoreCap(t *testing.T) {
+	st := newSessionStore(time.Minute)
+	st.max = 2
+	now := time.Now()
+	st.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if _, err := st.create(nil); err != nil {
+			t.Fatalf("create %d: %v", i, err)
+		}
+	}
+	if _, err := st.create(nil); !errors.Is(err, ErrTooManySessions) {
+		t.Fatalf("create over cap: got %v, want ErrTooManySessions", err)
+	}
+
+	// Expired sessions free their slots: 期限切れのセッションは枠を解放する
+	now = now.Add(2 * time.Minute)
+	if _, err := st.create(nil); err != nil {
+		t.Fatalf("create after expiry: %v", err)
+	}
+}
+
+// TestSessionStoreSweep checks that idle sessions are removed without a new create
+// TestSessionStoreSweep: 新しいcreateが無くてもアイドルなセッションが削除されることを確認するテスト
+func TestSessionStoreSweep(t *testing.T) {
+	st := newSessionStore(10 * time.Millisecond)
+	if _, err := st.create(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		st.mu.Lock()
+		n := len(st.sessions)
+		st.mu.Unlock()
+		if n == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d sessions left after the idle timeout", n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// TestHTTPInitializeTooManySessions checks that initialize answers 503 when the cap is reached
+// TestHTTPInitializeTooManySessions: 上限到達時にinitializeが503を返すことを確認するテスト
+func TestHTTPInitializeTooManySessions(t *testing.T) {
+	s := NewMCPServer(WithMaxSessions(1))
+	initialize := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"initialize"}`))
+		r.Header.Set("Content-Type", "application/json")
+		s.ServeHTTP(w, r)
+		return w
+	}
+
+	if w := initialize(); w.Code != http.StatusOK || w.Header().Get(sessionHeader) == "" {
+		t.Fatalf("first initialize: %d %q", w.Code, w.Header().Get(sessionHeader))
+	}
+	w := initialize()
+	if w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), "Too many sessions") {
+		t.Fatalf("second initialize: %d %s", w.Code, w.Body)
+	}
+}
+
+// TestHTTPSessionLifecycle checks that initialize issues a session id, that later
+// requests resume it, and that unknown and idle-expired ids get 404
+// TestHTTPSessionLifecycle: initializeがセッションIDを発行し、以降のリクエストで再開でき、
+// 不明なIDとアイドルで期限切れになったIDが404になることを確認するテスト
+func TestHTTPSessionLifecycle(t *testing.T) {
+	s := NewMCPServer(WithSessionIdleTimeout(time.Minute))
+	now := time.Now()
+	s.sessions.now = func() time.Time { return now }
+	post := func(body, id string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		if id != "" {
+			r.Header.Set(sessionHeader, id)
+		}
+		s.ServeHTTP(w, r)
+		return w
+	}
+	list := `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`
+
+	id := post(`{"jsonrpc":"2.0","id":1,"method":"initialize"}`, "").Header().Get(sessionHeader)
+	if id == "" {
+		t.Fatal("initialize returned no session id")
+	}
+	if w := post(list, id); w.Code != http.StatusOK {
+		t.Fatalf("resume: got %d, want 200", w.Code)
+	}
+	if w := post(list, "unknown"); w.Code != http.StatusNotFound || !strings.Contains(w.Body.String(), "Session not found") {
+		t.Fatalf("unknown id: got %d %s", w.Code, w.Body)
+	}
+
+	// Each use restarts the idle timeout: 使用する度にアイドルタイムアウトが再開する
+	for i := 0; i < 2; i++ {
+		now = now.Add(45 * time.Second)
+		if w := post(list, id); w.Code != http.StatusOK {
+			t.Fatalf("resume %d: got %d, want 200", i, w.Code)
+		}
+	}
+	now = now.Add(2 * time.Minute)
+	if w := post(list, id); w.Code != http.StatusNotFound {
+		t.Fatalf("expired id: got %d, want 404", w.Code)
+	}
+}
+
+// TestSessionRegistrations checks that session tools and resources are listed and
+// callable only within their session, shadowing global tools of the same name
+// TestSessionRegistrations: セッションのツールとリソースがそのセッション内でのみ一覧・呼び出しでき、
+// 同名のグローバルなツールを覆い隠すことを確認するテスト
+func TestSessionRegistrations(t *testing.T) {
+	s := NewMCPServer()
+	s.RegisterTool(Tool{Name: "shared", Description: "global"})
+	a, _ := s.sessions.create(nil)
+	b, _ := s.sessions.create(nil)
+	if err := a.RegisterTool(textTool("extra", "x")); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.RegisterTool(Tool{Name: "shared", Description: "session"}); err != nil {
+		t.Fatal(err)
+	}
+	a.RegisterResource(Resource{URI: "data:,a", Name: "a"})
+	request := func(sess *Session, method string, params interface{}) *JSONRPCResponse {
+		return s.HandleRequest(SessionContext(context.Background(), sess), &JSONRPCRequest{JSONRPC: "2.0", ID: IntID(1), Method: method, Params: params})
+	}
+	tools := func(sess *Session) string {
+		var list []string
+		for _, tool := range request(sess, "tools/list", nil).Result.(*ToolsListResult).Tools {
+			list = append(list, tool.Name+":"+tool.Description)
+		}
+		return strings.Join(list, ",")
+	}
+	resources := func(sess *Session) int {
+		return len(request(sess, "resources/list", nil).Result.(*ResourcesListResult).Resources)
+	}
+
+	if got := tools(a); got != "extra:,shared:session" {
+		t.Fatalf("session a tools: got %q", got)
+	}
+	if got := tools(b); got != "shared:global" {
+		t.Fatalf("session b tools: got %q", got)
+	}
+	if resp := request(b, "tools/call", map[string]interface{}{"name": "extra"}); resp.Error == nil {
+		t.Fatal("session b called session a's tool")
+	}
+	if resp := request(a, "tools/call", map[string]interface{}{"name": "extra"}); resp.Error != nil {
+		t.Fatalf("session a: %v", resp.Error)
+	}
+	if got, other := resources(a), resources(b); got != other+1 {
+		t.Fatalf("session a lists %d resources, session b %d; want one more in a", got, other)
+	}
+
+	a.UnregisterTool("shared")
+	if got := tools(a); got != "extra:,shared:global" {
+		t.Fatalf("after unregister: got %q", got)
+	}
+}
+
+// TestResetKeepsSessionCap checks that Reset keeps the configured session cap
+// TestResetKeepsSessionCap: Resetが設定済みのセッション上限を保持することを確認するテスト
+func TestResetKeepsSessionCap(t *testing.T) {
+	s := NewMCPServer(WithMaxSessions(3))
+	s.Reset()
+	if s.sessions.max != 3 {
+		t.Fatalf("max after Reset: got %d, want 3", s.sessions.max)
+	}
+}
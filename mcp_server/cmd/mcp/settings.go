@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"  // context: log handler interface (ログハンドラーのインターフェース)
+	"fmt"      // fmt: validation errors (検証エラー)
+	"log/slog" // log/slog: runtime log level (実行時のログレベル)
+	"sort"     // sort: stable setting order (設定の安定した順序)
+	"strings"  // strings: level names (レベル名)
+	"time"     // time: timeout settings (タイムアウト設定)
+)
+
+// Setting is one runtime-tunable server setting exposed through config/get and config/set
+// Setting: config/getとconfig/setで公開される、実行時に調整可能なサーバー設定
+// Validate checks a new value and returns it in the form Set expects; Set is only
+// called with values that passed Validate, so it cannot fail halfway.
+// Validateは新しい値を検査しSetが期待する形で返す。SetはValidateを通過した値でのみ呼ばれるため途中で失敗しない
+// tunable: 調整可能な
+type Setting struct {
+	Get      func() interface{}                           // get: current value (現在の値)
+	Validate func(value interface{}) (interface{}, error) // validate: checks and normalizes (検査と正規化)
+	Set      func(value interface{})                      // set: applies a validated value (検証済みの値を適用)
+}
+
+// RegisterSetting exposes a setting under name through config/get and config/set
+// RegisterSetting: 設定をnameでconfig/getとconfig/setに公開する関数
+// Only registered settings are reachable, so secrets stay hidden unless registered.
+// 登録された設定のみ参照できるため、登録しない限り秘密情報は公開されない
+func (s *MCPServer) RegisterSetting(name string, setting Setting) {
+	s.settingsMu.Lock()
+	defer s.settingsMu.Unlock()
+	if s.settings == nil {
+		s.settings = make(map[string]Setting)
+	}
+	s.settings[name] = setting
+}
+
+// enableConfigMethods registers the built-in settings and the config/* methods
+// enableConfigMethods: 組み込みの設定とconfig/*メソッドを登録する関数
+func (s *MCPServer) enableConfigMethods() {
+	// Filter the logger through an adjustable level: 調整可能なレベルでロガーを絞り込む
+	handler := s.logger.Handler()
+	s.logLevel.Set(lowestEnabledLevel(handler))
+	s.logger = slog.New(&levelHandler{Handler: handler, level: &s.logLevel})
+
+	s.RegisterSetting("logLevel", Setting{
+		Get: func() interface{} { return strings.ToLower(s.logLevel.Level().String()) },
+		Validate: func(value interface{}) (interface{}, error) {
+			name, _ := value.(string)
+			var level slog.Level
+			if err := level.UnmarshalText([]byte(name)); err != nil {
+				return nil, fmt.Errorf("logLevel must be one of debug, info, warn, error")
+			}
+			return level, nil
+		},
+		Set: func(value interface{}) { s.logLevel.Set(value.(slog.Level)) },
+	})
+	s.RegisterSetting("toolTimeout", Setting{
+		Get:      func() interface{} { return s.currentToolTimeout().String() },
+		Validate: validateDuration("toolTimeout"),
+		Set: func(value interface{}) {
+			s.settingsMu.Lock() // already validated: 検証済み
+			s.toolTimeout = value.(time.Duration)
+			s.settingsMu.Unlock()
+		},
+	})
+	s.RegisterSetting("sessionIdleTimeout", Setting{
+		Get: func() interface{} {
+			s.sessions.mu.Lock()
+			defer s.sessions.mu.Unlock()
+			return s.sessions.idle.String()
+		},
+		Validate: validateDuration("sessionIdleTimeout"),
+		Set: func(value interface{}) {
+			s.sessions.mu.Lock()
+			s.sessions.idle = value.(time.Duration)
+			s.sessions.mu.Unlock()
+		},
+	})
+
+	s.methods["config/get"] = s.handleConfigGet
+	s.methods["config/set"] = s.handleConfigSet
+}
+
+// validateDuration accepts a non-negative Go duration string such as "30s"; "0s" disables
+// validateDuration: "30s"のような負でないGoの期間文字列を受け付ける検証関数 ("0s"で無効化)
+func validateDuration(name string) func(interface{}) (interface{}, error) {
+	return func(value interface{}) (interface{}, error) {
+		text, _ := value.(string)
+		d, err := time.ParseDuration(text)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("%s must be a non-negative duration such as \"30s\"", name)
+		}
+		return d, nil
+	}
+}
+
+// currentToolTimeout returns the default tool timeout, which config/set may change
+// currentToolTimeout: config/setで変更され得るデフォルトのツールタイムアウトを返す関数
+func (s *MCPServer) currentToolTimeout() time.Duration {
+	s.settingsMu.RLock()
+	defer s.settingsMu.RUnlock()
+	return s.toolTimeout
+}
+
+// handleConfigGet returns one setting, or every setting when no name is given
+// handleConfigGet: 1つの設定、または名前が無い場合は全設定を返す関数
+func (s *MCPServer) handleConfigGet(ctx context.Context, params interface{}) (interface{}, error) {
+	p, _ := params.(map[string]interface{})
+	name, _ := p["name"].(string)
+
+	s.settingsMu.RLock()
+	settings := make(map[string]Setting, len(s.settings))
+	for n, setting := range s.settings {
+		settings[n] = setting
+	}
+	s.settingsMu.RUnlock()
+
+	if name == "" {
+		names := make([]string, 0, len(settings))
+		for n := range settings {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		values := make(map[string]interface{}, len(names))
+		for _, n := range names {
+			values[n] = settings[n].Get()
+		}
+		return &ConfigGetResult{Settings: values}, nil
+	}
+
+	setting, ok := settings[name]
+	if !ok {
+		return nil, unknownSetting(name)
+	}
+	return &SettingResult{Name: name, Value: setting.Get()}, nil
+}
+
+// handleConfigSet validates and applies a new value for one setting
+// handleConfigSet: 1つの設定の新しい値を検証して適用する関数
+// Sets are serialized, and an invalid value leaves the setting unchanged.
+// 設定は直列に適用され、不正な値の場合は設定は変更されない
+func (s *MCPServer) handleConfigSet(ctx context.Context, params interface{}) (interface{}, error) {
+	p, _ := params.(map[string]interface{})
+	name, _ := p["name"].(string)
+
+	s.settingsMu.RLock()
+	setting, ok := s.settings[name]
+	s.settingsMu.RUnlock()
+	if !ok {
+		return nil, unknownSetting(name)
+	}
+
+	s.configMu.Lock()
+	defer s.configMu.Unlock()
+	value, err := setting.Validate(p["value"])
+	if err != nil {
+		return nil, &JSONRPCError{
+			Code:    -32602,          // Invalid params (無効なパラメータ)
+			Message: "Invalid value", // value: 値
+			Data:    map[string]interface{}{"name": name, "reason": err.Error()},
+		}
+	}
+	setting.Set(value)
+	s.logger.Info("setting changed", "name", name, "value", setting.Get())
+	return &SettingResult{Name: name, Value: setting.Get()}, nil
+}
+
+// unknownSetting reports a name that is not in the settings registry
+// unknownSetting: 設定レジストリに無い名前を表すエラーを作る関数
+func unknownSetting(name string) *JSONRPCError {
+	return &JSONRPCError{
+		Code:    -32602,            // Invalid params (無効なパラメータ)
+		Message: "Unknown setting", // setting: 設定
+		Data:    map[string]interface{}{"name": name},
+	}
+}
+
+// levelHandler drops records below an adjustable level before the wrapped handler
+// levelHandler: 調整可能なレベル未満のレコードをラップ先のハンドラーより前で捨てるハンドラー
+type levelHandler struct {
+	slog.Handler                // Handler: wrapped handler (ラップ先のハンドラー)
+	level        *slog.LevelVar // level: minimum level (最低レベル)
+}
+
+func (h *levelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level.Level() && h.Handler.Enabled(ctx, level)
+}
+
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &levelHandler{Handler: h.Handler.WithAttrs(attrs), level: h.level}
+}
+
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	return &levelHandler{Handler: h.Handler.WithGroup(name), level: h.level}
+}
+
+// lowestEnabledLevel returns the lowest standard level handler accepts
+// lowestEnabledLevel: ハンドラーが受け付ける最も低い標準レベルを返す関数
+// Starting there keeps the logger's behaviour unchanged until config/set is used.
+// そこから始めることで、config/setが使われるまでロガーの挙動は変わらない
+func lowestEnabledLevel(handler slog.Handler) slog.Level {
+	for _, level := range []slog.Level{slog.LevelDebug, slog.LevelInfo, slog.LevelWarn} {
+		if handler.Enabled(context.Background(), level) {
+			return level
+		}
+	}
+	return slog.LevelError
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"    // bytes: captured log output (取得したログ出力)
+	"context"  // context: request contexts (リクエストコンテキスト)
+	"log/slog" // log/slog: logger whose level is tuned (レベルを調整するロガー)
+	"strings"  // strings: log matching (ログの照合)
+	"testing"  // testing: test framework (テストフレームワーク)
+	"time"     // time: timeout settings (タイムアウト設定)
+)
+
+// TestConfigMethods checks that config/get reads settings, that config/set applies a
+// valid value and rejects an invalid one without change, and that unregistered
+// names and servers without WithConfigMethods expose nothing
+// TestConfigMethods: config/getが設定を読み、config/setが有効な値を適用し不正な値は
+// 変更せずに拒否し、未登録の名前とWithConfigMethods無しのサーバーが何も公開しないことを確認するテスト
+func TestConfigMethods(t *testing.T) {
+	var logs bytes.Buffer
+	s := NewMCPServer(WithConfigMethods(), WithLogger(slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))))
+	call := func(method string, params map[string]interface{}) *JSONRPCResponse {
+		return s.HandleRequest(context.Background(), &JSONRPCRequest{JSONRPC: "2.0", ID: IntID(1), Method: method, Params: params})
+	}
+
+	resp := call("config/get", map[string]interface{}{"name": "logLevel"})
+	if resp.Error != nil || resp.Result.(*SettingResult).Value != "debug" {
+		t.Fatalf("get logLevel: got %+v, %v", resp.Result, resp.Error)
+	}
+	resp = call("config/get", nil)
+	if resp.Error != nil || len(resp.Result.(*ConfigGetResult).Settings) != 3 {
+		t.Fatalf("get all: got %+v, %v", resp.Result, resp.Error)
+	}
+
+	if resp := call("config/set", map[string]interface{}{"name": "toolTimeout", "value": "2s"}); resp.Error != nil || s.currentToolTimeout() != 2*time.Second {
+		t.Fatalf("set toolTimeout: got %v, %v", s.currentToolTimeout(), resp.Error)
+	}
+	resp = call("config/set", map[string]interface{}{"name": "toolTimeout", "value": "-1s"})
+	if resp.Error == nil || resp.Error.Code != -32602 || s.currentToolTimeout() != 2*time.Second {
+		t.Fatalf("set invalid toolTimeout: got %v, %+v", s.currentToolTimeout(), resp.Error)
+	}
+
+	// Raising the level hides info logs: レベルを上げるとinfoログが出なくなる
+	if resp := call("config/set", map[string]interface{}{"name": "logLevel", "value": "warn"}); resp.Error != nil {
+		t.Fatal(resp.Error)
+	}
+	s.logger.Info("hidden")
+	if strings.Contains(logs.String(), "hidden") {
+		t.Fatalf("info log after setting warn: %s", logs.String())
+	}
+
+	if resp := call("config/get", map[string]interface{}{"name": "cursorKey"}); resp.Error == nil {
+		t.Fatal("an unregistered setting was exposed")
+	}
+	plain := NewMCPServer()
+	if resp := plain.HandleRequest(context.Background(), &JSONRPCRequest{JSONRPC: "2.0", ID: IntID(1), Method: "config/get"}); resp.Error == nil || resp.Error.Code != -32601 {
+		t.Fatalf("config/get without WithConfigMethods: got %+v", resp.Error)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"       // context: waiting and detached execution (待機と切り離した実行)
+	"crypto/sha256" // crypto/sha256: SHA-256 hashing (SHA-256ハッシュ)
+	"encoding/hex"  // encoding/hex: hexadecimal encoding (16進エンコード)
+	"encoding/json" // encoding/json: JSON encoding and decoding (JSONエンコード・デコード)
+	"sync"          // sync: mutual exclusion (排他制御)
+)
+
+// flightCall is one in-flight or completed call in a flightGroup
+// flightCall: flightGroup内で実行中または完了した呼び出し
+type flightCall struct {
+	done   chan struct{}          // done: closed on completion (完了時に閉じる)
+	result map[string]interface{} // result: shared result (共有される結果)
+	meta   map[string]interface{} // meta: result metadata set by the call, copied to each caller (呼び出しが設定した結果メタデータ、各呼び出し元へコピー)
+	err    error                  // err: shared error (共有されるエラー)
+}
+
+// flightGroup deduplicates concurrent calls with the same key
+// flightGroup: 同じキーを持つ同時呼び出しを重複排除する構造体
+// It is a small internal equivalent of golang.org/x/sync/singleflight.
+// golang.org/x/sync/singleflightの小さな内部版
+type flightGroup struct {
+	mu    sync.Mutex             // mu: guards calls (callsを保護)
+	calls map[string]*flightCall // calls: in-flight calls by key (キーごとの実行中の呼び出し)
+}
+
+// Do runs fn once for all concurrent callers sharing key
+// Do: 同じキーを共有する同時呼び出し元に対してfnを1回だけ実行する関数
+// fn runs on its own goroutine under a context detached from every caller, with
+// its own result metadata and no event stream, so no caller's cancellation,
+// deadline, _meta or progress reaches the others. Each caller waits until the call
+// completes or its own ctx ends. shared reports whether the call was started by
+// another caller.
+// fnは全ての呼び出し元から切り離したコンテキストの下、専用の結果メタデータを持ち
+// イベントストリーム無しで専用のgoroutineで実行されるため、ある呼び出し元のキャンセル、期限、
+// _metaや進捗が他へ及ぶことはない。各呼び出し元は呼び出しの完了か自身のctxの終了まで待つ。
+// sharedは呼び出しが他の呼び出し元によって開始されたかどうかを示す
+func (g *flightGroup) Do(ctx context.Context, key string, fn func(ctx context.Context) (map[string]interface{}, error)) (result, meta map[string]interface{}, err error, shared bool) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*flightCall)
+	}
+	call, shared := g.calls[key]
+	if !shared {
+		call = &flightCall{done: make(chan struct{})}
+		g.calls[key] = call
+		go g.run(ctx, key, call, fn)
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.result, call.meta, call.err, shared
+	case <-ctx.Done():
+		return nil, nil, ctx.Err(), shared // the call keeps running for the others: 呼び出しは他のために続行する
+	}
+}
+
+// run executes fn for call on a context detached from the caller that started it
+// run: 開始した呼び出し元から切り離したコンテキストでcallのfnを実行する関数
+func (g *flightGroup) run(ctx context.Context, key string, call *flightCall, fn func(ctx context.Context) (map[string]interface{}, error)) {
+	detached := context.WithValue(context.WithoutCancel(ctx), streamKey{}, (*eventStream)(nil)) // no progress: 進捗なし
+	detached, state := withMeta(detached, &JSONRPCRequest{})
+	result, err := fn(detached)
+
+	state.mu.Lock()
+	call.result, call.meta, call.err = result, state.result, err
+	state.mu.Unlock()
+
+	g.mu.Lock()
+	delete(g.calls, key) // forget: 完了した呼び出しを忘れる
+	g.mu.Unlock()
+	close(call.done)
+}
+
+// hashArguments returns a canonical SHA-256 hash of tool arguments
+// hashArguments: ツール引数の正規化されたSHA-256ハッシュを返す関数
+// encoding/json sorts map keys, so equal arguments always hash identically.
+// encoding/jsonはマップのキーを並べ替えるため、同じ引数は常に同じハッシュになる
+func hashArguments(arguments interface{}) string {
+	data, err := json.Marshal(arguments)
+	if err != nil {
+		return "" // unhashable: ハッシュ不可
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"       // context: caller cancellation (呼び出し元のキャンセル)
+	"encoding/json" // encoding/json: inspecting responses (レスポンスの検査)
+	"errors"        // errors: error inspection (エラー検査)
+	"strings"       // strings: response matching (レスポンスの照合)
+	"sync/atomic"   // sync/atomic: counting executions (実行回数の計数)
+	"testing"       // testing: test framework (テストフレームワーク)
+	"time"          // time: letting callers join (呼び出し元の合流待ち)
+)
+
+// TestSingleFlightSharesExecution checks that concurrent identical calls to an
+// idempotent tool run the handler once and each caller gets the result metadata
+// TestSingleFlightSharesExecution: 冪等なツールへの同一の同時呼び出しでハンドラーが1回だけ実行され、
+// 各呼び出し元が結果メタデータを受け取ることを確認するテスト
+func TestSingleFlightSharesExecution(t *testing.T) {
+	s := NewMCPServer(WithSingleFlight())
+	var runs atomic.Int32
+	release := make(chan struct{})
+	s.RegisterTool(Tool{
+		Name:        "slow",
+		Annotations: &ToolAnnotations{IdempotentHint: Bool(true)},
+		Handler: func(ctx context.Context, arguments interface{}) (map[string]interface{}, error) {
+			runs.Add(1)
+			SetResultMeta(ctx, "served", "once")
+			<-release
+			return map[string]interface{}{"content": []interface{}{}}, nil
+		},
+	})
+
+	var calls []<-chan *JSONRPCResponse
+	for id := int64(1); id <= 5; id++ {
+		calls = append(calls, callAsync(s, id, "slow"))
+	}
+	eventually(t, func() bool { return runs.Load() == 1 })
+	time.Sleep(20 * time.Millisecond) // let the other callers join: 他の呼び出し元の合流を待つ
+	close(release)
+
+	for _, call := range calls {
+		resp := receive(t, call)
+		data, err := json.Marshal(resp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Error != nil || !strings.Contains(string(data), `"served":"once"`) {
+			t.Fatalf("got %s, want a result with _meta.served", data)
+		}
+	}
+	if n := runs.Load(); n != 1 {
+		t.Fatalf("handler ran %d times, want 1", n)
+	}
+}
+
+// TestFlightGroupDetached checks that the shared call runs on a context detached
+// from the caller that started it, so that caller cancelling does not fail the others
+// TestFlightGroupDetached: 共有された呼び出しが開始した呼び出し元から切り離したコンテキストで実行され、
+// その呼び出し元のキャンセルが他を失敗させないことを確認するテスト
+func TestFlightGroupDetached(t *testing.T) {
+	var g flightGroup
+	started := make(chan struct{})
+	release := make(chan struct{})
+	fn := func(ctx context.Context) (map[string]interface{}, error) {
+		close(started)
+		<-release
+		if stream, _ := ctx.Value(streamKey{}).(*eventStream); stream != nil {
+			t.Error("shared call sees a caller's event stream")
+		}
+		SetResultMeta(ctx, "shared", true)
+		return map[string]interface{}{"ctxErr": ctx.Err()}, nil
+	}
+
+	// The first caller starts the call and gives up: 最初の呼び出し元が呼び出しを開始して諦める
+	first, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, _, err, _ := g.Do(first, "k", fn)
+		firstErr <- err
+	}()
+	<-started
+
+	type outcome struct {
+		result, meta map[string]interface{}
+		err          error
+		shared       bool
+	}
+	second := make(chan outcome, 1)
+	go func() {
+		var o outcome
+		o.result, o.meta, o.err, o.shared = g.Do(context.Background(), "k", func(ctx context.Context) (map[string]interface{}, error) {
+			t.Error("joiner started a second execution")
+			return nil, nil
+		})
+		second <- o
+	}()
+	time.Sleep(20 * time.Millisecond) // let the second caller join: 2番目の呼び出し元の合流を待つ
+
+	cancel()
+	if err := <-firstErr; !errors.Is(err, context.Canceled) {
+		t.Fatalf("first caller: got %v, want context.Canceled", err)
+	}
+	close(release)
+
+	o := <-second
+	if o.err != nil || !o.shared {
+		t.Fatalf("second caller: err %v, shared %v", o.err, o.shared)
+	}
+	if o.result["ctxErr"] != nil {
+		t.Fatalf("shared call was cancelled: %v", o.result["ctxErr"])
+	}
+	if o.meta["shared"] != true {
+		t.Fatalf("second caller meta: got %v, want shared=true", o.meta)
+	}
+}
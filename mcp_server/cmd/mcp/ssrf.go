@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"  // context: cancellation and deadlines (キャンセルと期限)
+	"errors"   // errors: error values (エラー値)
+	"fmt"      // fmt: error formatting (エラーの整形)
+	"net"      // net: address resolution and dialing (アドレスの解決と接続)
+	"net/http" // net/http: HTTP client (HTTPクライアント)
+	"strings"  // strings: case-insensitive host matching (大文字小文字を区別しないホスト照合)
+	"time"     // time: dial timeout (接続タイムアウト)
+)
+
+// errBlockedAddress reports a connection to an address that is not publicly routable
+// errBlockedAddress: 公開ルーティング可能でないアドレスへの接続を表すエラー
+var errBlockedAddress = errors.New("address is not publicly routable")
+
+// defaultSafeClient is the guarded client HTTPSProvider uses when none is set
+// defaultSafeClient: Clientが未設定の場合にHTTPSProviderが使う保護付きクライアント
+var defaultSafeClient = SafeHTTPClient()
+
+// SafeHTTPClient returns an HTTP client that refuses to connect to non-public addresses
+// SafeHTTPClient: 公開されていないアドレスへの接続を拒否するHTTPクライアントを返す関数
+// Every host is resolved and all its addresses must pass checkPublicIP before one is
+// dialed, which prevents SSRF when URLs come from untrusted input. Redirects are
+// checked the same way, and no proxy is used since a proxy would dial for us.
+// allowHosts names trusted internal hosts, or IP literals, that skip the check.
+// 全てのホストは解決され、接続前に全てのアドレスがcheckPublicIPを通過しなければならないため、
+// URLが信頼できない入力から来る場合のSSRFを防ぐ。リダイレクトも同様に検査し、
+// プロキシは代わりに接続してしまうため使わない。allowHostsは検査を省く信頼済みの内部ホストまたはIPリテラル
+func SafeHTTPClient(allowHosts ...string) *http.Client {
+	return safeHTTPClient(allowHosts, checkPublicIP)
+}
+
+// safeHTTPClient builds a guarded client with check deciding which addresses may be dialed
+// safeHTTPClient: 接続してよいアドレスをcheckで判定する保護付きクライアントを作成する関数
+func safeHTTPClient(allowHosts []string, check func(ip net.IP) error) *http.Client {
+	g := &dialGuard{
+		allow:  make(map[string]bool, len(allowHosts)),
+		check:  check,
+		dialer: net.Dialer{Timeout: 10 * time.Second},
+	}
+	for _, host := range allowHosts {
+		g.allow[strings.ToLower(host)] = true
+	}
+	return &http.Client{Transport: &http.Transport{
+		Proxy:               nil, // never through a proxy: プロキシを経由しない
+		DialContext:         g.DialContext,
+		ForceAttemptHTTP2:   true,
+		TLSHandshakeTimeout: 10 * time.Second,
+		IdleConnTimeout:     90 * time.Second,
+	}}
+}
+
+// dialGuard dials only addresses that pass its check
+// dialGuard: 検査を通過したアドレスにのみ接続する構造体
+type dialGuard struct {
+	allow  map[string]bool       // allow: lower-cased hosts that skip the check (検査を省く小文字のホスト)
+	check  func(ip net.IP) error // check: refuses an address with an error (アドレスをエラーで拒否)
+	dialer net.Dialer            // dialer: underlying dialer (実際の接続を行うダイアラー)
+}
+
+// DialContext resolves address, checks every resolved IP, then dials the checked IPs
+// DialContext: addressを解決し、解決された全てのIPを検査してから、検査済みのIPへ接続する関数
+// The checked IPs are dialed rather than the name, so a second lookup cannot swap in
+// another address (DNS rebinding).
+// 名前ではなく検査済みのIPへ接続するため、再度の名前解決で別のアドレスに差し替えられることはない (DNSリバインディング)
+func (g *dialGuard) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	if g.allow[strings.ToLower(host)] {
+		return g.dialer.DialContext(ctx, network, address) // trusted: 信頼済み
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	for _, addr := range addrs {
+		if err := g.check(addr.IP); err != nil {
+			return nil, err
+		}
+	}
+
+	var firstErr error
+	for _, addr := range addrs {
+		conn, err := g.dialer.DialContext(ctx, network, net.JoinHostPort(addr.IP.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return nil, firstErr
+}
+
+// deniedNets lists shared, reserved and special-purpose ranges that the net.IP
+// predicates do not cover but that must not be reached either
+// deniedNets: net.IPの判定関数では扱われないが、接続してはならない共有・予約済み・特殊用途の範囲
+var deniedNets = parseCIDRs(
+	"0.0.0.0/8",       // "this network" (このネットワーク)
+	"100.64.0.0/10",   // carrier-grade NAT shared space (キャリアグレードNATの共有アドレス空間)
+	"192.0.0.0/24",    // IETF protocol assignments (IETFプロトコル割り当て)
+	"192.0.2.0/24",    // TEST-NET-1 documentation (文書用)
+	"192.88.99.0/24",  // deprecated 6to4 relay anycast (廃止された6to4リレー)
+	"198.18.0.0/15",   // benchmarking (ベンチマーク用)
+	"198.51.100.0/24", // TEST-NET-2 documentation (文書用)
+	"203.0.113.0/24",  // TEST-NET-3 documentation (文書用)
+	"240.0.0.0/4",     // reserved, including broadcast (ブロードキャストを含む予約済み)
+	"100::/64",        // discard-only (破棄専用)
+	"2001:db8::/32",   // documentation (文書用)
+)
+
+// nat64Net is the well-known NAT64 prefix, whose last 32 bits embed an IPv4 address
+// nat64Net: 下位32ビットにIPv4アドレスを埋め込む、既知のNAT64プレフィックス
+var nat64Net = parseCIDRs("64:ff9b::/96")[0]
+
+// parseCIDRs parses CIDR literals, panicking on a malformed one
+// parseCIDRs: CIDRリテラルを解析する関数 (不正なものがあればパニックする)
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets[i] = n
+	}
+	return nets
+}
+
+// checkPublicIP refuses addresses that are not publicly routable
+// checkPublicIP: 公開ルーティング可能でないアドレスを拒否する関数
+// Loopback, private, link-local, multicast and unspecified addresses are refused, as
+// are the ranges in deniedNets. A NAT64 address is judged by the IPv4 address it embeds.
+// ループバック・プライベート・リンクローカル・マルチキャスト・未指定のアドレスと、deniedNetsの範囲を拒否する。
+// NAT64アドレスは埋め込まれたIPv4アドレスで判定する
+func checkPublicIP(ip net.IP) error {
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("%w: %s", errBlockedAddress, ip)
+	}
+	for _, n := range deniedNets {
+		if n.Contains(ip) {
+			return fmt.Errorf("%w: %s", errBlockedAddress, ip)
+		}
+	}
+	if nat64Net.Contains(ip) {
+		if err := checkPublicIP(ip[12:16]); err != nil {
+			return fmt.Errorf("%w: %s", errBlockedAddress, ip)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"           // context: resource reads (リソースの読み取り)
+	"errors"            // errors: error matching (エラーの照合)
+	"net"               // net: IP literals (IPリテラル)
+	"net/http"          // net/http: test handler and transport (テスト用ハンドラーとトランスポート)
+	"net/http/httptest" // httptest: local TLS server (ローカルのTLSサーバー)
+	"testing"           // testing: test framework (テストフレームワーク)
+)
+
+// TestCheckPublicIP checks which addresses the SSRF guard refuses
+// TestCheckPublicIP: SSRF対策が拒否するアドレスを確認するテスト
+func TestCheckPublicIP(t *testing.T) {
+	blocked := []string{
+		"127.0.0.1", "::1", "10.1.2.3", "172.16.0.1", "192.168.1.1", "169.254.169.254", "fe80::1",
+		"0.0.0.0", "0.1.2.3", "100.64.0.1", "100.127.255.254", "192.0.0.170", "198.18.0.1",
+		"192.0.2.1", "240.0.0.1", "255.255.255.255", "224.0.0.1", "fd00::1", "2001:db8::1",
+		"::ffff:10.0.0.1", "::ffff:100.64.0.1", "64:ff9b::a9fe:a9fe", "64:ff9b::6440:1",
+	}
+	for _, addr := range blocked {
+		if err := checkPublicIP(net.ParseIP(addr)); !errors.Is(err, errBlockedAddress) {
+			t.Errorf("%s: got %v, want errBlockedAddress", addr, err)
+		}
+	}
+
+	public := []string{"8.8.8.8", "100.63.255.255", "100.128.0.1", "2606:4700::1111", "64:ff9b::808:808"}
+	for _, addr := range public {
+		if err := checkPublicIP(net.ParseIP(addr)); err != nil {
+			t.Errorf("%s: %v", addr, err)
+		}
+	}
+}
+
+// TestSafeHTTPClient checks that the https provider refuses a loopback server unless
+// the host is allowlisted, both directly and through resources/read
+// TestSafeHTTPClient: httpsプロバイダーが許可リストに無い限りループバックのサーバーを拒否することを、
+// 直接とresources/read経由の両方で確認するテスト
+func TestSafeHTTPClient(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("internal"))
+	}))
+	defer srv.Close()
+	client := func(allowHosts ...string) *http.Client {
+		c := SafeHTTPClient(allowHosts...)
+		c.Transport.(*http.Transport).TLSClientConfig = srv.Client().Transport.(*http.Transport).TLSClientConfig // trust the test certificate: テスト用証明書を信頼する
+		return c
+	}
+
+	_, err := HTTPSProvider{Client: client()}.Read(context.Background(), srv.URL+"/secret")
+	if !errors.Is(err, errBlockedAddress) {
+		t.Fatalf("loopback: got %v, want errBlockedAddress", err)
+	}
+	got, err := HTTPSProvider{Client: client("127.0.0.1")}.Read(context.Background(), srv.URL+"/secret")
+	if err != nil || got.Text != "internal" {
+		t.Fatalf("allowlisted: got %+v, %v", got, err)
+	}
+
+	if _, err := NewClient(NewMCPServer()).ReadResource(srv.URL + "/secret"); err == nil {
+		t.Fatal("resources/read reached a loopback server")
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"log" // log: standard logger output (標準ロガーの出力先)
+	"os"  // os: process stdout and stderr (プロセスの標準出力と標準エラー)
+)
+
+// guardStdout reserves the process's stdout for protocol messages
+// guardStdout: プロセスの標準出力をプロトコルのメッセージ専用にする関数
+// It returns the real stdout and points os.Stdout and the standard logger at stderr,
+// so a stray fmt.Println or library log line cannot corrupt the JSON-RPC stream.
+// restore undoes the redirect.
+// 本来の標準出力を返し、os.Stdoutと標準ロガーを標準エラーへ向けるため、
+// 紛れ込んだfmt.Printlnやライブラリのログ行がJSON-RPCストリームを壊さない。restoreで元に戻す
+// stray: 紛れ込んだ
+func guardStdout() (protocol *os.File, restore func()) {
+	protocol = os.Stdout
+	prevWriter := log.Writer()
+
+	os.Stdout = os.Stderr
+	log.SetOutput(os.Stderr) // slog.Default writes through it as well: slog.Defaultもこれを経由する
+	return protocol, func() {
+		os.Stdout = protocol
+		log.SetOutput(prevWriter)
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"     // fmt: stray writes to stdout (標準出力への紛れ込んだ書き込み)
+	"io"      // io: reading the pipes (パイプの読み取り)
+	"log"     // log: library log lines (ライブラリのログ行)
+	"os"      // os: replacing stdout and stderr (標準出力と標準エラーの差し替え)
+	"strings" // strings: output matching (出力の照合)
+	"testing" // testing: test framework (テストフレームワーク)
+)
+
+// TestGuardStdout checks that while the guard is active only protocol writes reach
+// stdout, stray prints and library logs go to stderr, and restore undoes the redirect
+// TestGuardStdout: ガード中はプロトコルの書き込みだけが標準出力に届き、紛れ込んだ出力と
+// ライブラリのログは標準エラーへ向かい、restoreで元に戻ることを確認するテスト
+func TestGuardStdout(t *testing.T) {
+	outR, outW, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	errR, errW, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	origOut, origErr, origLog := os.Stdout, os.Stderr, log.Writer()
+	defer func() { os.Stdout, os.Stderr = origOut, origErr; log.SetOutput(origLog) }()
+	os.Stdout, os.Stderr = outW, errW
+
+	protocol, restore := guardStdout()
+	fmt.Println("stray")
+	log.Print("library log")
+	fmt.Fprint(protocol, `{"jsonrpc":"2.0"}`+"\n")
+	restore()
+	if os.Stdout != outW || log.Writer() != origLog {
+		t.Fatal("restore did not undo the redirect")
+	}
+	outW.Close()
+	errW.Close()
+
+	out, _ := io.ReadAll(outR)
+	errOut, _ := io.ReadAll(errR)
+	if string(out) != `{"jsonrpc":"2.0"}`+"\n" {
+		t.Fatalf("stdout: %q", out)
+	}
+	if !strings.Contains(string(errOut), "stray") || !strings.Contains(string(errOut), "library log") {
+		t.Fatalf("stderr: %q", errOut)
+	}
+}
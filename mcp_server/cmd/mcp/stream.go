@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context" // context: carries the event stream (イベントストリームの受け渡し)
+	"errors"  // errors: error values (エラー値)
+	"fmt"     // fmt: event framing (イベントの区切り)
+	"io"      // io: output writer (出力ライター)
+	"sync"    // sync: serializes events (イベントの直列化)
+	"time"    // time: keep-alive interval (キープアライブの間隔)
+)
+
+// ContentWriter receives content entries from a streaming tool
+// ContentWriter: ストリーミングツールからcontent項目を受け取るインターフェース
+type ContentWriter interface {
+	WriteContent(item map[string]interface{}) error
+}
+
+// StreamingToolHandler executes a tool that emits its content incrementally
+// StreamingToolHandler: 内容を少しずつ出力するツールを実行する関数型
+// Over an event stream each entry is sent as a notifications/progress message as soon
+// as it is written; elsewhere the entries are buffered into the final result's content.
+// イベントストリーム上では各項目が書き込まれ次第notifications/progressとして送信され、
+// それ以外では最終結果のcontentにまとめてバッファされる
+// incrementally: 少しずつ
+type StreamingToolHandler func(ctx context.Context, arguments interface{}, w ContentWriter) (map[string]interface{}, error)
+
+// errStreamClosed reports a write after the response was completed
+// errStreamClosed: レスポンス完了後の書き込みを表すエラー
+var errStreamClosed = errors.New("event stream closed")
+
+// eventStream writes JSON-RPC messages as server-sent events for one HTTP request
+// eventStream: 1つのHTTPリクエストに対してJSON-RPCメッセージをServer-Sent Eventsとして書き込む構造体
+type eventStream struct {
+	id RequestID // id: request being answered (応答中のリクエスト)
+
+	mu     sync.Mutex // mu: serializes events and guards closed (イベントを直列化しclosedを保護)
+	w      io.Writer  // w: response body (レスポンスボディ)
+	flush  func()     // flush: pushes buffered bytes to the client (バッファをクライアントへ送る)
+	closed bool       // closed: final response sent (最終レスポンス送信済み)
+
+	marshal func(interface{}) ([]byte, error) // marshal: server's JSON encoder (サーバーのJSONエンコーダー)
+}
+
+// send writes msg as one "message" event and flushes it
+// send: msgを1つの"message"イベントとして書き込み、フラッシュする関数
+func (e *eventStream) send(msg interface{}) error {
+	data, err := e.marshal(msg)
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+	return e.write(data)
+}
+
+// write sends already-encoded data as one "message" event
+// write: エンコード済みのdataを1つの"message"イベントとして送信する関数
+func (e *eventStream) write(data []byte) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.closed {
+		return errStreamClosed
+	}
+	if _, err := fmt.Fprintf(e.w, "event: message\ndata: %s\n\n", data); err != nil {
+		return err
+	}
+	e.flush()
+	return nil
+}
+
+// ping writes an SSE comment, which clients ignore but which keeps proxies from idling out
+// ping: クライアントは無視するが、プロキシによるアイドル切断を防ぐSSEコメントを書き込む関数
+func (e *eventStream) ping() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.closed {
+		return errStreamClosed
+	}
+	if _, err := io.WriteString(e.w, ": ping\n\n"); err != nil {
+		return err
+	}
+	e.flush()
+	return nil
+}
+
+// keepAlive pings the stream every interval until done is closed or ctx ends
+// keepAlive: doneが閉じられるかctxが終了するまで、interval毎にストリームへpingを送る関数
+// ctx ends when the client disconnects.
+// ctxはクライアントの切断で終了する
+func (e *eventStream) keepAlive(ctx context.Context, interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-done:
+			return
+		case <-ticker.C:
+			if err := e.ping(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// finish sends the final response and rejects any later events
+// finish: 最終レスポンスを送信し、以降のイベントを拒否する関数
+// It returns the encoded size of the response.
+// Late events come from handlers that outlived their timeout.
+// エンコード後のレスポンスのサイズを返す。遅れたイベントはタイムアウト後も動き続けたハンドラーから来る
+func (e *eventStream) finish(resp *JSONRPCResponse) (int, error) {
+	data, err := e.marshal(resp)
+	if err == nil {
+		err = e.write(data)
+	}
+	e.mu.Lock()
+	e.closed = true
+	e.mu.Unlock()
+	return len(data), err
+}
+
+// streamKey is the context key for the request's event stream
+// streamKey: リクエストのイベントストリーム用のコンテキストキー
+type streamKey struct{}
+
+// eventStreamFrom returns the event stream carried by ctx, or nil
+// eventStreamFrom: ctxが持つイベントストリームを返す関数 (無ければnil)
+func eventStreamFrom(ctx context.Context) *eventStream {
+	e, _ := ctx.Value(streamKey{}).(*eventStream)
+	return e
+}
+
+// chunkWriter is the ContentWriter handed to streaming tools
+// chunkWriter: ストリーミングツールに渡されるContentWriter
+type chunkWriter struct {
+	stream *eventStream             // stream: nil when buffering (バッファ時はnil)
+	token  interface{}              // token: progress token (進捗トークン)
+	count  int                      // count: entries written (書き込んだ項目数)
+	items  []map[string]interface{} // items: buffered entries (バッファした項目)
+}
+
+func (c *chunkWriter) WriteContent(item map[string]interface{}) error {
+	c.count++
+	if c.stream == nil {
+		c.items = append(c.items, item)
+		return nil
+	}
+	return c.stream.send(&JSONRPCNotification{
+		JSONRPC: "2.0",
+		Method:  "notifications/progress", // progress: 進捗
+		Params: map[string]interface{}{
+			"progressToken": c.token,
+			"progress":      c.count,
+			"content":       []map[string]interface{}{item}, // partial result: 部分的な結果
+		},
+	})
+}
+
+// executeStreamingTool runs a streaming handler, streaming or buffering its content
+// executeStreamingTool: ストリーミングハンドラーを実行し、内容をストリーミングまたはバッファする関数
+// The progress token is the request's _meta.progressToken, falling back to its id.
+// 進捗トークンはリクエストの_meta.progressTokenで、無ければリクエストIDを使う
+func (s *MCPServer) executeStreamingTool(ctx context.Context, tool Tool, arguments interface{}) (map[string]interface{}, error) {
+	w := &chunkWriter{stream: eventStreamFrom(ctx)}
+	if w.stream != nil {
+		w.token = w.stream.id
+		if token, ok := RequestMeta(ctx)["progressToken"]; ok {
+			w.token = token
+		}
+	}
+
+	result, err := tool.Stream(ctx, arguments, w)
+	if err != nil {
+		return nil, err
+	}
+
+	// Final result: 最終結果
+	final := make(map[string]interface{}, len(result)+1)
+	for k, v := range result {
+		final[k] = v
+	}
+	if w.stream != nil {
+		SetResultMeta(ctx, "streamedChunks", w.count) // streamed: ストリーミング済み
+	}
+	content, ok := final["content"].([]map[string]interface{})
+	if !ok && final["content"] != nil {
+		return final, nil // custom shape, left as is: 独自の形はそのまま
+	}
+	if w.stream == nil {
+		content = append(w.items, content...)
+	}
+	if content == nil {
+		content = []map[string]interface{}{}
+	}
+	final["content"] = content
+	return final, nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bufio"             // bufio: reading events line by line (イベントを行単位で読む)
+	"context"           // context: handler signature (ハンドラーのシグネチャ)
+	"io"                // io: reading whole streams (ストリーム全体の読み取り)
+	"net/http"          // net/http: event stream requests (イベントストリームのリクエスト)
+	"net/http/httptest" // net/http/httptest: real HTTP server for flushing (フラッシュ用の実HTTPサーバー)
+	"strings"           // strings: request bodies and matching (リクエスト本文と照合)
+	"testing"           // testing: test framework (テストフレームワーク)
+	"time"              // time: keep-alive cadence (キープアライブの間隔)
+)
+
+// TestStreamingToolOverSSE checks that chunks written by a streaming tool arrive as
+// progress notifications before the final result, which counts them in _meta
+// TestStreamingToolOverSSE: ストリーミングツールが書き込んだ項目が最終結果より前に
+// 進捗通知として届き、最終結果の_metaにその数が入ることを確認するテスト
+func TestStreamingToolOverSSE(t *testing.T) {
+	s := NewMCPServer()
+	step := make(chan struct{})
+	s.RegisterTool(Tool{Name: "big", Stream: func(ctx context.Context, arguments interface{}, w ContentWriter) (map[string]interface{}, error) {
+		w.WriteContent(TextContent("one"))
+		<-step // hold the result back: 結果を保留する
+		w.WriteContent(TextContent("two"))
+		return nil, nil
+	}})
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader(`{"jsonrpc":"2.0","id":7,"method":"tools/call","params":{"name":"big"}}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	events := bufio.NewReader(resp.Body)
+	next := func() string {
+		for {
+			line, err := events.ReadString('\n')
+			if err != nil {
+				t.Fatalf("read event: %v", err)
+			}
+			if data, ok := strings.CutPrefix(line, "data: "); ok {
+				return data
+			}
+		}
+	}
+
+	// The first chunk arrives while the handler is still blocked: ハンドラーが止まっている間に最初の項目が届く
+	if first := next(); !strings.Contains(first, `"notifications/progress"`) || !strings.Contains(first, `"one"`) || !strings.Contains(first, `"progressToken":7`) {
+		t.Fatalf("first event: %s", first)
+	}
+	close(step)
+	if second := next(); !strings.Contains(second, `"notifications/progress"`) || !strings.Contains(second, `"two"`) {
+		t.Fatalf("second event: %s", second)
+	}
+	if final := next(); !strings.Contains(final, `"id":7`) || !strings.Contains(final, `"streamedChunks":2`) {
+		t.Fatalf("final event: %s", final)
+	}
+}
+
+// TestStreamingToolBuffered checks that without an event stream the chunks are
+// buffered ahead of the handler's own content in the final result
+// TestStreamingToolBuffered: イベントストリームが無い場合、項目が最終結果の
+// ハンドラー自身のcontentより前にバッファされることを確認するテスト
+func TestStreamingToolBuffered(t *testing.T) {
+	s := NewMCPServer()
+	s.RegisterTool(Tool{Name: "big", Stream: func(ctx context.Context, arguments interface{}, w ContentWriter) (map[string]interface{}, error) {
+		w.WriteContent(TextContent("one"))
+		w.WriteContent(TextContent("two"))
+		return map[string]interface{}{"content": []map[string]interface{}{TextContent("done")}}, nil
+	}})
+
+	result, err := NewClient(s).CallTool("big", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var texts []string
+	for _, item := range result.Content {
+		texts = append(texts, item["text"].(string))
+	}
+	if got := strings.Join(texts, ","); got != "one,two,done" {
+		t.Fatalf("content: got %q, want %q", got, "one,two,done")
+	}
+}
+
+// TestKeepAlivePings checks that an open event stream gets a ping comment about every
+// interval until the final response, and none when keep-alive is off
+// TestKeepAlivePings: 開いたイベントストリームに最終レスポンスまでほぼinterval毎に
+// pingコメントが届き、キープアライブが無効なら届かないことを確認するテスト
+func TestKeepAlivePings(t *testing.T) {
+	pings := func(s *MCPServer) (int, string) {
+		s.RegisterTool(Tool{Name: "slow", Handler: func(ctx context.Context, arguments interface{}) (map[string]interface{}, error) {
+			time.Sleep(280 * time.Millisecond)
+			return ToolResult(), nil
+		}})
+		srv := httptest.NewServer(s)
+		defer srv.Close()
+		req, _ := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader(`{"jsonrpc":"2.0","id":7,"method":"tools/call","params":{"name":"slow"}}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json, text/event-stream")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.Count(string(body), ": ping\n\n"), string(body)
+	}
+
+	n, body := pings(NewMCPServer(WithKeepAlive(50 * time.Millisecond)))
+	if n < 3 || n > 6 {
+		t.Fatalf("got %d pings in 280ms at a 50ms interval:\n%s", n, body)
+	}
+	if !strings.Contains(body, `"id":7`) || !strings.HasSuffix(body, "\n\n") {
+		t.Fatalf("final response missing:\n%s", body)
+	}
+	if n, body := pings(NewMCPServer()); n != 0 {
+		t.Fatalf("got %d pings with keep-alive off:\n%s", n, body)
+	}
+}
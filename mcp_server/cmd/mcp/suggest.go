@@ -0,0 +1,43 @@
+package main
+
+// maxSuggestDistance is the largest edit distance suggestMethod accepts
+// maxSuggestDistance: suggestMethodが受け入れる最大の編集距離
+const maxSuggestDistance = 3
+
+// suggestMethod returns the known method closest to name, or "" when none is close
+// suggestMethod: nameに最も近い既知のメソッドを返す関数 (近いものが無ければ"")
+// A method is close when its Levenshtein distance is at most 3 and under half the
+// length of name, so short unrelated names get no suggestion. Ties go to the first in known.
+// レーベンシュタイン距離が3以下かつnameの長さの半分未満なら近いとみなすため、
+// 短い無関係な名前には提案しない。同点の場合はknownで先のものを選ぶ
+func suggestMethod(name string, known []string) string {
+	best, bestDistance := "", maxSuggestDistance+1
+	for _, method := range known {
+		if d := levenshtein(name, method); d < bestDistance && 2*d < len(name) {
+			best, bestDistance = method, d
+		}
+	}
+	return best
+}
+
+// levenshtein returns the number of single-byte insertions, deletions and substitutions turning a into b
+// levenshtein: aをbに変える1バイト単位の挿入・削除・置換の回数を返す関数
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost) // delete, insert, substitute: 削除・挿入・置換
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context" // context: request contexts (リクエストコンテキスト)
+	"testing" // testing: test framework (テストフレームワーク)
+)
+
+// TestLevenshtein checks the edit distance of a few pairs
+// TestLevenshtein: いくつかの組の編集距離を確認するテスト
+func TestLevenshtein(t *testing.T) {
+	for _, tt := range []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"tool/list", "tools/list", 1},
+		{"kitten", "sitting", 3},
+	} {
+		if got := levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("levenshtein(%q, %q): got %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+// TestMethodNotFoundSuggestion checks that a near-miss method name gets a suggestion
+// in the -32601 error data, and that unrelated or short names get none
+// TestMethodNotFoundSuggestion: 惜しいメソッド名の-32601エラーのデータに提案が含まれ、
+// 無関係な名前や短い名前には含まれないことを確認するテスト
+func TestMethodNotFoundSuggestion(t *testing.T) {
+	s := NewMCPServer()
+	call := func(method string) *JSONRPCError {
+		return s.HandleRequest(context.Background(), &JSONRPCRequest{JSONRPC: "2.0", ID: IntID(1), Method: method}).Error
+	}
+	for method, want := range map[string]string{
+		"tool/list":      "tools/list",
+		"resource/read":  "resources/read",
+		"prompts/gett":   "prompts/get",
+		"completely/odd": "",
+		"x":              "",
+	} {
+		err := call(method)
+		if err == nil || err.Code != -32601 {
+			t.Fatalf("%s: got %+v, want -32601", method, err)
+		}
+		data, _ := err.Data.(map[string]interface{})
+		if got, _ := data["suggestion"].(string); got != want {
+			t.Errorf("%s: suggested %q, want %q", method, got, want)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"sync" // sync: guards the queue (キューの保護)
+)
+
+// updateQueue coalesces resources/updated notifications between their source and the writer
+// updateQueue: 発生元と書き込みの間でresources/updated通知をまとめるキュー
+// At most one update per URI is pending, since a client re-reads the resource anyway.
+// When the queue is full the oldest pending update is dropped. A single drain
+// goroutine writes updates in order; it exits when the queue empties.
+// クライアントはどのみちリソースを読み直すため、URI毎に保留される更新は最大1件。
+// キューが満杯の場合は最も古い保留中の更新を破棄する。1つの排出goroutineが順に
+// 更新を書き込み、キューが空になると終了する
+// coalesce: まとめる
+type updateQueue struct {
+	max int // max: pending URIs allowed (保留できるURIの数)
+
+	mu       sync.Mutex      // mu: guards the fields below (以下のフィールドを保護)
+	order    []string        // order: pending URIs, oldest first (保留中のURI、古い順)
+	pending  map[string]bool // pending: membership of order (orderに含まれるか)
+	draining bool            // draining: the drain goroutine is running (排出goroutineが実行中)
+}
+
+// newUpdateQueue creates a queue holding at most max pending URIs
+// newUpdateQueue: 最大max件のURIを保留するキューを作成する関数
+func newUpdateQueue(max int) *updateQueue {
+	return &updateQueue{max: max, pending: make(map[string]bool)}
+}
+
+// queueUpdate adds uri to the queue unless an update for it is already pending
+// queueUpdate: uriの更新が保留中でなければキューへ追加する関数
+func (s *MCPServer) queueUpdate(uri string) {
+	q := s.updates
+	q.mu.Lock()
+	if q.pending[uri] {
+		q.mu.Unlock()
+		return // coalesced: まとめられた
+	}
+	if len(q.order) >= q.max {
+		oldest := q.order[0]
+		q.order = q.order[1:]
+		delete(q.pending, oldest)
+		s.logger.Warn("update queue full, dropping oldest update", "uri", oldest) // dropping: 破棄する
+	}
+	q.order = append(q.order, uri)
+	q.pending[uri] = true
+	start := !q.draining
+	q.draining = true
+	q.mu.Unlock()
+
+	if start {
+		go s.drainUpdates()
+	}
+}
+
+// drainUpdates writes pending updates until the queue is empty
+// drainUpdates: キューが空になるまで保留中の更新を書き込む関数
+// A slow writer blocks here, so changes arriving meanwhile coalesce in the queue.
+// 遅い書き込み先はここでブロックするため、その間に届いた変更はキュー内でまとめられる
+func (s *MCPServer) drainUpdates() {
+	q := s.updates
+	for {
+		q.mu.Lock()
+		if len(q.order) == 0 {
+			q.draining = false
+			q.mu.Unlock()
+			return
+		}
+		uri := q.order[0]
+		q.order = q.order[1:]
+		delete(q.pending, uri) // later changes queue again: 以降の変更は再びキューに入る
+		q.mu.Unlock()
+
+		s.notifyResourceUpdated(uri)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"    // bytes: captured log output (取得したログ出力)
+	"log/slog" // log/slog: logger capturing warnings (警告を取得するロガー)
+	"strings"  // strings: counting notifications (通知の計数)
+	"testing"  // testing: test framework (テストフレームワーク)
+)
+
+// gatedWriter blocks every write until gate is closed, reporting each write on entered
+// gatedWriter: gateが閉じられるまで全ての書き込みを止め、書き込みの開始をenteredで知らせるWriter
+type gatedWriter struct {
+	entered chan struct{} // entered: a write started (書き込みが始まった)
+	gate    chan struct{} // gate: closed to let writes through (閉じると書き込みを通す)
+	out     syncBuffer    // out: written output (書き込まれた出力)
+}
+
+func (w *gatedWriter) Write(p []byte) (int, error) {
+	select {
+	case w.entered <- struct{}{}:
+	default:
+	}
+	<-w.gate
+	return w.out.Write(p)
+}
+
+// TestUpdateQueueCoalescing checks that a burst of changes behind a slow writer
+// collapses to one update per URI and that a full queue drops the oldest with a warning
+// TestUpdateQueueCoalescing: 遅い書き込み先の背後での大量の変更がURI毎に1件へまとめられ、
+// 満杯のキューは最も古いものを警告付きで破棄することを確認するテスト
+func TestUpdateQueueCoalescing(t *testing.T) {
+	var logs bytes.Buffer
+	s := NewMCPServer(WithUpdateQueue(2), WithLogger(slog.New(slog.NewTextHandler(&logs, nil))))
+	w := &gatedWriter{entered: make(chan struct{}, 1), gate: make(chan struct{})}
+	c := s.newConnSession(w)
+	c.initialized.Store(true)
+	for _, uri := range []string{"file:///a", "file:///b", "file:///c"} {
+		c.subscribe(uri, false, true)
+	}
+	s.addConn(c)
+
+	s.NotifyResourceUpdated("file:///a")
+	<-w.entered // the drain goroutine holds a and blocks: 排出goroutineがaを持って止まる
+	for i := 0; i < 100; i++ {
+		s.NotifyResourceUpdated("file:///b")
+		s.NotifyResourceUpdated("file:///c")
+	}
+	s.NotifyResourceUpdated("file:///a") // full: drops b (満杯のためbを破棄)
+	close(w.gate)
+
+	eventually(t, func() bool { return strings.Count(w.out.String(), "\n") == 3 })
+	out := w.out.String()
+	if strings.Count(out, "file:///a") != 2 || strings.Count(out, "file:///c") != 1 || strings.Contains(out, "file:///b") {
+		t.Fatalf("updates:\n%s", out)
+	}
+	if !strings.Contains(logs.String(), "update queue full") || !strings.Contains(logs.String(), "uri=file:///b") {
+		t.Fatalf("log: %s", logs.String())
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import "context" // context: identifies the connection (接続の識別)
+
+// Protocol versions the server speaks, oldest first
+// サーバーが話せるプロトコルバージョン (古い順)
+var protocolVersions = []string{"2024-11-05", "2025-03-26", "2025-06-18"}
+
+// latestProtocolVersion is offered when the client asks for an unsupported version
+// latestProtocolVersion: クライアントが未対応のバージョンを求めた場合に提示するバージョン
+var latestProtocolVersion = protocolVersions[len(protocolVersions)-1]
+
+// featureVersions maps optional protocol features to the first version that has them
+// featureVersions: 任意のプロトコル機能を、それを持つ最初のバージョンへ対応付ける表
+// Versions are dates, so they compare as strings.
+// バージョンは日付のため文字列として比較できる
+var featureVersions = map[string]string{
+	"toolAnnotations":   "2025-03-26", // annotations on tools: ツールのアノテーション
+	"structuredContent": "2025-06-18", // structuredContent in tool results: ツール結果のstructuredContent
+	"outputSchema":      "2025-06-18", // outputSchema on tools: ツールのoutputSchema
+}
+
+// negotiateVersion picks the protocol version to answer initialize with
+// negotiateVersion: initializeに応答するプロトコルバージョンを選ぶ関数
+// A supported request is echoed; anything else gets the latest version, and the
+// client decides whether it can continue.
+// 対応しているバージョンの要求はそのまま返し、それ以外には最新のバージョンを返して
+// 続行できるかの判断はクライアントに任せる
+func negotiateVersion(requested string) string {
+	for _, v := range protocolVersions {
+		if v == requested {
+			return v
+		}
+	}
+	return latestProtocolVersion
+}
+
+// ProtocolVersion returns the version the connection carrying ctx negotiated by initialize
+// ProtocolVersion: ctxを運ぶ接続がinitializeで合意したバージョンを返す関数
+// Before initialize it is the latest supported version.
+// initialize前は対応している最新のバージョン
+func (s *MCPServer) ProtocolVersion(ctx context.Context) string {
+	if v, ok := s.connFor(ctx).protocolVersion.Load().(string); ok {
+		return v
+	}
+	return latestProtocolVersion
+}
+
+// SupportsFeature reports whether the negotiated protocol version has the named feature
+// SupportsFeature: 合意したプロトコルバージョンが指定した機能を持つかを判定する関数
+// Unknown feature names report false.
+// 未知の機能名にはfalseを返す
+func (s *MCPServer) SupportsFeature(ctx context.Context, name string) bool {
+	since, ok := featureVersions[name]
+	return ok && s.ProtocolVersion(ctx) >= since
+}
+
+// adaptTool hides tool fields the negotiated version does not know
+// adaptTool: 合意したバージョンが知らないツールのフィールドを隠す関数
+func (s *MCPServer) adaptTool(ctx context.Context, tool Tool) Tool {
+	if !s.SupportsFeature(ctx, "toolAnnotations") {
+		tool.Annotations = nil
+	}
+	if !s.SupportsFeature(ctx, "outputSchema") {
+		tool.OutputSchema = nil
+	}
+	return tool
+}
+
+// adaptToolResult drops structuredContent for versions without it
+// adaptToolResult: structuredContentを持たないバージョンではそれを取り除く関数
+// The result is copied, since it may be shared through the cache.
+// 結果はキャッシュ経由で共有されている可能性があるためコピーする
+func (s *MCPServer) adaptToolResult(ctx context.Context, result map[string]interface{}) map[string]interface{} {
+	if _, ok := result["structuredContent"]; !ok || s.SupportsFeature(ctx, "structuredContent") {
+		return result
+	}
+	adapted := make(map[string]interface{}, len(result))
+	for k, v := range result {
+		if k != "structuredContent" {
+			adapted[k] = v
+		}
+	}
+	return adapted
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context" // context: per-connection sessions (接続ごとのセッション)
+	"testing" // testing: test framework (テストフレームワーク)
+)
+
+// TestNegotiateVersion checks that supported versions are echoed and others get the latest
+// TestNegotiateVersion: 対応するバージョンはそのまま返り、それ以外は最新になることを確認するテスト
+func TestNegotiateVersion(t *testing.T) {
+	for requested, want := range map[string]string{
+		"2024-11-05": "2024-11-05",
+		"2025-03-26": "2025-03-26",
+		"1999-01-01": latestProtocolVersion,
+		"":           latestProtocolVersion,
+	} {
+		if got := negotiateVersion(requested); got != want {
+			t.Errorf("negotiateVersion(%q): got %s, want %s", requested, got, want)
+		}
+	}
+}
+
+// TestSupportsFeature checks that the version negotiated by each connection gates
+// features, hiding tool annotations and structuredContent from older clients
+// TestSupportsFeature: 各接続が合意したバージョンで機能が制限され、古いクライアントには
+// ツールのアノテーションとstructuredContentが隠されることを確認するテスト
+func TestSupportsFeature(t *testing.T) {
+	s := NewMCPServer()
+	s.RegisterTool(Tool{Name: "w", Annotations: &ToolAnnotations{Title: "Weather"}, Handler: func(ctx context.Context, arguments interface{}) (map[string]interface{}, error) {
+		return StructuredResult(map[string]interface{}{"t": 1})
+	}})
+	oldConn := withConn(context.Background(), s.newConnSession(nil))
+	newConn := withConn(context.Background(), s.newConnSession(nil))
+	request := func(ctx context.Context, method string, params map[string]interface{}) *JSONRPCResponse {
+		t.Helper()
+		resp := s.HandleRequest(ctx, &JSONRPCRequest{JSONRPC: "2.0", ID: IntID(1), Method: method, Params: params})
+		if resp.Error != nil {
+			t.Fatalf("%s: %v", method, resp.Error)
+		}
+		return resp
+	}
+
+	if !s.SupportsFeature(oldConn, "structuredContent") {
+		t.Fatal("features are gated before initialize")
+	}
+	request(oldConn, "initialize", map[string]interface{}{"protocolVersion": "2024-11-05"})
+	request(newConn, "initialize", map[string]interface{}{"protocolVersion": latestProtocolVersion})
+	if s.SupportsFeature(oldConn, "structuredContent") || s.SupportsFeature(oldConn, "toolAnnotations") {
+		t.Fatal("2024-11-05 supports newer features")
+	}
+	if !s.SupportsFeature(newConn, "structuredContent") || s.SupportsFeature(newConn, "noSuchFeature") {
+		t.Fatal("latest version gating is wrong")
+	}
+
+	for ctx, want := range map[context.Context]bool{oldConn: false, newConn: true} {
+		tools := request(ctx, "tools/list", nil).Result.(*ToolsListResult).Tools
+		if got := tools[0].Annotations != nil; got != want {
+			t.Errorf("annotations listed: got %v, want %v", got, want)
+		}
+		result := request(ctx, "tools/call", map[string]interface{}{"name": "w"}).Result.(map[string]interface{})
+		if _, got := result["structuredContent"]; got != want {
+			t.Errorf("structuredContent returned: got %v, want %v", got, want)
+		}
+	}
+}
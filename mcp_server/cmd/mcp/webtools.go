@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"      // context: cancellation and deadlines (キャンセルと期限)
+	"fmt"          // fmt: formatted I/O (フォーマット済みI/O)
+	"html"         // html: title entity decoding (タイトルの実体参照のデコード)
+	"io"           // io: bounded body reads (上限付きのボディ読み取り)
+	"mime"         // mime: media type parsing (メディアタイプの解析)
+	"net"          // net: address checks (アドレスの検査)
+	"net/http"     // net/http: HTTP client (HTTPクライアント)
+	"net/url"      // net/url: URL parsing (URLの解析)
+	"regexp"       // regexp: HTML title extraction (HTMLタイトルの抽出)
+	"strings"      // strings: whitespace folding (空白の整理)
+	"unicode/utf8" // unicode/utf8: truncation on rune boundaries (文字境界での切り詰め)
+)
+
+// defaultFetchMaxBytes caps the body web/fetch returns (100KB)
+// defaultFetchMaxBytes: web/fetchが返すボディの上限 (100KB)
+const defaultFetchMaxBytes = 100 << 10
+
+// titlePattern finds the title of an HTML document
+// titlePattern: HTML文書のタイトルを探すパターン
+var titlePattern = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+
+// WebFetchResult is the structured result of web/fetch
+// WebFetchResult: web/fetchの構造化された結果
+type WebFetchResult struct {
+	URL         string `json:"url"`                   // url: final URL after redirects (リダイレクト後の最終URL)
+	Status      int    `json:"status"`                // status: HTTP status code (HTTPステータスコード)
+	ContentType string `json:"contentType,omitempty"` // contentType: media type (メディアタイプ)
+	Title       string `json:"title,omitempty"`       // title: HTML title (HTMLタイトル)
+	Body        string `json:"body,omitempty"`        // body: text body (テキストのボディ)
+	Blob        string `json:"blob,omitempty"`        // blob: base64 binary body (base64のバイナリボディ)
+	Truncated   bool   `json:"truncated"`             // truncated: the body was cut at the limit (ボディが上限で切り詰められた)
+}
+
+// WebFetchTool returns the web/fetch tool, which GETs an http or https URL
+// WebFetchTool: httpまたはhttpsのURLをGETするweb/fetchツールを返す関数
+// It returns the status, content type, HTML title and at most maxBytes of the body
+// (100KB when zero); a longer body is truncated rather than refused. Requests go
+// through HTTPSProvider's retries and SafeHTTPClient's SSRF guard, so every address the
+// host resolves to, including after redirects, must be publicly routable.
+// ステータス、コンテンツタイプ、HTMLタイトルと、最大maxBytes (0なら100KB) のボディを返す。
+// 長いボディは拒否せず切り詰める。リクエストはHTTPSProviderのリトライとSafeHTTPClientのSSRF対策を経由するため、
+// ホストが解決される全てのアドレス (リダイレクト後を含む) は公開ルーティング可能でなければならない
+func WebFetchTool(maxBytes int64) Tool {
+	return webFetchTool(maxBytes, checkPublicIP)
+}
+
+// webFetchTool builds web/fetch with check deciding which addresses may be dialed
+// webFetchTool: 接続してよいアドレスをcheckで判定するweb/fetchを作成する関数
+func webFetchTool(maxBytes int64, check func(ip net.IP) error) Tool {
+	if maxBytes <= 0 {
+		maxBytes = defaultFetchMaxBytes
+	}
+	provider := HTTPSProvider{Client: safeHTTPClient(nil, check)}
+
+	return Tool{
+		Name:        "web/fetch",
+		Description: "Fetch a public web page with HTTP GET", // fetch: 取得する
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"url": map[string]interface{}{
+					"type":        "string",
+					"description": "http or https URL to fetch",
+				},
+			},
+			"required": []string{"url"},
+		},
+		Annotations: &ToolAnnotations{ReadOnlyHint: Bool(true), OpenWorldHint: Bool(true)},
+		Handler: func(ctx context.Context, arguments interface{}) (map[string]interface{}, error) {
+			args, _ := arguments.(map[string]interface{})
+			raw, _ := args["url"].(string)
+			u, err := url.Parse(raw)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+				return nil, fmt.Errorf("%w: %s", errInvalidURI, raw)
+			}
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", errInvalidURI, err)
+			}
+			resp, err := provider.do(provider.Client, req)
+			if err != nil {
+				return nil, fmt.Errorf("fetch %s: %w", raw, err)
+			}
+			defer resp.Body.Close()
+			data, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+			if err != nil {
+				return nil, fmt.Errorf("fetch %s: %w", raw, err)
+			}
+
+			result := WebFetchResult{
+				URL:         resp.Request.URL.String(),
+				Status:      resp.StatusCode,
+				ContentType: resp.Header.Get("Content-Type"),
+			}
+			if int64(len(data)) > maxBytes {
+				data = data[:maxBytes]
+				result.Truncated = true
+				// Do not split a character: 文字を分割しない
+				for i := 0; i < utf8.UTFMax-1 && !utf8.Valid(data); i++ {
+					data = data[:len(data)-1]
+				}
+			}
+			if result.ContentType == "" {
+				result.ContentType = http.DetectContentType(data)
+			}
+			content := encodeContent(result.ContentType, data)
+			result.Body, result.Blob = content.Text, content.Blob
+			if mediaType, _, _ := mime.ParseMediaType(result.ContentType); mediaType == "text/html" {
+				if m := titlePattern.FindSubmatch(data); m != nil {
+					result.Title = strings.Join(strings.Fields(html.UnescapeString(string(m[1]))), " ")
+				}
+			}
+			return StructuredResult(result)
+		},
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"           // context: handler calls (ハンドラーの呼び出し)
+	"errors"            // errors: error matching (エラーの照合)
+	"net"               // net: address check signature (アドレス検査のシグネチャ)
+	"net/http"          // net/http: test handlers (テスト用ハンドラー)
+	"net/http/httptest" // httptest: local web server (ローカルのWebサーバー)
+	"strings"           // strings: long bodies (長いボディ)
+	"testing"           // testing: test framework (テストフレームワーク)
+)
+
+// TestWebFetchTool checks the status, content type, title and truncation web/fetch
+// reports, and that loopback and non-HTTP URLs are refused
+// TestWebFetchTool: web/fetchが報告するステータス、コンテンツタイプ、タイトル、切り詰めと、
+// ループバックとHTTP以外のURLが拒否されることを確認するテスト
+func TestWebFetchTool(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/long" {
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte(strings.Repeat("あ", 10)))
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte("<html><head><title>\n  Tom &amp; Jerry </title></head></html>"))
+	}))
+	defer srv.Close()
+	allowAll := func(net.IP) error { return nil } // the server is on loopback: サーバーはループバック上
+	fetch := webFetchTool(0, allowAll).Handler
+
+	result, err := fetch(context.Background(), map[string]interface{}{"url": srv.URL + "/page"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := result["structuredContent"].(WebFetchResult)
+	if page.Status != http.StatusTeapot || page.Title != "Tom & Jerry" || !strings.HasPrefix(page.ContentType, "text/html") {
+		t.Fatalf("page: %+v", page)
+	}
+
+	result, err = webFetchTool(10, allowAll).Handler(context.Background(), map[string]interface{}{"url": srv.URL + "/long"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if long := result["structuredContent"].(WebFetchResult); !long.Truncated || long.Body != "あああ" {
+		t.Fatalf("long body: %+v", long) // 10 bytes cut back to a whole character: 10バイトを文字境界まで戻す
+	}
+
+	guarded := WebFetchTool(0).Handler
+	if _, err := guarded(context.Background(), map[string]interface{}{"url": srv.URL}); !errors.Is(err, errBlockedAddress) {
+		t.Fatalf("loopback: got %v, want errBlockedAddress", err)
+	}
+	for _, raw := range []string{"file:///etc/passwd", "ftp://example.com/x", "http://"} {
+		if _, err := guarded(context.Background(), map[string]interface{}{"url": raw}); !errors.Is(err, errInvalidURI) {
+			t.Errorf("%s: got %v, want errInvalidURI", raw, err)
+		}
+	}
+}
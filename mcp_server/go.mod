@@ -1,3 +1,5 @@
 module mcp
 
 go 1.24.4
+
+require golang.org/x/text v0.28.0
@@ -0,0 +1,60 @@
+// Package mcptest provides helpers for testing MCP servers
+// Package mcptest: MCPサーバーのテスト用ヘルパーを提供するパッケージ
+package mcptest
+
+import (
+	"bytes"         // bytes: line splitting and trimming (行の分割とトリム)
+	"encoding/json" // encoding/json: JSON validation (JSONの検証)
+	"testing"       // testing: test reporting (テスト結果の報告)
+)
+
+// AssertValidNDJSON reports an error for each non-empty line of output that is not one complete JSON message
+// AssertValidNDJSON: outputの空でない各行が1つの完全なJSONメッセージでなければエラーを報告する関数
+// A message is a JSON object, or a batch array of objects. A response that
+// accidentally contains a raw newline splits into lines that fail this check, so
+// it catches framing bugs. It reports whether every line was valid.
+// メッセージはJSONオブジェクト、またはオブジェクトのバッチ配列。誤って生の改行を含むレスポンスは
+// この検査に失敗する行に分かれるため、フレーミングのバグを検出できる。全ての行が有効だったかを返す
+// framing: フレーミング、メッセージの区切り
+func AssertValidNDJSON(t testing.TB, output string) bool {
+	t.Helper()
+	valid := true
+	for i, line := range bytes.Split([]byte(output), []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue // skip empty lines: 空行をスキップ
+		}
+		if err := checkMessage(line); err != "" {
+			t.Errorf("line %d: %s: %q", i+1, err, line)
+			valid = false
+		}
+	}
+	return valid
+}
+
+// checkMessage describes why line is not one JSON message, or returns "" when it is
+// checkMessage: lineが1つのJSONメッセージでない理由を返す関数 (メッセージなら"")
+func checkMessage(line []byte) string {
+	if !json.Valid(line) {
+		return "not a complete JSON value" // incomplete: 不完全
+	}
+	switch bytes.TrimSpace(line)[0] {
+	case '{':
+		return ""
+	case '[':
+		var batch []json.RawMessage
+		if err := json.Unmarshal(line, &batch); err != nil {
+			return err.Error()
+		}
+		if len(batch) == 0 {
+			return "empty batch" // empty: 空の
+		}
+		for _, msg := range batch {
+			if msg[0] != '{' {
+				return "batch element is not an object" // element: 要素
+			}
+		}
+		return ""
+	default:
+		return "not a JSON object or batch array"
+	}
+}
@@ -0,0 +1,52 @@
+package mcptest
+
+import (
+	"fmt"     // fmt: formatting recorded errors (記録するエラーの整形)
+	"strings" // strings: matching recorded errors (記録したエラーの照合)
+	"testing" // testing: test framework (テストフレームワーク)
+)
+
+// recorder is a testing.TB that records Errorf calls instead of failing
+// recorder: 失敗させる代わりにErrorfの呼び出しを記録するtesting.TB
+type recorder struct {
+	testing.TB          // TB: the real test, for everything else (それ以外は本物のテストへ)
+	errors     []string // errors: recorded messages (記録したメッセージ)
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+// TestAssertValidNDJSON checks that well-framed output passes and that each kind of
+// corrupted line is reported with its line number
+// TestAssertValidNDJSON: 正しく区切られた出力が通り、壊れた各種の行が行番号付きで
+// 報告されることを確認するテスト
+func TestAssertValidNDJSON(t *testing.T) {
+	valid := `{"jsonrpc":"2.0","id":1,"result":{}}` + "\n" +
+		"\n" +
+		`[{"jsonrpc":"2.0","method":"a"},{"jsonrpc":"2.0","method":"b"}]` + "\n"
+	r := &recorder{TB: t}
+	if !AssertValidNDJSON(r, valid) || len(r.errors) != 0 {
+		t.Fatalf("valid output rejected: %v", r.errors)
+	}
+
+	for name, output := range map[string]string{
+		"raw newline":  "{\"jsonrpc\":\"2.0\",\"id\":1,\"result\":{\"text\":\"a\nb\"}}\n",
+		"bare value":   `{"jsonrpc":"2.0","id":1,"result":{}}` + "\n42\n",
+		"empty batch":  "[]\n",
+		"scalar batch": `[{"jsonrpc":"2.0","method":"a"},1]` + "\n",
+	} {
+		r := &recorder{TB: t}
+		if AssertValidNDJSON(r, output) || len(r.errors) == 0 {
+			t.Errorf("%s: not detected", name)
+		}
+	}
+
+	r = &recorder{TB: t}
+	AssertValidNDJSON(r, `{"ok":true}`+"\n"+`{"broken":`+"\n")
+	if len(r.errors) != 1 || !strings.HasPrefix(r.errors[0], "line 2:") {
+		t.Fatalf("got %q, want one error on line 2", r.errors)
+	}
+}